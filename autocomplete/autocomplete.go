@@ -0,0 +1,285 @@
+// Package autocomplete suggests products, categories and sellers while a
+// buyer types. Their names are kept in an in-memory prefix trie that is
+// rebuilt in the background when the catalog changes.
+package autocomplete
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// Entry kinds.
+const (
+	Product  = "product"
+	Category = "category"
+	Seller   = "seller"
+)
+
+// Entry is something that can be suggested. Entries with a higher
+// popularity are suggested first.
+type Entry struct {
+	Kind       string  `json:"type"`
+	ID         string  `json:"id"`
+	Text       string  `json:"text"`
+	Popularity float64 `json:"popularity"`
+}
+
+// Source lists every entry that may be suggested.
+type Source func(ctx context.Context) ([]Entry, error)
+
+// nodeCapacity is the number of entries of each kind kept per trie node.
+// A prefix shared by more entries of a kind only finds the most popular
+// ones.
+const nodeCapacity = 64
+
+type node struct {
+	children map[rune]*node
+	// top holds, by kind, the most popular entries with a word starting
+	// with the prefix of the node, most popular first. Kinds are capped
+	// apart so popular products can't crowd out every seller.
+	top map[string][]int
+}
+
+// trie is an immutable snapshot of the entries.
+type trie struct {
+	root    *node
+	entries []Entry
+	words   [][]string
+	kinds   []string
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func build(entries []Entry) *trie {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Popularity > entries[j].Popularity })
+	t := &trie{root: &node{}, entries: entries, words: make([][]string, len(entries))}
+	for i, entry := range entries {
+		if !contains(t.kinds, entry.Kind) {
+			t.kinds = append(t.kinds, entry.Kind)
+		}
+		t.words[i] = words(entry.Text)
+		for _, word := range t.words[i] {
+			n := t.root
+			for _, r := range word {
+				if n.children == nil {
+					n.children = map[rune]*node{}
+				}
+				child, ok := n.children[r]
+				if !ok {
+					child = &node{}
+					n.children[r] = child
+				}
+				n = child
+				if n.top == nil {
+					n.top = map[string][]int{}
+				}
+				// Entries come most popular first, so appending keeps top
+				// sorted. An entry with two words of the same prefix is
+				// already the last one.
+				top := n.top[entry.Kind]
+				if len(top) < nodeCapacity && (len(top) == 0 || top[len(top)-1] != i) {
+					n.top[entry.Kind] = append(top, i)
+				}
+			}
+		}
+	}
+	return t
+}
+
+func (t *trie) find(prefix string) *node {
+	n := t.root
+	for _, r := range prefix {
+		if n = n.children[r]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// Index answers lookups from the latest snapshot of its source.
+type Index struct {
+	cfg    config.Autocomplete
+	source Source
+
+	mu      sync.RWMutex
+	current *trie
+	// changes counts the calls to Invalidate, built is the count the
+	// current trie was built after. running is set while Run keeps the
+	// index warm.
+	changes int
+	built   int
+	running bool
+	changed chan struct{}
+}
+
+func New(cfg config.Autocomplete, source Source) *Index {
+	return &Index{cfg: cfg, source: source, changed: make(chan struct{}, 1)}
+}
+
+// Refresh rebuilds the index from its source.
+func (x *Index) Refresh(ctx context.Context) error {
+	x.mu.RLock()
+	changes := x.changes
+	x.mu.RUnlock()
+	entries, err := x.source(ctx)
+	if err != nil {
+		return err
+	}
+	t := build(entries)
+	x.mu.Lock()
+	x.current, x.built = t, changes
+	x.mu.Unlock()
+	return nil
+}
+
+// Invalidate tells the index its source changed. It is cheap enough to
+// call on every write.
+func (x *Index) Invalidate() {
+	x.mu.Lock()
+	x.changes++
+	x.mu.Unlock()
+	select {
+	case x.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Run keeps the index warm until ctx is done: it builds it right away, then
+// after every burst of changes and at least every MaxAge.
+func (x *Index) Run(ctx context.Context) {
+	x.mu.Lock()
+	x.running = true
+	x.mu.Unlock()
+	defer func() {
+		x.mu.Lock()
+		x.running = false
+		x.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(x.cfg.MaxAge)
+	defer ticker.Stop()
+	for {
+		if err := x.Refresh(ctx); err != nil {
+			log.Println("autocomplete: rebuilding index:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-x.changed:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(x.cfg.Debounce):
+			}
+		}
+	}
+}
+
+// snapshot returns the trie to answer from. Without Run in the background
+// the index is built, or rebuilt after a change, on the spot.
+func (x *Index) snapshot(ctx context.Context) (*trie, error) {
+	x.mu.RLock()
+	t, stale, running := x.current, x.built != x.changes, x.running
+	x.mu.RUnlock()
+	if t != nil && (!stale || running) {
+		return t, nil
+	}
+	if err := x.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.current, nil
+}
+
+// Suggest returns up to limit entries of the given kinds, all kinds when
+// none are given, with a word starting with every word of query. The most
+// popular come first. Lookups taking longer than the budget return what
+// they found so far.
+func (x *Index) Suggest(ctx context.Context, query string, kinds []string, limit int) ([]Entry, error) {
+	t, err := x.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(x.cfg.Budget)
+	prefixes := words(query)
+	if len(prefixes) == 0 {
+		return []Entry{}, nil
+	}
+
+	nodes := make([]*node, len(prefixes))
+	for i, prefix := range prefixes {
+		if nodes[i] = t.find(prefix); nodes[i] == nil {
+			return []Entry{}, nil
+		}
+	}
+	if len(kinds) == 0 {
+		kinds = t.kinds
+	}
+	// For every kind, start from the prefix with the fewest entries, the
+	// others filter them. Entry indexes are in order of popularity.
+	var candidates []int
+	for _, kind := range kinds {
+		var fewest []int
+		for i, n := range nodes {
+			if top := n.top[kind]; i == 0 || len(top) < len(fewest) {
+				fewest = top
+			}
+		}
+		candidates = append(candidates, fewest...)
+	}
+	sort.Ints(candidates)
+
+	suggestions := []Entry{}
+	for k, i := range candidates {
+		if len(suggestions) == limit || time.Now().After(deadline) {
+			break
+		}
+		// A kind asked for twice lists its entries twice.
+		if k > 0 && candidates[k-1] == i {
+			continue
+		}
+		if matchesAll(t.words[i], prefixes) {
+			suggestions = append(suggestions, t.entries[i])
+		}
+	}
+	return suggestions, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAll reports whether every prefix starts one of the words.
+func matchesAll(words, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package autocomplete
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+func newTestIndex(entries []Entry) *Index {
+	return New(config.Autocomplete{Budget: time.Second}, func(ctx context.Context) ([]Entry, error) {
+		return entries, nil
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	entries := []Entry{
+		{Kind: Product, ID: "p1", Text: "Red Cotton Shirt", Popularity: 5},
+		{Kind: Product, ID: "p2", Text: "Blue Shirt", Popularity: 9},
+		{Kind: Product, ID: "p3", Text: "Shirt-Dress (red)", Popularity: 1},
+		{Kind: Category, ID: "c1", Text: "Shirts", Popularity: 3},
+		{Kind: Seller, ID: "s1", Text: "Shirtmakers & Co", Popularity: 0},
+		{Kind: Seller, ID: "s2", Text: "Redwood Traders", Popularity: 2},
+	}
+	index := newTestIndex(entries)
+	tests := []struct {
+		name  string
+		query string
+		kinds []string
+		limit int
+		want  []string
+	}{
+		{"prefix", "shi", nil, 10, []string{"p2", "p1", "c1", "p3", "s1"}},
+		{"case and punctuation", "  SHIRT!", nil, 10, []string{"p2", "p1", "c1", "p3", "s1"}},
+		{"multi word", "red shi", nil, 10, []string{"p1", "p3"}},
+		{"multi word in any order", "shirt cot", nil, 10, []string{"p1"}},
+		{"kind", "shi", []string{Seller}, 10, []string{"s1"}},
+		{"kinds", "red", []string{Seller, Product}, 10, []string{"p1", "s2", "p3"}},
+		{"kind asked twice", "shi", []string{Category, Category}, 10, []string{"c1"}},
+		{"limit", "shi", nil, 2, []string{"p2", "p1"}},
+		{"no match", "shoe", nil, 10, nil},
+		{"one word missing", "red shoe", nil, 10, nil},
+		{"empty query", " - ", nil, 10, nil},
+		{"unknown kind", "shi", []string{"brand"}, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := index.Suggest(context.Background(), tt.query, tt.kinds, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, entry := range got {
+				ids = append(ids, entry.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestKindBeyondTheCap(t *testing.T) {
+	var entries []Entry
+	for i := 0; i < 2*nodeCapacity; i++ {
+		entries = append(entries, Entry{Kind: Product, ID: fmt.Sprint("p", i), Text: fmt.Sprint("apple ", i), Popularity: float64(100 + i)})
+	}
+	entries = append(entries, Entry{Kind: Seller, ID: "s1", Text: "Apex Traders", Popularity: 1})
+	index := newTestIndex(entries)
+
+	got, err := index.Suggest(context.Background(), "a", []string{Seller}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "s1" {
+		t.Errorf("got %+v, want the seller behind %d more popular products", got, 2*nodeCapacity)
+	}
+	got, err = index.Suggest(context.Background(), "a", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].ID != fmt.Sprint("p", 2*nodeCapacity-1) {
+		t.Errorf("got %+v, want the most popular products first", got)
+	}
+}
+
+func TestSuggestRebuildsAfterInvalidate(t *testing.T) {
+	entries := []Entry{{Kind: Product, ID: "p1", Text: "Mug"}}
+	index := New(config.Autocomplete{Budget: time.Second}, func(ctx context.Context) ([]Entry, error) {
+		return entries, nil
+	})
+	if got, _ := index.Suggest(context.Background(), "plate", nil, 10); len(got) != 0 {
+		t.Fatalf("got %+v", got)
+	}
+	entries = append(entries, Entry{Kind: Product, ID: "p2", Text: "Plate"})
+	index.Invalidate()
+	if got, _ := index.Suggest(context.Background(), "plate", nil, 10); len(got) != 1 {
+		t.Errorf("got %+v after invalidating", got)
+	}
+}
@@ -0,0 +1,79 @@
+// Command bootstrap-admin creates the first super admin of a fresh
+// deployment. Further admins are invited from the admin panel, so it
+// refuses to run once a super admin exists.
+//
+//	ADMIN_PASSWORD=... go run ./cmd/bootstrap-admin -email ops@growthbiz.co -name Ops -mobileno 9999999999
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/database"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the super admin")
+	name := flag.String("name", "", "name of the super admin")
+	mobileNo := flag.String("mobileno", "", "mobile number of the super admin")
+	flag.Parse()
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if *email == "" || *name == "" {
+		log.Fatal("-email and -name are required")
+	}
+	if len(password) < 8 {
+		log.Fatal("ADMIN_PASSWORD must be set to at least 8 characters")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := database.DBSet(cfg.Mongo)
+	if client == nil {
+		log.Fatal("unable to connect to mongodb")
+	}
+	repos := repository.NewMongo(database.Database(client, cfg.Mongo))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	count, err := repos.Admins.Count(ctx, repository.AdminFilter{Role: utils.SuperAdmin})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if count > 0 {
+		log.Fatal("a super admin already exists, invite further admins from the admin panel")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+	now := time.Now()
+	admin := models.AdminUser{
+		ID:         primitive.NewObjectID(),
+		Name:       *name,
+		Email:      strings.ToLower(strings.TrimSpace(*email)),
+		MobileNo:   *mobileNo,
+		Password:   string(hashedPassword),
+		Role:       utils.SuperAdmin,
+		Created_at: now,
+		Updated_at: now,
+	}
+	if err := repos.Admins.Insert(ctx, &admin); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("created super admin %s (%s)", admin.Email, admin.ID.Hex())
+}
@@ -0,0 +1,127 @@
+// Command migrate-prices rewrites the free-form string prices of products,
+// their price range tiers and product references as money documents with
+// an amount in minor units and a currency. Prices that do not parse are
+// listed and left untouched so they can be fixed by hand.
+//
+//	go run ./cmd/migrate-prices -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/database"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing them")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	money.DefaultCurrency = cfg.Pricing.Currency
+	client := database.DBSet(cfg.Mongo)
+	if client == nil {
+		log.Fatal("unable to connect to mongodb")
+	}
+	db := database.Database(client, cfg.Mongo)
+	ctx := context.Background()
+
+	for _, coll := range []*mongo.Collection{db.Collection("Products"), db.Collection("ProductReference")} {
+		if err := migrate(ctx, coll, *dryRun); err != nil {
+			log.Fatalf("%s: %v", coll.Name(), err)
+		}
+	}
+}
+
+// convert returns the money document of a legacy price, false when value
+// is one already.
+func convert(value interface{}) (money.Money, bool, error) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return money.Money{}, true, nil
+		}
+		m, err := money.Parse(v, money.DefaultCurrency)
+		return m, true, err
+	case int32:
+		m, err := money.Parse(strconv.FormatInt(int64(v), 10), money.DefaultCurrency)
+		return m, true, err
+	case int64:
+		m, err := money.Parse(strconv.FormatInt(v, 10), money.DefaultCurrency)
+		return m, true, err
+	case float64:
+		m, err := money.Parse(strconv.FormatFloat(v, 'f', -1, 64), money.DefaultCurrency)
+		return m, true, err
+	}
+	return money.Money{}, false, nil
+}
+
+func migrate(ctx context.Context, coll *mongo.Collection, dryRun bool) error {
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated, failed int
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		set := bson.M{}
+		var problems []string
+
+		if m, legacy, err := convert(doc["price"]); err != nil {
+			problems = append(problems, fmt.Sprintf("price %q: %v", doc["price"], err))
+		} else if legacy {
+			set["price"] = m
+		}
+		if tiers, ok := doc["pricerange"].(bson.A); ok {
+			for i, tier := range tiers {
+				fields, ok := tier.(bson.M)
+				if !ok {
+					continue
+				}
+				m, legacy, err := convert(fields["price"])
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("pricerange.%d.price %q: %v", i, fields["price"], err))
+				} else if legacy {
+					set[fmt.Sprintf("pricerange.%d.price", i)] = m
+				}
+			}
+		}
+
+		if len(problems) > 0 {
+			failed++
+			log.Printf("%s %v: skipped, %v", coll.Name(), doc["_id"], problems)
+			continue
+		}
+		if len(set) == 0 {
+			continue
+		}
+		migrated++
+		if dryRun {
+			log.Printf("%s %v: would set %v", coll.Name(), doc["_id"], set)
+			continue
+		}
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("%s: %d documents migrated, %d need fixing by hand", coll.Name(), migrated, failed)
+	return nil
+}
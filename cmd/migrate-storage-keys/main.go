@@ -0,0 +1,282 @@
+// Command migrate-storage-keys copies the uploads of sellers, products,
+// content items, feeds and support tickets stored under their original
+// file names to keys of the per-document layout of the storage package,
+// and rewrites the URLs in the documents. Uploads with the same name used
+// to overwrite each other; each document gets its own copy of what is
+// stored now. The old objects are left in place, to be removed once the
+// migration is checked.
+//
+//	go run ./cmd/migrate-storage-keys -dry-run
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/database"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ref is a stored URL in a document.
+type ref struct {
+	path   string
+	url    string
+	prefix string
+	// A rendition is stored next to its image, the ref at index parent,
+	// under the name of the image with its name appended.
+	rendition string
+	parent    int
+}
+
+// collection lists the refs of the documents of a collection.
+type collection struct {
+	name string
+	refs func(id string, doc bson.M) []ref
+}
+
+var collections = []collection{
+	{"seller", sellerRefs},
+	{"Products", productRefs},
+	{"ContentItem", contentRefs},
+	{"New_Feeds", feedRefs},
+	{"CustomerSupportTicket", ticketRefs},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing them")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := database.DBSet(cfg.Mongo)
+	if client == nil {
+		log.Fatal("unable to connect to mongodb")
+	}
+	db := database.Database(client, cfg.Mongo)
+	ctx := context.Background()
+
+	for _, c := range collections {
+		if err := migrate(ctx, db.Collection(c.name), store, c.refs, *dryRun); err != nil {
+			log.Fatalf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func migrate(ctx context.Context, coll *mongo.Collection, store storage.Storage, refs func(string, bson.M) []ref, dryRun bool) error {
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated, failed int
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		id, ok := doc["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		set := bson.M{}
+		var problems []string
+		keys := map[int]string{}
+		copied := map[string]moved{}
+		for i, r := range refs(id.Hex(), doc) {
+			key := store.KeyFromURL(r.url)
+			if key == "" || strings.HasPrefix(key, r.prefix) {
+				keys[i] = key
+				continue
+			}
+			var newKey string
+			if r.rendition != "" {
+				parent, ok := keys[r.parent]
+				if !ok {
+					continue
+				}
+				newKey = strings.TrimSuffix(parent, path.Ext(parent)) + "-" + r.rendition + ".jpg"
+			}
+			url, to, err := move(ctx, store, key, newKey, r.prefix, copied, dryRun)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s %q: %v", r.path, r.url, err))
+				continue
+			}
+			keys[i] = to
+			set[r.path] = url
+		}
+
+		if len(problems) > 0 {
+			failed++
+			log.Printf("%s %s: %v", coll.Name(), id.Hex(), problems)
+		}
+		if len(set) == 0 {
+			continue
+		}
+		migrated++
+		if dryRun {
+			log.Printf("%s %s: would set %v", coll.Name(), id.Hex(), set)
+			continue
+		}
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("%s: %d documents migrated, %d with uploads left as they were", coll.Name(), migrated, failed)
+	return nil
+}
+
+// moved is an object copied to its new key.
+type moved struct {
+	url string
+	key string
+}
+
+// move copies the object at key to newKey, a new key under prefix when
+// empty, and returns its URL and key. Objects referenced more than once in
+// a document are copied once, copied holds them by their old key.
+func move(ctx context.Context, store storage.Storage, key, newKey, prefix string, copied map[string]moved, dryRun bool) (string, string, error) {
+	if c, ok := copied[key]; ok {
+		return c.url, c.key, nil
+	}
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	mtype := mimetype.Detect(data)
+	filename := path.Base(key)
+	if newKey == "" {
+		newKey = storage.NewKey(prefix, storage.Extension(mtype, filename))
+	}
+	url := newKey
+	if !dryRun {
+		if url, err = store.Put(ctx, newKey, bytes.NewReader(data), storage.Metadata{ContentType: mtype.String(), Filename: filename}); err != nil {
+			return "", "", err
+		}
+	}
+	copied[key] = moved{url: url, key: newKey}
+	return url, newKey, nil
+}
+
+func sellerRefs(id string, doc bson.M) []ref {
+	var refs []ref
+	company, _ := doc["companydetail"].(bson.M)
+	owner, _ := doc["ownerdetail"].(bson.M)
+	if url, ok := company["profilepicture"].(string); ok && url != "" {
+		refs = append(refs, ref{path: "companydetail.profilepicture", url: url, prefix: storage.SellerProfilePrefix(id)})
+	}
+	for _, field := range []string{"gstindoc", "cindoc", "llpindoc", "panimage"} {
+		if url, ok := company[field].(string); ok && url != "" {
+			refs = append(refs, ref{path: "companydetail." + field, url: url, prefix: storage.SellerKYCPrefix(id)})
+		}
+	}
+	for _, field := range []string{"aadharDoc", "passportDoc", "panDoc"} {
+		if url, ok := owner[field].(string); ok && url != "" {
+			refs = append(refs, ref{path: "ownerdetail." + field, url: url, prefix: storage.SellerKYCPrefix(id)})
+		}
+	}
+	for _, field := range []string{"businesslicenses", "exportpermission"} {
+		licenses, _ := company[field].(bson.A)
+		for i, license := range licenses {
+			fields, _ := license.(bson.M)
+			if url, ok := fields["licensefile"].(string); ok && url != "" {
+				refs = append(refs, ref{path: fmt.Sprintf("companydetail.%s.%d.licensefile", field, i), url: url, prefix: storage.SellerKYCPrefix(id)})
+			}
+		}
+	}
+	return refs
+}
+
+// imageRefs lists the refs of the images at path, plain URLs or image
+// documents with renditions.
+func imageRefs(refs []ref, path string, images interface{}, prefix string) []ref {
+	list, _ := images.(bson.A)
+	for i, image := range list {
+		switch image := image.(type) {
+		case string:
+			if image != "" {
+				refs = append(refs, ref{path: fmt.Sprintf("%s.%d", path, i), url: image, prefix: prefix})
+			}
+		case bson.M:
+			url, _ := image["original"].(string)
+			if url == "" {
+				continue
+			}
+			parent := len(refs)
+			refs = append(refs, ref{path: fmt.Sprintf("%s.%d.original", path, i), url: url, prefix: prefix})
+			renditions, _ := image["renditions"].(bson.A)
+			for j, rendition := range renditions {
+				fields, _ := rendition.(bson.M)
+				name, _ := fields["name"].(string)
+				if url, ok := fields["url"].(string); ok && url != "" && name != "" {
+					refs = append(refs, ref{
+						path:      fmt.Sprintf("%s.%d.renditions.%d.url", path, i, j),
+						url:       url,
+						prefix:    prefix,
+						rendition: name,
+						parent:    parent,
+					})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+func productRefs(id string, doc bson.M) []ref {
+	prefix := storage.ProductImagesPrefix(id)
+	refs := imageRefs(nil, "image", doc["image"], prefix)
+	variants, _ := doc["variant"].(bson.A)
+	for i, variant := range variants {
+		fields, _ := variant.(bson.M)
+		refs = imageRefs(refs, fmt.Sprintf("variant.%d.image", i), fields["image"], prefix)
+	}
+	return refs
+}
+
+// urlRefs lists the refs of an array of URLs at path.
+func urlRefs(refs []ref, path string, urls interface{}, prefix string) []ref {
+	list, _ := urls.(bson.A)
+	for i, url := range list {
+		if url, ok := url.(string); ok && url != "" {
+			refs = append(refs, ref{path: fmt.Sprintf("%s.%d", path, i), url: url, prefix: prefix})
+		}
+	}
+	return refs
+}
+
+func contentRefs(id string, doc bson.M) []ref {
+	if doc["type"] != "file" {
+		return nil
+	}
+	return urlRefs(nil, "content", doc["content"], storage.ContentPrefix(id))
+}
+
+func feedRefs(id string, doc bson.M) []ref {
+	// Feeds are created with "feeddocument" but updated with "feedDocument".
+	refs := urlRefs(nil, "feeddocument", doc["feeddocument"], storage.FeedPrefix(id))
+	return urlRefs(refs, "feedDocument", doc["feedDocument"], storage.FeedPrefix(id))
+}
+
+func ticketRefs(id string, doc bson.M) []ref {
+	return urlRefs(nil, "attachments", doc["attachments"], storage.TicketPrefix(id))
+}
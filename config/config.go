@@ -0,0 +1,841 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the server needs. It is loaded once at startup
+// by Load and handed to the packages that need it.
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Mongo     Mongo     `yaml:"mongo"`
+	JWT       JWT       `yaml:"jwt"`
+	Admin     Admin     `yaml:"admin"`
+	OTP       OTP       `yaml:"otp"`
+	SMS       SMS       `yaml:"sms"`
+	SMTP      SMTP      `yaml:"smtp"`
+	Storage   Storage   `yaml:"storage"`
+	Images    Images    `yaml:"images"`
+	Uploads   Uploads   `yaml:"uploads"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Pricing   Pricing   `yaml:"pricing"`
+	Search    Search    `yaml:"search"`
+
+	Autocomplete    Autocomplete    `yaml:"autocomplete"`
+	Recommendations Recommendations `yaml:"recommendations"`
+	Events          Events          `yaml:"events"`
+	Popularity      Popularity      `yaml:"popularity"`
+}
+
+type Server struct {
+	Port string `yaml:"port"`
+	// TrustedProxies lists the addresses or CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For header is believed when working out the
+	// client IP. Requests from anywhere else are keyed by their own address.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type Mongo struct {
+	// URI is a complete connection string. When set, User, Password, Host
+	// and Options are ignored.
+	URI      string `yaml:"uri"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	// Options is the query string appended to the built connection string.
+	Options  string `yaml:"options"`
+	Database string `yaml:"database"`
+}
+
+// JWT holds the token signing keys. Tokens carry the ID of the key that
+// signed them, tokens issued before key IDs were introduced are refused, so
+// the first deploy with key IDs logs every session out.
+type JWT struct {
+	// SellerSecret signs the tokens of sellers and admins. It is the key
+	// with ID "default".
+	SellerSecret string `yaml:"seller_secret"`
+	// UserSecret signs the tokens of storefront users, see SellerSecret.
+	UserSecret string `yaml:"user_secret"`
+	// SellerKeys and UserKeys map key IDs to secrets for key rotation. Every
+	// listed key verifies tokens, the one named by SellerKeyID and UserKeyID
+	// signs new ones ("default" when empty).
+	SellerKeys  map[string]string `yaml:"seller_keys"`
+	SellerKeyID string            `yaml:"seller_key_id"`
+	UserKeys    map[string]string `yaml:"user_keys"`
+	UserKeyID   string            `yaml:"user_key_id"`
+}
+
+// Pricing configures prices and the tax added to quotes.
+type Pricing struct {
+	// Currency is the ISO 4217 code of prices entered without one.
+	Currency string `yaml:"currency"`
+	// TaxRate is the tax added to quotes in basis points (1800 is 18%).
+	TaxRate int `yaml:"tax_rate"`
+	// CategoryTaxRates overrides TaxRate per product category, in basis
+	// points.
+	CategoryTaxRates map[string]string `yaml:"category_tax_rates"`
+	// MaxOrderQuantity is the largest quantity a quote is given for.
+	MaxOrderQuantity int `yaml:"max_order_quantity"`
+}
+
+// CategoryTaxRate returns the tax rate of category in basis points.
+func (p Pricing) CategoryTaxRate(category string) int {
+	if rate, ok := p.CategoryTaxRates[category]; ok {
+		if bps, err := strconv.Atoi(rate); err == nil {
+			return bps
+		}
+	}
+	return p.TaxRate
+}
+
+// Search tunes the product search.
+type Search struct {
+	// Stopwords are dropped from queries unless nothing else is left.
+	Stopwords []string `yaml:"stopwords"`
+	// Synonyms maps a word to the words, separated by "|", that it also
+	// finds, e.g. "tee: tshirt|top". Synonyms work both ways and are single
+	// words.
+	Synonyms map[string]string `yaml:"synonyms"`
+	// MaxCandidates caps the number of products ranked for one query.
+	MaxCandidates int `yaml:"max_candidates"`
+	// PriceBuckets are the ascending prices, in pricing.currency, at which
+	// the price facet is split. "500,1000" gives 0-500, 500-1000 and 1000+.
+	PriceBuckets []string `yaml:"price_buckets"`
+}
+
+// Autocomplete tunes the in-memory index behind the search suggestions.
+type Autocomplete struct {
+	// Debounce is how long the index waits after a change to the catalog
+	// before it is rebuilt, so bursts of changes cause one rebuild.
+	Debounce time.Duration `yaml:"debounce"`
+	// MaxAge rebuilds the index at least this often, picking up changes
+	// made outside of the server.
+	MaxAge time.Duration `yaml:"max_age"`
+	// Budget is the time a lookup may take, slower lookups return the
+	// suggestions found so far.
+	Budget time.Duration `yaml:"budget"`
+}
+
+// Recommendations tunes the table the product recommendations are served
+// from.
+type Recommendations struct {
+	// Interval is how often the table is computed again.
+	Interval time.Duration `yaml:"interval"`
+	// Window is how far back enquiries and product views are looked at.
+	Window time.Duration `yaml:"window"`
+	// Related is the number of products kept per product and category.
+	Related int `yaml:"related"`
+}
+
+// Events tunes the pipeline that writes product views, search impressions
+// and clicks in the background.
+type Events struct {
+	// Buffer is the number of events queued for writing, more are dropped.
+	Buffer int `yaml:"buffer"`
+	// BatchSize is the most events written at once.
+	BatchSize int `yaml:"batch_size"`
+	// FlushInterval is the longest an event waits to be written.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+// Popularity tunes the daily popularity scores of products and categories.
+type Popularity struct {
+	// Interval is how often the events of the day are rolled up.
+	Interval time.Duration `yaml:"interval"`
+	// Window is how many days of roll-ups a popularity counts.
+	Window time.Duration `yaml:"window"`
+	// HalfLife is the age at which a day counts half.
+	HalfLife time.Duration `yaml:"half_life"`
+}
+
+// RateLimit throttles the endpoints that send or check OTPs and passwords,
+// the ones open to sellers before they sign in and the event endpoints.
+type RateLimit struct {
+	// Store keeps the counters: "memory" for a single instance, or "mongo"
+	// to share them between instances.
+	Store string `yaml:"store"`
+	// OTP applies to the endpoints that send a code, Login to the ones that
+	// check a code or password, Upload to the upload sessions of sellers
+	// registering and Event to the events sent by the storefront.
+	OTP    RateRule `yaml:"otp"`
+	Login  RateRule `yaml:"login"`
+	Upload RateRule `yaml:"upload"`
+	Event  RateRule `yaml:"event"`
+	// A mobile number or e-mail that hits its limit BlockAfter times within
+	// BlockWindow is refused everywhere for BlockFor. Zero disables it.
+	BlockAfter  int           `yaml:"block_after"`
+	BlockWindow time.Duration `yaml:"block_window"`
+	BlockFor    time.Duration `yaml:"block_for"`
+}
+
+// RateRule limits requests per client IP and per mobile number or e-mail.
+type RateRule struct {
+	IP      Limit `yaml:"ip"`
+	Subject Limit `yaml:"subject"`
+}
+
+// Limit allows Requests requests in any Window long period. Zero requests
+// disables the limit.
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// DefaultKeyID names the key built from SellerSecret or UserSecret.
+const DefaultKeyID = "default"
+
+// keyring returns every key of one audience and the ID of its signing key.
+func (j JWT) keyring(secret string, keys map[string]string, activeID string) (map[string]string, string) {
+	ring := map[string]string{}
+	if secret != "" {
+		ring[DefaultKeyID] = secret
+	}
+	for id, key := range keys {
+		ring[id] = key
+	}
+	if activeID == "" {
+		activeID = DefaultKeyID
+	}
+	return ring, activeID
+}
+
+func (j JWT) SellerKeyring() (map[string]string, string) {
+	return j.keyring(j.SellerSecret, j.SellerKeys, j.SellerKeyID)
+}
+
+func (j JWT) UserKeyring() (map[string]string, string) {
+	return j.keyring(j.UserSecret, j.UserKeys, j.UserKeyID)
+}
+
+type Admin struct {
+	// InviteURL is the page of the admin panel that accepts invitations,
+	// the invitation token is appended as the "token" query parameter.
+	InviteURL string `yaml:"invite_url"`
+	// InviteTTL is how long an invitation link can be used.
+	InviteTTL time.Duration `yaml:"invite_ttl"`
+}
+
+type OTP struct {
+	// Provider delivers the codes: "sms", "email" or "log". The log provider
+	// only writes codes to the server log and is meant for development.
+	Provider string `yaml:"provider"`
+	// Template is the text/template for the message body, {{.Code}} is
+	// replaced by the one-time password.
+	Template string `yaml:"template"`
+	// Subject is the e-mail subject line, also a text/template.
+	Subject string `yaml:"subject"`
+	// TTL is how long an issued code stays valid.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxAttempts is the number of wrong guesses after which a code is
+	// locked and a new one has to be requested.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Cooldown is the minimum time between two codes for the same number.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Secret keys the HMAC under which codes are stored.
+	Secret string `yaml:"secret"`
+}
+
+type SMS struct {
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	Route   string `yaml:"route"`
+	Sender  string `yaml:"sender"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type Storage struct {
+	// Driver is "s3" or "local".
+	Driver string       `yaml:"driver"`
+	S3     S3Storage    `yaml:"s3"`
+	Local  LocalStorage `yaml:"local"`
+	// Signing tunes the signed URLs handed out to read objects.
+	Signing Signing `yaml:"signing"`
+}
+
+// Signing tunes the signed URLs of stored objects. A URL is handed out
+// again until less than Renew is left of its Expiry, then a new one is
+// signed.
+type Signing struct {
+	Expiry time.Duration `yaml:"expiry"`
+	Renew  time.Duration `yaml:"renew"`
+	// CacheSize bounds the number of signed URLs kept.
+	CacheSize int `yaml:"cache_size"`
+}
+
+// Images tunes the processing of uploaded pictures.
+type Images struct {
+	// Quality is the JPEG quality of the renditions, 1 to 100.
+	Quality int `yaml:"quality"`
+	// MaxPixels rejects uploads larger than this many pixels, which would
+	// take too much memory to decode.
+	MaxPixels int `yaml:"max_pixels"`
+}
+
+// Uploads tunes the upload sessions, with which clients put files straight
+// into storage instead of sending them through the API.
+type Uploads struct {
+	// URLExpiry is how long the presigned URL of a session can be used.
+	URLExpiry time.Duration `yaml:"url_expiry"`
+	// TTL is how long an upload waits to be attached to a document before
+	// it is removed.
+	TTL time.Duration `yaml:"ttl"`
+	// CleanupInterval is how often the expired uploads are removed.
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+	// MaxImageSize and MaxDocumentSize bound the declared size of an
+	// upload, in bytes.
+	MaxImageSize    int `yaml:"max_image_size"`
+	MaxDocumentSize int `yaml:"max_document_size"`
+}
+
+type S3Storage struct {
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+}
+
+type LocalStorage struct {
+	Dir        string `yaml:"dir"`
+	PublicURL  string `yaml:"public_url"`
+	SigningKey string `yaml:"signing_key"`
+}
+
+// ConnectionURI returns the Mongo connection string, building it from the
+// individual fields when no URI was configured.
+func (m Mongo) ConnectionURI() string {
+	if m.URI != "" {
+		return m.URI
+	}
+	uri := "mongodb+srv://" + url.QueryEscape(m.User) + ":" + url.QueryEscape(m.Password) + "@" + m.Host + "/"
+	if m.Options != "" {
+		uri += "?" + m.Options
+	}
+	return uri
+}
+
+// Default returns the configuration used before the file and the
+// environment are applied. Secrets have no defaults.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:           "8080",
+			TrustedProxies: []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
+		},
+		Mongo: Mongo{
+			Host:     "grwothbiz.srweepy.mongodb.net",
+			Options:  "retryWrites=true&w=majority&appName=GrwothBiz",
+			Database: "giftOrchids",
+		},
+		Admin: Admin{
+			InviteURL: "http://localhost:3000/accept-invite",
+			InviteTTL: 72 * time.Hour,
+		},
+		OTP: OTP{
+			Provider:    "sms",
+			Template:    "Welcome to Growth Biz! Your One-Time Password (OTP) for verification is {{.Code}} Please use this code to complete the verification process. Do not share this code with anyone for security reasons.\"\n\tVisit:Growthbiz.co or mail info@growthbiz.co",
+			Subject:     "Your Growth Biz verification code",
+			TTL:         5 * time.Minute,
+			MaxAttempts: 5,
+			Cooldown:    30 * time.Second,
+		},
+		SMS: SMS{
+			BaseURL: "https://1.rapidsms.co.in/api/push",
+			Route:   "TRANS",
+			Sender:  "GRWTHB",
+		},
+		SMTP: SMTP{Port: "587"},
+		Storage: Storage{
+			Driver:  "s3",
+			Signing: Signing{Expiry: 24 * time.Hour, Renew: time.Hour, CacheSize: 100000},
+		},
+		Images: Images{Quality: 82, MaxPixels: 40000000},
+		Uploads: Uploads{
+			URLExpiry:       15 * time.Minute,
+			TTL:             24 * time.Hour,
+			CleanupInterval: time.Hour,
+			MaxImageSize:    20 << 20,
+			MaxDocumentSize: 10 << 20,
+		},
+		Pricing: Pricing{Currency: "INR", TaxRate: 1800, MaxOrderQuantity: 1000000},
+		Search: Search{
+			Stopwords:     []string{"a", "an", "and", "by", "for", "in", "of", "on", "or", "the", "to", "with"},
+			MaxCandidates: 1000,
+			PriceBuckets:  []string{"500", "1000", "2500", "5000", "10000"},
+		},
+		Autocomplete: Autocomplete{
+			Debounce: 2 * time.Second,
+			MaxAge:   10 * time.Minute,
+			Budget:   50 * time.Millisecond,
+		},
+		Recommendations: Recommendations{
+			Interval: time.Hour,
+			Window:   180 * 24 * time.Hour,
+			Related:  20,
+		},
+		Events: Events{
+			Buffer:        10000,
+			BatchSize:     500,
+			FlushInterval: 2 * time.Second,
+		},
+		Popularity: Popularity{
+			Interval: 15 * time.Minute,
+			Window:   30 * 24 * time.Hour,
+			HalfLife: 7 * 24 * time.Hour,
+		},
+		RateLimit: RateLimit{
+			Store: "memory",
+			OTP: RateRule{
+				IP:      Limit{Requests: 20, Window: time.Hour},
+				Subject: Limit{Requests: 5, Window: 15 * time.Minute},
+			},
+			Login: RateRule{
+				IP:      Limit{Requests: 30, Window: 15 * time.Minute},
+				Subject: Limit{Requests: 10, Window: 15 * time.Minute},
+			},
+			Upload: RateRule{
+				IP:      Limit{Requests: 60, Window: 15 * time.Minute},
+				Subject: Limit{Requests: 30, Window: 15 * time.Minute},
+			},
+			Event: RateRule{
+				IP: Limit{Requests: 300, Window: 15 * time.Minute},
+			},
+			BlockAfter:  3,
+			BlockWindow: time.Hour,
+			BlockFor:    24 * time.Hour,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file named by
+// CONFIG_FILE (if any) and finally the environment, then validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// envBinding maps environment variables onto a field, which is a *string,
+// *int, *time.Duration, a *[]string written as "a,b" or a
+// *map[string]string written as "k1:v1,k2:v2". The first variable that is
+// set wins, later names are kept for older deployments.
+type envBinding struct {
+	field interface{}
+	names []string
+}
+
+func (c *Config) bindings() []envBinding {
+	return []envBinding{
+		{&c.Server.Port, []string{"PORT"}},
+		{&c.Mongo.URI, []string{"MONGO_URI"}},
+		{&c.Mongo.User, []string{"DB_USER"}},
+		{&c.Mongo.Password, []string{"DB_PASSWORD"}},
+		{&c.Mongo.Host, []string{"DB_HOST"}},
+		{&c.Mongo.Options, []string{"DB_OPTIONS"}},
+		{&c.Mongo.Database, []string{"DB_NAME"}},
+		{&c.JWT.SellerSecret, []string{"JWT_SELLER_SECRET", "SECRET_KRY"}},
+		{&c.JWT.UserSecret, []string{"JWT_USER_SECRET", "SECRET_USERKEY"}},
+		{&c.JWT.SellerKeys, []string{"JWT_SELLER_KEYS"}},
+		{&c.JWT.SellerKeyID, []string{"JWT_SELLER_KEY_ID"}},
+		{&c.JWT.UserKeys, []string{"JWT_USER_KEYS"}},
+		{&c.JWT.UserKeyID, []string{"JWT_USER_KEY_ID"}},
+		{&c.Admin.InviteURL, []string{"ADMIN_INVITE_URL"}},
+		{&c.Admin.InviteTTL, []string{"ADMIN_INVITE_TTL"}},
+		{&c.OTP.Provider, []string{"OTP_PROVIDER"}},
+		{&c.OTP.Template, []string{"OTP_TEMPLATE"}},
+		{&c.OTP.Subject, []string{"OTP_SUBJECT"}},
+		{&c.OTP.TTL, []string{"OTP_TTL"}},
+		{&c.OTP.MaxAttempts, []string{"OTP_MAX_ATTEMPTS"}},
+		{&c.OTP.Cooldown, []string{"OTP_COOLDOWN"}},
+		{&c.OTP.Secret, []string{"OTP_SECRET"}},
+		{&c.SMS.BaseURL, []string{"SMS_BASE_URL"}},
+		{&c.SMS.APIKey, []string{"SMS_API_KEY"}},
+		{&c.SMS.Route, []string{"SMS_ROUTE"}},
+		{&c.SMS.Sender, []string{"SMS_SENDER"}},
+		{&c.SMTP.Host, []string{"SMTP_HOST"}},
+		{&c.SMTP.Port, []string{"SMTP_PORT"}},
+		{&c.SMTP.Username, []string{"SMTP_USERNAME"}},
+		{&c.SMTP.Password, []string{"SMTP_PASSWORD"}},
+		{&c.SMTP.From, []string{"SMTP_FROM"}},
+		{&c.Storage.Driver, []string{"STORAGE_DRIVER"}},
+		{&c.Storage.S3.AccessKey, []string{"AWS_ACCESS_KEY_ID"}},
+		{&c.Storage.S3.SecretKey, []string{"AWS_SECRET_ACCESS_KEY"}},
+		{&c.Storage.S3.Region, []string{"AWS_REGION"}},
+		{&c.Storage.S3.Bucket, []string{"AWS_BUCKET_NAME"}},
+		{&c.Storage.Local.Dir, []string{"STORAGE_LOCAL_DIR"}},
+		{&c.Storage.Local.PublicURL, []string{"STORAGE_PUBLIC_URL"}},
+		{&c.Storage.Local.SigningKey, []string{"STORAGE_SIGNING_KEY"}},
+		{&c.Storage.Signing.Expiry, []string{"STORAGE_SIGNING_EXPIRY"}},
+		{&c.Storage.Signing.Renew, []string{"STORAGE_SIGNING_RENEW"}},
+		{&c.Storage.Signing.CacheSize, []string{"STORAGE_SIGNING_CACHE_SIZE"}},
+		{&c.Images.Quality, []string{"IMAGES_QUALITY"}},
+		{&c.Images.MaxPixels, []string{"IMAGES_MAX_PIXELS"}},
+		{&c.Uploads.URLExpiry, []string{"UPLOADS_URL_EXPIRY"}},
+		{&c.Uploads.TTL, []string{"UPLOADS_TTL"}},
+		{&c.Uploads.CleanupInterval, []string{"UPLOADS_CLEANUP_INTERVAL"}},
+		{&c.Uploads.MaxImageSize, []string{"UPLOADS_MAX_IMAGE_SIZE"}},
+		{&c.Uploads.MaxDocumentSize, []string{"UPLOADS_MAX_DOCUMENT_SIZE"}},
+		{&c.Server.TrustedProxies, []string{"TRUSTED_PROXIES"}},
+		{&c.RateLimit.Store, []string{"RATE_LIMIT_STORE"}},
+		{&c.RateLimit.OTP.IP.Requests, []string{"RATE_LIMIT_OTP_IP_REQUESTS"}},
+		{&c.RateLimit.OTP.IP.Window, []string{"RATE_LIMIT_OTP_IP_WINDOW"}},
+		{&c.RateLimit.OTP.Subject.Requests, []string{"RATE_LIMIT_OTP_MOBILE_REQUESTS"}},
+		{&c.RateLimit.OTP.Subject.Window, []string{"RATE_LIMIT_OTP_MOBILE_WINDOW"}},
+		{&c.RateLimit.Login.IP.Requests, []string{"RATE_LIMIT_LOGIN_IP_REQUESTS"}},
+		{&c.RateLimit.Login.IP.Window, []string{"RATE_LIMIT_LOGIN_IP_WINDOW"}},
+		{&c.RateLimit.Login.Subject.Requests, []string{"RATE_LIMIT_LOGIN_MOBILE_REQUESTS"}},
+		{&c.RateLimit.Login.Subject.Window, []string{"RATE_LIMIT_LOGIN_MOBILE_WINDOW"}},
+		{&c.RateLimit.Upload.IP.Requests, []string{"RATE_LIMIT_UPLOAD_IP_REQUESTS"}},
+		{&c.RateLimit.Upload.IP.Window, []string{"RATE_LIMIT_UPLOAD_IP_WINDOW"}},
+		{&c.RateLimit.Upload.Subject.Requests, []string{"RATE_LIMIT_UPLOAD_MOBILE_REQUESTS"}},
+		{&c.RateLimit.Upload.Subject.Window, []string{"RATE_LIMIT_UPLOAD_MOBILE_WINDOW"}},
+		{&c.RateLimit.Event.IP.Requests, []string{"RATE_LIMIT_EVENT_IP_REQUESTS"}},
+		{&c.RateLimit.Event.IP.Window, []string{"RATE_LIMIT_EVENT_IP_WINDOW"}},
+		{&c.RateLimit.BlockAfter, []string{"RATE_LIMIT_BLOCK_AFTER"}},
+		{&c.RateLimit.BlockWindow, []string{"RATE_LIMIT_BLOCK_WINDOW"}},
+		{&c.RateLimit.BlockFor, []string{"RATE_LIMIT_BLOCK_FOR"}},
+		{&c.Pricing.Currency, []string{"PRICING_CURRENCY"}},
+		{&c.Pricing.TaxRate, []string{"PRICING_TAX_RATE"}},
+		{&c.Pricing.CategoryTaxRates, []string{"PRICING_CATEGORY_TAX_RATES"}},
+		{&c.Pricing.MaxOrderQuantity, []string{"PRICING_MAX_ORDER_QUANTITY"}},
+		{&c.Search.Stopwords, []string{"SEARCH_STOPWORDS"}},
+		{&c.Search.Synonyms, []string{"SEARCH_SYNONYMS"}},
+		{&c.Search.MaxCandidates, []string{"SEARCH_MAX_CANDIDATES"}},
+		{&c.Search.PriceBuckets, []string{"SEARCH_PRICE_BUCKETS"}},
+		{&c.Autocomplete.Debounce, []string{"AUTOCOMPLETE_DEBOUNCE"}},
+		{&c.Autocomplete.MaxAge, []string{"AUTOCOMPLETE_MAX_AGE"}},
+		{&c.Autocomplete.Budget, []string{"AUTOCOMPLETE_BUDGET"}},
+		{&c.Recommendations.Interval, []string{"RECOMMENDATIONS_INTERVAL"}},
+		{&c.Recommendations.Window, []string{"RECOMMENDATIONS_WINDOW"}},
+		{&c.Recommendations.Related, []string{"RECOMMENDATIONS_RELATED"}},
+		{&c.Events.Buffer, []string{"EVENTS_BUFFER"}},
+		{&c.Events.BatchSize, []string{"EVENTS_BATCH_SIZE"}},
+		{&c.Events.FlushInterval, []string{"EVENTS_FLUSH_INTERVAL"}},
+		{&c.Popularity.Interval, []string{"POPULARITY_INTERVAL"}},
+		{&c.Popularity.Window, []string{"POPULARITY_WINDOW"}},
+		{&c.Popularity.HalfLife, []string{"POPULARITY_HALF_LIFE"}},
+	}
+}
+
+func (c *Config) applyEnv() error {
+	var problems ValidationError
+	for _, b := range c.bindings() {
+		for _, name := range b.names {
+			value, ok := os.LookupEnv(name)
+			if !ok || value == "" {
+				continue
+			}
+			switch field := b.field.(type) {
+			case *string:
+				*field = value
+			case *int:
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s %q is not a number", name, value))
+				}
+				*field = n
+			case *time.Duration:
+				d, err := time.ParseDuration(value)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s %q is not a duration such as 30s or 5m", name, value))
+				}
+				*field = d
+			case *[]string:
+				var list []string
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						list = append(list, item)
+					}
+				}
+				*field = list
+			case *map[string]string:
+				m := map[string]string{}
+				for _, pair := range strings.Split(value, ",") {
+					k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+					if !ok || k == "" || v == "" {
+						problems = append(problems, fmt.Sprintf("%s entry %q is not of the form id:value", name, pair))
+						continue
+					}
+					m[k] = v
+				}
+				*field = m
+			}
+			break
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// ValidationError lists every problem found in a configuration so they can
+// all be fixed in one go.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Validate reports missing or malformed settings as a ValidationError.
+func (c *Config) Validate() error {
+	var problems ValidationError
+	require := func(value, key, env string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, fmt.Sprintf("%s is required (set %s or %s in the config file)", key, env, key))
+		}
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port %q is not a valid port", c.Server.Port))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problems = append(problems, fmt.Sprintf("server.trusted_proxies entry %q is not an IP address or CIDR range", proxy))
+		}
+	}
+
+	if c.Mongo.URI == "" {
+		require(c.Mongo.User, "mongo.user", "DB_USER")
+		require(c.Mongo.Password, "mongo.password", "DB_PASSWORD")
+		require(c.Mongo.Host, "mongo.host", "DB_HOST")
+	}
+	require(c.Mongo.Database, "mongo.database", "DB_NAME")
+
+	checkKeyring := func(audience string, ring map[string]string, activeID string) {
+		if len(ring) == 0 {
+			problems = append(problems, fmt.Sprintf("jwt.%s_secret or jwt.%s_keys is required (set JWT_%s_SECRET or JWT_%s_KEYS)",
+				audience, audience, strings.ToUpper(audience), strings.ToUpper(audience)))
+			return
+		}
+		if _, ok := ring[activeID]; !ok {
+			problems = append(problems, fmt.Sprintf("jwt.%s_key_id %q is not one of the configured keys", audience, activeID))
+		}
+		for id, secret := range ring {
+			if strings.TrimSpace(secret) == "" {
+				problems = append(problems, fmt.Sprintf("jwt %s key %q is empty", audience, id))
+			}
+		}
+	}
+	sellerRing, sellerID := c.JWT.SellerKeyring()
+	checkKeyring("seller", sellerRing, sellerID)
+	userRing, userID := c.JWT.UserKeyring()
+	checkKeyring("user", userRing, userID)
+
+	if u, err := url.Parse(c.Admin.InviteURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("admin.invite_url %q is not an absolute URL", c.Admin.InviteURL))
+	}
+	if c.Admin.InviteTTL <= 0 {
+		problems = append(problems, "admin.invite_ttl must be positive")
+	}
+
+	require(c.OTP.Template, "otp.template", "OTP_TEMPLATE")
+	require(c.OTP.Secret, "otp.secret", "OTP_SECRET")
+	if c.OTP.TTL <= 0 {
+		problems = append(problems, "otp.ttl must be positive")
+	}
+	if c.OTP.MaxAttempts <= 0 {
+		problems = append(problems, "otp.max_attempts must be positive")
+	}
+	if c.OTP.Cooldown < 0 {
+		problems = append(problems, "otp.cooldown can't be negative")
+	}
+	switch strings.ToLower(c.OTP.Provider) {
+	case "sms":
+		if u, err := url.Parse(c.SMS.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("sms.base_url %q is not an absolute URL", c.SMS.BaseURL))
+		}
+		require(c.SMS.APIKey, "sms.api_key", "SMS_API_KEY")
+		require(c.SMS.Sender, "sms.sender", "SMS_SENDER")
+	case "email":
+		require(c.SMTP.Host, "smtp.host", "SMTP_HOST")
+		require(c.SMTP.From, "smtp.from", "SMTP_FROM")
+		if port, err := strconv.Atoi(c.SMTP.Port); err != nil || port <= 0 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("smtp.port %q is not a valid port", c.SMTP.Port))
+		}
+	case "log":
+	default:
+		problems = append(problems, fmt.Sprintf("otp.provider %q is not one of sms, email, log", c.OTP.Provider))
+	}
+
+	switch strings.ToLower(c.Storage.Driver) {
+	case "s3":
+		require(c.Storage.S3.Region, "storage.s3.region", "AWS_REGION")
+		require(c.Storage.S3.Bucket, "storage.s3.bucket", "AWS_BUCKET_NAME")
+	case "local":
+	default:
+		problems = append(problems, fmt.Sprintf("storage.driver %q is not one of s3, local", c.Storage.Driver))
+	}
+	if c.Storage.Signing.Expiry <= 0 {
+		problems = append(problems, "storage.signing.expiry must be positive")
+	}
+	if c.Storage.Signing.Renew < 0 || c.Storage.Signing.Renew >= c.Storage.Signing.Expiry {
+		problems = append(problems, "storage.signing.renew must be at least 0 and less than storage.signing.expiry")
+	}
+	if c.Storage.Signing.CacheSize < 0 {
+		problems = append(problems, "storage.signing.cache_size can't be negative")
+	}
+	if c.Images.Quality < 1 || c.Images.Quality > 100 {
+		problems = append(problems, "images.quality must be between 1 and 100")
+	}
+	if c.Images.MaxPixels <= 0 {
+		problems = append(problems, "images.max_pixels must be positive")
+	}
+	if c.Uploads.URLExpiry <= 0 || c.Uploads.TTL <= 0 || c.Uploads.CleanupInterval <= 0 {
+		problems = append(problems, "uploads.url_expiry, uploads.ttl and uploads.cleanup_interval must be positive")
+	}
+	if c.Uploads.TTL < c.Uploads.URLExpiry {
+		problems = append(problems, "uploads.ttl can't be shorter than uploads.url_expiry")
+	}
+	if c.Uploads.MaxImageSize <= 0 || c.Uploads.MaxDocumentSize <= 0 {
+		problems = append(problems, "uploads.max_image_size and uploads.max_document_size must be positive")
+	}
+
+	if len(c.Pricing.Currency) != 3 {
+		problems = append(problems, fmt.Sprintf("pricing.currency %q is not a three letter ISO 4217 code", c.Pricing.Currency))
+	}
+	if c.Pricing.TaxRate < 0 {
+		problems = append(problems, "pricing.tax_rate can't be negative")
+	}
+	for category, rate := range c.Pricing.CategoryTaxRates {
+		if bps, err := strconv.Atoi(rate); err != nil || bps < 0 {
+			problems = append(problems, fmt.Sprintf("pricing.category_tax_rates %q rate %q is not a number of basis points", category, rate))
+		}
+	}
+	if c.Pricing.MaxOrderQuantity <= 0 {
+		problems = append(problems, "pricing.max_order_quantity must be positive")
+	}
+
+	if c.Search.MaxCandidates <= 0 {
+		problems = append(problems, "search.max_candidates must be positive")
+	}
+	previous := 0.0
+	for _, edge := range c.Search.PriceBuckets {
+		price, err := strconv.ParseFloat(edge, 64)
+		if err != nil || price <= previous {
+			problems = append(problems, fmt.Sprintf("search.price_buckets %q is not a price above the one before", edge))
+			continue
+		}
+		previous = price
+	}
+	for word, synonyms := range c.Search.Synonyms {
+		for _, synonym := range append([]string{word}, strings.Split(synonyms, "|")...) {
+			if strings.TrimSpace(synonym) == "" || strings.IndexFunc(strings.TrimSpace(synonym), isNotWordRune) >= 0 {
+				problems = append(problems, fmt.Sprintf("search.synonyms %q entry %q is not a single word", word, synonym))
+			}
+		}
+	}
+	if c.Autocomplete.Debounce < 0 {
+		problems = append(problems, "autocomplete.debounce can't be negative")
+	}
+	if c.Autocomplete.MaxAge <= 0 {
+		problems = append(problems, "autocomplete.max_age must be positive")
+	}
+	if c.Autocomplete.Budget <= 0 {
+		problems = append(problems, "autocomplete.budget must be positive")
+	}
+	if c.Recommendations.Interval <= 0 {
+		problems = append(problems, "recommendations.interval must be positive")
+	}
+	if c.Recommendations.Window <= 0 {
+		problems = append(problems, "recommendations.window must be positive")
+	}
+	if c.Recommendations.Related <= 0 {
+		problems = append(problems, "recommendations.related must be positive")
+	}
+	if c.Events.Buffer <= 0 {
+		problems = append(problems, "events.buffer must be positive")
+	}
+	if c.Events.BatchSize <= 0 {
+		problems = append(problems, "events.batch_size must be positive")
+	}
+	if c.Events.FlushInterval <= 0 {
+		problems = append(problems, "events.flush_interval must be positive")
+	}
+	if c.Popularity.Interval <= 0 {
+		problems = append(problems, "popularity.interval must be positive")
+	}
+	if c.Popularity.Window < 24*time.Hour {
+		problems = append(problems, "popularity.window must be at least a day")
+	}
+	if c.Popularity.HalfLife <= 0 {
+		problems = append(problems, "popularity.half_life must be positive")
+	}
+
+	switch strings.ToLower(c.RateLimit.Store) {
+	case "memory", "mongo":
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.store %q is not one of memory, mongo", c.RateLimit.Store))
+	}
+	checkLimit := func(key string, l Limit) {
+		if l.Requests < 0 {
+			problems = append(problems, fmt.Sprintf("rate_limit.%s.requests can't be negative", key))
+		}
+		if l.Requests > 0 && l.Window <= 0 {
+			problems = append(problems, fmt.Sprintf("rate_limit.%s.window must be positive", key))
+		}
+	}
+	checkLimit("otp.ip", c.RateLimit.OTP.IP)
+	checkLimit("otp.subject", c.RateLimit.OTP.Subject)
+	checkLimit("login.ip", c.RateLimit.Login.IP)
+	checkLimit("login.subject", c.RateLimit.Login.Subject)
+	checkLimit("upload.ip", c.RateLimit.Upload.IP)
+	checkLimit("upload.subject", c.RateLimit.Upload.Subject)
+	checkLimit("event.ip", c.RateLimit.Event.IP)
+	if c.RateLimit.BlockAfter < 0 {
+		problems = append(problems, "rate_limit.block_after can't be negative")
+	}
+	if c.RateLimit.BlockAfter > 0 && (c.RateLimit.BlockWindow <= 0 || c.RateLimit.BlockFor <= 0) {
+		problems = append(problems, "rate_limit.block_window and rate_limit.block_for must be positive")
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/mail"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	generate "github.com/kravi0/BizGrowth-backend/tokens"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// currentAdmin returns the enabled admin account the request was
+// authenticated as.
+func (app *Application) currentAdmin(ctx context.Context, c *gin.Context) (*models.AdminUser, bool) {
+	id, err := primitive.ObjectIDFromHex(c.GetString("uid"))
+	if err != nil {
+		return nil, false
+	}
+	admin, err := app.repos.Admins.FindByID(ctx, id)
+	if err != nil || admin.Disabled || admin.Email != c.GetString("email") {
+		return nil, false
+	}
+	return admin, true
+}
+
+// ActiveAdmin rejects admin tokens whose account was disabled or had its
+// role changed after the token was issued.
+func (app *Application) ActiveAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		admin, ok := app.currentAdmin(ctx, c)
+		if !ok || admin.Role != c.GetString("role") {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "session has ended, please log in again"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newInviteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (app *Application) adminSession(ctx context.Context, admin *models.AdminUser) (gin.H, error) {
+	token, err := generate.TokenGenerator(admin.Email, admin.MobileNo, admin.Name, admin.ID.Hex(), admin.Role)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := app.issueRefreshToken(ctx, admin.ID.Hex(), admin.Role, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{"token": token, "refresh_token": refreshToken, "admin": admin}, nil
+}
+
+func (app *Application) AdminLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var input struct {
+			Email    string `json:"email" binding:"required"`
+			Password string `json:"password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "email and password are required"})
+			return
+		}
+
+		admin, err := app.repos.Admins.FindOne(ctx, repository.AdminFilter{Email: strings.ToLower(strings.TrimSpace(input.Email))})
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if err == repository.ErrNotFound || bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(input.Password)) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "invalid email or password"})
+			return
+		}
+		if admin.Disabled {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "this account has been disabled"})
+			return
+		}
+
+		session, err := app.adminSession(ctx, admin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// InviteAdmin e-mails a one-time link that lets the receiver create an
+// admin account with the given role. It answers 503 when no SMTP server is
+// configured.
+func (app *Application) InviteAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		inviter, ok := app.currentAdmin(ctx, c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized to access this resource"})
+			return
+		}
+		// The invitation link is a credential, it's only ever mailed.
+		if !mail.Configured(app.mailer) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "invitations need an SMTP server to be configured"})
+			return
+		}
+
+		var input struct {
+			Email string `json:"email" binding:"required,email"`
+			Role  string `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "a valid email and role are required"})
+			return
+		}
+		if !utils.IsAdminRole(input.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("role must be one of %s", strings.Join(utils.AdminRoles, ", "))})
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(input.Email))
+
+		count, err := app.repos.Admins.Count(ctx, repository.AdminFilter{Email: email})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"Error": "an admin with this email already exists"})
+			return
+		}
+
+		token, err := newInviteToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		now := time.Now()
+		invite := models.AdminInvite{
+			ID:         primitive.NewObjectID(),
+			Email:      email,
+			Role:       input.Role,
+			TokenHash:  hashInviteToken(token),
+			InvitedBy:  inviter.ID.Hex(),
+			Expires_at: now.Add(app.config.Admin.InviteTTL),
+			Created_at: now,
+		}
+		if err := app.repos.AdminInvites.Insert(ctx, &invite); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		link, err := url.Parse(app.config.Admin.InviteURL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		body := fmt.Sprintf("%s invited you to the Growth Biz admin panel.\n\nOpen the link below to set up your account, it can be used once and expires on %s.\n\n%s",
+			inviter.Name, invite.Expires_at.Format(time.RFC1123), link.String())
+		if err := app.mailer.Send(ctx, email, "Your Growth Biz admin invitation", body); err != nil {
+			log.Println("sending admin invitation:", err)
+			c.JSON(http.StatusBadGateway, gin.H{"Error": "could not send the invitation email"})
+			return
+		}
+
+		setAuditEntity(c, invite.ID)
+		c.JSON(http.StatusCreated, gin.H{"message": "invitation sent", "invite": invite})
+	}
+}
+
+// AcceptAdminInvite creates the admin account of an invitation. The
+// invitation is marked accepted first so a token can only be used once.
+func (app *Application) AcceptAdminInvite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var input struct {
+			Token    string `json:"token" binding:"required"`
+			Name     string `json:"name" binding:"required"`
+			MobileNo string `json:"mobileno" binding:"required"`
+			Password string `json:"password" binding:"required,min=8"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "token, name, mobileno and a password of at least 8 characters are required"})
+			return
+		}
+
+		invite, err := app.repos.AdminInvites.FindByTokenHash(ctx, hashInviteToken(input.Token))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "invitation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if invite.Accepted || time.Now().After(invite.Expires_at) {
+			c.JSON(http.StatusGone, gin.H{"Error": "this invitation has expired or was already used"})
+			return
+		}
+		count, err := app.repos.Admins.Count(ctx, repository.AdminFilter{Email: invite.Email})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"Error": "an admin with this email already exists"})
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		// Accepting first claims the invite, so it creates one account
+		// however many requests use it at once.
+		now := time.Now()
+		if err := app.repos.AdminInvites.Accept(ctx, invite.ID, now); err == repository.ErrNotFound {
+			c.JSON(http.StatusGone, gin.H{"Error": "this invitation has expired or was already used"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		admin := models.AdminUser{
+			ID:         primitive.NewObjectID(),
+			Name:       input.Name,
+			Email:      invite.Email,
+			MobileNo:   input.MobileNo,
+			Password:   string(hashedPassword),
+			Role:       invite.Role,
+			InvitedBy:  invite.InvitedBy,
+			Created_at: now,
+			Updated_at: now,
+		}
+		if err := app.repos.Admins.Insert(ctx, &admin); err != nil {
+			log.Println("creating invited admin:", err)
+			// Hand the invite back so the link can be used again.
+			if err := app.repos.AdminInvites.Reopen(ctx, invite.ID); err != nil {
+				log.Println("reopening admin invitation", invite.ID.Hex()+":", err)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		session, err := app.adminSession(ctx, &admin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusCreated, session)
+	}
+}
+
+func (app *Application) ListAdmins() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		filter := repository.AdminFilter{Role: c.Query("role")}
+		switch c.Query("disabled") {
+		case "true":
+			filter.Disabled = repository.Bool(true)
+		case "false":
+			filter.Disabled = repository.Bool(false)
+		}
+
+		admins, err := app.repos.Admins.Find(ctx, filter, repository.Page{Sort: "created_at"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if admins == nil {
+			admins = []models.AdminUser{}
+		}
+		c.JSON(http.StatusOK, admins)
+	}
+}
+
+// updateAdmin applies set to the admin named by the :id parameter. Admins
+// cannot change their own account, so there is always a super admin left.
+func (app *Application) updateAdmin(c *gin.Context, set repository.Fields) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid admin id"})
+		return
+	}
+	if id.Hex() == c.GetString("uid") {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "you cannot change your own account"})
+		return
+	}
+
+	set["updated_at"] = time.Now()
+	err = app.repos.Admins.Update(ctx, id, repository.Update{Set: set})
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "admin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return
+	}
+
+	admin, err := app.repos.Admins.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return
+	}
+	c.JSON(http.StatusOK, admin)
+}
+
+func (app *Application) DisableAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		app.updateAdmin(c, repository.Fields{"disabled": true})
+	}
+}
+
+func (app *Application) EnableAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		app.updateAdmin(c, repository.Fields{"disabled": false})
+	}
+}
+
+func (app *Application) ChangeAdminRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || !utils.IsAdminRole(input.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("role must be one of %s", strings.Join(utils.AdminRoles, ", "))})
+			return
+		}
+		app.updateAdmin(c, repository.Fields{"role": input.Role})
+	}
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/tokens"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAcceptAdminInviteOnce(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	if err := tokens.Configure(config.JWT{SellerSecret: "seller", UserSecret: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	token, err := newInviteToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite := models.AdminInvite{
+		ID:         primitive.NewObjectID(),
+		Email:      "new@example.com",
+		Role:       utils.Admin,
+		TokenHash:  hashInviteToken(token),
+		Expires_at: time.Now().Add(time.Hour),
+	}
+	if err := app.repos.AdminInvites.Insert(ctx, &invite); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.POST("/admin/invitations/accept", app.AcceptAdminInvite())
+	body, _ := json.Marshal(gin.H{"token": token, "name": "New", "mobileno": "9000000004", "password": "a long password"})
+
+	if w := serve(router, http.MethodPost, "/admin/invitations/accept", "application/json", body); w.Code != http.StatusCreated {
+		t.Fatalf("accept: %d %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodPost, "/admin/invitations/accept", "application/json", body); w.Code != http.StatusGone {
+		t.Fatalf("second accept: %d %s", w.Code, w.Body)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
-	"log"
 	"math"
 	"net/http"
 	"strconv"
@@ -14,18 +13,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // get analytics related to seller registered, user, enquiry, from last 30 days and last 1 days
-func GetAnalytics() gin.HandlerFunc {
+func (app *Application) GetAnalytics() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized to access it"})
 			return
@@ -36,34 +34,34 @@ func GetAnalytics() gin.HandlerFunc {
 
 		daysInt, _ := strconv.ParseInt(days, 10, 64)
 
-		total_user_count, err := CountDocument(UserCollection, ctx, -1)
+		total_user_count, err := CountDocument(app.countUsersCreated, ctx, -1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		user_count, err := CountDocument(UserCollection, ctx, int(daysInt))
+		user_count, err := CountDocument(app.countUsersCreated, ctx, int(daysInt))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		user_one_day_count, err := CountDocument(UserCollection, ctx, 1)
+		user_one_day_count, err := CountDocument(app.countUsersCreated, ctx, 1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		seller_total_count, err := CountDocument(SellerCollection, ctx, -1)
+		seller_total_count, err := CountDocument(app.repos.Sellers.CountCreated, ctx, -1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		sellerCount, err := CountDocument(SellerCollection, ctx, int(daysInt))
+		sellerCount, err := CountDocument(app.repos.Sellers.CountCreated, ctx, int(daysInt))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
@@ -71,7 +69,7 @@ func GetAnalytics() gin.HandlerFunc {
 
 		//one day seller count
 
-		seller_one_day_count, err := CountDocument(SellerCollection, ctx, 1)
+		seller_one_day_count, err := CountDocument(app.repos.Sellers.CountCreated, ctx, 1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
@@ -79,21 +77,21 @@ func GetAnalytics() gin.HandlerFunc {
 		}
 
 		//total enquiry count
-		total_enquiry_count, err := CountDocument(EnquireCollection, ctx, -1)
+		total_enquiry_count, err := CountDocument(app.repos.Enquiries.CountCreated, ctx, -1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		enquiry_count, err := CountDocument(EnquireCollection, ctx, int(daysInt))
+		enquiry_count, err := CountDocument(app.repos.Enquiries.CountCreated, ctx, int(daysInt))
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		enquiry_one_day_count, err := CountDocument(EnquireCollection, ctx, 1)
+		enquiry_one_day_count, err := CountDocument(app.repos.Enquiries.CountCreated, ctx, 1)
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
@@ -101,19 +99,19 @@ func GetAnalytics() gin.HandlerFunc {
 		}
 
 		// Previous period counts
-		previousUserCount, err := CountDocumentInRange(UserCollection, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
+		previousUserCount, err := CountDocumentInRange(app.countUsersCreated, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		previousSellerCount, err := CountDocumentInRange(SellerCollection, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
+		previousSellerCount, err := CountDocumentInRange(app.repos.Sellers.CountCreated, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
 
-		previousEnquiryCount, err := CountDocumentInRange(EnquireCollection, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
+		previousEnquiryCount, err := CountDocumentInRange(app.repos.Enquiries.CountCreated, ctx, time.Now().AddDate(0, 0, -2*int(daysInt)), time.Now().AddDate(0, 0, -int(daysInt)))
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
@@ -152,51 +150,44 @@ func GetAnalytics() gin.HandlerFunc {
 	}
 }
 
-func CountDocument(collection *mongo.Collection, ctx context.Context, days int) (int64, error) {
+// createdCounter counts documents created in [from, to), a zero bound is open.
+type createdCounter func(ctx context.Context, from, to time.Time) (int64, error)
 
-	var filter primitive.M
+func (app *Application) countUsersCreated(ctx context.Context, from, to time.Time) (int64, error) {
+	filter := bson.M{}
+	created := bson.M{}
+	if !from.IsZero() {
+		created["$gte"] = primitive.NewDateTimeFromTime(from)
+	}
+	if !to.IsZero() {
+		created["$lt"] = primitive.NewDateTimeFromTime(to)
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	return app.userCollection.CountDocuments(ctx, filter)
+}
+
+func CountDocument(count createdCounter, ctx context.Context, days int) (int64, error) {
+
+	var from time.Time
 
 	if days > 0 {
-		pastTime := time.Now().AddDate(0, 0, -days)
-		filter = bson.M{
-			"created_at": bson.M{
-				"$gte": primitive.NewDateTimeFromTime(pastTime)},
-		}
-	} else {
-		filter = bson.M{}
+		from = time.Now().AddDate(0, 0, -days)
 	}
 
-	count, err := collection.CountDocuments(ctx, filter)
-	return count, err
+	return count(ctx, from, time.Time{})
 }
 
 func CountDocumentInRange(
-	collection *mongo.Collection,
+	count createdCounter,
 	ctx context.Context,
 	startDate time.Time,
 	endDate time.Time,
 ) (int64, error) {
 	endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond)
 
-	filter := bson.M{
-
-		"created_at": bson.M{
-
-			"$gte": primitive.NewDateTimeFromTime(startDate),
-
-			"$lte": primitive.NewDateTimeFromTime(endDate),
-		},
-	}
-
-	count, err := collection.CountDocuments(ctx, filter)
-
-	if err != nil {
-
-		log.Fatal(err)
-
-	}
-
-	return count, nil
+	return count(ctx, startDate, endDate)
 }
 
 func CalculatePercentageChange(currentCount, previousCount int64) float64 {
@@ -211,7 +202,7 @@ func CalculatePercentageChange(currentCount, previousCount int64) float64 {
 	return math.Round(percentageChange*100) / 100
 }
 
-func GenerateCSVByCollection() gin.HandlerFunc {
+func (app *Application) GenerateCSVByCollection() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		collectionName := c.Query("collection") // Collection name from URL parameter
 		if collectionName == "" {
@@ -221,13 +212,13 @@ func GenerateCSVByCollection() gin.HandlerFunc {
 
 		switch collectionName {
 		case "seller":
-			GetSellerCSV(c)
+			app.GetSellerCSV(c)
 		case "product":
-			GetProductsCsv(c)
+			app.GetProductsCsv(c)
 		case "user":
-			GetUserCsv(c)
+			app.GetUserCsv(c)
 		case "enquiry":
-			GetEnquiryDetailsCsv(c)
+			app.GetEnquiryDetailsCsv(c)
 		default:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection name"})
 			return
@@ -6,20 +6,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var AttributesCollection *mongo.Collection = database.ProductData(database.Client, "AttributeType")
-
-func GetAllAttributes() gin.HandlerFunc {
+func (app *Application) GetAllAttributes() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cursor, err := AttributesCollection.Find(ctx, bson.M{})
+		cursor, err := app.attributesCollection.Find(ctx, bson.M{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
 			return
@@ -35,7 +32,7 @@ func GetAllAttributes() gin.HandlerFunc {
 
 // @Summary Add new attribute type to the
 
-func AddAttributeType() gin.HandlerFunc {
+func (app *Application) AddAttributeType() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -50,18 +47,19 @@ func AddAttributeType() gin.HandlerFunc {
 			return
 
 		}
-		_, err := AttributesCollection.InsertOne(ctx, attribute)
+		_, err := app.attributesCollection.InsertOne(ctx, attribute)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
 			return
 		}
+		setAuditEntity(c, attribute.ID)
 		c.JSON(http.StatusOK, attribute)
 	}
 }
 
 //get attribute by id
 
-func GetAttributeByID() gin.HandlerFunc {
+func (app *Application) GetAttributeByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -76,7 +74,7 @@ func GetAttributeByID() gin.HandlerFunc {
 			return
 		}
 		var attribute models.AttributeType
-		err = AttributesCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&attribute)
+		err = app.attributesCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&attribute)
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
 			return
@@ -91,7 +89,7 @@ func GetAttributeByID() gin.HandlerFunc {
 
 //update attribute
 
-func UpdateAttributeType() gin.HandlerFunc {
+func (app *Application) UpdateAttributeType() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -106,7 +104,7 @@ func UpdateAttributeType() gin.HandlerFunc {
 			return
 		}
 		var attribute models.AttributeType
-		err = AttributesCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&attribute)
+		err = app.attributesCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&attribute)
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Attribute not found"})
 			return
@@ -120,7 +118,7 @@ func UpdateAttributeType() gin.HandlerFunc {
 			return
 		}
 		attribute.ID = oid
-		_, err = AttributesCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": attribute})
+		_, err = app.attributesCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": attribute})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err})
 			return
@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Entity types recorded in the audit log.
+const (
+	AuditSeller   = "seller"
+	AuditProduct  = "product"
+	AuditCategory = "category"
+	AuditEnquiry  = "enquiry"
+	AuditReview   = "review"
+	AuditAdmin    = "admin"
+	AuditOffer    = "offer"
+
+	AuditAttribute   = "attribute"
+	AuditAdminInvite = "admin_invite"
+	AuditTicket      = "ticket"
+	AuditFeed        = "feed"
+	AuditBlog        = "blog"
+	AuditContent     = "content"
+)
+
+// auditEntityKey is where a handler publishes the hex ID of the entity it
+// created, which Audit cannot locate before the handler ran.
+const auditEntityKey = "audit_entity_id"
+
+// setAuditEntity publishes the entity a handler created to Audit.
+func setAuditEntity(c *gin.Context, id primitive.ObjectID) {
+	c.Set(auditEntityKey, id.Hex())
+}
+
+// auditRedacted lists fields whose values never end up in the audit log.
+var auditRedacted = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"refresh_token": true,
+	"token_hash":    true,
+}
+
+// AuditLocator extracts the hex ID of the audited entity from a request.
+type AuditLocator func(c *gin.Context) string
+
+func AuditParam(name string) AuditLocator {
+	return func(c *gin.Context) string { return c.Param(name) }
+}
+
+func AuditQuery(name string) AuditLocator {
+	return func(c *gin.Context) string { return c.Query(name) }
+}
+
+func AuditForm(name string) AuditLocator {
+	return func(c *gin.Context) string { return c.PostForm(name) }
+}
+
+// AuditCreated audits the entity the handler publishes with
+// setAuditEntity, for handlers that create it.
+func AuditCreated() AuditLocator {
+	return func(c *gin.Context) string { return "" }
+}
+
+// AuditSelf audits the account of the caller, for handlers that change the
+// caller's own profile.
+func AuditSelf() AuditLocator {
+	return func(c *gin.Context) string { return c.GetString("uid") }
+}
+
+// Audit records the handlers after it in the audit log. The entity is
+// loaded before and after the handler runs and the changed fields are
+// stored together with the caller and the request ID. When locate finds no
+// entity, the one the handler published with setAuditEntity is recorded
+// as created. Nothing is written when the handler fails.
+func (app *Application) Audit(action, entityType string, locate AuditLocator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var before interface{}
+		id, err := primitive.ObjectIDFromHex(locate(c))
+		located := err == nil
+		if located {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			before, err = app.loadAuditEntity(ctx, entityType, id)
+			cancel()
+			if err != nil && err != repository.ErrNotFound {
+				log.Println("audit: loading", entityType, id.Hex(), err)
+			}
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusMultipleChoices {
+			return
+		}
+		if !located {
+			if id, err = primitive.ObjectIDFromHex(c.GetString(auditEntityKey)); err != nil {
+				// The handler rejected the request or created nothing.
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		after, err := app.loadAuditEntity(ctx, entityType, id)
+		if err != nil && err != repository.ErrNotFound {
+			log.Println("audit: loading", entityType, id.Hex(), err)
+		}
+		app.recordAudit(ctx, models.AuditEntry{
+			Actor:       c.GetString("uid"),
+			Actor_email: c.GetString("email"),
+			Role:        c.GetString("role"),
+			Action:      action,
+			Entity_type: entityType,
+			Entity_id:   id.Hex(),
+			Request_id:  c.GetString("request_id"),
+		}, before, after)
+	}
+}
+
+// recordAudit stores entry with the changes between the before and after
+// versions of its entity. Failures are only logged.
+func (app *Application) recordAudit(ctx context.Context, entry models.AuditEntry, before, after interface{}) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		log.Println("audit: diffing", entry.Entity_type, entry.Entity_id, err)
+	}
+	entry.ID = primitive.NewObjectID()
+	entry.Changes = changes
+	entry.Created_at = time.Now()
+	if err := app.repos.Audit.Insert(ctx, &entry); err != nil {
+		log.Println("audit: writing entry for", entry.Action, entry.Entity_id, err)
+	}
+}
+
+// loadAuditEntity returns the current state of an audited entity, or nil
+// and repository.ErrNotFound when it does not exist.
+func (app *Application) loadAuditEntity(ctx context.Context, entityType string, id primitive.ObjectID) (interface{}, error) {
+	var (
+		entity interface{}
+		err    error
+	)
+	switch entityType {
+	case AuditSeller:
+		entity, err = app.repos.Sellers.FindByID(ctx, id)
+	case AuditProduct:
+		entity, err = app.repos.Products.FindByID(ctx, id)
+	case AuditEnquiry:
+		entity, err = app.repos.Enquiries.FindByID(ctx, id)
+	case AuditReview:
+		entity, err = app.repos.Reviews.FindByID(ctx, id)
+	case AuditAdmin:
+		entity, err = app.repos.Admins.FindByID(ctx, id)
+	case AuditOffer:
+		entity, err = app.repos.ProductReferences.FindByID(ctx, id)
+	case AuditAdminInvite:
+		entity, err = app.repos.AdminInvites.FindByID(ctx, id)
+	case AuditTicket:
+		entity, err = app.repos.Tickets.FindByID(ctx, id)
+	case AuditBlog:
+		entity, err = app.repos.Blogs.FindByID(ctx, id)
+	case AuditContent:
+		entity, err = app.repos.Contents.FindByID(ctx, id)
+	case AuditCategory:
+		var category models.Categories
+		err = findAuditDoc(ctx, app.categoriesCollection, id, &category)
+		entity = &category
+	case AuditAttribute:
+		var attribute models.AttributeType
+		err = findAuditDoc(ctx, app.attributesCollection, id, &attribute)
+		entity = &attribute
+	case AuditFeed:
+		var feed models.Feed
+		err = findAuditDoc(ctx, app.feedsCollection, id, &feed)
+		entity = &feed
+	default:
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// findAuditDoc decodes the document with id from a collection the
+// repositories don't cover yet.
+func findAuditDoc(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, v interface{}) error {
+	if coll == nil {
+		return repository.ErrNotFound
+	}
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(v)
+	if err == mongo.ErrNoDocuments {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+func toAuditDoc(v interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if v == nil {
+		return doc, nil
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// auditChanges compares the top level fields of two versions of an entity.
+func auditChanges(before, after interface{}) ([]models.AuditChange, error) {
+	old, err := toAuditDoc(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := toAuditDoc(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]bool{}
+	for field := range old {
+		fields[field] = true
+	}
+	for field := range updated {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if field != "_id" {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.AuditChange{}
+	for _, field := range names {
+		if reflect.DeepEqual(old[field], updated[field]) {
+			continue
+		}
+		change := models.AuditChange{Field: field, Before: old[field], After: updated[field]}
+		if auditRedacted[field] {
+			change.Before, change.After = "[redacted]", "[redacted]"
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetAuditLog lists audit entries, newest first. It filters on
+// entity_type, entity_id, actor and action, and on a from/to range of
+// RFC 3339 timestamps.
+func (app *Application) GetAuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		filter := repository.AuditFilter{
+			EntityType: c.Query("entity_type"),
+			EntityID:   c.Query("entity_id"),
+			Actor:      c.Query("actor"),
+			Action:     c.Query("action"),
+			JobID:      c.Query("job_id"),
+		}
+		for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": param + " must be an RFC 3339 timestamp"})
+				return
+			}
+			*bound = t
+		}
+
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		pageNo, err := strconv.Atoi(c.Query("page"))
+		if err != nil || pageNo <= 0 {
+			pageNo = 1
+		}
+		page := repository.Page{Sort: "-created_at", Skip: int64((pageNo - 1) * limit), Limit: int64(limit)}
+
+		entries, err := app.repos.Audit.Find(ctx, filter, page)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if entries == nil {
+			entries = []models.AuditEntry{}
+		}
+		count, err := app.repos.Audit.Count(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"entries": entries,
+			"page":    pageNo,
+			"limit":   limit,
+			"total":   count,
+			"hasMore": count > int64(pageNo*limit),
+		})
+	}
+}
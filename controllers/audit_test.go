@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuditRecordsCreatedEntities(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("uid", "admin-1") })
+	router.POST("/blogs", app.Audit("blog.create", AuditBlog, AuditCreated()), func(c *gin.Context) {
+		if c.Query("title") == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "title is required"})
+			return
+		}
+		blog := models.Blog{BlogID: primitive.NewObjectID(), Title: c.Query("title")}
+		if err := app.repos.Blogs.Insert(ctx, &blog); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		setAuditEntity(c, blog.BlogID)
+		c.JSON(http.StatusCreated, blog)
+	})
+	router.DELETE("/blogs/:id", app.Audit("blog.delete", AuditBlog, AuditParam("id")), func(c *gin.Context) {
+		id, _ := primitive.ObjectIDFromHex(c.Param("id"))
+		if err := app.repos.Blogs.Delete(ctx, id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "blog not found"})
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	if w := serve(router, http.MethodPost, "/blogs", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("create without title: %d", w.Code)
+	}
+	if w := serve(router, http.MethodPost, "/blogs?title=Hello", "", nil); w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	entries, err := app.repos.Audit.Find(ctx, repository.AuditFilter{}, repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d entries, want only the created blog: %+v", len(entries), entries)
+	}
+	created := entries[0]
+	if created.Action != "blog.create" || created.Actor != "admin-1" || created.Entity_type != AuditBlog {
+		t.Errorf("entry %+v", created)
+	}
+	found := false
+	for _, change := range created.Changes {
+		if change.Field == "title" && change.Before == nil && change.After == "Hello" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("changes %+v, want the title set", created.Changes)
+	}
+
+	if w := serve(router, http.MethodDelete, "/blogs/"+created.Entity_id, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	entries, err = app.repos.Audit.Find(ctx, repository.AuditFilter{Action: "blog.delete", EntityID: created.Entity_id}, repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Changes) == 0 {
+		t.Errorf("delete entries %+v", entries)
+	}
+}
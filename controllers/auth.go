@@ -2,17 +2,13 @@ package controllers
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"math/rand"
 	"net/http"
-	"net/url"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/otp"
+	"github.com/kravi0/BizGrowth-backend/repository"
 	generate "github.com/kravi0/BizGrowth-backend/tokens"
 	"github.com/kravi0/BizGrowth-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,9 +17,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var UserCollection *mongo.Collection = database.ProductData(database.Client, "User")
-
-func SetOtpHandler() gin.HandlerFunc {
+func (app *Application) SetOtpHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -36,8 +30,8 @@ func SetOtpHandler() gin.HandlerFunc {
 		}
 		isNewUser := false
 		filter := primitive.M{"mobileno": contactNo}
-		res := UserCollection.FindOne(ctx, filter)
-		err := res.Err()
+		var existing models.USer
+		err := app.userCollection.FindOne(ctx, filter).Decode(&existing)
 		if err != nil && err != mongo.ErrNoDocuments {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
@@ -54,29 +48,24 @@ func SetOtpHandler() gin.HandlerFunc {
 				Created_at: created_at,
 				Updated_at: created_at,
 			}
-			UserCollection.InsertOne(ctx, user)
+			app.userCollection.InsertOne(ctx, user)
 			isNewUser = true
 		}
-		otp, errG := generateOTP(contactNo)
+		errG := app.sendOTP(ctx, otp.PurposeUserLogin, otp.Recipient{MobileNo: contactNo, Email: existing.Email})
 		if errG != nil {
+			status, msg := otpErrorResponse(errG)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Something went wrong"})
+			c.JSON(status, gin.H{"Error": msg})
 			c.Abort()
 			return
 		}
-		update := primitive.M{
-			"$set": primitive.M{
-				"otp": otp,
-			},
-		}
-		UserCollection.UpdateOne(ctx, filter, update)
 
 		c.Header("content-type", "application/json")
 		c.JSON(http.StatusOK, gin.H{"success": "OTP sent successfully", "newUser": isNewUser})
 
 	}
 }
-func ValidateOtpHandler() gin.HandlerFunc {
+func (app *Application) ValidateOtpHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -87,15 +76,15 @@ func ValidateOtpHandler() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		otp := c.PostForm("otp")
-		if otp == "" {
+		code := c.PostForm("otp")
+		if code == "" {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "otp can't be empty"})
 			c.Abort()
 			return
 		}
 		filter := primitive.M{utils.Mobileno: contactNo}
-		res := UserCollection.FindOne(ctx, filter)
+		res := app.userCollection.FindOne(ctx, filter)
 		err := res.Err()
 		if err != nil && err != mongo.ErrNoDocuments {
 			c.Header("content-type", "application/json")
@@ -110,7 +99,7 @@ func ValidateOtpHandler() gin.HandlerFunc {
 			return
 		}
 		userDetails := models.USer{}
-		dbErr := UserCollection.FindOne(ctx, filter).Decode(&userDetails)
+		dbErr := app.userCollection.FindOne(ctx, filter).Decode(&userDetails)
 		if dbErr != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong while decode"})
@@ -118,29 +107,34 @@ func ValidateOtpHandler() gin.HandlerFunc {
 			return
 		}
 
-		if userDetails.OTP == otp {
-			token, refreshtoken, _ := generate.UserTokenGenerator(userDetails.MobileNo, string(userDetails.User_id.Hex()))
-			userDetails.Token = token
-			userDetails.Refresh_token = refreshtoken
-			update := primitive.M{
-				"$set": primitive.M{
-					"token":         token,
-					"refresh_token": refreshtoken,
-					"otp":           "",
-				},
-			}
-			UserCollection.FindOneAndUpdate(ctx, filter, update)
+		if err := app.otpStore.Verify(ctx, otp.PurposeUserLogin, contactNo, code); err != nil {
+			status, msg := otpErrorResponse(err)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusAccepted, gin.H{"token": userDetails.Token})
-		} else {
+			c.JSON(status, gin.H{"Error": msg})
+			return
+		}
+
+		token, _ := generate.UserTokenGenerator(userDetails.MobileNo, string(userDetails.User_id.Hex()))
+		refreshtoken, err := app.issueRefreshToken(ctx, userDetails.User_id.Hex(), utils.User, primitive.NilObjectID)
+		if err != nil {
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid OTP"})
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
 		}
+		userDetails.Token = token
+		update := primitive.M{
+			"$set": primitive.M{
+				"token": token,
+			},
+		}
+		app.userCollection.FindOneAndUpdate(ctx, filter, update)
+		c.Header("content-type", "application/json")
+		c.JSON(http.StatusAccepted, gin.H{"token": userDetails.Token, "refresh_token": refreshtoken})
 	}
 }
 
 // RegisterUser handles the registration of a new user
-func UpdateUserDetails() gin.HandlerFunc {
+func (app *Application) UpdateUserDetails() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Parse mobile number from request
 		mobileNo := c.PostForm("mobileno")
@@ -152,7 +146,7 @@ func UpdateUserDetails() gin.HandlerFunc {
 		// Check if the user with the given mobile number exists
 		var existingUser models.USer
 		filter := bson.M{"mobileno": mobileNo}
-		err := UserCollection.FindOne(context.Background(), filter).Decode(&existingUser)
+		err := app.userCollection.FindOne(context.Background(), filter).Decode(&existingUser)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
@@ -171,7 +165,7 @@ func UpdateUserDetails() gin.HandlerFunc {
 			"user_address": existingUser.User_Address,
 			// Add other fields as needed
 		}}
-		_, err = UserCollection.UpdateOne(context.Background(), filter, update)
+		_, err = app.userCollection.UpdateOne(context.Background(), filter, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user details"})
 			return
@@ -181,33 +175,7 @@ func UpdateUserDetails() gin.HandlerFunc {
 	}
 }
 
-func generateOTP(mobileNo string) (string, error) {
-	rand.Seed(time.Now().UnixNano())
-	otp := 100000 + rand.Intn(900000)
-
-	baseURL := "https://1.rapidsms.co.in/api/push"
-	apiKey := "65be127aebd18"
-	route := "TRANS"
-	sender := "GRWTHB"
-	message := `Welcome to Growth Biz! Your One-Time Password (OTP) for verification is ` + strconv.Itoa(otp) + ` Please use this code to complete the verification process. Do not share this code with anyone for security reasons."
-	Visit:Growthbiz.co or mail info@growthbiz.co`
-	// Encode the message
-	encodedMessage := url.QueryEscape(message)
-
-	// Construct the final URL
-	finalURL := fmt.Sprintf("%s?apikey=%s&route=%s&sender=%s&mobileno=%s&text=%s", baseURL, apiKey, route, sender, mobileNo, encodedMessage)
-
-	response, err := http.Get(finalURL)
-	fmt.Println(response.Request.URL)
-	//response.Request()
-	if err != nil || response.StatusCode != 200 {
-		fmt.Println("Error making GET request:", err)
-		return "", errors.New("error ! plese try again")
-	}
-	return fmt.Sprintf("%06d", otp), nil
-}
-
-func ResetPassword() gin.HandlerFunc {
+func (app *Application) ResetPassword() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input struct {
 			MobileNo string `json:"mobileno" validate:"required"`
@@ -221,16 +189,15 @@ func ResetPassword() gin.HandlerFunc {
 			return
 		}
 
-		var seller models.Seller
-
-		err := SellerCollection.FindOne(context.Background(), bson.M{"mobileno": input.MobileNo}).Decode(&seller)
+		seller, err := app.repos.Sellers.FindOne(context.Background(), repository.SellerFilter{MobileNo: input.MobileNo})
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "No Account is registered with this number"})
 			return
 		}
 
-		if input.OTP != seller.OTP {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid OTP"})
+		if err := app.otpStore.Verify(context.Background(), otp.PurposeSellerLogin, seller.MobileNo, input.OTP); err != nil {
+			status, msg := otpErrorResponse(err)
+			c.JSON(status, gin.H{"Error": msg})
 			return
 		}
 
@@ -240,9 +207,8 @@ func ResetPassword() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"mobileno": input.MobileNo}
-		update := bson.M{"$set": bson.M{"password": string(hashedPassword)}}
-		_, err = SellerCollection.UpdateOne(context.Background(), filter, update)
+		update := repository.Update{Set: repository.Fields{"password": string(hashedPassword)}}
+		err = app.repos.Sellers.Update(context.Background(), seller.ID, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -253,7 +219,7 @@ func ResetPassword() gin.HandlerFunc {
 	}
 }
 
-func LoadUser() gin.HandlerFunc {
+func (app *Application) LoadUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userId, exists := c.Get("uid")
 		if !exists {
@@ -270,7 +236,7 @@ func LoadUser() gin.HandlerFunc {
 
 		// Query the database to get seller information
 		var user models.USer // Assuming Seller struct is defined in models package
-		err = UserCollection.FindOne(context.Background(), bson.M{"_id": userObjID}).Decode(&user)
+		err = app.userCollection.FindOne(context.Background(), bson.M{"_id": userObjID}).Decode(&user)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Seller not found"})
 			return
@@ -279,69 +245,3 @@ func LoadUser() gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"user": user})
 	}
 }
-
-func RegisterAdmin() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var input struct {
-			Name     string `json:"name" binding:"required"`
-			Mobile   string `json:"mobile" binding:"required"`
-			Email    string `json:"email" binding:"required"`
-			Password string `json:"password" binding:"required,min=6"`
-		}
-
-		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		ctx := context.Background()
-
-		// Check if the email or mobile number is already registered
-		count, err := SellerCollection.CountDocuments(ctx, bson.M{"$or": []bson.M{
-			{"email": input.Email},
-			{"mobileno": input.Mobile},
-		}})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-		if count > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Email or mobile number already registered"})
-			return
-		}
-
-		// Hash the password before saving it to the database
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-			return
-		}
-
-		// Generate a unique seller ID
-		id := primitive.NewObjectID()
-		token, refreshtoken, _ := generate.TokenGenerator(input.Email, input.Mobile, input.Name, id.Hex())
-
-		admin := models.Seller{
-			ID:            id,
-			Seller_ID:     id.Hex(), // You can generate a unique seller ID here if needed
-			Company_Name:  input.Name,
-			MobileNo:      input.Mobile,
-			Email:         input.Email,
-			Password:      string(hashedPassword),
-			Token:         token,
-			Refresh_token: refreshtoken,
-			User_type:     "ADMIN",
-			Created_at:    time.Now(),
-			Updated_at:    time.Now(),
-		}
-
-		// Insert the admin into the database
-		_, err = SellerCollection.InsertOne(ctx, admin)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusCreated, gin.H{"message": "Admin registered successfully"})
-	}
-}
@@ -3,25 +3,23 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var BlogCollection *mongo.Collection = database.ProductData(database.Client, "Blog")
-
-func CreateBlog() gin.HandlerFunc {
+func (app *Application) CreateBlog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var blog models.Blog
+		blog.BlogID = primitive.NewObjectID()
 
 		title := c.PostForm("title")
 		if title == "" {
@@ -44,33 +42,17 @@ func CreateBlog() gin.HandlerFunc {
 
 		keywords := c.PostForm("keywords")
 
-		form, err := c.MultipartForm()
+		// The cover is a file, or the ID of an upload session confirmed for
+		// the admin
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		image, err := app.formImage(ctx, c, "cover", c.GetString("uid"), storage.BlogImagesPrefix(blog.BlogID.Hex()))
 		if err != nil {
-			log.Println("error while multipart")
-			c.String(http.StatusBadRequest, "get form err: %s", err.Error())
+			c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		files := form.File["cover"]
-
-		if len(files) != 0 {
-
-			f, err := files[0].Open()
-			if err != nil {
-				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
-				return
-			}
-			uploadedURL, err := saveFile(f, files[0])
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			} else {
-				blog.CoverImage = uploadedURL
-			}
-
-			blog.CoverImage = uploadedURL
-
-		}
+		blog.CoverImage = image
 
-		blog.BlogID = primitive.NewObjectID()
 		blog.Created_at = time.Now()
 		blog.Updated_at = time.Now()
 		blog.ContentUrl = content
@@ -93,19 +75,20 @@ func CreateBlog() gin.HandlerFunc {
 			blog.Keywords = keywordsArray
 		}
 
-		_, insertErr := BlogCollection.InsertOne(context.Background(), blog)
+		insertErr := app.repos.Blogs.Insert(ctx, &blog)
 		if insertErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create blog post"})
 			return
 		}
 
+		setAuditEntity(c, blog.BlogID)
 		c.JSON(http.StatusCreated, blog)
 	}
 
 }
 
 // TogglePublishBlog toggles the published status of a blog post
-func TogglePublishBlog() gin.HandlerFunc {
+func (app *Application) TogglePublishBlog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -117,29 +100,25 @@ func TogglePublishBlog() gin.HandlerFunc {
 			return
 		}
 
-		var blog models.Blog
-		err = BlogCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&blog)
+		blog, err := app.repos.Blogs.FindByID(ctx, objId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Blog not found"})
 			return
 		}
 
 		newPublishedStatus := !blog.Published
-		update := bson.M{
-			"$set": bson.M{
-				"published":   newPublishedStatus,
-				"is_archived": false,
-			},
-		}
+		update := repository.Update{Set: repository.Fields{
+			"published":  newPublishedStatus,
+			"isArchived": false,
+		}}
 
-		result, err := BlogCollection.UpdateOne(ctx, bson.M{"_id": objId}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update blog"})
+		err = app.repos.Blogs.Update(ctx, objId, update)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Blog not found"})
 			return
 		}
-
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Blog not found"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update blog"})
 			return
 		}
 
@@ -148,15 +127,9 @@ func TogglePublishBlog() gin.HandlerFunc {
 }
 
 // get all blogs
-func GetAllBlogs() gin.HandlerFunc {
+func (app *Application) GetAllBlogs() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		cursor, err := BlogCollection.Find(context.Background(), bson.D{})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to get blogs"})
-			return
-		}
-		var blogs []models.Blog
-		err = cursor.All(context.Background(), &blogs)
+		blogs, err := app.repos.Blogs.Find(context.Background(), repository.BlogFilter{}, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to get blogs"})
 			return
@@ -166,51 +139,46 @@ func GetAllBlogs() gin.HandlerFunc {
 }
 
 // get only title, cover, created_at for all blogs
-func GetBlogs() gin.HandlerFunc {
+func (app *Application) GetBlogs() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
-		projection := bson.D{
-			{Key: "_id", Value: 1},
-			{Key: "title", Value: 1},
-			{Key: "coverImage", Value: 1},
-			{Key: "created_at", Value: 1},
-			{Key: "keywords", Value: 1},
-			{Key: "author", Value: 1},
-			{Key: "published", Value: 1},
-		}
-
-		cursor, err := BlogCollection.Find(ctx, bson.D{}, options.Find().SetProjection(projection))
+		posts, err := app.repos.Blogs.Find(ctx, repository.BlogFilter{}, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get blogs"})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var blogs []struct {
+		type blogSummary struct {
 			BlogID     primitive.ObjectID `bson:"_id" json:"id"`
 			Title      string             `bson:"title" json:"title"`
-			CoverImage string             `bson:"coverImage" json:"coverImage"`
+			CoverImage models.Image       `bson:"coverImage" json:"coverImage"`
 			CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 			Author     string             `bson:"author" json:"author"`
 			Keywords   []string           `bson:"keywords" json:"keywords"`
 			Published  bool               `bson:"published" json:"published"`
 		}
 
-		if err := cursor.All(ctx, &blogs); err != nil {
-
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode blogs"})
-			return
+		blogs := make([]blogSummary, 0, len(posts))
+		for _, post := range posts {
+			blogs = append(blogs, blogSummary{
+				BlogID:     post.BlogID,
+				Title:      post.Title,
+				CoverImage: post.CoverImage,
+				CreatedAt:  post.Created_at,
+				Author:     post.Author,
+				Keywords:   post.Keywords,
+				Published:  post.Published,
+			})
 		}
 
-		for i, blog := range blogs {
-			if blog.CoverImage != "" {
-				blogs[i].CoverImage, err = getPresignURL(blogs[i].CoverImage)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to generate presigned URL"})
-					return
-				}
-			}
+		var covers signBatch
+		for i := range blogs {
+			covers.addImage(&blogs[i].CoverImage)
+		}
+		if err = app.sign(&covers); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to generate presigned URL"})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "success", "data": blogs})
@@ -218,7 +186,7 @@ func GetBlogs() gin.HandlerFunc {
 }
 
 // get blog by id
-func GetBlogByID() gin.HandlerFunc {
+func (app *Application) GetBlogByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		objID, er := primitive.ObjectIDFromHex(id)
@@ -226,16 +194,14 @@ func GetBlogByID() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
 			return
 		}
-		var blog models.Blog
-		err := BlogCollection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&blog)
+		blog, err := app.repos.Blogs.FindByID(context.Background(), objID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to get blog"})
 			return
 		}
 
-		blog.CoverImage, err = getPresignURL(blog.CoverImage)
-		if err != nil {
-			blog.CoverImage = ""
+		if err = app.presignImage(&blog.CoverImage); err != nil {
+			blog.CoverImage = models.Image{}
 		}
 
 		c.JSON(http.StatusOK, blog)
@@ -244,7 +210,7 @@ func GetBlogByID() gin.HandlerFunc {
 
 // update blog
 // UpdateBlog updates an existing blog post
-func UpdateBlog() gin.HandlerFunc {
+func (app *Application) UpdateBlog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		objID, err := primitive.ObjectIDFromHex(id)
@@ -273,7 +239,7 @@ func UpdateBlog() gin.HandlerFunc {
 		}
 		files := form.File["cover"]
 
-		var url string
+		var cover models.Image
 
 		if len(files) != 0 {
 
@@ -282,16 +248,16 @@ func UpdateBlog() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, files[0])
+			image, err := app.saveImage(f, files[0], storage.BlogImagesPrefix(objID.Hex()))
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			} else {
-				url = uploadedURL
+				c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
+				return
 			}
+			cover = image
 
 		}
 		// Prepare the update document with the $set operator
-		setUpdate := bson.M{}
+		setUpdate := repository.Fields{}
 
 		if title != "" {
 			setUpdate["title"] = title
@@ -320,34 +286,35 @@ func UpdateBlog() gin.HandlerFunc {
 			}
 			setUpdate["keywords"] = keywordsArray
 		}
-		if url != "" {
-			setUpdate["coverImage"] = url
+		if !cover.IsZero() {
+			setUpdate["coverImage"] = cover
 		}
 
-		// Now create the update document
-		update := bson.M{"$set": setUpdate}
+		setUpdate["updated_at"] = time.Now()
+
 		// Perform the update
-		result := BlogCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": objID}, update)
+		err = app.repos.Blogs.Update(context.Background(), objID, repository.Update{Set: setUpdate})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update blog"})
+			c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
 			return
 		}
 
-		if result.Err() != nil {
-			c.JSON(http.StatusNotFound, gin.H{"Error": result.Err().Error()})
+		blog, err := app.repos.Blogs.FindByID(context.Background(), objID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update blog"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "Blog updated successfully", "data": result})
+		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "Blog updated successfully", "data": blog})
 	}
 }
 
 // delete blog
-func DeleteBlog() gin.HandlerFunc {
+func (app *Application) DeleteBlog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"message": "You're not authroize for this."})
 			return
 		}
@@ -359,14 +326,13 @@ func DeleteBlog() gin.HandlerFunc {
 			return
 		}
 
-		result, err := BlogCollection.DeleteOne(ctx, bson.M{"_id": objID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
+		err = app.repos.Blogs.Delete(ctx, objID)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Blog not found"})
 			return
 		}
-
-		if result.DeletedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Blog not found"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
 
@@ -375,13 +341,12 @@ func DeleteBlog() gin.HandlerFunc {
 }
 
 // GetBlogBySlug retrieves a blog post by its slug
-func GetBlogBySlug() gin.HandlerFunc {
+func (app *Application) GetBlogBySlug() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		slug := c.Param("slug")
-		var blog models.Blog
 
 		if slug == "" {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusBadRequest, "Error": "Slug value can not be empty"})
@@ -389,16 +354,15 @@ func GetBlogBySlug() gin.HandlerFunc {
 		}
 
 		// Find the blog by slug
-		err := BlogCollection.FindOne(ctx, bson.M{"slug": slug, "published": true}).Decode(&blog)
+		blog, err := app.repos.Blogs.FindOne(ctx, repository.BlogFilter{Slug: slug, Published: repository.Bool(true)})
 		if err != nil {
 
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "error": err.Error(), "message": "Blog not found"})
 			return
 		}
 
-		blog.CoverImage, err = getPresignURL(blog.CoverImage)
-		if err != nil {
-			blog.CoverImage = ""
+		if err = app.presignImage(&blog.CoverImage); err != nil {
+			blog.CoverImage = models.Image{}
 		}
 
 		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "success", "data": blog})
@@ -406,37 +370,29 @@ func GetBlogBySlug() gin.HandlerFunc {
 }
 
 // GetPublishedBlogs retrieves all published blog posts
-func GetPublishedBlogs() gin.HandlerFunc {
+func (app *Application) GetPublishedBlogs() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		cursor, err := BlogCollection.Find(ctx, bson.M{"published": true}) // Filter for published blogs
+		blogs, err := app.repos.Blogs.Find(ctx, repository.BlogFilter{Published: repository.Bool(true)}, repository.Page{}) // Filter for published blogs
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
-		defer cursor.Close(ctx)
-
-		var blogs []models.Blog
-		if err = cursor.All(ctx, &blogs); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
-			return
-		}
 
 		if blogs == nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNoContent, "Message": "error", "data": "No blog found"})
 			return
 		}
 
-		for i, blog := range blogs {
-			if blog.CoverImage != "" {
-				blogs[i].CoverImage, err = getPresignURL(blogs[i].CoverImage)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to generate presigned URL"})
-					return
-				}
-			}
+		var covers signBatch
+		for i := range blogs {
+			covers.addImage(&blogs[i].CoverImage)
+		}
+		if err = app.sign(&covers); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to generate presigned URL"})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "success", "data": blogs})
@@ -444,12 +400,12 @@ func GetPublishedBlogs() gin.HandlerFunc {
 }
 
 // ArchiveBlog archives a blog post
-func ArchiveBlog() gin.HandlerFunc {
+func (app *Application) ArchiveBlog() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"message": "You're not authroize for this."})
 			return
 		}
@@ -473,15 +429,14 @@ func ArchiveBlog() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{"$set": bson.M{"isArchived": isArchived, "published": false}} // Set IsArchived to true
-		result, err := BlogCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
+		update := repository.Update{Set: repository.Fields{"isArchived": isArchived, "published": false}} // Set IsArchived to true
+		err = app.repos.Blogs.Update(ctx, objID, update)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Blog not found"})
 			return
 		}
-
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Blog not found"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
 
@@ -11,16 +11,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var CategoriesCollection *mongo.Collection = database.ProductData(database.Client, "Categories")
-
 type CategoryWithChildren struct {
 	Category        models.Categories
 	ChildCategories []models.Categories
@@ -36,7 +33,7 @@ type CategoryList struct {
 	Category    string             `json:"category" bson:"category"`
 }
 
-func AddCategory() gin.HandlerFunc {
+func (app *Application) AddCategory() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -80,9 +77,9 @@ func AddCategory() gin.HandlerFunc {
 		}
 		defer categoryImageHeader.Close()
 
-		categoryImage, err := saveFile(categoryImageHeader, image[0])
+		categoryImage, err := app.saveImage(categoryImageHeader, image[0], storage.CategoryImagesPrefix(category.Category_ID.Hex()))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err})
+			c.JSON(imageErrorStatus(err), gin.H{"Error": err.Error()})
 			return
 		}
 		category.Category_image = categoryImage
@@ -97,7 +94,7 @@ func AddCategory() gin.HandlerFunc {
 
 		category.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		count, err := CategoriesCollection.CountDocuments(ctx, bson.M{"category": category.Category})
+		count, err := app.categoriesCollection.CountDocuments(ctx, bson.M{"category": category.Category})
 		defer cancel()
 		if err != nil {
 			log.Panic(err)
@@ -109,11 +106,13 @@ func AddCategory() gin.HandlerFunc {
 			return
 		}
 
-		_, anyerr := CategoriesCollection.InsertOne(ctx, category)
+		_, anyerr := app.categoriesCollection.InsertOne(ctx, category)
 		if anyerr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Category Not Created"})
 			return
 		}
+		app.suggestions.Invalidate()
+		setAuditEntity(c, category.Category_ID)
 		defer cancel()
 		c.JSON(http.StatusOK, "Succesfully added category")
 
@@ -121,13 +120,13 @@ func AddCategory() gin.HandlerFunc {
 }
 
 // make category as Featured , max count of featured category will be 10;
-func HandleCategoryFeatured() gin.HandlerFunc {
+func (app *Application) HandleCategoryFeatured() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		//check if not admin
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Unauthorized"})
 			return
 		}
@@ -158,7 +157,7 @@ func HandleCategoryFeatured() gin.HandlerFunc {
 
 		//count number of featured category
 
-		count, err := CategoriesCollection.CountDocuments(ctx, bson.M{"isFeatured": true})
+		count, err := app.categoriesCollection.CountDocuments(ctx, bson.M{"isFeatured": true})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err})
@@ -170,7 +169,7 @@ func HandleCategoryFeatured() gin.HandlerFunc {
 			return
 		}
 
-		err = CategoriesCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&category)
+		err = app.categoriesCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&category)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err})
@@ -182,12 +181,13 @@ func HandleCategoryFeatured() gin.HandlerFunc {
 
 		category.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		_, err = CategoriesCollection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": category})
+		_, err = app.categoriesCollection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": category})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err})
 			return
 		}
+		app.suggestions.Invalidate()
 
 		defer cancel()
 
@@ -198,38 +198,48 @@ func HandleCategoryFeatured() gin.HandlerFunc {
 }
 
 // get featured category
-func GetFeaturedCategory() gin.HandlerFunc {
+func (app *Application) GetFeaturedCategory() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Get featured categories sorted by updated time, latest one should come first
-		var featuredCategories []bson.M
+		featuredCategories := []bson.M{}
 		findOptions := options.Find()
 		findOptions.SetSort(bson.D{{Key: "updated_at", Value: -1}})
 		findOptions.SetLimit(10)
 
-		cursor, err := CategoriesCollection.Find(ctx, bson.M{"isFeatured": true, "isApproved": true}, findOptions)
+		cursor, err := app.categoriesCollection.Find(ctx, bson.M{"isFeatured": true, "isApproved": true}, findOptions)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching featured categories: " + err.Error()})
 			return
 		}
 		defer cursor.Close(ctx)
 
-		if err = cursor.All(ctx, &featuredCategories); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding featured categories: " + err.Error()})
-			return
-		}
-		//get image s3 url and append
-		for i, category := range featuredCategories {
-			url, err := getPresignURL(category["category_image"].(string))
+		for cursor.Next(ctx) {
+			var category bson.M
+			// The image is decoded on its own as well, it may still be a plain URL
+			var image struct {
+				Category_image models.Image `bson:"category_image"`
+			}
+			if err = cursor.Decode(&category); err == nil {
+				err = cursor.Decode(&image)
+			}
 			if err != nil {
-				log.Println("Error generating pre-signed URL for image:", err)
-				continue
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding featured categories: " + err.Error()})
+				return
 			}
-			if url != "" {
-				featuredCategories[i]["category_image"] = url
+			//get image s3 url and append
+			if err := app.presignImage(&image.Category_image); err != nil {
+				log.Println("Error generating pre-signed URL for image:", err)
+			} else {
+				category["category_image"] = image.Category_image
 			}
+			featuredCategories = append(featuredCategories, category)
+		}
+		if err = cursor.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding featured categories: " + err.Error()})
+			return
 		}
 
 		defer cancel()
@@ -238,14 +248,14 @@ func GetFeaturedCategory() gin.HandlerFunc {
 	}
 }
 
-func GetCategory() gin.HandlerFunc {
+func (app *Application) GetCategory() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Execute aggregation pipeline
-		cursor, err := CategoriesCollection.Find(ctx, bson.M{"isApproved": true})
+		cursor, err := app.categoriesCollection.Find(ctx, bson.M{"isApproved": true})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, "Something went wrong. Please try again.")
 			return
@@ -260,23 +270,20 @@ func GetCategory() gin.HandlerFunc {
 			return
 		}
 
-		// Loop through the cursor and get image of each category
+		// Presign the image of every category at once
+		var images signBatch
 		for i := range results {
-			url, err := getPresignURL(results[i].Category_image)
-			if err != nil {
-				log.Println("Error generating pre-signed URL for image:", err)
-				continue
-			}
-			if url != "" {
-				results[i].Category_image = url
-			}
+			images.addImage(&results[i].Category_image)
+		}
+		if err := app.sign(&images); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
 		}
 
 		c.JSON(http.StatusOK, results)
 	}
 }
 
-func GetCategoryTree() gin.HandlerFunc {
+func (app *Application) GetCategoryTree() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -301,7 +308,7 @@ func GetCategoryTree() gin.HandlerFunc {
 		}
 
 		// Execute aggregation pipeline
-		cursor, err := CategoriesCollection.Aggregate(ctx, pipeline)
+		cursor, err := app.categoriesCollection.Aggregate(ctx, pipeline)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, "Something went wrong. Please try again.")
 			return
@@ -320,7 +327,7 @@ func GetCategoryTree() gin.HandlerFunc {
 
 		for i := range results {
 			var category CategoryListWithChildren
-			child_category, err := GetChildCategoryWithId(results[i].Category_ID)
+			child_category, err := app.GetChildCategoryWithId(results[i].Category_ID)
 			if err != nil {
 				fmt.Println(err)
 
@@ -338,7 +345,7 @@ func GetCategoryTree() gin.HandlerFunc {
 	}
 }
 
-func AdminGetCategoryHandler() gin.HandlerFunc {
+func (app *Application) AdminGetCategoryHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -357,7 +364,7 @@ func AdminGetCategoryHandler() gin.HandlerFunc {
 		}
 
 		// Execute aggregation pipeline
-		cursor, err := CategoriesCollection.Aggregate(ctx, pipeline)
+		cursor, err := app.categoriesCollection.Aggregate(ctx, pipeline)
 		if err != nil {
 			fmt.Print(err)
 			c.JSON(http.StatusInternalServerError, "Something went wrong. Please try again.")
@@ -374,23 +381,20 @@ func AdminGetCategoryHandler() gin.HandlerFunc {
 			return
 		}
 
-		// Loop through the cursor and get image of each category
+		// Presign the image of every category at once
+		var images signBatch
 		for i := range results {
-			url, err := getPresignURL(results[i].Category_image)
-			if err != nil {
-				log.Println("Error generating pre-signed URL for image:", err)
-				continue
-			}
-			if url != "" {
-				results[i].Category_image = url
-			}
+			images.addImage(&results[i].Category_image)
+		}
+		if err := app.sign(&images); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
 		}
 
 		c.JSON(http.StatusOK, results)
 	}
 }
 
-func GetSingleCategory() gin.HandlerFunc {
+func (app *Application) GetSingleCategory() gin.HandlerFunc {
 	// Extract category ID from query parameter
 	return func(c *gin.Context) {
 		categoryID := c.Query("id")
@@ -410,22 +414,17 @@ func GetSingleCategory() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		var category models.Categories
-		err = CategoriesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&category)
+		err = app.categoriesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&category)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Main category not found"})
 			return
 		}
 
-		url, err := getPresignURL(category.Category_image)
-		if err != nil {
+		if err := app.presignImage(&category.Category_image); err != nil {
 			log.Println("Error generating pre-signed URL for image:", err)
-			url = ""
-		}
-		if url != "" {
-			category.Category_image = url
 		}
 
-		child_category, err := GetCategoryWithId(objID)
+		child_category, err := app.GetCategoryWithId(objID)
 
 		categoryWithChildren := CategoryWithChildren{
 			Category:        category,
@@ -436,7 +435,7 @@ func GetSingleCategory() gin.HandlerFunc {
 	}
 }
 
-func GetCategoryWithId(categoryID primitive.ObjectID) ([]models.Categories, error) {
+func (app *Application) GetCategoryWithId(categoryID primitive.ObjectID) ([]models.Categories, error) {
 	var ctx = context.Background()
 
 	// Aggregation pipeline to find category details and its child categories recursively
@@ -457,7 +456,7 @@ func GetCategoryWithId(categoryID primitive.ObjectID) ([]models.Categories, erro
 	}
 
 	// Execute aggregation pipeline
-	cursor, err := CategoriesCollection.Aggregate(ctx, pipeline)
+	cursor, err := app.categoriesCollection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -472,13 +471,8 @@ func GetCategoryWithId(categoryID primitive.ObjectID) ([]models.Categories, erro
 		}
 		// Get image of each category prsign url
 
-		url, err := getPresignURL(category.Category_image)
-		if err != nil {
+		if err := app.presignImage(&category.Category_image); err != nil {
 			log.Println("Error generating pre-signed URL for image:", err)
-			url = ""
-		}
-		if url != "" {
-			category.Category_image = url
 		}
 
 		categories = append(categories, category)
@@ -492,7 +486,7 @@ func GetCategoryWithId(categoryID primitive.ObjectID) ([]models.Categories, erro
 	return categories, nil
 }
 
-func GetChildCategoryWithId(categoryID primitive.ObjectID) ([]CategoryList, error) {
+func (app *Application) GetChildCategoryWithId(categoryID primitive.ObjectID) ([]CategoryList, error) {
 	var ctx = context.Background()
 
 	// Aggregation pipeline to find category details and its child categories recursively
@@ -513,7 +507,7 @@ func GetChildCategoryWithId(categoryID primitive.ObjectID) ([]CategoryList, erro
 	}
 
 	// Execute aggregation pipeline
-	cursor, err := CategoriesCollection.Aggregate(ctx, pipeline)
+	cursor, err := app.categoriesCollection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -541,7 +535,7 @@ func GetChildCategoryWithId(categoryID primitive.ObjectID) ([]CategoryList, erro
 	return categories, nil
 }
 
-func EditCategory() gin.HandlerFunc {
+func (app *Application) EditCategory() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -568,11 +562,12 @@ func EditCategory() gin.HandlerFunc {
 		defer cancel()
 		filter := bson.D{primitive.E{Key: "_id", Value: catID}}
 		update := bson.D{{Key: "$set", Value: bson.D{primitive.E{Key: "category", Value: Editcategory.Category}}}}
-		_, err = CategoriesCollection.UpdateOne(ctx, filter, update)
+		_, err = app.categoriesCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, "Internal server error")
 			return
 		}
+		app.suggestions.Invalidate()
 		defer cancel()
 		ctx.Done()
 		c.IndentedJSON(http.StatusOK, "Successfully updated category")
@@ -580,7 +575,7 @@ func EditCategory() gin.HandlerFunc {
 	}
 }
 
-func ApproveCategory() gin.HandlerFunc {
+func (app *Application) ApproveCategory() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -623,12 +618,13 @@ func ApproveCategory() gin.HandlerFunc {
 			return
 		}
 
-		_, err = CategoriesCollection.UpdateOne(ctx, bson.M{"_id": catID}, bson.D{{Key: "$set", Value: bson.M{"isApproved": status}}})
+		_, err = app.categoriesCollection.UpdateOne(ctx, bson.M{"_id": catID}, bson.D{{Key: "$set", Value: bson.M{"isApproved": status}}})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Internal server error"})
 			return
 		}
+		app.suggestions.Invalidate()
 
 		c.JSON(http.StatusOK, gin.H{"message": "Category status updated successfully"})
 
@@ -2,22 +2,19 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var contentCollection *mongo.Collection = database.ProductData(database.Client, "ContentItem")
-
-func CreateContentItem() gin.HandlerFunc {
+func (app *Application) CreateContentItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		var contentItem models.ContentItem
@@ -29,13 +26,12 @@ func CreateContentItem() gin.HandlerFunc {
 			return
 		}
 
-		var existingContentItem models.ContentItem
-		err := contentCollection.FindOne(ctx, bson.M{"contentKey": ContentKey}).Decode(&existingContentItem)
-		if err != nil && err != mongo.ErrNoDocuments {
+		_, err := app.repos.Contents.FindByKey(ctx, ContentKey)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while checking for existing content item"})
 			return
 		}
-		if existingContentItem.ID != primitive.NilObjectID {
+		if err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Content item with this key already exists"})
 			return
 		}
@@ -54,6 +50,7 @@ func CreateContentItem() gin.HandlerFunc {
 
 		}
 
+		contentID := primitive.NewObjectID()
 		var content interface{}
 		if Type == "file" {
 			files := form.File["content"]
@@ -71,7 +68,7 @@ func CreateContentItem() gin.HandlerFunc {
 				}
 				defer f.Close()
 
-				uploadedURL, err := saveFile(f, file)
+				uploadedURL, err := app.saveFile(f, file, storage.ContentPrefix(contentID.Hex()))
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to save file: " + err.Error()})
 					return
@@ -92,7 +89,7 @@ func CreateContentItem() gin.HandlerFunc {
 		}
 
 		contentItem = models.ContentItem{
-			ID:          primitive.NewObjectID(),
+			ID:          contentID,
 			ContentKey:  ContentKey,
 			Type:        Type,
 			Description: Description,
@@ -103,26 +100,26 @@ func CreateContentItem() gin.HandlerFunc {
 			UpdatedAt:   time.Now(),
 		}
 
-		result, err := contentCollection.InsertOne(ctx, contentItem)
+		err = app.repos.Contents.Insert(ctx, &contentItem)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"Status": http.StatusCreated, "Message": "success", "data": result})
+		setAuditEntity(c, contentItem.ID)
+		c.JSON(http.StatusCreated, gin.H{"Status": http.StatusCreated, "Message": "success", "data": gin.H{"InsertedID": contentItem.ID}})
 	}
 }
 
-func GetContentItem() gin.HandlerFunc {
+func (app *Application) GetContentItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		contentItemId := c.Param("contentItemId")
-		var contentItem models.ContentItem
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(contentItemId)
 
-		err := contentCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&contentItem)
+		contentItem, err := app.repos.Contents.FindByID(ctx, objId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": err.Error()})
 			return
@@ -132,16 +129,15 @@ func GetContentItem() gin.HandlerFunc {
 	}
 }
 
-func UpdateContentItem() gin.HandlerFunc {
+func (app *Application) UpdateContentItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		contentItemId := c.Param("contentItemId")
-		var contentItem models.ContentItem
 		defer cancel()
 
 		objId, _ := primitive.ObjectIDFromHex(contentItemId)
 
-		err := contentCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&contentItem)
+		contentItem, err := app.repos.Contents.FindByID(ctx, objId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": err.Error()})
 			return
@@ -170,7 +166,7 @@ func UpdateContentItem() gin.HandlerFunc {
 				}
 				defer f.Close()
 
-				uploadedURL, err := saveFile(f, file)
+				uploadedURL, err := app.saveFile(f, file, storage.ContentPrefix(objId.Hex()))
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to save file: " + err.Error()})
 					return
@@ -182,41 +178,28 @@ func UpdateContentItem() gin.HandlerFunc {
 			content = c.PostForm("content")
 		}
 
-		contentItem = models.ContentItem{
-			Content:   content,
-			UpdatedAt: time.Now(),
+		update := repository.Fields{
+			"content":    content,
+			"updated_at": time.Now(),
 		}
 
-		update := bson.M{
-			"contentKey":  contentItem.ContentKey,
-			"type":        contentItem.Type,
-			"description": contentItem.Description,
-			"content":     contentItem.Content,
-			"metadata":    contentItem.Metadata,
-			"is_active":   contentItem.IsActive,
-			"updated_at":  time.Now(),
-		}
-
-		result, err := contentCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+		err = app.repos.Contents.Update(ctx, objId, repository.Update{Set: update})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
 
-		var updatedContentItem models.ContentItem
-		if result.MatchedCount == 1 {
-			err := contentCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedContentItem)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
-				return
-			}
+		updatedContentItem, err := app.repos.Contents.FindByID(ctx, objId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{"Status": http.StatusOK, "Message": "success", "data": updatedContentItem})
 	}
 }
 
-func DeleteContentItem() gin.HandlerFunc {
+func (app *Application) DeleteContentItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		contentItemId := c.Param("id")
@@ -224,14 +207,13 @@ func DeleteContentItem() gin.HandlerFunc {
 
 		objId, _ := primitive.ObjectIDFromHex(contentItemId)
 
-		result, err := contentCollection.DeleteOne(ctx, bson.M{"_id": objId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
+		err := app.repos.Contents.Delete(ctx, objId)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "Error": "Content item with specified ID not found!"})
 			return
 		}
-
-		if result.DeletedCount < 1 {
-			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "Error": "Content item with specified ID not found!"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
 		}
 
@@ -239,33 +221,23 @@ func DeleteContentItem() gin.HandlerFunc {
 	}
 }
 
-func GetAllContentItems() gin.HandlerFunc {
+func (app *Application) GetAllContentItems() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		var contentItems []models.ContentItem
 		defer cancel()
 
-		results, err := contentCollection.Find(ctx, bson.M{})
+		contentItems, err := app.repos.Contents.Find(ctx, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "Error": err.Error()})
 			return
 		}
-
-		defer results.Close(ctx)
-		for results.Next(ctx) {
-			var singleContentItem models.ContentItem
-			if err = results.Decode(&singleContentItem); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
-			}
-			contentItems = append(contentItems, singleContentItem)
-		}
 		for i, item := range contentItems {
 			if content, ok := item.Content.(primitive.A); ok {
 				updatedContent := make([]interface{}, len(content))
 				for j, contentItem := range content {
 					if contentItem != nil {
 						if strItem, ok := contentItem.(string); ok {
-							url, err := getPresignURL(strItem)
+							url, err := app.getPresignURL(strItem)
 							if err == nil {
 								updatedContent[j] = url
 							} else {
@@ -284,15 +256,14 @@ func GetAllContentItems() gin.HandlerFunc {
 	}
 }
 
-func GetContentItemsByKey() gin.HandlerFunc {
+func (app *Application) GetContentItemsByKey() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		contentType := c.Param("contentKey")
 
-		var contentItems models.ContentItem
 		defer cancel()
 
-		err := contentCollection.FindOne(ctx, bson.M{"contentKey": contentType}).Decode(&contentItems)
+		contentItems, err := app.repos.Contents.FindByKey(ctx, contentType)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
@@ -303,7 +274,7 @@ func GetContentItemsByKey() gin.HandlerFunc {
 			for i, item := range content {
 				if item != nil {
 					if strItem, ok := item.(string); ok {
-						url, err := getPresignURL(strItem)
+						url, err := app.getPresignURL(strItem)
 						if err == nil {
 							updatedContent[i] = url
 						} else {
@@ -319,7 +290,7 @@ func GetContentItemsByKey() gin.HandlerFunc {
 	}
 }
 
-func ToggleContentItemStatus() gin.HandlerFunc {
+func (app *Application) ToggleContentItemStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		contentItemId := c.Param("id")
@@ -327,21 +298,18 @@ func ToggleContentItemStatus() gin.HandlerFunc {
 
 		objId, _ := primitive.ObjectIDFromHex(contentItemId)
 
-		var contentItem models.ContentItem
-		err := contentCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&contentItem)
+		contentItem, err := app.repos.Contents.FindByID(ctx, objId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": err.Error()})
 			return
 		}
 
-		update := bson.M{
-			"$set": bson.M{
-				"is_active":  !contentItem.IsActive,
-				"updated_at": time.Now(),
-			},
-		}
+		update := repository.Update{Set: repository.Fields{
+			"is_active":  !contentItem.IsActive,
+			"updated_at": time.Now(),
+		}}
 
-		_, err = contentCollection.UpdateOne(ctx, bson.M{"_id": objId}, update)
+		err = app.repos.Contents.Update(ctx, objId, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": err.Error()})
 			return
@@ -351,7 +319,21 @@ func ToggleContentItemStatus() gin.HandlerFunc {
 	}
 }
 
-func UpdateFileContentItemContent() gin.HandlerFunc {
+// AuditContentKey locates the content item with the :contentKey
+// parameter.
+func (app *Application) AuditContentKey() AuditLocator {
+	return func(c *gin.Context) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		contentItem, err := app.repos.Contents.FindByKey(ctx, c.Param("contentKey"))
+		if err != nil {
+			return ""
+		}
+		return contentItem.ID.Hex()
+	}
+}
+
+func (app *Application) UpdateFileContentItemContent() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -362,8 +344,7 @@ func UpdateFileContentItemContent() gin.HandlerFunc {
 			return
 		}
 
-		var contentItem models.ContentItem
-		err := contentCollection.FindOne(ctx, bson.M{"contentKey": contentKey}).Decode(&contentItem)
+		contentItem, err := app.repos.Contents.FindByKey(ctx, contentKey)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Content item not found"})
 			return
@@ -395,7 +376,7 @@ func UpdateFileContentItemContent() gin.HandlerFunc {
 			defer src.Close()
 
 			// Upload the file to S3 and get the URL
-			uploadedURL, err := saveFile(src, file)
+			uploadedURL, err := app.saveFile(src, file, storage.ContentPrefix(contentItem.ID.Hex()))
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to upload file"})
 				return
@@ -417,15 +398,18 @@ func UpdateFileContentItemContent() gin.HandlerFunc {
 		}
 		updatedContent := append(existingContent, uploadedURLs...)
 
-		update := bson.M{
-			"$set": bson.M{
-				"content":    updatedContent,
-				"updated_at": time.Now(),
-			},
+		update := repository.Update{Set: repository.Fields{
+			"content":    updatedContent,
+			"updated_at": time.Now(),
+		}}
+
+		err = app.repos.Contents.Update(ctx, contentItem.ID, update)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to update content item"})
+			return
 		}
 
-		var updatedContentItem models.ContentItem
-		err = contentCollection.FindOneAndUpdate(ctx, bson.M{"contentKey": contentKey}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedContentItem)
+		updatedContentItem, err := app.repos.Contents.FindByID(ctx, contentItem.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to fetch updated content item"})
 			return
@@ -435,7 +419,7 @@ func UpdateFileContentItemContent() gin.HandlerFunc {
 	}
 }
 
-func DeleteImageFromContentItem() gin.HandlerFunc {
+func (app *Application) DeleteImageFromContentItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -455,8 +439,7 @@ func DeleteImageFromContentItem() gin.HandlerFunc {
 			return
 		}
 
-		var contentItem models.ContentItem
-		err = contentCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&contentItem)
+		contentItem, err := app.repos.Contents.FindByID(ctx, objId)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Content Item not found"})
 			return
@@ -485,8 +468,8 @@ func DeleteImageFromContentItem() gin.HandlerFunc {
 		contentSlice = append(contentSlice[:index], contentSlice[index+1:]...)
 
 		// Update the Content field in the database
-		update := bson.M{"$set": bson.M{"content": contentSlice}}
-		_, err = contentCollection.UpdateOne(ctx, bson.M{"_id": objId}, update)
+		update := repository.Update{Set: repository.Fields{"content": contentSlice}}
+		err = app.repos.Contents.Update(ctx, objId, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update Content Item"})
 			return
@@ -1,15 +1,83 @@
 package controllers
 
-import "go.mongodb.org/mongo-driver/mongo"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/autocomplete"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/events"
+	"github.com/kravi0/BizGrowth-backend/mail"
+	"github.com/kravi0/BizGrowth-backend/otp"
+	"github.com/kravi0/BizGrowth-backend/ratelimit"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/search"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+)
 
-func NewApplication(prodCollection *mongo.Collection, userCollection *mongo.Collection) *Application {
-	return &Application{
-		prodCollection: prodCollection,
-		userCollection: userCollection,
+// Application carries every dependency of the HTTP handlers. The entities
+// with a repository are reached through repos, the remaining collections are
+// still used directly and are nil when the application is built without a
+// database (e.g. against repository.NewMemory in tests).
+type Application struct {
+	config    *config.Config
+	repos     repository.Repositories
+	storage   storage.Storage
+	signer    *storage.Signer
+	otpSender otp.Sender
+	otpStore  otp.Store
+	mailer    mail.Mailer
+	limiter   *ratelimit.Limiter
+	search    search.Config
+	// suggestions is invalidated by the writes to products and sellers
+	// and by the category handlers.
+	suggestions *autocomplete.Index
+	// events queues product views, search impressions and clicks.
+	events *events.Recorder
+
+	userCollection               *mongo.Collection
+	categoriesCollection         *mongo.Collection
+	attributesCollection         *mongo.Collection
+	requirementMessageCollection *mongo.Collection
+	feedsCollection              *mongo.Collection
+	productReferenceCollection   *mongo.Collection
+	sellerTmpCollection          *mongo.Collection
+	settingsCollection           *mongo.Collection
+}
+
+func NewApplication(cfg *config.Config, repos repository.Repositories, store storage.Storage, sender otp.Sender, otpStore otp.Store, limiter *ratelimit.Limiter, db *mongo.Database) *Application {
+	app := &Application{
+		config:    cfg,
+		repos:     repos,
+		storage:   store,
+		signer:    storage.NewSigner(store, cfg.Storage.Signing),
+		otpSender: sender,
+		otpStore:  otpStore,
+		mailer:    mail.New(cfg.SMTP),
+		limiter:   limiter,
+		search:    search.NewConfig(cfg.Search, cfg.Pricing.Currency),
 	}
+	app.events = events.New(cfg.Events, repos.Events.InsertMany)
+	app.suggestions = autocomplete.New(cfg.Autocomplete, app.suggestionEntries)
+	app.repos.Products = suggestProductRepo{repos.Products, app.suggestions.Invalidate}
+	app.repos.Sellers = suggestSellerRepo{repos.Sellers, app.suggestions.Invalidate}
+	if db != nil {
+		app.userCollection = db.Collection("User")
+		app.categoriesCollection = db.Collection("Categories")
+		app.attributesCollection = db.Collection("AttributeType")
+		app.requirementMessageCollection = db.Collection("RequirementMessage")
+		app.feedsCollection = db.Collection("New_Feeds")
+		app.productReferenceCollection = db.Collection("ProductReference")
+		app.sellerTmpCollection = db.Collection("SellerTmp")
+		app.settingsCollection = db.Collection("Settings")
+	}
+	return app
 }
 
-type Application struct {
-	prodCollection *mongo.Collection
-	userCollection *mongo.Collection
+// RateLimit throttles the handlers after it with the limits of scope, see
+// ratelimit.Limiter. Without a limiter every request is let through.
+func (app *Application) RateLimit(scope ratelimit.Scope, subject ratelimit.Subject) gin.HandlerFunc {
+	if app.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return app.limiter.Limit(scope, subject)
 }
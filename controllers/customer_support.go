@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,25 +10,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"github.com/kravi0/BizGrowth-backend/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var SupportTickerCollection = database.ProductData(database.Client, "CustomerSupportTicket")
-var SupportChatMessage = database.ProductData(database.Client, "SupportChatMessage")
-
-func GenerateUniqueTicketID(ctx context.Context, suffix string) (string, error) {
+func (app *Application) GenerateUniqueTicketID(ctx context.Context, suffix string) (string, error) {
 	// Generate the ticket ID based on creation timestamp and name.
 	currentTime, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	generatedTicketID := strconv.FormatInt(currentTime.Unix(), 5) + strings.ToUpper(suffix)[0:2]
 
 	// Check if the generated ID already exists.
 	for {
-		count, err := SupportTickerCollection.CountDocuments(ctx, bson.M{"ticketid": generatedTicketID})
+		count, err := app.repos.Tickets.Count(ctx, repository.TicketFilter{TicketID: generatedTicketID})
 		if err != nil {
 			return "", err
 		}
@@ -41,7 +38,7 @@ func GenerateUniqueTicketID(ctx context.Context, suffix string) (string, error)
 	return generatedTicketID, nil
 }
 
-func CreateTicket() gin.HandlerFunc {
+func (app *Application) CreateTicket() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
@@ -56,7 +53,7 @@ func CreateTicket() gin.HandlerFunc {
 		ticket.Name = c.PostForm("name")
 		ticket.MobileNo = c.PostForm("mobileno")
 		ticket.Status = "Initiated"
-		id, err := GenerateUniqueTicketID(ctx, ticket.Name)
+		id, err := app.GenerateUniqueTicketID(ctx, ticket.Name)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Unable to create ticket"})
 			return
@@ -83,7 +80,7 @@ func CreateTicket() gin.HandlerFunc {
 			}
 			defer f.Close()
 
-			uploadUrl, err := saveFile(f, file)
+			uploadUrl, err := app.saveFile(f, file, storage.TicketPrefix(ticket.Ticket_id.Hex()))
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 				return
@@ -100,7 +97,7 @@ func CreateTicket() gin.HandlerFunc {
 
 		ticket.Attachments = attachmentsUrl
 
-		_, insertErr := SupportTickerCollection.InsertOne(ctx, ticket)
+		insertErr := app.repos.Tickets.Insert(ctx, &ticket)
 		if insertErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": insertErr.Error()})
 			return
@@ -111,49 +108,38 @@ func CreateTicket() gin.HandlerFunc {
 }
 
 // @Summary Get all tickets
-func GetTickets() gin.HandlerFunc {
+func (app *Application) GetTickets() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		status := c.Query("status")
-		filter := bson.M{}
-		if status != "" {
-			filter["status"] = status
-		}
 
-		cursor, err := SupportTickerCollection.Find(ctx, filter)
+		tickets, err := app.repos.Tickets.Find(ctx, repository.TicketFilter{Status: status}, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var tickets []models.CustomerSupportTicket
-		if err := cursor.All(ctx, &tickets); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-
-		//go through each ticket , iterate over attachments, create presignurl and add back
+		//go through each ticket , collect the attachments and presign them at once
 
-		for i, ticket := range tickets {
-			for j, attachment := range ticket.Attachments {
-				url, err := getPresignURL(attachment)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-					return
-				}
-				tickets[i].Attachments[j] = url
+		var attachments signBatch
+		for i := range tickets {
+			for j := range tickets[i].Attachments {
+				attachments.addURL(&tickets[i].Attachments[j])
 			}
 		}
+		if err := app.sign(&attachments); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
 
 		c.JSON(http.StatusOK, tickets)
 	}
 }
 
 // @Summary Update ticket status
-func UpdateTicketStatus() gin.HandlerFunc {
+func (app *Application) UpdateTicketStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -185,21 +171,25 @@ func UpdateTicketStatus() gin.HandlerFunc {
 		default:
 		}
 
-		filter := bson.M{"ticketid": ticketId}
+		ticket, err := app.repos.Tickets.FindByTicketID(ctx, ticketId)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Ticket not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
 
-		update := bson.M{"$set": bson.M{
+		update := repository.Update{Set: repository.Fields{
 			"status":     status,
 			"updated_at": updatedAt,
 		}}
 
-		var ticket models.CustomerSupportTicket
-
-		updateErr := SupportTickerCollection.FindOneAndUpdate(ctx, filter, update).Decode(&ticket)
+		updateErr := app.repos.Tickets.Update(ctx, ticket.Ticket_id, update)
 		if updateErr != nil {
-			if updateErr == mongo.ErrNoDocuments {
-				c.JSON(http.StatusNotFound, gin.H{"Error": "Ticket not found"})
-				return
-			}
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": updateErr.Error()})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -209,34 +199,26 @@ func UpdateTicketStatus() gin.HandlerFunc {
 
 }
 
-func GetTicketById() gin.HandlerFunc {
+func (app *Application) GetTicketById() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		ticketID := c.Param("id")
-		fmt.Print(ticketID)
-		filter := bson.M{
-			"$or": []bson.M{
-				{"_id": ticketID},
-				{"ticketid": ticketID},
-			},
-		}
 
 		if ticketID == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Ticket ID is required"})
 			return
 		}
 
-		var ticket models.CustomerSupportTicket
-		err := SupportTickerCollection.FindOne(ctx, filter).Decode(&ticket)
+		ticket, err := app.findTicket(ctx, ticketID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
 		}
 
 		for j, attachment := range ticket.Attachments {
-			url, err := getPresignURL(attachment)
+			url, err := app.getPresignURL(attachment)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 				return
@@ -248,13 +230,12 @@ func GetTicketById() gin.HandlerFunc {
 	}
 }
 
-func AssignTicket() gin.HandlerFunc {
+func (app *Application) AssignTicket() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		ticketId := c.Param("id")
-		var ticket models.CustomerSupportTicket
-		err := SupportTickerCollection.FindOne(ctx, bson.M{"_id": ticketId}).Decode(&ticket)
+		ticket, err := app.findTicket(ctx, ticketId)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
@@ -266,14 +247,12 @@ func AssignTicket() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"_id": ticketId}
-
-		update := bson.M{"$set": bson.M{
-			"assigned_to": ticket.AssignedSupport,
-			"updated_at":  updatedAt,
+		update := repository.Update{Set: repository.Fields{
+			"assignedsupport": ticket.AssignedSupport,
+			"updated_at":      updatedAt,
 		}}
 
-		_, updateErr := SupportTickerCollection.UpdateOne(ctx, filter, update)
+		updateErr := app.repos.Tickets.Update(ctx, ticket.Ticket_id, update)
 		if updateErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": updateErr.Error()})
 			return
@@ -284,7 +263,7 @@ func AssignTicket() gin.HandlerFunc {
 	}
 }
 
-func GetTicketCounts() gin.HandlerFunc {
+func (app *Application) GetTicketCounts() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
@@ -304,7 +283,7 @@ func GetTicketCounts() gin.HandlerFunc {
 		counts := TicketCounts{}
 
 		for status, code := range statusMap {
-			count, err := SupportTickerCollection.CountDocuments(ctx, bson.M{"status": status})
+			count, err := app.repos.Tickets.Count(ctx, repository.TicketFilter{Status: status})
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -324,13 +303,13 @@ func GetTicketCounts() gin.HandlerFunc {
 	}
 }
 
-func AddMessage() gin.HandlerFunc {
+func (app *Application) AddMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		ticketId := c.Param("id")
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Unauthorized"})
 			return
 		}
@@ -340,8 +319,7 @@ func AddMessage() gin.HandlerFunc {
 			return
 		}
 
-		var ticket models.CustomerSupportTicket
-		findErr := SupportTickerCollection.FindOne(ctx, bson.M{"ticketid": ticketId}).Decode(&ticket)
+		ticket, findErr := app.repos.Tickets.FindByTicketID(ctx, ticketId)
 		if findErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Invalid support ticket"})
 			return
@@ -363,28 +341,15 @@ func AddMessage() gin.HandlerFunc {
 			return
 		}
 
-		var existingChatMessage models.SupportChatMessage
-
-		finderr := SupportChatMessage.FindOne(ctx, bson.M{"ticketId": ticketId}).Decode(&existingChatMessage)
-		if finderr != nil {
-
-			//create new
-			chatMessage := models.SupportChatMessage{
-				SupportChatId:   primitive.NewObjectID(),
-				SupportTicketId: ticketId,
-				Messages:        []models.ChatMessage{message},
-			}
-
-			_, insertErr := SupportChatMessage.InsertOne(ctx, chatMessage)
-			if insertErr != nil {
-				fmt.Println(insertErr)
-				c.JSON(http.StatusBadRequest, gin.H{"Error": insertErr.Error()})
-				return
-			}
+		chatId, created, appendErr := app.repos.Tickets.AppendMessage(ctx, ticketId, message)
+		if appendErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": appendErr.Error()})
+			return
+		}
+		if created {
 			//update SupportTicker with id of SupportChatMessage
-
-			_, updateErr := SupportTickerCollection.UpdateOne(ctx, bson.M{"_id": ticketId}, bson.M{"$set": bson.M{
-				"chatmessage": chatMessage.SupportChatId,
+			updateErr := app.repos.Tickets.Update(ctx, ticket.Ticket_id, repository.Update{Set: repository.Fields{
+				"chatmessage": chatId,
 				"updated_at":  updatedAt,
 			}})
 			if updateErr != nil {
@@ -393,13 +358,6 @@ func AddMessage() gin.HandlerFunc {
 			}
 		}
 
-		_, updateErr := SupportChatMessage.UpdateOne(ctx, bson.M{"ticketId": ticketId}, bson.M{"$push": bson.M{
-			"messages": message,
-		}})
-		if updateErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": updateErr.Error()})
-			return
-		}
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Message has been sent successfully",
 		})
@@ -408,13 +366,13 @@ func AddMessage() gin.HandlerFunc {
 
 }
 
-func AddSellerMessage() gin.HandlerFunc {
+func (app *Application) AddSellerMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		ticketId := c.Param("id")
 
-		if !checkSeller(ctx, c) {
+		if !app.checkSeller(ctx, c) {
 			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Unauthorized"})
 			return
 		}
@@ -429,8 +387,7 @@ func AddSellerMessage() gin.HandlerFunc {
 		// Convert to seller id objectid
 		sellerId, _ := primitive.ObjectIDFromHex(uid.(string))
 
-		var foundSeller models.Seller
-		err := SellerCollection.FindOne(ctx, bson.M{"_id": sellerId}).Decode(&foundSeller)
+		foundSeller, err := app.repos.Sellers.FindByID(ctx, sellerId)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller"})
 			return
@@ -443,8 +400,7 @@ func AddSellerMessage() gin.HandlerFunc {
 			return
 		}
 
-		var ticket models.CustomerSupportTicket
-		findErr := SupportTickerCollection.FindOne(ctx, bson.M{"ticketid": ticketId}).Decode(&ticket)
+		ticket, findErr := app.repos.Tickets.FindByTicketID(ctx, ticketId)
 		if findErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Invalid support ticket"})
 			return
@@ -462,41 +418,21 @@ func AddSellerMessage() gin.HandlerFunc {
 
 		updatedAt := time.Now()
 
-		var existingChatMessage models.SupportChatMessage
-		finderr := SupportChatMessage.FindOne(ctx, bson.M{"SupportTicketId": ticketId}).Decode(&existingChatMessage)
-		if finderr != nil {
-			// Create new chat message document
-			chatMessage := models.SupportChatMessage{
-				SupportChatId:   primitive.NewObjectID(),
-				SupportTicketId: ticketId,
-				Messages:        []models.ChatMessage{message},
-			}
-
-			_, insertErr := SupportChatMessage.InsertOne(ctx, chatMessage)
-			if insertErr != nil {
-				fmt.Println(insertErr)
-				c.JSON(http.StatusBadRequest, gin.H{"Error": insertErr.Error()})
-				return
-			}
-
+		chatId, created, appendErr := app.repos.Tickets.AppendMessage(ctx, ticketId, message)
+		if appendErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": appendErr.Error()})
+			return
+		}
+		if created {
 			// Update SupportTicket with id of SupportChatMessage
-			_, updateErr := SupportTickerCollection.UpdateOne(ctx, bson.M{"ticketid": ticketId}, bson.M{"$set": bson.M{
-				"chatmessage": chatMessage.SupportChatId,
+			updateErr := app.repos.Tickets.Update(ctx, ticket.Ticket_id, repository.Update{Set: repository.Fields{
+				"chatmessage": chatId,
 				"updated_at":  updatedAt,
 			}})
 			if updateErr != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": updateErr.Error()})
 				return
 			}
-		} else {
-			// Add message to existing chat document
-			_, updateErr := SupportChatMessage.UpdateOne(ctx, bson.M{"SupportTicketId": ticketId}, bson.M{"$push": bson.M{
-				"messages": message,
-			}})
-			if updateErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"Error": updateErr.Error()})
-				return
-			}
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -505,7 +441,7 @@ func AddSellerMessage() gin.HandlerFunc {
 	}
 }
 
-func GetChatMessagesHandler() gin.HandlerFunc {
+func (app *Application) GetChatMessagesHandler() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
@@ -516,7 +452,7 @@ func GetChatMessagesHandler() gin.HandlerFunc {
 			return
 		}
 
-		messages, err := GetChatMessagesByTicketID(ticketID)
+		messages, err := app.GetChatMessagesByTicketID(ticketID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
@@ -526,16 +462,24 @@ func GetChatMessagesHandler() gin.HandlerFunc {
 	}
 }
 
-func GetChatMessagesByTicketID(ticketID string) ([]models.ChatMessage, error) {
+func (app *Application) GetChatMessagesByTicketID(ticketID string) ([]models.ChatMessage, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var supportChatMessage models.SupportChatMessage
-	err := SupportChatMessage.FindOne(ctx, bson.M{"ticketId": ticketID}).Decode(&supportChatMessage)
-	if err != nil {
+	return app.repos.Tickets.Messages(ctx, ticketID)
+}
+
+// findTicket looks a ticket up by its public ticket id, falling back to the
+// document id for callers that still pass the ObjectID.
+func (app *Application) findTicket(ctx context.Context, id string) (*models.CustomerSupportTicket, error) {
+	ticket, err := app.repos.Tickets.FindByTicketID(ctx, id)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return ticket, err
+	}
+	objID, convErr := primitive.ObjectIDFromHex(id)
+	if convErr != nil {
 		return nil, err
 	}
-
-	return supportChatMessage.Messages, nil
+	return app.repos.Tickets.FindByID(ctx, objID)
 }
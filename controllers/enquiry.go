@@ -11,12 +11,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func EnquiryHandler() gin.HandlerFunc {
+func (app *Application) EnquiryHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		mobileno, existse := c.Get("mobile")
 
@@ -40,6 +41,30 @@ func EnquiryHandler() gin.HandlerFunc {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
+
+		// An enquiry about a variant has to name a variant of the product.
+		if enquire.Variant_id != "" {
+			productID, err := primitive.ObjectIDFromHex(enquire.Product_id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
+				return
+			}
+			product, err := app.repos.Products.FindByID(ctx, productID)
+			if errors.Is(err, repository.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
+				return
+			}
+			if err != nil {
+				log.Print(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+				return
+			}
+			if findVariant(product, enquire.Variant_id) == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "Variant not found"})
+				return
+			}
+		}
+
 		enquire.Enquire_id = primitive.NewObjectID()
 		enquire.User_id = uid.(string)
 
@@ -48,7 +73,7 @@ func EnquiryHandler() gin.HandlerFunc {
 		enquire.Enquire_date, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		enquire.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		_, err := EnquireCollection.InsertOne(ctx, enquire)
+		err := app.repos.Enquiries.Insert(ctx, &enquire)
 		if err != nil {
 			log.Print(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
@@ -59,12 +84,12 @@ func EnquiryHandler() gin.HandlerFunc {
 }
 
 // update status of requirement
-func UpdateEnquireStatus() gin.HandlerFunc {
+func (app *Application) UpdateEnquireStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -80,16 +105,12 @@ func UpdateEnquireStatus() gin.HandlerFunc {
 		}
 
 		//find  update enquiry status
-		var enquiry models.Enquire
-
-		filter := bson.M{"_id": objectId}
+		update := repository.Update{Set: repository.Fields{"status": status}}
 
-		update := bson.M{"$set": bson.M{"status": status}}
-
-		findErr := EnquireCollection.FindOneAndUpdate(ctx, filter, update).Decode(&enquiry)
+		findErr := app.repos.Enquiries.Update(ctx, objectId, update)
 
 		if findErr != nil {
-			if errors.Is(findErr, mongo.ErrNoDocuments) {
+			if errors.Is(findErr, repository.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"Error": "Enquiry not found"})
 				return
 			}
@@ -102,7 +123,7 @@ func UpdateEnquireStatus() gin.HandlerFunc {
 	}
 }
 
-func GetUserEnquiries() gin.HandlerFunc {
+func (app *Application) GetUserEnquiries() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from token
 		uid, exists := c.Get("uid")
@@ -115,27 +136,19 @@ func GetUserEnquiries() gin.HandlerFunc {
 		userID := uid.(string)
 		fmt.Println(userID)
 		// Define filter to fetch enquiries for the specific user
-		filter := bson.M{"user_id": userID}
+		filter := repository.EnquiryFilter{UserID: userID}
 
 		// Fetch enquiries from the database
 		var enquiries []map[string]interface{}
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cursor, err := EnquireCollection.Find(ctx, filter)
+		found, err := app.repos.Enquiries.Find(ctx, filter, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
 			return
 		}
-		defer cursor.Close(ctx)
-		for cursor.Next(ctx) {
-			var enquire models.Enquire
-			if err := cursor.Decode(&enquire); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
-				return
-			}
-
+		for _, enquire := range found {
 			// Fetch product details based on product_id
-			var product models.Product
 			fmt.Println(enquire)
 
 			prodID, err := primitive.ObjectIDFromHex(enquire.Product_id)
@@ -146,19 +159,18 @@ func GetUserEnquiries() gin.HandlerFunc {
 				return
 			}
 
-			errors := ProductCollection.FindOne(ctx, bson.M{"_id": prodID}).Decode(&product)
+			product, errors := app.repos.Products.FindByID(ctx, prodID)
 			if errors != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"Error": "failed to fetch product details"})
 				return
 			}
 
 			var imgUrl string
-			if product.Image != nil {
-				url, err := getPresignURL(product.Image[0])
-				if err != nil {
-					imgUrl = ""
+			if len(product.Image) > 0 {
+				image := product.Image[0]
+				if err := app.presignImage(&image); err == nil {
+					imgUrl = image.URL("thumbnail")
 				}
-				imgUrl = url
 			}
 
 			// Add product name and image to enquiry
@@ -169,59 +181,49 @@ func GetUserEnquiries() gin.HandlerFunc {
 				"product_image":    imgUrl,
 				"enquire_note":     enquire.Enquiry_note,
 				"enquire_quantity": enquire.Quantity,
+				"variant_id":       enquire.Variant_id,
 				"enquire_status":   enquire.Status,
 				// Add other enquiry fields if needed
 			}
 			enquiries = append(enquiries, enquiryWithProduct)
 		}
-		if err := cursor.Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
-			return
-		}
 
 		c.JSON(http.StatusOK, enquiries)
 	}
 }
 
-func GETEnquiryHandler() gin.HandlerFunc {
+func (app *Application) GETEnquiryHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "Admin Token Not found"})
 			return
 		}
 
-		var enquire []models.Enquire
-
-		cursor, err := EnquireCollection.Find(ctx, primitive.M{})
+		enquire, err := app.repos.Enquiries.Find(ctx, repository.EnquiryFilter{}, repository.Page{})
 		if err != nil {
 			log.Print(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch"})
 			return
 		}
-		if err := cursor.All(ctx, &enquire); err != nil {
-			log.Print(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
-			return
-		}
-		defer cursor.Close(ctx)
 
 		// Enrich enquiry data with additional details
 		enquiriesWithDetails := make([]map[string]interface{}, 0)
 
 		for _, enquiry := range enquire {
 			// Fetch product details based on product_id
-			productDetails := getProductDetails(ctx, enquiry.Product_id)
+			productDetails := app.getProductDetails(ctx, enquiry.Product_id)
 
 			// Fetch user details based on user_id
-			userDetails := getUserDetails(ctx, enquiry.User_id)
+			userDetails := app.getUserDetails(ctx, enquiry.User_id)
 
 			// Construct enriched enquiry
 			enquiryWithDetails := map[string]interface{}{
 				"enquiry": enquiry,
 				"product": productDetails,
+				"variant": app.getVariantDetails(ctx, enquiry.Product_id, enquiry.Variant_id),
 				"user":    userDetails,
 			}
 
@@ -232,7 +234,7 @@ func GETEnquiryHandler() gin.HandlerFunc {
 	}
 }
 
-func GetAdminSingleEnquiry() gin.HandlerFunc {
+func (app *Application) GetAdminSingleEnquiry() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -244,16 +246,12 @@ func GetAdminSingleEnquiry() gin.HandlerFunc {
 			return
 		}
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "Admin Token Not found"})
 			return
 		}
 
-		var enquire models.Enquire
-
-		findErr := EnquireCollection.FindOne(ctx, primitive.M{
-			"_id": id,
-		}).Decode(&enquire)
+		enquire, findErr := app.repos.Enquiries.FindByID(ctx, id)
 		if findErr != nil {
 			log.Print(findErr)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Unable to fetch"})
@@ -261,15 +259,16 @@ func GetAdminSingleEnquiry() gin.HandlerFunc {
 		}
 
 		// Fetch product details based on product_id
-		productDetails := getProductDetails(ctx, enquire.Product_id)
+		productDetails := app.getProductDetails(ctx, enquire.Product_id)
 
 		// Fetch user details based on user_id
-		userDetails := getUserDetails(ctx, enquire.User_id)
+		userDetails := app.getUserDetails(ctx, enquire.User_id)
 
 		// Construct enriched enquiry
 		enquiryWithDetails := map[string]interface{}{
 			"enquiry": enquire,
 			"product": productDetails,
+			"variant": app.getVariantDetails(ctx, enquire.Product_id, enquire.Variant_id),
 			"user":    userDetails,
 		}
 
@@ -277,10 +276,38 @@ func GetAdminSingleEnquiry() gin.HandlerFunc {
 	}
 }
 
-// Function to fetch product details based on product_id
-func getProductDetails(ctx context.Context, productID string) map[string]interface{} {
-	var productDetails models.Product
+// getVariantDetails returns the variant an enquiry is about, nil when the
+// enquiry names none or the variant was removed since.
+func (app *Application) getVariantDetails(ctx context.Context, productID, variantID string) map[string]interface{} {
+	if variantID == "" {
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil
+	}
+	product, err := app.repos.Products.FindByID(ctx, id)
+	if err != nil {
+		log.Printf("Error fetching product details for product ID %s: %s", productID, err.Error())
+		return nil
+	}
+	variant := findVariant(product, variantID)
+	if variant == nil {
+		return nil
+	}
+	priced := variantPricing(product, variant)
+	return map[string]interface{}{
+		"_id":         variant.ID,
+		"sku":         variant.SKU,
+		"attribute":   variant.Attribute,
+		"price":       priced.Price,
+		"price_range": priced.PriceRange,
+		"stock":       variant.Stock,
+	}
+}
 
+// Function to fetch product details based on product_id
+func (app *Application) getProductDetails(ctx context.Context, productID string) map[string]interface{} {
 	id, err := primitive.ObjectIDFromHex(productID)
 
 	if err != nil {
@@ -288,29 +315,26 @@ func getProductDetails(ctx context.Context, productID string) map[string]interfa
 		return nil
 	}
 
-	errs := ProductCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&productDetails)
+	productDetails, errs := app.repos.Products.FindByID(ctx, id)
 	if errs != nil {
 		log.Printf("Error fetching product details for product ID %s: %s", productID, errs.Error())
 		return nil
 	}
 
-	for i, url := range productDetails.Image {
+	for i := range productDetails.Image {
 
-		// Get pre-signed URL for the image
-		url, err := getPresignURL(url)
-		if err != nil {
+		// Get pre-signed URLs for the image
+		if err := app.presignImage(&productDetails.Image[i]); err != nil {
 			log.Println("Error generating pre-signed URL for image:", err)
 			continue
 		}
-		// Update the image URL in the product
-		productDetails.Image[i] = url
 
 	}
 
 	var sellerArray []map[string]interface{}
 	for _, seller := range productDetails.SellerRegistered {
 		//get seller details
-		sellerDetail := getSellerDetails(ctx, seller)
+		sellerDetail := app.getSellerDetails(ctx, seller)
 		sellerArray = append(sellerArray, sellerDetail)
 	}
 
@@ -328,7 +352,7 @@ func getProductDetails(ctx context.Context, productID string) map[string]interfa
 }
 
 // Function to fetch user details based on user_id
-func getUserDetails(ctx context.Context, userID string) map[string]interface{} {
+func (app *Application) getUserDetails(ctx context.Context, userID string) map[string]interface{} {
 	var userDetails models.USer
 
 	id, err := primitive.ObjectIDFromHex(userID)
@@ -337,7 +361,7 @@ func getUserDetails(ctx context.Context, userID string) map[string]interface{} {
 		return nil
 	}
 
-	errs := UserCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&userDetails)
+	errs := app.userCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&userDetails)
 	if errs != nil {
 		log.Printf("Error fetching user details for user ID %s: %s", userID, errs.Error())
 		return nil
@@ -354,9 +378,7 @@ func getUserDetails(ctx context.Context, userID string) map[string]interface{} {
 	return newUserDetails
 }
 
-func getSellerDetails(ctx context.Context, id string) map[string]interface{} {
-
-	var sellerDetails models.Seller
+func (app *Application) getSellerDetails(ctx context.Context, id string) map[string]interface{} {
 
 	sellerId, err := primitive.ObjectIDFromHex(id)
 
@@ -365,7 +387,7 @@ func getSellerDetails(ctx context.Context, id string) map[string]interface{} {
 		return nil
 	}
 
-	errs := SellerCollection.FindOne(ctx, bson.M{"_id": sellerId}).Decode(&sellerDetails)
+	sellerDetails, errs := app.repos.Sellers.FindByID(ctx, sellerId)
 	if errs != nil {
 
 		log.Printf("Error fetching seller details for seller ID %s: %s", id, errs.Error())
@@ -385,17 +407,17 @@ func getSellerDetails(ctx context.Context, id string) map[string]interface{} {
 
 }
 
-func GetAllRequirementMessages() gin.HandlerFunc {
+func (app *Application) GetAllRequirementMessages() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
 
-		cursor, err := RequirementMessageCollection.Find(ctx, bson.M{})
+		cursor, err := app.requirementMessageCollection.Find(ctx, bson.M{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -413,12 +435,12 @@ func GetAllRequirementMessages() gin.HandlerFunc {
 }
 
 // get  by id
-func GetRequirementMessage() gin.HandlerFunc {
+func (app *Application) GetRequirementMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -430,7 +452,7 @@ func GetRequirementMessage() gin.HandlerFunc {
 
 		objID, _ := primitive.ObjectIDFromHex(id)
 
-		err := RequirementMessageCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&message)
+		err := app.requirementMessageCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&message)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
@@ -442,7 +464,7 @@ func GetRequirementMessage() gin.HandlerFunc {
 }
 
 // CreateRequirementMessage creates a new RequirementMessage
-func CreateRequirementMessage() gin.HandlerFunc {
+func (app *Application) CreateRequirementMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var message models.RequirementMessage
 		if err := c.BindJSON(&message); err != nil {
@@ -464,7 +486,7 @@ func CreateRequirementMessage() gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		result, err := RequirementMessageCollection.InsertOne(ctx, message)
+		result, err := app.requirementMessageCollection.InsertOne(ctx, message)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -475,7 +497,7 @@ func CreateRequirementMessage() gin.HandlerFunc {
 }
 
 // UpdateRequirementMessage updates a RequirementMessage
-func UpdateRequirementMessage() gin.HandlerFunc {
+func (app *Application) UpdateRequirementMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var message models.RequirementMessage
 		if err := c.BindJSON(&message); err != nil {
@@ -489,7 +511,7 @@ func UpdateRequirementMessage() gin.HandlerFunc {
 		filter := bson.M{"_id": message.Requirement_id}
 		update := bson.M{"$set": message}
 
-		_, err := RequirementMessageCollection.UpdateOne(ctx, filter, update)
+		_, err := app.requirementMessageCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -500,7 +522,7 @@ func UpdateRequirementMessage() gin.HandlerFunc {
 }
 
 // DeleteRequirementMessage deletes a RequirementMessage
-func DeleteRequirementMessage() gin.HandlerFunc {
+func (app *Application) DeleteRequirementMessage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var message models.RequirementMessage
 		if err := c.BindJSON(&message); err != nil {
@@ -513,7 +535,7 @@ func DeleteRequirementMessage() gin.HandlerFunc {
 
 		filter := bson.M{"_id": message.Requirement_id}
 
-		_, err := RequirementMessageCollection.DeleteOne(ctx, filter)
+		_, err := app.requirementMessageCollection.DeleteOne(ctx, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -523,48 +545,50 @@ func DeleteRequirementMessage() gin.HandlerFunc {
 	}
 }
 
-func GetEnquiryDetailsCsv(c *gin.Context) {
+func (app *Application) GetEnquiryDetailsCsv(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if !checkAdmin(ctx, c) {
+	if !app.checkAdmin(ctx, c) {
 		c.JSON(http.StatusForbidden, gin.H{"Error": "Admin Token Not found"})
 		return
 	}
 
-	var enquiries []models.Enquire
-
-	cursor, err := EnquireCollection.Find(ctx, primitive.M{})
+	enquiries, err := app.repos.Enquiries.Find(ctx, repository.EnquiryFilter{}, repository.Page{})
 	if err != nil {
 		log.Print(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch enquiries"})
 		return
 	}
 
-	if err := cursor.All(ctx, &enquiries); err != nil {
-		log.Print(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding enquiries"})
-		return
-	}
-
 	// Write CSV header
 	headers := []string{
 		"Enquiry ID", "Quantity", "Resolved", "Status",
 		"Enquire Note", "Enquire Date", "Product Name", "Product Category",
-		"Product Price", "User Email", "User Mobile", "Seller Company Name",
+		"Product Price", "Variant SKU", "User Email", "User Mobile", "Seller Company Name",
 		"Seller Email", "Seller Mobile",
 	}
 
 	// Enrich enquiry data with additional details and write to CSV
 	var rows [][]string
 	for _, enquiry := range enquiries {
-		productDetails := getProductDetails(ctx, enquiry.Product_id)
-		userDetails := getUserDetails(ctx, enquiry.User_id)
+		productDetails := app.getProductDetails(ctx, enquiry.Product_id)
+		userDetails := app.getUserDetails(ctx, enquiry.User_id)
 
 		// Type assertions
 		productName, _ := productDetails["name"].(string)
 		productCategory, _ := productDetails["category"].(string)
-		productPrice, _ := productDetails["price"].(string)
+		var productPrice string
+		if price, ok := productDetails["price"].(money.Money); ok {
+			productPrice = price.String()
+		}
+		var variantSKU string
+		if variant := app.getVariantDetails(ctx, enquiry.Product_id, enquiry.Variant_id); variant != nil {
+			variantSKU, _ = variant["sku"].(string)
+			if price, ok := variant["price"].(money.Money); ok {
+				productPrice = price.String()
+			}
+		}
 
 		userEmail, _ := userDetails["email"].(string)
 		userMobile, _ := userDetails["mobile"].(string)
@@ -581,7 +605,7 @@ func GetEnquiryDetailsCsv(c *gin.Context) {
 			string(enquiry.EnquireId),
 			strconv.Itoa(enquiry.Quantity), strconv.FormatBool(enquiry.Resolved),
 			enquiry.Status, enquiry.Enquiry_note, enquiry.Enquire_date.String(),
-			productName, productCategory, productPrice,
+			productName, productCategory, productPrice, variantSKU,
 			userEmail, userMobile, sellerCompanyName, sellerEmail, sellerMobile,
 		}
 
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kravi0/BizGrowth-backend/images"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/storage"
+)
+
+// saveImage checks that an upload is an image and stores it without its
+// metadata under a new key below prefix, together with its renditions.
+func (app *Application) saveImage(fileReader io.Reader, fileHeader *multipart.FileHeader, prefix string) (models.Image, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	data, err := io.ReadAll(fileReader)
+	if err != nil {
+		return models.Image{}, err
+	}
+	return app.storeImage(ctx, prefix, fileHeader.Filename, data)
+}
+
+// storeImage processes an image uploaded as filename and stores it under a
+// new key below prefix. A rendition is stored next to it, its size
+// appended to the key.
+func (app *Application) storeImage(ctx context.Context, prefix, filename string, data []byte) (models.Image, error) {
+	result, err := images.Process(app.config.Images, data)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	ext := storage.Extension(mimetype.Lookup(result.Original.ContentType), filename)
+	key := storage.NewKey(prefix, ext)
+	url, err := app.storage.Put(ctx, key, bytes.NewReader(result.Original.Data), storage.Metadata{
+		ContentType: result.Original.ContentType,
+		Filename:    filename,
+	})
+	if err != nil {
+		return models.Image{}, err
+	}
+	image := models.Image{Original: url, Width: result.Original.Width, Height: result.Original.Height}
+	base := strings.TrimSuffix(key, ext)
+	name := strings.TrimSuffix(filename, path.Ext(filename))
+	for _, rendition := range result.Renditions {
+		url, err := app.storage.Put(ctx, base+"-"+rendition.Name+".jpg", bytes.NewReader(rendition.Data), storage.Metadata{
+			ContentType: rendition.ContentType,
+			Filename:    name + "-" + rendition.Name + ".jpg",
+		})
+		if err != nil {
+			app.removeImages(ctx, []models.Image{image})
+			return models.Image{}, err
+		}
+		image.Renditions = append(image.Renditions, models.Rendition{
+			Name:        rendition.Name,
+			URL:         url,
+			ContentType: rendition.ContentType,
+			Width:       rendition.Width,
+			Height:      rendition.Height,
+		})
+	}
+	return image, nil
+}
+
+// removeImages deletes the files of images and their renditions, stored
+// for a request that failed. Failures are only logged.
+func (app *Application) removeImages(ctx context.Context, images []models.Image) {
+	for _, image := range images {
+		urls := []string{image.Original}
+		for _, rendition := range image.Renditions {
+			urls = append(urls, rendition.URL)
+		}
+		for _, url := range urls {
+			if err := app.storage.Delete(ctx, app.extractKeyFromURL(url)); err != nil {
+				log.Println("images: removing", url+":", err)
+			}
+		}
+	}
+}
+
+// imageErrorStatus is the status to answer a failed saveImage with: the
+// client's fault when the upload isn't an image that can be processed.
+func imageErrorStatus(err error) int {
+	if err == images.ErrUnsupported || err == images.ErrTooLarge {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// signBatch collects the URLs of a response so they're signed in one pass
+// by sign. Listings add every image at once instead of signing one by one.
+type signBatch struct {
+	urls   []*string
+	images []*models.Image
+}
+
+func (b *signBatch) addURL(url *string) {
+	b.urls = append(b.urls, url)
+}
+
+// addImage adds the URLs of an image, its srcset is filled in once they're
+// signed.
+func (b *signBatch) addImage(image *models.Image) {
+	b.addURL(&image.Original)
+	for i := range image.Renditions {
+		b.addURL(&image.Renditions[i].URL)
+	}
+	b.images = append(b.images, image)
+}
+
+func (b *signBatch) addImages(images []models.Image) {
+	for i := range images {
+		b.addImage(&images[i])
+	}
+}
+
+// addProducts adds the images of products and of their variants.
+func (b *signBatch) addProducts(products []models.Product) {
+	for i := range products {
+		b.addImages(products[i].Image)
+		for j := range products[i].Variant {
+			b.addImages(products[i].Variant[j].Image)
+		}
+	}
+}
+
+// sign replaces the URLs of b with presigned ones. Nothing is replaced
+// when signing fails.
+func (app *Application) sign(b *signBatch) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := make([]string, len(b.urls))
+	for i, url := range b.urls {
+		keys[i] = app.extractKeyFromURL(*url)
+	}
+	urls, err := app.signer.SignAll(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for i, url := range b.urls {
+		*url = urls[i]
+	}
+	for _, image := range b.images {
+		fillSrcset(image)
+	}
+	return nil
+}
+
+// fillSrcset lists the signed renditions of an image in its srcset.
+func fillSrcset(image *models.Image) {
+	var srcset []string
+	largest := 0
+	for _, rendition := range image.Renditions {
+		srcset = append(srcset, fmt.Sprintf("%s %dw", rendition.URL, rendition.Width))
+		if rendition.Width > largest {
+			largest = rendition.Width
+		}
+	}
+	// The original is only worth listing when it's larger than every
+	// rendition; images stored before renditions have no srcset.
+	if len(srcset) > 0 && image.Width > largest && image.Original != "" {
+		srcset = append(srcset, fmt.Sprintf("%s %dw", image.Original, image.Width))
+	}
+	image.Srcset = strings.Join(srcset, ", ")
+}
+
+// presignImage replaces the URLs of an image with presigned ones and fills
+// in its srcset.
+func (app *Application) presignImage(image *models.Image) error {
+	var b signBatch
+	b.addImage(image)
+	return app.sign(&b)
+}
+
+// presignImages presigns every image of images.
+func (app *Application) presignImages(images []models.Image) error {
+	var b signBatch
+	b.addImages(images)
+	return app.sign(&b)
+}
+
+// presignProducts presigns the images of products and their variants.
+func (app *Application) presignProducts(products []models.Product) error {
+	var b signBatch
+	b.addProducts(products)
+	return app.sign(&b)
+}
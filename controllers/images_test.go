@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/kravi0/BizGrowth-backend/storage"
+)
+
+// flakyStore fails every Put after the first ok ones, and keeps the keys
+// of the objects that were stored.
+type flakyStore struct {
+	storage.Storage
+	ok     int
+	stored map[string]bool
+}
+
+func (s *flakyStore) Put(ctx context.Context, key string, body io.Reader, meta storage.Metadata) (string, error) {
+	if s.ok == 0 {
+		return "", errors.New("put failed")
+	}
+	s.ok--
+	url, err := s.Storage.Put(ctx, key, body, meta)
+	if err == nil {
+		s.stored[key] = true
+	}
+	return url, err
+}
+
+func (s *flakyStore) Delete(ctx context.Context, key string) error {
+	delete(s.stored, key)
+	return s.Storage.Delete(ctx, key)
+}
+
+func TestStoreImageRemovesWhatItStoredOnFailure(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	app, store, _ := newUploadTestApp(t)
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 800, 400))); err != nil {
+		t.Fatal(err)
+	}
+	for ok := 0; ok <= 2; ok++ {
+		flaky := &flakyStore{Storage: store, ok: ok, stored: map[string]bool{}}
+		app.storage = flaky
+		if _, err := app.storeImage(context.Background(), "products", "shirt.png", picture.Bytes()); err == nil {
+			t.Fatalf("%d puts ok: no error", ok)
+		}
+		if len(flaky.stored) != 0 {
+			t.Errorf("%d puts ok: left %v behind", ok, flaky.stored)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/inventory"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// productAvailability summarizes the offers of each of the products.
+// Products without offers are left out of the result.
+func (app *Application) productAvailability(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]inventory.Summary, error) {
+	summaries := map[primitive.ObjectID]inventory.Summary{}
+	if len(productIDs) == 0 {
+		return summaries, nil
+	}
+	offers, err := app.repos.ProductReferences.Find(ctx, repository.ProductReferenceFilter{ProductIDs: productIDs}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	byProduct := map[primitive.ObjectID][]models.ProductReference{}
+	for _, offer := range offers {
+		byProduct[offer.ProductID] = append(byProduct[offer.ProductID], offer)
+	}
+	for id, offers := range byProduct {
+		summaries[id] = inventory.Summarize(offers)
+	}
+	return summaries, nil
+}
+
+// offerView is an offer together with the stock figures derived from it.
+type offerView struct {
+	models.ProductReference
+	ProductName string `json:"product_name"`
+	Available   int    `json:"available"`
+	Status      string `json:"status"`
+}
+
+func (app *Application) offerViews(ctx context.Context, offers []models.ProductReference) []offerView {
+	views := make([]offerView, 0, len(offers))
+	names := map[primitive.ObjectID]string{}
+	for _, offer := range offers {
+		name, ok := names[offer.ProductID]
+		if !ok {
+			if product, err := app.repos.Products.FindByID(ctx, offer.ProductID); err == nil {
+				name = product.Product_Name
+			}
+			names[offer.ProductID] = name
+		}
+		views = append(views, offerView{
+			ProductReference: offer,
+			ProductName:      name,
+			Available:        inventory.Available(offer.Inventory),
+			Status:           inventory.Status(offer.Inventory),
+		})
+	}
+	return views
+}
+
+// GetSellerOffers lists the offers of the calling seller with their stock.
+func (app *Application) GetSellerOffers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		sellerID, err := primitive.ObjectIDFromHex(c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Seller ID not found in context"})
+			return
+		}
+		filter := repository.ProductReferenceFilter{SellerID: sellerID, Archived: repository.Bool(false)}
+		filter.LowStock, _ = strconv.ParseBool(c.Query("low_stock"))
+
+		offers, err := app.repos.ProductReferences.Find(ctx, filter, repository.Page{Sort: "-updated_at"})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, app.offerViews(ctx, offers))
+	}
+}
+
+// UpdateOfferInventory sets the stock of one of the calling seller's
+// offers. Fields left out of the JSON body keep their value.
+func (app *Application) UpdateOfferInventory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var input struct {
+			OnHand            *int `json:"on_hand"`
+			Reserved          *int `json:"reserved"`
+			LowStockThreshold *int `json:"low_stock_threshold"`
+			LeadTimeDays      *int `json:"lead_time_days"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid offer id"})
+			return
+		}
+		offer, err := app.repos.ProductReferences.FindByID(ctx, id)
+		if err == nil && offer.SellerID.Hex() != c.GetString("uid") {
+			err = repository.ErrNotFound
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "offer not found"})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		inv := offer.Inventory
+		for _, field := range []struct {
+			value *int
+			into  *int
+		}{
+			{input.OnHand, &inv.OnHand},
+			{input.Reserved, &inv.Reserved},
+			{input.LowStockThreshold, &inv.LowStockThreshold},
+			{input.LeadTimeDays, &inv.LeadTimeDays},
+		} {
+			if field.value == nil {
+				continue
+			}
+			if *field.value < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "stock figures cannot be negative"})
+				return
+			}
+			*field.into = *field.value
+		}
+		if inv.Reserved > inv.OnHand {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "reserved cannot exceed on hand"})
+			return
+		}
+		inv.Tracked = true
+		inv.Updated_at = time.Now()
+
+		err = app.repos.ProductReferences.Update(ctx, id, repository.Update{Set: repository.Fields{
+			"inventory":  inv,
+			"updated_at": inv.Updated_at,
+		}})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		offer.Inventory = inv
+		c.JSON(http.StatusOK, app.offerViews(ctx, []models.ProductReference{*offer})[0])
+	}
+}
+
+// GetLowStockReport lists the offers at or below their low stock
+// threshold, optionally for one seller_id.
+func (app *Application) GetLowStockReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		filter := repository.ProductReferenceFilter{Archived: repository.Bool(false), LowStock: true}
+		if sellerID := c.Query("seller_id"); sellerID != "" {
+			id, err := primitive.ObjectIDFromHex(sellerID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid seller id"})
+				return
+			}
+			filter.SellerID = id
+		}
+
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		pageNo, err := strconv.Atoi(c.Query("page"))
+		if err != nil || pageNo <= 0 {
+			pageNo = 1
+		}
+		page := repository.Page{Sort: "inventory.updated_at", Skip: int64((pageNo - 1) * limit), Limit: int64(limit)}
+
+		offers, err := app.repos.ProductReferences.Find(ctx, filter, page)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		count, err := app.repos.ProductReferences.Count(ctx, filter)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"offers":  app.offerViews(ctx, offers),
+			"page":    pageNo,
+			"limit":   limit,
+			"total":   count,
+			"hasMore": count > int64(pageNo*limit),
+		})
+	}
+}
@@ -9,16 +9,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func PostFeedHandler() gin.HandlerFunc {
+func (app *Application) PostFeedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var errors []string
 
 		var feed models.Feed
+		feed.FeedID = primitive.NewObjectID()
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 
@@ -42,7 +44,7 @@ func PostFeedHandler() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedURL, err := app.saveFile(f, file, storage.FeedPrefix(feed.FeedID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
@@ -55,26 +57,26 @@ func PostFeedHandler() gin.HandlerFunc {
 			}
 		}
 
-		feed.FeedID = primitive.NewObjectID()
 		feed.FeedDocument = uploadedURLs
 		feed.Content = content
 		feed.Title = title
-		_, anyerr := FeedsCollection.InsertOne(ctx, feed)
+		_, anyerr := app.feedsCollection.InsertOne(ctx, feed)
 		if anyerr != nil {
 			fmt.Print(anyerr.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Not Created"})
 			return
 		}
 
+		setAuditEntity(c, feed.FeedID)
 		c.JSON(http.StatusOK, "Successfully added feed!!")
 		defer cancel()
 	}
 }
-func GetAllFeedsHandler() gin.HandlerFunc {
+func (app *Application) GetAllFeedsHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cursor, err := FeedsCollection.Find(ctx, bson.M{})
+		cursor, err := app.feedsCollection.Find(ctx, bson.M{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
@@ -94,7 +96,7 @@ func GetAllFeedsHandler() gin.HandlerFunc {
 
 }
 
-func DeleteFeed() gin.HandlerFunc {
+func (app *Application) DeleteFeed() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -109,7 +111,7 @@ func DeleteFeed() gin.HandlerFunc {
 			return
 		}
 		filter := primitive.M{"_id": objID}
-		result, err := FeedsCollection.DeleteOne(ctx, filter)
+		result, err := app.feedsCollection.DeleteOne(ctx, filter)
 		if err != nil {
 			log.Println(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Something went wrong"})
@@ -124,11 +126,11 @@ func DeleteFeed() gin.HandlerFunc {
 }
 
 // update feed handler
-func UpdateFeed() gin.HandlerFunc {
+func (app *Application) UpdateFeed() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You are not authorized for this"})
 			return
 		}
@@ -165,7 +167,7 @@ func UpdateFeed() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedURL, err := app.saveFile(f, file, storage.FeedPrefix(objID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
@@ -187,7 +189,7 @@ func UpdateFeed() gin.HandlerFunc {
 			"content":      content,
 			"feedDocument": uploadedURLs,
 		}}
-		result, err := FeedsCollection.UpdateOne(ctx, filter, update)
+		result, err := app.feedsCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			log.Println(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Something went wrong"})
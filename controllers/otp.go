@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kravi0/BizGrowth-backend/otp"
+)
+
+var errOTPDelivery = errors.New("otp could not be delivered")
+
+// sendOTP issues a code for purpose and delivers it through the configured
+// provider. Codes are keyed by the recipient's mobile number.
+func (app *Application) sendOTP(ctx context.Context, purpose string, to otp.Recipient) error {
+	code, err := app.otpStore.Issue(ctx, purpose, to.MobileNo)
+	if err != nil {
+		return err
+	}
+	if err := app.otpSender.Send(ctx, to, code); err != nil {
+		log.Println("sending otp:", err)
+		app.otpStore.Discard(ctx, purpose, to.MobileNo)
+		return errOTPDelivery
+	}
+	return nil
+}
+
+// otpErrorResponse maps an error of sendOTP or otp.Store.Verify to the
+// status and message returned to the client.
+func otpErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, otp.ErrCooldown):
+		return http.StatusTooManyRequests, "Please wait before requesting another OTP"
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		return http.StatusTooManyRequests, "Too many attempts, please request a new OTP"
+	case errors.Is(err, otp.ErrExpired):
+		return http.StatusBadRequest, "OTP has expired, please request a new one"
+	case errors.Is(err, otp.ErrInvalidCode), errors.Is(err, otp.ErrNoCode):
+		return http.StatusBadRequest, "invalid OTP"
+	default:
+		return http.StatusInternalServerError, "Something went wrong"
+	}
+}
@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/popularity"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RunEvents writes the recorded events until ctx is done.
+func (app *Application) RunEvents(ctx context.Context) {
+	app.events.Run(ctx)
+}
+
+// rollupPopularity computes the popularity rows of the UTC day starting at
+// day from its events and enquiries. A visitor's views, impressions and
+// clicks of a product count once a day.
+func (app *Application) rollupPopularity(ctx context.Context, day time.Time) error {
+	end := day.AddDate(0, 0, 1)
+	counts, err := app.repos.Events.CountVisitorsByProduct(ctx, repository.EventFilter{From: day, To: end})
+	if err != nil {
+		return err
+	}
+	enquiries, err := app.repos.Enquiries.Find(ctx, repository.EnquiryFilter{Since: day}, repository.Page{})
+	if err != nil {
+		return err
+	}
+
+	index := map[string]int{}
+	var rows []models.Popularity
+	row := func(productID string) *models.Popularity {
+		i, ok := index[productID]
+		if !ok {
+			i = len(rows)
+			index[productID] = i
+			rows = append(rows, models.Popularity{Day: day, Kind: models.PopularityProduct, Key: productID})
+		}
+		return &rows[i]
+	}
+	for _, count := range counts {
+		switch count.Type {
+		case models.EventView:
+			row(count.ProductID).Views += count.Count
+		case models.EventImpression:
+			row(count.ProductID).Impressions += count.Count
+		case models.EventClick:
+			row(count.ProductID).Clicks += count.Count
+		}
+	}
+	for _, enquiry := range enquiries {
+		if enquiry.Enquire_date.Before(end) {
+			row(enquiry.Product_id).Enquiries++
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var ids []primitive.ObjectID
+	for productID := range index {
+		if id, err := primitive.ObjectIDFromHex(productID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	products, err := app.repos.Products.Find(ctx, repository.ProductFilter{IDs: ids}, repository.Page{})
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		rows[index[product.Product_ID.Hex()]].Category = product.Category
+	}
+	for i := range rows {
+		rows[i].Score = popularity.Score(rows[i])
+	}
+	return app.repos.Popularity.Upsert(ctx, append(rows, popularity.Categories(rows)...))
+}
+
+// RunPopularity rolls up the events of yesterday and today right away and
+// then every configured interval until ctx is done. Yesterday is rolled up
+// again so the last events before midnight are counted.
+func (app *Application) RunPopularity(ctx context.Context) {
+	ticker := time.NewTicker(app.config.Popularity.Interval)
+	defer ticker.Stop()
+	for {
+		today := popularity.Day(time.Now())
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			if err := app.rollupPopularity(ctx, day); err != nil {
+				log.Println("popularity: rolling up", day.Format("2006-01-02")+":", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// popularityTotals returns the popularity of the products or categories
+// with the given keys, all of them when there are none, over the last days,
+// the most popular first.
+func (app *Application) popularityTotals(ctx context.Context, kind string, keys []string, days int) ([]popularity.Total, error) {
+	now := time.Now()
+	rows, err := app.repos.Popularity.Find(ctx, repository.PopularityFilter{
+		Kind: kind,
+		Keys: keys,
+		From: popularity.Day(now).AddDate(0, 0, 1-days),
+	}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	return popularity.Totals(rows, now, app.config.Popularity.HalfLife), nil
+}
+
+// popularityWindow is the configured popularity window in days.
+func (app *Application) popularityWindow() int {
+	return int(app.config.Popularity.Window / (24 * time.Hour))
+}
+
+// popularityDays reads the days parameter, defaulting to the configured
+// window.
+func (app *Application) popularityDays(c *gin.Context) int {
+	window := app.popularityWindow()
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days <= 0 || days > window {
+		return window
+	}
+	return days
+}
+
+// productPopularity is the popularity of a product for the dashboards.
+type productPopularity struct {
+	popularity.Total
+	ProductName string `json:"product_name"`
+}
+
+// productPopularities names the products of totals.
+func (app *Application) productPopularities(ctx context.Context, totals []popularity.Total) ([]productPopularity, error) {
+	var ids []primitive.ObjectID
+	for _, total := range totals {
+		if id, err := primitive.ObjectIDFromHex(total.Key); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	names := map[string]string{}
+	if len(ids) > 0 {
+		products, err := app.repos.Products.Find(ctx, repository.ProductFilter{IDs: ids}, repository.Page{})
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			names[product.Product_ID.Hex()] = product.Product_Name
+		}
+	}
+	views := make([]productPopularity, len(totals))
+	for i, total := range totals {
+		views[i] = productPopularity{Total: total, ProductName: names[total.Key]}
+	}
+	return views, nil
+}
+
+// GetPopularity lists the most popular products and categories of the last
+// "days" days for the admin dashboard, up to "limit" of each, 10 by
+// default.
+func (app *Application) GetPopularity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		days := app.popularityDays(c)
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 10
+		}
+		products, err := app.popularityTotals(ctx, models.PopularityProduct, nil, days)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		categories, err := app.popularityTotals(ctx, models.PopularityCategory, nil, days)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if len(products) > limit {
+			products = products[:limit]
+		}
+		if len(categories) > limit {
+			categories = categories[:limit]
+		}
+		named, err := app.productPopularities(ctx, products)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"days":       days,
+			"products":   named,
+			"categories": categories,
+			"dropped":    app.events.Dropped(),
+		})
+	}
+}
+
+// GetSellerPopularity lists the popularity of the calling seller's products
+// over the last "days" days, the most popular first.
+func (app *Application) GetSellerPopularity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		sellerID := c.GetString("uid")
+		products, err := app.repos.Products.Find(ctx, repository.ProductFilter{SellerID: sellerID}, repository.Page{})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		days := app.popularityDays(c)
+		named := []productPopularity{}
+		if len(products) > 0 {
+			keys := make([]string, len(products))
+			for i, product := range products {
+				keys[i] = product.Product_ID.Hex()
+			}
+			totals, err := app.popularityTotals(ctx, models.PopularityProduct, keys, days)
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+			// Products nobody looked at are listed last, with nothing counted.
+			counted := map[string]bool{}
+			for _, total := range totals {
+				counted[total.Key] = true
+			}
+			for _, product := range products {
+				if !counted[product.Product_ID.Hex()] {
+					totals = append(totals, popularity.Total{Key: product.Product_ID.Hex(), Category: product.Category})
+				}
+			}
+			if named, err = app.productPopularities(ctx, totals); err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"days": days, "products": named})
+	}
+}
+
+// maxEventQuery is the longest search query kept with an event, in runes.
+const maxEventQuery = 200
+
+// eventQuery cuts query to maxEventQuery.
+func eventQuery(query string) string {
+	if utf8.RuneCountInString(query) <= maxEventQuery {
+		return query
+	}
+	return string([]rune(query)[:maxEventQuery])
+}
+
+// eventVisitor identifies who sent a request: the signed in user, or a
+// hash of the client IP so addresses aren't stored. Every event records
+// it, so repeats by one visitor count once.
+func eventVisitor(c *gin.Context) string {
+	if uid := c.GetString("uid"); uid != "" {
+		return "user:" + uid
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP()))
+	return "ip:" + hex.EncodeToString(sum[:16])
+}
+
+// TrackClick records a click on a product in the search results. Views and
+// impressions are recorded by the product and search handlers themselves.
+func (app *Application) TrackClick() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var input struct {
+			ProductID string `json:"product_id"`
+			Query     string `json:"query"`
+			Position  int    `json:"position"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		productID, err := primitive.ObjectIDFromHex(input.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
+			return
+		}
+		if input.Position < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "position must not be negative"})
+			return
+		}
+		product, err := app.repos.Products.FindByID(ctx, productID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && (!product.Approved || product.IsArchived)) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		app.events.Record(models.Event{
+			Type:       models.EventClick,
+			User_id:    c.GetString("uid"),
+			Product_id: input.ProductID,
+			Query:      eventQuery(input.Query),
+			Position:   input.Position,
+			Visitor:    eventVisitor(c),
+		})
+		c.Status(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/popularity"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRollupCountsAVisitorOnceADay(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	day := popularity.Day(time.Now())
+	product := primitive.NewObjectID().Hex()
+	var events []models.Event
+	for _, typ := range []string{models.EventView, models.EventImpression, models.EventClick} {
+		for _, visitor := range []string{"ip:a", "ip:a", "ip:a", "user:b", ""} {
+			events = append(events, models.Event{ID: primitive.NewObjectID(), Type: typ, Product_id: product, Visitor: visitor, Created_at: day.Add(time.Hour)})
+		}
+	}
+	if err := app.repos.Events.InsertMany(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.rollupPopularity(ctx, day); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := app.repos.Popularity.Find(ctx, repository.PopularityFilter{Kind: models.PopularityProduct, Keys: []string{product}}, repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Views != 3 || rows[0].Impressions != 3 || rows[0].Clicks != 3 {
+		t.Fatalf("rows %+v, want one with 3 views, impressions and clicks", rows)
+	}
+}
+
+func TestProductViewsRecordTheVisitor(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	product := models.Product{Product_ID: primitive.NewObjectID(), Product_Name: "Mug", Approved: true}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET("/getproduct", app.GetProduct())
+	for i := 0; i < 2; i++ {
+		if w := serve(router, http.MethodGet, "/getproduct?productId="+product.Product_ID.Hex(), "", nil); w.Code != http.StatusOK {
+			t.Fatalf("get: %d %s", w.Code, w.Body)
+		}
+	}
+	app.events.Flush(ctx)
+	counts, err := app.repos.Events.CountVisitorsByProduct(ctx, repository.EventFilter{Type: models.EventView})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || counts[0].Count != 1 {
+		t.Errorf("counts %+v, want one visitor", counts)
+	}
+}
+
+func TestTrackClickRefusesUnknownProducts(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	router := gin.New()
+	router.POST("/events/click", app.TrackClick())
+	body := `{"product_id": "` + primitive.NewObjectID().Hex() + `", "position": 1}`
+	if w := serve(router, http.MethodPost, "/events/click", "application/json", []byte(body)); w.Code != http.StatusNotFound {
+		t.Fatalf("click: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestEventQuery(t *testing.T) {
+	long := strings.Repeat("é", maxEventQuery+10)
+	if got := eventQuery(long); got != strings.Repeat("é", maxEventQuery) {
+		t.Errorf("kept %d runes", len([]rune(got)))
+	}
+	if got := eventQuery("shirts"); got != "shirts" {
+		t.Errorf("got %q", got)
+	}
+}
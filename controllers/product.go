@@ -9,72 +9,39 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
+	"github.com/kravi0/BizGrowth-backend/inventory"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"github.com/kravi0/BizGrowth-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var ProductCollection *mongo.Collection = database.ProductData(database.Client, "Products")
-var EnquireCollection *mongo.Collection = database.ProductData(database.Client, "enquire")
-var RequirementMessageCollection *mongo.Collection = database.ProductData(database.Client, "RequirementMessage")
-var ReviewsCollection *mongo.Collection = database.ProductData(database.Client, "Reviews")
-var FeedsCollection *mongo.Collection = database.ProductData(database.Client, "New_Feeds")
-
-var uploader *s3manager.Uploader
-
-func init() {
-
-	/*if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-	}*/
-
-	// Read AWS credentials from environment variables
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	region := os.Getenv("AWS_REGION")
-
-	//load these from aws secret manager
-
-	// Create a new AWS session with the provided credentials and region
-	awsSession, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region: aws.String(region),
-			Credentials: credentials.NewStaticCredentials(
-				accessKey,
-				secretKey,
-				"",
-			),
-		},
-	})
-
-	if err != nil {
-		panic(err)
+// ServeStoredFile serves signed URLs handed out by the local storage driver.
+func (app *Application) ServeStoredFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		local, ok := app.storage.(*storage.Local)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Not found"})
+			return
+		}
+		local.Handler()(c)
 	}
-
-	// Create an uploader instance using the AWS session
-	uploader = s3manager.NewUploader(awsSession)
 }
 
-func saveFile(fileReader io.Reader, fileHeader *multipart.FileHeader) (string, error) {
-	// Upload the file to S3 using the fileReader
-
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
+// saveFile stores an upload under a new key below prefix, see the layout in
+// the storage package. The name it was uploaded with is kept as metadata.
+func (app *Application) saveFile(fileReader io.Reader, fileHeader *multipart.FileHeader, prefix string) (string, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
 
 	mtype, error := mimetype.DetectReader(fileReader)
 
@@ -82,152 +49,52 @@ func saveFile(fileReader io.Reader, fileHeader *multipart.FileHeader) (string, e
 		return "", error
 	}
 
-	_, err := uploader.Upload(&s3manager.UploadInput{
-		Bucket:      aws.String(bucketName),
-		Key:         aws.String(fileHeader.Filename),
-		Body:        fileReader,
-		ContentType: aws.String(mtype.String()),
-	})
-	if err != nil {
-		return "", err
+	// DetectReader consumes the head of the file, rewind before uploading
+	if seeker, ok := fileReader.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
 	}
 
-	// Get the URL of the uploaded file
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucketName, fileHeader.Filename)
-
-	return url, nil
+	key := storage.NewKey(prefix, storage.Extension(mtype, fileHeader.Filename))
+	return app.storage.Put(ctx, key, fileReader, storage.Metadata{ContentType: mtype.String(), Filename: fileHeader.Filename})
 }
 
-func extractKeyFromURL(url string) string {
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		return parts[len(parts)-1]
-	}
-	return ""
+func (app *Application) extractKeyFromURL(url string) string {
+	return app.storage.KeyFromURL(url)
 }
 
-func DownloadPDFFromS3(s3Url string) ([]byte, error) {
-	// Create a new AWS session
-	sess := session.Must(session.NewSession())
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
-
-	// Create a downloader with the S3 client
-	downloader := s3manager.NewDownloader(sess)
+func (app *Application) DownloadPDFFromS3(s3Url string) ([]byte, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
 
-	keyName := extractKeyFromURL(s3Url)
+	keyName := app.extractKeyFromURL(s3Url)
 
 	if keyName == "" {
 		return nil, errors.New("Invalid S3 URL" + keyName)
 	}
 
-	// Input parameters for the S3 object you want to download
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(keyName),
-	}
-
-	// Create a buffer to hold the downloaded file contents
-	buffer := aws.NewWriteAtBuffer([]byte{})
-
-	// Download the file from S3 and write it to the buffer
-	_, err := downloader.Download(buffer, input)
-	if err != nil {
-		return nil, err
-	}
-
-	return buffer.Bytes(), nil
+	return app.storage.Get(ctx, keyName)
 }
 
-func getPresignURL(s3Url string) (string, error) {
-	// Create an S3 service client using the provided session
-
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	region := os.Getenv("AWS_REGION")
-
-	// Create a new AWS session with the provided credentials and region
-	awsSession, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region: aws.String(region),
-			Credentials: credentials.NewStaticCredentials(
-				accessKey,
-				secretKey,
-				"",
-			),
-		},
-	})
-
-	if err != nil {
-		panic(err)
-	}
-
-	if awsSession == nil {
-		fmt.Println("AWS session is nil")
-		return "", nil
-	}
-
-	s3client := s3.New(awsSession)
-
-	// Retrieve bucket name from environment variable
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
-	if bucketName == "" {
-		fmt.Println("Bucket name is empty")
-		return "", nil
-	}
+func (app *Application) getPresignURL(s3Url string) (string, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	keyName := extractKeyFromURL(s3Url)
+	keyName := app.extractKeyFromURL(s3Url)
 	if keyName == "" {
 		return "", nil // Return nil error as keyName is empty
 	}
 
-	var contentType string
-
-	if strings.Contains(s3Url, "pdf") {
-		contentType = "application/pdf"
-	} else if strings.Contains(s3Url, "docx") {
-		contentType = "application/docx"
-	} else if strings.Contains(s3Url, "mp4") {
-		contentType = "video/mp4"
-	} else if strings.Contains(s3Url, "jpg") {
-		contentType = "image/jpg"
-	} else if strings.Contains(s3Url, "png") {
-		contentType = "image/png"
-	} else if strings.Contains(s3Url, "webp") {
-		contentType = "image/webp"
-	} else if strings.Contains(s3Url, "avif") {
-		contentType = "image/avif"
-	} else if strings.Contains(s3Url, "svg") {
-		contentType = "image/svg"
-	} else if strings.Contains(s3Url, "jpeg") {
-		contentType = "image/jpeg"
-	}
-
-	req, _ := s3client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket:                  aws.String(bucketName),
-		Key:                     aws.String(keyName),
-		ResponseContentType:     aws.String(contentType),
-		ResponseContentEncoding: aws.String("base64"),
-	})
-
-	q := req.HTTPRequest.URL.Query()
-	q.Add("x-amz-acl", "public-read")
-	q.Add("Content-Type", contentType)
-	req.HTTPRequest.URL.RawQuery = q.Encode()
-
-	presignedURL, err := req.Presign(time.Hour * 24) // URL expires in 24 hours
-	if err != nil {
-		return "", err
-	}
-
-	return presignedURL, nil
+	return app.signer.Sign(ctx, keyName)
 }
 
-func ProductViewerAdmin() gin.HandlerFunc {
+func (app *Application) ProductViewerAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 		var sellerId string
-		if checkSeller(ctx, c) {
+		if app.checkSeller(ctx, c) {
 			sellerID, exists := c.Get("uid")
 			if !exists {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "Seller ID not found in context"})
@@ -268,8 +135,11 @@ func ProductViewerAdmin() gin.HandlerFunc {
 
 		product.Attributes = attributes
 
-		price := strings.TrimSpace(c.PostForm("price"))
-
+		price, err := money.Parse(c.PostForm("price"), money.DefaultCurrency)
+		if err != nil || price.Amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "price is not a valid amount"})
+			return
+		}
 		product.Price = price
 
 		product.Discription = c.PostForm("discription")
@@ -288,7 +158,7 @@ func ProductViewerAdmin() gin.HandlerFunc {
 		files := form.File["files"]
 
 		var errors []string
-		var uploadedURLs []string
+		var uploadedImages []models.Image
 		var resFileName []string
 		fmt.Println(files)
 		for _, file := range files {
@@ -299,20 +169,29 @@ func ProductViewerAdmin() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedImage, err := app.saveImage(f, file, storage.ProductImagesPrefix(product.Product_ID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
-				uploadedURLs = append(uploadedURLs, uploadedURL)
+				uploadedImages = append(uploadedImages, uploadedImage)
 			}
 			if len(errors) > 0 {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": errors})
 			} else {
-				c.JSON(http.StatusOK, gin.H{"url": uploadedURLs})
+				c.JSON(http.StatusOK, gin.H{"url": models.ImageURLs(uploadedImages)})
 			}
 		}
 		fmt.Println(resFileName)
-		product.Image = uploadedURLs
+		// Images put straight into storage come as upload session IDs
+		for _, id := range form.Value["files"] {
+			uploadedImage, err := app.attachImage(ctx, id, c.GetString("uid"), storage.ProductImagesPrefix(product.Product_ID.Hex()))
+			if err != nil {
+				c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+				return
+			}
+			uploadedImages = append(uploadedImages, uploadedImage)
+		}
+		product.Image = uploadedImages
 
 		var sellers []string
 		sellers = append(sellers, sellerId)
@@ -323,17 +202,18 @@ func ProductViewerAdmin() gin.HandlerFunc {
 			product.AddedBy = "Admin"
 		}
 
-		_, anyerr := ProductCollection.InsertOne(ctx, product)
+		anyerr := app.repos.Products.Insert(ctx, &product)
 		if anyerr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Not Created"})
 			return
 		}
+		setAuditEntity(c, product.Product_ID)
 		defer cancel()
 		c.JSON(http.StatusOK, "Successfully added our Product Admin!!")
 	}
 }
 
-func AddProductByAdmin() gin.HandlerFunc {
+func (app *Application) AddProductByAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -374,8 +254,11 @@ func AddProductByAdmin() gin.HandlerFunc {
 
 		product.Attributes = attributes
 
-		price := strings.TrimSpace(c.PostForm("price"))
-
+		price, err := money.Parse(c.PostForm("price"), money.DefaultCurrency)
+		if err != nil || price.Amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "price is not a valid amount"})
+			return
+		}
 		product.Price = price
 
 		product.Discription = c.PostForm("discription")
@@ -394,7 +277,7 @@ func AddProductByAdmin() gin.HandlerFunc {
 		files := form.File["files"]
 
 		var errors []string
-		var uploadedURLs []string
+		var uploadedImages []models.Image
 		var resFileName []string
 		fmt.Println(files)
 		for _, file := range files {
@@ -405,38 +288,48 @@ func AddProductByAdmin() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedImage, err := app.saveImage(f, file, storage.ProductImagesPrefix(product.Product_ID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
-				uploadedURLs = append(uploadedURLs, uploadedURL)
+				uploadedImages = append(uploadedImages, uploadedImage)
 			}
 			if len(errors) > 0 {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": errors})
 			} else {
-				c.JSON(http.StatusOK, gin.H{"url": uploadedURLs})
+				c.JSON(http.StatusOK, gin.H{"url": models.ImageURLs(uploadedImages)})
 			}
 		}
 		fmt.Println(resFileName)
-		product.Image = uploadedURLs
+		// Images put straight into storage come as upload session IDs
+		for _, id := range form.Value["files"] {
+			uploadedImage, err := app.attachImage(ctx, id, c.GetString("uid"), storage.ProductImagesPrefix(product.Product_ID.Hex()))
+			if err != nil {
+				c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+				return
+			}
+			uploadedImages = append(uploadedImages, uploadedImage)
+		}
+		product.Image = uploadedImages
 
 		var sellers []string
 		sellers = append(sellers, sellerId)
 		product.SellerRegistered = sellers
 		product.AddedBy = "Admin"
 
-		_, anyerr := ProductCollection.InsertOne(ctx, product)
+		anyerr := app.repos.Products.Insert(ctx, &product)
 		if anyerr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Not Created"})
 			return
 		}
+		setAuditEntity(c, product.Product_ID)
 		defer cancel()
 		c.JSON(http.StatusOK, "Successfully added our Product Admin!!")
 	}
 }
 
 // this will give detail of the particular product, product id is mendatory filed
-func GetProduct() gin.HandlerFunc {
+func (app *Application) GetProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -458,70 +351,68 @@ func GetProduct() gin.HandlerFunc {
 		type ProductWithAttributes struct {
 			models.Product
 			AttributesInfo []models.AttributeType `bson:"attributes_info" json:"attributes_info"`
+			VariantMatrix  variantMatrix          `bson:"variant_matrix" json:"variant_matrix"`
+			Availability   inventory.Summary      `bson:"availability" json:"availability"`
 		}
 
-		// Perform aggregation
-		pipeline := []bson.M{
-			{
-				"$match": bson.M{"_id": prodID},
-			},
-			{
-				"$lookup": bson.M{
-					"from":         "AttributeType",
-					"localField":   "attributes.attribute_type",
-					"foreignField": "_id",
-					"as":           "attributes_info",
-				},
-			},
+		product, err := app.repos.Products.FindByID(ctx, prodID)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
+			return
 		}
-
-		// Create a variable to store the result
-		var result ProductWithAttributes
-
-		// Perform aggregation
-		cursor, err := ProductCollection.Aggregate(ctx, pipeline)
 		if err != nil {
 			log.Println(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		// Iterate over the cursor and decode the result
-		// Iterate over the cursor and decode the result
-		for cursor.Next(ctx) {
-			if err := cursor.Decode(&result); err != nil {
+		app.events.Record(models.Event{Type: models.EventView, User_id: c.GetString("uid"), Visitor: eventVisitor(c), Product_id: productID})
+
+		result := ProductWithAttributes{Product: *product, AttributesInfo: []models.AttributeType{}}
+
+		// Resolve the attribute types referenced by the product
+		var attributeIDs []primitive.ObjectID
+		for _, attribute := range product.Attributes {
+			attributeIDs = append(attributeIDs, attribute.AttributeType)
+		}
+		for _, variant := range product.Variant {
+			for _, attribute := range variant.Attribute {
+				attributeIDs = append(attributeIDs, attribute.AttributeType)
+			}
+		}
+		if len(attributeIDs) > 0 {
+			cursor, err := app.attributesCollection.Find(ctx, bson.M{"_id": bson.M{"$in": attributeIDs}})
+			if err != nil {
 				log.Println(err)
 				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 				return
 			}
-			// Decode Product details separately
-			if err := cursor.Decode(&result.Product); err != nil {
+			defer cursor.Close(ctx)
+			if err := cursor.All(ctx, &result.AttributesInfo); err != nil {
 				log.Println(err)
 				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 				return
 			}
 		}
 
-		// Check if no result found
-		if result.Product.Product_ID.IsZero() {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
-			return
-		}
-
 		fmt.Println(result.Product.Image)
 		fmt.Println(len(result.Product.Image))
 
-		if result.Product.Image != nil {
-			for i, url := range result.Product.Image {
-				imageUrl, err := getPresignURL(url)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-					return
-				}
-				result.Product.Image[i] = imageUrl
-			}
+		if err := app.presignProducts([]models.Product{result.Product}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		result.VariantMatrix = buildVariantMatrix(&result.Product, result.AttributesInfo)
 
+		availability, err := app.productAvailability(ctx, []primitive.ObjectID{product.Product_ID})
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		result.Availability = inventory.Summarize(nil)
+		if summary, ok := availability[product.Product_ID]; ok {
+			result.Availability = summary
 		}
 
 		c.JSON(http.StatusOK, result)
@@ -530,12 +421,12 @@ func GetProduct() gin.HandlerFunc {
 }
 
 // this will update product need to pass whole struct json data
-func UpdateProduct() gin.HandlerFunc {
+func (app *Application) UpdateProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You are not authorized to perform this action."})
 			return
 		}
@@ -547,11 +438,8 @@ func UpdateProduct() gin.HandlerFunc {
 		}
 
 		productID, _ := primitive.ObjectIDFromHex(id)
-		filter := bson.M{"_id": productID}
 
-		var product models.Product
-
-		err := ProductCollection.FindOne(ctx, filter).Decode(&product)
+		_, err := app.repos.Products.FindByID(ctx, productID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
 			return
@@ -578,6 +466,13 @@ func UpdateProduct() gin.HandlerFunc {
 		}
 
 		price := strings.TrimSpace(c.PostForm("price"))
+		var parsedPrice money.Money
+		if price != "" {
+			if parsedPrice, err = money.Parse(price, money.DefaultCurrency); err != nil || parsedPrice.Amount < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "price is not a valid amount"})
+				return
+			}
+		}
 		description := c.PostForm("discription")
 		category := c.PostForm("category")
 		sku := c.PostForm("sku")
@@ -592,7 +487,7 @@ func UpdateProduct() gin.HandlerFunc {
 		files := form.File["files"]
 
 		var errors []string
-		var uploadedURLs []string
+		var uploadedImages []models.Image
 		for _, file := range files {
 			f, err := file.Open()
 			if err != nil {
@@ -600,11 +495,11 @@ func UpdateProduct() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedImage, err := app.saveImage(f, file, storage.ProductImagesPrefix(productID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
-				uploadedURLs = append(uploadedURLs, uploadedURL)
+				uploadedImages = append(uploadedImages, uploadedImage)
 			}
 		}
 		if len(errors) > 0 {
@@ -612,7 +507,7 @@ func UpdateProduct() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{}
+		update := repository.Fields{}
 		if product_name != "" {
 			update["product_name"] = product_name
 		}
@@ -622,7 +517,7 @@ func UpdateProduct() gin.HandlerFunc {
 		}
 
 		if price != "" {
-			update["price"] = price
+			update["price"] = parsedPrice
 		}
 		if description != "" {
 			update["discription"] = description
@@ -632,77 +527,45 @@ func UpdateProduct() gin.HandlerFunc {
 		}
 		update["updated_at"] = updated_at
 
-		pushUpdates := bson.M{}
-		if len(uploadedURLs) > 0 {
-			pushUpdates["image"] = bson.M{
-				"$each": uploadedURLs,
-			}
+		pushUpdates := repository.Fields{}
+		if len(uploadedImages) > 0 {
+			pushUpdates["image"] = uploadedImages
 		}
 		if len(productPriceRanges) > 0 {
-			pushUpdates["pricerange"] = bson.M{
-				"$each": productPriceRanges,
-			}
+			pushUpdates["pricerange"] = productPriceRanges
 		}
 		if len(attributes) > 0 {
-			pushUpdates["attributes"] = bson.M{
-				"$each": attributes,
-			}
+			pushUpdates["attributes"] = attributes
 		}
 
-		updateOperation := bson.M{"$set": update}
-		if len(pushUpdates) > 0 {
-			updateOperation["$push"] = pushUpdates
+		err = app.repos.Products.Update(ctx, productID, repository.Update{Set: update, Push: pushUpdates})
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
+			return
 		}
-
-		result, err := ProductCollection.UpdateOne(ctx, filter, updateOperation)
 		if err != nil {
 			fmt.Println(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while updating product"})
 			return
 		}
 
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
-			return
-		}
-
 		c.JSON(http.StatusOK, gin.H{"Message": "Product updated successfully"})
 	}
 
 }
 
 // accept diff filter to get product
-func SearchProductByQuery() gin.HandlerFunc {
+func (app *Application) SearchProductByQuery() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var searchProducts []models.Product
-		queryParam := c.Query("name")
-		filter := []primitive.M{}
-
-		if queryParam != "" {
-			filter = append(filter, primitive.M{"name": primitive.M{"$regex": queryParam, "$options": "i"}})
-		}
-
-		productCategory := c.Query("category")
-		productCategory = strings.TrimSpace(productCategory)
-		if productCategory != "" {
-			filter = append(filter, primitive.M{utils.Categories: productCategory})
-		}
-
-		brand := c.Query("brand")
-		if brand != "" {
-			filter = append(filter, primitive.M{utils.Brand: brand})
-		}
-
-		productName := c.Query("productname")
-		if productName != "" {
-			filter = append(filter, primitive.M{utils.ProductName: productName})
-		}
-
-		filter = append(filter, primitive.M{"approved": true})
-
-		finalFilter := primitive.M{}
-		if len(filter) > 0 {
-			finalFilter = primitive.M{"$and": filter}
+		filter := repository.ProductFilter{
+			NameLike:    c.Query("name"),
+			Category:    strings.TrimSpace(c.Query("category")),
+			ProductName: c.Query("productname"),
+			Approved:    repository.Bool(true),
+		}
+		if err := bindPriceBounds(c, &filter); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 
 		limit, err := strconv.Atoi(c.Query("limit"))
@@ -710,48 +573,38 @@ func SearchProductByQuery() gin.HandlerFunc {
 			limit = 20 // Default limit
 		}
 
-		page, err := strconv.Atoi(c.Query("page"))
-		if err != nil || page <= 0 {
-			page = 1 // Default page
+		pageNo, err := strconv.Atoi(c.Query("page"))
+		if err != nil || pageNo <= 0 {
+			pageNo = 1 // Default page
 		}
 
-		skip := (page - 1) * limit
+		skip := (pageNo - 1) * limit
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		findOptions := options.Find()
-		findOptions.SetLimit(int64(limit))
-		findOptions.SetSkip(int64(skip))
-		findOptions.SetSort(bson.D{{Key: "updated_at", Value: -1}}) // Sort by updated_at in descending order
+		// Sort by updated_at in descending order unless a price order is asked for
+		page := repository.Page{Sort: "-updated_at", Skip: int64(skip), Limit: int64(limit)}
+		switch c.Query("sort") {
+		case "price":
+			page.Sort = "price.amount"
+		case "-price":
+			page.Sort = "-price.amount"
+		}
 
-		cursor, err := ProductCollection.Find(ctx, finalFilter, findOptions)
+		searchProducts, err := app.repos.Products.Find(ctx, filter, page)
 		if err != nil {
 			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Error fetching products: " + err.Error()})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		if err := cursor.All(ctx, &searchProducts); err != nil {
-			log.Println(err)
-			c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Error decoding products: " + err.Error()})
-			return
-		}
-
-		for i := range searchProducts {
-			for j := range searchProducts[i].Image {
-				url, err := getPresignURL(searchProducts[i].Image[j])
-				if err != nil {
-					log.Println("Error generating pre-signed URL for image:", err)
-					continue
-				}
-				searchProducts[i].Image[j] = url
-			}
+		if err := app.presignProducts(searchProducts); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
 		}
 
 		//find if it has more products to be fetched
 
-		count, err := ProductCollection.CountDocuments(ctx, finalFilter)
+		count, err := app.repos.Products.Count(ctx, filter)
 		if err != nil {
 
 			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Error fetching products"})
@@ -759,156 +612,66 @@ func SearchProductByQuery() gin.HandlerFunc {
 
 		}
 
-		c.IndentedJSON(http.StatusOK, gin.H{
-			"products": searchProducts,
-			"page":     page,
-			"limit":    limit,
-			"nextPage": page + 1,
-			"hasMore":  count > int64(page*limit),
-		})
-
-	}
-}
-
-func GetAllProducts() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var searchProducts []models.Product
-
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		defer cancel()
-
-		cursor, err := ProductCollection.Find(ctx, bson.M{})
+		// Show the stock situation of every product found
+		productIDs := make([]primitive.ObjectID, len(searchProducts))
+		for i := range searchProducts {
+			productIDs[i] = searchProducts[i].Product_ID
+		}
+		availability, err := app.productAvailability(ctx, productIDs)
 		if err != nil {
-			c.IndentedJSON(http.StatusNotFound, "Something went wrong while fetching the data")
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Error fetching products"})
 			return
 		}
-		defer cursor.Close(ctx)
-
-		if err := cursor.All(ctx, &searchProducts); err != nil {
-			log.Println(err)
-			c.IndentedJSON(http.StatusBadRequest, "Invalid")
-			return
+		type productWithAvailability struct {
+			models.Product
+			Availability inventory.Summary `json:"availability"`
 		}
-
-		// Iterate over each product and get pre-signed URLs for each image
+		products := make([]productWithAvailability, len(searchProducts))
 		for i := range searchProducts {
-			for j := range searchProducts[i].Image {
-				// Get pre-signed URL for the image
-				url, err := getPresignURL(searchProducts[i].Image[j])
-				if err != nil {
-					log.Println("Error generating pre-signed URL for image:", err)
-					continue
-				}
-				// Update the image URL in the product
-				searchProducts[i].Image[j] = url
+			products[i] = productWithAvailability{Product: searchProducts[i], Availability: inventory.Summarize(nil)}
+			if summary, ok := availability[searchProducts[i].Product_ID]; ok {
+				products[i].Availability = summary
 			}
 		}
 
-		c.IndentedJSON(http.StatusOK, searchProducts)
+		c.IndentedJSON(http.StatusOK, gin.H{
+			"products": products,
+			"page":     pageNo,
+			"limit":    limit,
+			"nextPage": pageNo + 1,
+			"hasMore":  count > int64(pageNo*limit),
+		})
+
 	}
 }
 
-// user specific product
-// accept two param age and gender if these param are not found it will take users
-// age and gender to display the product
-func GetUserSpecificProduct() gin.HandlerFunc {
+func (app *Application) GetAllProducts() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var searchProducts []models.Product
-		var user models.USer
-		var age string
-		var gender string
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
-		age = fmt.Sprintf("%d", c.Query("age"))
-		gender = c.Query("gender")
-
-		if age != "" && gender != "" {
-			userId, exist := c.Get("uid")
-			if !exist {
-				c.JSON(http.StatusBadRequest, gin.H{"Error": "User ID not found"})
-				return
-			}
-
-			oid, err := primitive.ObjectIDFromHex(userId.(string))
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid user ID"})
-				return
-			}
-			err = UserCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&user)
-			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "User not found: " + err.Error()})
-				return
-			}
-			// Calculate age
-			currentDate := time.Now()
-			dob := user.DOB
-			ageCal := currentDate.Year() - dob.Year()
-
-			// Adjust for the user's birthday not having occurred this year yet
-			if currentDate.Month() < dob.Month() || (currentDate.Month() == dob.Month() && currentDate.Day() < dob.Day()) {
-				ageCal--
-			}
-			age = fmt.Sprintf("%d", ageCal)
-			gender = user.Gender
-		}
-		filter := bson.M{}
 
-		if gender != "" {
-			filter["gender"] = bson.M{
-				"$in": bson.A{gender, "both"},
-			}
-		}
-
-		filter["$expr"] = bson.M{
-			"$and": bson.A{
-				bson.M{"$gte": bson.A{
-					bson.M{"$toInt": bson.M{"$split": bson.A{"$agegroup", "-"}[0]}},
-					age,
-				}},
-				bson.M{"$lte": bson.A{
-					bson.M{"$toInt": bson.M{"$split": bson.A{"$agegroup", "-"}[1]}},
-					age,
-				}},
-			},
-		}
-
-		cursor, err := ProductCollection.Find(ctx, filter)
+		searchProducts, err := app.repos.Products.Find(ctx, repository.ProductFilter{}, repository.Page{})
 		if err != nil {
 			c.IndentedJSON(http.StatusNotFound, "Something went wrong while fetching the data")
 			return
 		}
-		defer cursor.Close(ctx)
-
-		if err := cursor.All(ctx, &searchProducts); err != nil {
-			log.Println(err)
-			c.IndentedJSON(http.StatusBadRequest, "Invalid")
-			return
-		}
 
-		// Iterate over each product and get pre-signed URLs for each image
-		for i := range searchProducts {
-			for j := range searchProducts[i].Image {
-				// Get pre-signed URL for the image
-				url, err := getPresignURL(searchProducts[i].Image[j])
-				if err != nil {
-					log.Println("Error generating pre-signed URL for image:", err)
-					continue
-				}
-				// Update the image URL in the product
-				searchProducts[i].Image[j] = url
-			}
+		// Get pre-signed URLs for the images of every product
+		if err := app.presignProducts(searchProducts); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
 		}
 
 		c.IndentedJSON(http.StatusOK, searchProducts)
 	}
 }
-func ApproveProduct() gin.HandlerFunc {
+
+func (app *Application) ApproveProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Check if the user is an admin
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -922,16 +685,15 @@ func ApproveProduct() gin.HandlerFunc {
 		}
 
 		// Find the product in the database
-		var product models.Product
-		err = ProductCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+		product, err := app.repos.Products.FindByID(ctx, objID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "product not found"})
 			return
 		}
 
 		// Update the product as approved
-		update := bson.M{"$set": bson.M{"approved": true, "isRejected": false}}
-		_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		update := repository.Update{Set: repository.Fields{"approved": true, "isRejected": false}}
+		err = app.repos.Products.Update(ctx, objID, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "could not approve product"})
 			return
@@ -941,13 +703,13 @@ func ApproveProduct() gin.HandlerFunc {
 	}
 }
 
-func RejectProduct() gin.HandlerFunc {
+func (app *Application) RejectProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Check if the user is an admin
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -963,32 +725,36 @@ func RejectProduct() gin.HandlerFunc {
 		rejection_note := c.PostForm("rejection_note")
 
 		// Find the product in the database
-		var product models.Product
-
-		// Update the product as rejected
-		update := bson.M{"$set": bson.M{"approved": false, "isRejected": true, "rejection_note": rejection_note}}
-		err = ProductCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update).Decode(&product)
+		product, err := app.repos.Products.FindByID(ctx, objID)
 		if err != nil {
-
-			if errors.Is(err, mongo.ErrNoDocuments) {
+			if errors.Is(err, repository.ErrNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"Error": "product not found"})
+				return
 			}
 
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "could not reject product"})
 			return
 		}
 
+		// Update the product as rejected
+		update := repository.Update{Set: repository.Fields{"approved": false, "isRejected": true, "rejection_note": rejection_note}}
+		err = app.repos.Products.Update(ctx, objID, update)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "could not reject product"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "product rejected successfully", "product": product})
 	}
 }
 
-func DeleteProduct() gin.HandlerFunc {
+func (app *Application) DeleteProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Check admin permission
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -1009,21 +775,17 @@ func DeleteProduct() gin.HandlerFunc {
 			return
 		}
 
-		// Prepare filter to find product by ID
-		filter := primitive.M{"_id": objID}
-
 		//update and make it arhcive
-		update := bson.M{"$set": bson.M{"isArchived": true, "approved": false, "isRejected": false}}
+		update := repository.Update{Set: repository.Fields{"isArchived": true, "approved": false, "isRejected": false}}
 
 		//update
-		result, err := ProductCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete product"})
+		err = app.repos.Products.Update(ctx, objID, update)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find Product"})
 			return
 		}
-
-		if result.MatchedCount < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find Product"})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete product"})
 			return
 		}
 
@@ -1032,40 +794,39 @@ func DeleteProduct() gin.HandlerFunc {
 	}
 }
 
-func FetchProductsAndReferencesHandler() gin.HandlerFunc {
+func (app *Application) FetchProductsAndReferencesHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
-		// Aggregate pipeline to fetch products along with populated product references
-		pipeline := []bson.M{
-			{
-				"$lookup": bson.M{
-					"from":         "ProductReferences",
-					"localField":   "product_references.product_id",
-					"foreignField": "_id",
-					"as":           "product_references",
-				},
-			},
-		}
-
-		cursor, err := ProductCollection.Aggregate(ctx, pipeline)
+		found, err := app.repos.Products.Find(ctx, repository.ProductFilter{}, repository.Page{})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var products []bson.M
-		if err := cursor.All(ctx, &products); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		// Populate the product references of every product
+		products := make([]gin.H, 0, len(found))
+		for _, product := range found {
+			references, err := app.repos.ProductReferences.Find(ctx, repository.ProductReferenceFilter{ProductIDs: []primitive.ObjectID{product.Product_ID}}, repository.Page{})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if references == nil {
+				references = []models.ProductReference{}
+			}
+			products = append(products, gin.H{
+				"product":            product,
+				"product_references": references,
+				"availability":       inventory.Summarize(references),
+			})
 		}
 
 		c.JSON(http.StatusOK, products)
 	}
 }
 
-func GetProductReferenceHandler() gin.HandlerFunc {
+func (app *Application) GetProductReferenceHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := context.Background()
 
@@ -1089,7 +850,7 @@ func GetProductReferenceHandler() gin.HandlerFunc {
 			},
 		}
 
-		cursor, err := ProductReference.Aggregate(ctx, pipeline)
+		cursor, err := app.productReferenceCollection.Aggregate(ctx, pipeline)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1105,122 +866,12 @@ func GetProductReferenceHandler() gin.HandlerFunc {
 	}
 }
 
-func getSuggestions(query string) ([]string, error) {
-	// Simulated database or API call to fetch products and categories
-
-	productsCursor, err := ProductCollection.Find(context.Background(), bson.M{})
-	if err != nil {
-		return nil, err
-	}
-
-	var products []models.Product
-	if err = productsCursor.All(context.Background(), &products); err != nil {
-		return nil, err
-	}
-
-	categoriesCusror, err := CategoriesCollection.Find(context.Background(), bson.M{})
-	if err != nil {
-		return nil, err
-	}
-
-	var categories []models.Categories
-	if err = categoriesCusror.All(context.Background(), &categories); err != nil {
-		return nil, err
-	}
-
-	var suggestions []string
-
-	// Retrieve product suggestions
-	for _, product := range products {
-		if strings.Contains(strings.ToLower(product.Product_Name), strings.ToLower(query)) {
-			suggestions = append(suggestions, product.Product_Name)
-		}
-
-	}
-
-	// Retrieve category suggestions
-	for _, category := range categories {
-		if strings.Contains(strings.ToLower(category.Category), strings.ToLower(query)) {
-			suggestions = append(suggestions, category.Category)
-		}
-	}
-
-	return suggestions, nil
-}
-
-func SuggestionsHandler() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		query := c.Query("query")
-		suggestions, err := getSuggestions(query)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-		if len(suggestions) > 10 {
-			suggestions = suggestions[:10]
-		}
-
-		c.JSON(http.StatusOK, suggestions)
-	}
+func (app *Application) checkAdmin(ctx context.Context, c *gin.Context) bool {
+	_, ok := app.currentAdmin(ctx, c)
+	return ok
 }
 
-//search product
-
-func SearchProduct() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx := context.Background()
-
-		query := c.Query("query")
-
-		filter := bson.M{
-			"$or": []bson.M{
-				{"product_name": bson.M{"$regex": query, "$options": "i"}},
-
-				{"category": bson.M{"$regex": query, "$options": "i"}},
-			},
-		}
-
-		cursor, err := ProductCollection.Find(ctx, filter)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		defer cursor.Close(ctx)
-
-		var results []models.Product
-
-		if err := cursor.All(ctx, &results); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		//iterate through each product and add presignurl
-
-		for i := range results {
-
-			for j := range results[i].Image {
-
-				url, err := getPresignURL(results[i].Image[j])
-
-				if err != nil {
-
-					log.Println("Error generating pre-signed URL for image:", err)
-				}
-
-				results[i].Image[j] = url
-
-			}
-
-		}
-
-		c.JSON(http.StatusOK, results)
-
-	}
-}
-
-func checkAdmin(ctx context.Context, c *gin.Context) bool {
+func (app *Application) checkSeller(ctx context.Context, c *gin.Context) bool {
 
 	email, existse := c.Get("email")
 	uid, exists := c.Get("uid")
@@ -1231,56 +882,20 @@ func checkAdmin(ctx context.Context, c *gin.Context) bool {
 	emails := fmt.Sprintf("%v", email)
 	id, err := primitive.ObjectIDFromHex(uids)
 	if err != nil {
-		log.Fatal(err)
-
-		return false
-	}
-	filter := primitive.M{
-		"$and": []primitive.M{
-			{"_id": id},
-			{"email": emails},
-		},
-	}
-	var seller models.Seller
-	SellerCollection.FindOne(ctx, filter).Decode(&seller)
-	if seller.User_type == utils.Admin {
-		return true
-	}
-	return false
-}
-
-func checkSeller(ctx context.Context, c *gin.Context) bool {
+		log.Println(err)
 
-	email, existse := c.Get("email")
-	uid, exists := c.Get("uid")
-	if !existse || !exists || uid == "" || email == "" {
 		return false
 	}
-	uids := fmt.Sprintf("%v", uid)
-	emails := fmt.Sprintf("%v", email)
-	id, err := primitive.ObjectIDFromHex(uids)
+	seller, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{ID: id, Email: emails})
 	if err != nil {
-		log.Fatal(err)
-
 		return false
 	}
-	filter := primitive.M{
-		"$and": []primitive.M{
-			{"_id": id},
-			{"email": emails},
-		},
-	}
-	var seller models.Seller
-	SellerCollection.FindOne(ctx, filter).Decode(&seller)
-	if seller.User_type == utils.Seller {
-		return true
-	}
-	return false
+	return seller.User_type == utils.Seller
 }
 
 //handler to make a product featured
 
-func MakeProductFeatured() gin.HandlerFunc {
+func (app *Application) MakeProductFeatured() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
@@ -1288,7 +903,7 @@ func MakeProductFeatured() gin.HandlerFunc {
 
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
@@ -1309,9 +924,7 @@ func MakeProductFeatured() gin.HandlerFunc {
 
 		objID, _ := primitive.ObjectIDFromHex(id)
 
-		var product models.Product
-
-		err := ProductCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+		_, err := app.repos.Products.FindByID(ctx, objID)
 
 		if err != nil {
 
@@ -1321,13 +934,9 @@ func MakeProductFeatured() gin.HandlerFunc {
 
 		}
 
-		//get current time in RFC
-
-		updateTime := time.Now().Format(time.RFC3339)
+		update := repository.Update{Set: repository.Fields{"featured": isFeaturedBool, "updated_at": time.Now()}}
 
-		update := bson.M{"$set": bson.M{"featured": isFeaturedBool, "updated_at": updateTime}}
-
-		_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		err = app.repos.Products.Update(ctx, objID, update)
 
 		if err != nil {
 
@@ -1344,7 +953,7 @@ func MakeProductFeatured() gin.HandlerFunc {
 
 //get featured products
 
-func GetFeaturedProducts() gin.HandlerFunc {
+func (app *Application) GetFeaturedProducts() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
@@ -1352,23 +961,13 @@ func GetFeaturedProducts() gin.HandlerFunc {
 
 		defer cancel()
 
-		findOptions := options.Find()
-		findOptions.SetSort(bson.D{{Key: "updated_at", Value: -1}})
-		findOptions.SetLimit(20)
-
-		cursor, err := ProductCollection.Find(ctx, bson.M{"featured": true, "approved": true, "isRejected": false}, findOptions)
-
-		if err != nil {
-
-			c.IndentedJSON(http.StatusInternalServerError, "Something went wrong while fetching the data")
-
-			return
-
+		filter := repository.ProductFilter{
+			Featured: repository.Bool(true),
+			Approved: repository.Bool(true),
+			Rejected: repository.Bool(false),
 		}
 
-		var featuredProducts []models.Product
-
-		err = cursor.All(ctx, &featuredProducts)
+		featuredProducts, err := app.repos.Products.Find(ctx, filter, repository.Page{Sort: "-updated_at", Limit: 20})
 
 		if err != nil {
 
@@ -1379,23 +978,9 @@ func GetFeaturedProducts() gin.HandlerFunc {
 		}
 		//append prsign url for each imabge of each product
 
-		for i, product := range featuredProducts {
-
-			for j, image := range product.Image {
-
-				url, err := getPresignURL(image)
-
-				if err != nil {
-
-					log.Println("Error generating pre-signed URL for image:", err)
-
-					continue
-
-				}
+		if err := app.presignProducts(featuredProducts); err != nil {
 
-				featuredProducts[i].Image[j] = url
-
-			}
+			log.Println("Error generating pre-signed URL for image:", err)
 
 		}
 
@@ -1404,28 +989,19 @@ func GetFeaturedProducts() gin.HandlerFunc {
 	}
 
 }
-func GetSellerProductForAdmin() gin.HandlerFunc {
+func (app *Application) GetSellerProductForAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
 
 		var sellerId = c.Param("id")
 
-		var products []models.Product
-
-		cursor, err := ProductCollection.Find(ctx, bson.M{"sellerregistered": sellerId})
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		err = cursor.All(ctx, &products)
+		products, err := app.repos.Products.Find(ctx, repository.ProductFilter{SellerID: sellerId}, repository.Page{})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1434,29 +1010,12 @@ func GetSellerProductForAdmin() gin.HandlerFunc {
 
 		//itertate through all products and generate presign url for image
 
-		for i := 0; i < len(products); i++ {
-
-			if products[i].Image != nil {
+		if err := app.presignProducts(products); err != nil {
 
-				for j := 0; j < len(products[i].Image); j++ {
-
-					imageUrl, err := getPresignURL(products[i].Image[j])
-
-					if err !=
-
-						nil {
-
-						c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-
-						return
-
-					}
-
-					products[i].Image[j] = imageUrl
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 
-				}
+			return
 
-			}
 		}
 
 		c.JSON(http.StatusOK, products)
@@ -1465,35 +1024,19 @@ func GetSellerProductForAdmin() gin.HandlerFunc {
 
 }
 
-func GetProductsCsv(c *gin.Context) {
-	var searchProducts []models.Product
-
+func (app *Application) GetProductsCsv(c *gin.Context) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	cursor, err := ProductCollection.Find(ctx, bson.M{})
+	searchProducts, err := app.repos.Products.Find(ctx, repository.ProductFilter{}, repository.Page{})
 	if err != nil {
 		c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Error fetching products: " + err.Error()})
 		return
 	}
-	defer cursor.Close(ctx)
-
-	if err := cursor.All(ctx, &searchProducts); err != nil {
-		log.Println(err)
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Error decoding products: " + err.Error()})
-		return
-	}
 
 	// Pre-sign image URLs
-	for i := range searchProducts {
-		for j := range searchProducts[i].Image {
-			url, err := getPresignURL(searchProducts[i].Image[j])
-			if err != nil {
-				log.Println("Error generating pre-signed URL for image:", err)
-				continue
-			}
-			searchProducts[i].Image[j] = url
-		}
+	if err := app.presignProducts(searchProducts); err != nil {
+		log.Println("Error generating pre-signed URL for image:", err)
 	}
 
 	// Prepare CSV headers
@@ -1502,16 +1045,11 @@ func GetProductsCsv(c *gin.Context) {
 	// Prepare CSV rows
 	var rows [][]string
 	for _, product := range searchProducts {
-		priceFloat, err := strconv.ParseFloat(product.Price, 64)
-		if err != nil {
-			priceFloat = 0.0
-		}
-
 		row := []string{
 			product.Product_Name,
 			product.Category,
-			strconv.FormatFloat(priceFloat, 'f', 2, 64),
-			strings.Join(product.Image, ";"),
+			product.Price.Decimal(),
+			strings.Join(models.ImageURLs(product.Image), ";"),
 			product.Discription,
 			strconv.FormatBool(product.Approved),
 			strconv.FormatBool(product.Featured),
@@ -1520,7 +1058,7 @@ func GetProductsCsv(c *gin.Context) {
 
 		var priceRange []string
 		for _, price := range product.PriceRange {
-			priceRange = append(priceRange, "["+strconv.FormatFloat(float64(price.MinQuantity), 'f', 2, 64)+"-"+strconv.FormatFloat(float64(price.MaxQuantity), 'f', 2, 64)+":"+price.Price+"]")
+			priceRange = append(priceRange, "["+strconv.FormatFloat(float64(price.MinQuantity), 'f', 2, 64)+"-"+strconv.FormatFloat(float64(price.MaxQuantity), 'f', 2, 64)+":"+price.Price.Decimal()+"]")
 		}
 		row = append(row, strings.Join(priceRange, ";"))
 
@@ -0,0 +1,879 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Import job states.
+const (
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// Outcomes of an import row. Dry runs report the action a real import
+// would have taken.
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+	ImportError  = "error"
+)
+
+const (
+	maxImportSheet   = 10 << 20
+	maxImportArchive = 200 << 20
+	maxImportImage   = 20 << 20
+	maxImportRows    = 5000
+)
+
+// importColumns maps the accepted header spellings to column names.
+var importColumns = map[string]string{
+	"name":         "name",
+	"product_name": "name",
+	"sku":          "sku",
+	"category":     "category",
+	"price":        "price",
+	"price_tiers":  "price_tiers",
+	"pricerange":   "price_tiers",
+	"price_range":  "price_tiers",
+	"attributes":   "attributes",
+	"images":       "images",
+	"image":        "images",
+	"description":  "description",
+	"discription":  "description",
+	"agegroup":     "agegroup",
+	"age_group":    "agegroup",
+	"gender":       "gender",
+}
+
+var requiredImportColumns = []string{"name", "sku", "category"}
+
+// importSheet is a parsed sheet: one map of column name to cell per row.
+type importSheet struct {
+	rows []map[string]string
+	// lines holds the sheet row number of every entry of rows.
+	lines []int
+}
+
+func importColumnName(header string) string {
+	name := strings.ToLower(strings.TrimSpace(header))
+	name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+	return importColumns[name]
+}
+
+// readImportSheet parses a CSV file or the first sheet of an XLSX
+// workbook. Blank rows are skipped.
+func readImportSheet(filename string, data []byte) (*importSheet, error) {
+	var (
+		records [][]string
+		// lines holds the line of every record. The CSV reader skips empty
+		// lines, so they are not always consecutive.
+		lines []int
+	)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading csv: %w", err)
+			}
+			line, _ := reader.FieldPos(0)
+			records = append(records, record)
+			lines = append(lines, line)
+		}
+	case ".xlsx":
+		book, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("reading xlsx: %w", err)
+		}
+		defer book.Close()
+		sheets := book.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("the workbook has no sheets")
+		}
+		if records, err = book.GetRows(sheets[0]); err != nil {
+			return nil, fmt.Errorf("reading xlsx: %w", err)
+		}
+		for i := range records {
+			lines = append(lines, i+1)
+		}
+	default:
+		return nil, errors.New("the file must be a .csv or .xlsx file")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+
+	columns := make([]string, len(records[0]))
+	seen := map[string]bool{}
+	for i, header := range records[0] {
+		name := importColumnName(header)
+		if name != "" && seen[name] {
+			return nil, fmt.Errorf("column %q appears more than once", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	for _, name := range requiredImportColumns {
+		if !seen[name] {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	if !seen["price"] && !seen["price_tiers"] {
+		return nil, errors.New("missing column \"price\" or \"price_tiers\"")
+	}
+
+	sheet := &importSheet{}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		row := map[string]string{}
+		blank := true
+		for j, cell := range record {
+			if j >= len(columns) || columns[j] == "" {
+				continue
+			}
+			cell = strings.TrimSpace(cell)
+			if cell != "" {
+				blank = false
+			}
+			row[columns[j]] = cell
+		}
+		if blank {
+			continue
+		}
+		sheet.rows = append(sheet.rows, row)
+		sheet.lines = append(sheet.lines, lines[i])
+	}
+	if len(sheet.rows) == 0 {
+		return nil, errors.New("the file has no products")
+	}
+	if len(sheet.rows) > maxImportRows {
+		return nil, fmt.Errorf("the file has more than %d products", maxImportRows)
+	}
+	return sheet, nil
+}
+
+// readImportImages indexes the files of an image archive by base name.
+func readImportImages(data []byte) (map[string]*zip.File, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading images archive: %w", err)
+	}
+	images := map[string]*zip.File{}
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, ok := images[name]; ok {
+			return nil, fmt.Errorf("the images archive has more than one %q", name)
+		}
+		images[name] = file
+	}
+	return images, nil
+}
+
+// parsePriceTiers parses tiers like "1-9:100; 10-49:90; 50+:80". A "+"
+// leaves the last tier open ended.
+func parsePriceTiers(value string) ([]models.ProductPriceRange, error) {
+	var tiers []models.ProductPriceRange
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		quantities, price, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("price tier %q must look like 10-49:90", entry)
+		}
+		var tier models.ProductPriceRange
+		var err error
+		quantities = strings.TrimSpace(quantities)
+		if strings.HasSuffix(quantities, "+") {
+			tier.MinQuantity, err = strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(quantities, "+")))
+		} else if min, max, ok := strings.Cut(quantities, "-"); ok {
+			tier.MinQuantity, err = strconv.Atoi(strings.TrimSpace(min))
+			if err == nil {
+				tier.MaxQuantity, err = strconv.Atoi(strings.TrimSpace(max))
+			}
+		} else {
+			tier.MinQuantity, err = strconv.Atoi(quantities)
+			tier.MaxQuantity = tier.MinQuantity
+		}
+		if err != nil || tier.MinQuantity < 1 || (tier.MaxQuantity != 0 && tier.MaxQuantity < tier.MinQuantity) {
+			return nil, fmt.Errorf("price tier %q has invalid quantities", entry)
+		}
+		tier.Price, err = money.Parse(price, money.DefaultCurrency)
+		if err != nil || tier.Price.Amount < 0 {
+			return nil, fmt.Errorf("price tier %q has an invalid price", entry)
+		}
+		tiers = append(tiers, tier)
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinQuantity < tiers[j].MinQuantity })
+	for i := 1; i < len(tiers); i++ {
+		prev := tiers[i-1]
+		if prev.MaxQuantity == 0 || prev.MaxQuantity >= tiers[i].MinQuantity {
+			return nil, fmt.Errorf("price tiers starting at %d and %d overlap", prev.MinQuantity, tiers[i].MinQuantity)
+		}
+	}
+	return tiers, nil
+}
+
+// importCatalog holds the categories and attribute types rows are checked
+// against, keyed by lower case name.
+type importCatalog struct {
+	categories map[string]string
+	attributes map[string]*models.AttributeType
+}
+
+func (app *Application) loadImportCatalog(ctx context.Context) (*importCatalog, error) {
+	catalog := &importCatalog{categories: map[string]string{}, attributes: map[string]*models.AttributeType{}}
+	if app.categoriesCollection != nil {
+		var categories []models.Categories
+		cursor, err := app.categoriesCollection.Find(ctx, bson.M{"isArchived": bson.M{"$ne": true}})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &categories); err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			catalog.categories[strings.ToLower(category.Category)] = category.Category
+		}
+	}
+	if app.attributesCollection != nil {
+		var attributes []models.AttributeType
+		cursor, err := app.attributesCollection.Find(ctx, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &attributes); err != nil {
+			return nil, err
+		}
+		for i := range attributes {
+			attribute := &attributes[i]
+			catalog.attributes[strings.ToLower(attribute.Attribute_Name)] = attribute
+			if attribute.Attribute_Code != "" {
+				catalog.attributes[strings.ToLower(attribute.Attribute_Code)] = attribute
+			}
+		}
+	}
+	return catalog, nil
+}
+
+// parseAttributes parses attributes like "Color=Red|Blue; Size=M". Types
+// are matched by code or name and values must be among the options of the
+// type, when it has any.
+func (catalog *importCatalog) parseAttributes(value string) ([]models.AttributeValue, []string) {
+	var (
+		values []models.AttributeValue
+		errs   []string
+	)
+	seen := map[primitive.ObjectID]bool{}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, options, ok := strings.Cut(entry, "=")
+		if !ok {
+			errs = append(errs, fmt.Sprintf("attribute %q must look like Color=Red|Blue", entry))
+			continue
+		}
+		name = strings.TrimSpace(name)
+		attribute, ok := catalog.attributes[strings.ToLower(name)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unknown attribute %q", name))
+			continue
+		}
+		if seen[attribute.ID] {
+			errs = append(errs, fmt.Sprintf("attribute %q is given more than once", name))
+			continue
+		}
+		seen[attribute.ID] = true
+
+		attributeValue := models.AttributeValue{AttributeType: attribute.ID}
+		for _, option := range strings.Split(options, "|") {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				continue
+			}
+			if len(attribute.Options) == 0 {
+				attributeValue.Value = append(attributeValue.Value, option)
+				continue
+			}
+			known := ""
+			for _, candidate := range attribute.Options {
+				if strings.EqualFold(candidate, option) {
+					known = candidate
+					break
+				}
+			}
+			if known == "" {
+				errs = append(errs, fmt.Sprintf("%q is not an option of attribute %q", option, attribute.Attribute_Name))
+				continue
+			}
+			attributeValue.Value = append(attributeValue.Value, known)
+		}
+		if len(attributeValue.Value) == 0 {
+			errs = append(errs, fmt.Sprintf("attribute %q has no value", name))
+			continue
+		}
+		values = append(values, attributeValue)
+	}
+	return values, errs
+}
+
+// importProduct is a validated row.
+type importProduct struct {
+	name        string
+	sku         string
+	category    string
+	price       money.Money
+	tiers       []models.ProductPriceRange
+	attributes  []models.AttributeValue
+	images      []string
+	description string
+	ageGroup    string
+	gender      string
+}
+
+func isImageURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// validateImportRow checks a row against the catalog and the image archive.
+func (catalog *importCatalog) validateImportRow(row map[string]string, images map[string]*zip.File) (*importProduct, []string) {
+	var errs []string
+	product := &importProduct{
+		name:        row["name"],
+		sku:         row["sku"],
+		description: row["description"],
+		ageGroup:    row["agegroup"],
+		gender:      strings.ToLower(row["gender"]),
+	}
+	if product.name == "" {
+		errs = append(errs, "name is required")
+	}
+	if product.sku == "" {
+		errs = append(errs, "sku is required")
+	}
+
+	if row["category"] == "" {
+		errs = append(errs, "category is required")
+	} else if category, ok := catalog.categories[strings.ToLower(row["category"])]; ok {
+		product.category = category
+	} else {
+		errs = append(errs, fmt.Sprintf("unknown category %q", row["category"]))
+	}
+
+	if row["price_tiers"] != "" {
+		tiers, err := parsePriceTiers(row["price_tiers"])
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		product.tiers = tiers
+	}
+	switch {
+	case row["price"] != "":
+		price, err := money.Parse(row["price"], money.DefaultCurrency)
+		if err != nil || price.Amount < 0 {
+			errs = append(errs, fmt.Sprintf("price %q is not a valid amount", row["price"]))
+		}
+		product.price = price
+	case len(product.tiers) > 0:
+		// The tiers are sorted, the first one is the smallest order.
+		product.price = product.tiers[0].Price
+	case row["price_tiers"] == "":
+		errs = append(errs, "price or price_tiers is required")
+	}
+
+	if row["attributes"] != "" {
+		attributes, attributeErrs := catalog.parseAttributes(row["attributes"])
+		product.attributes = attributes
+		errs = append(errs, attributeErrs...)
+	}
+
+	for _, image := range strings.Split(row["images"], ";") {
+		image = strings.TrimSpace(image)
+		if image == "" {
+			continue
+		}
+		if !isImageURL(image) {
+			if _, ok := images[path.Base(image)]; !ok {
+				errs = append(errs, fmt.Sprintf("image %q is not in the images archive", image))
+				continue
+			}
+			image = path.Base(image)
+		}
+		product.images = append(product.images, image)
+	}
+
+	if product.ageGroup != "" {
+		min, max, ok := strings.Cut(product.ageGroup, "-")
+		low, errMin := strconv.Atoi(strings.TrimSpace(min))
+		high, errMax := strconv.Atoi(strings.TrimSpace(max))
+		if !ok || errMin != nil || errMax != nil || low > high {
+			errs = append(errs, fmt.Sprintf("agegroup %q must look like 3-12", product.ageGroup))
+		}
+	}
+	return product, errs
+}
+
+// productImporter runs one import job.
+type productImporter struct {
+	app     *Application
+	job     *models.ProductImport
+	catalog *importCatalog
+	images  map[string]*zip.File
+	// uploaded caches every archive image already stored, by product
+	// prefix and name.
+	uploaded map[string]models.Image
+	// actorEmail and requestID describe the request that started the job,
+	// for the audit log.
+	actorEmail string
+	requestID  string
+}
+
+// audit records a product the import created or changed live. Seller
+// updates are audited when their revision is reviewed.
+func (im *productImporter) audit(ctx context.Context, before, after *models.Product) {
+	entry := models.AuditEntry{
+		Actor:       im.job.Actor,
+		Actor_email: im.actorEmail,
+		Role:        im.job.Role,
+		Action:      "product.import",
+		Entity_type: AuditProduct,
+		Entity_id:   after.Product_ID.Hex(),
+		Request_id:  im.requestID,
+		Job_id:      im.job.ID.Hex(),
+	}
+	// A nil *models.Product would not be a nil interface.
+	if before == nil {
+		im.app.recordAudit(ctx, entry, nil, after)
+		return
+	}
+	im.app.recordAudit(ctx, entry, before, after)
+}
+
+// uploadImage stores an image of the archive for the product with
+// productID, once per import.
+func (im *productImporter) uploadImage(ctx context.Context, productID primitive.ObjectID, name string) (models.Image, error) {
+	prefix := storage.ProductImagesPrefix(productID.Hex())
+	if image, ok := im.uploaded[prefix+name]; ok {
+		return image, nil
+	}
+	file, err := im.images[name].Open()
+	if err != nil {
+		return models.Image{}, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportImage+1))
+	if err != nil {
+		return models.Image{}, err
+	}
+	if len(data) > maxImportImage {
+		return models.Image{}, fmt.Errorf("image %q is larger than %d MB", name, maxImportImage>>20)
+	}
+	image, err := im.app.storeImage(ctx, prefix, name, data)
+	if err != nil {
+		return models.Image{}, err
+	}
+	im.uploaded[prefix+name] = image
+	return image, nil
+}
+
+// existing returns the product with sku, nil when there is none.
+func (im *productImporter) existing(ctx context.Context, sku string) (*models.Product, error) {
+	products, err := im.app.repos.Products.Find(ctx, repository.ProductFilter{SKU: sku}, repository.Page{Limit: 2})
+	if err != nil {
+		return nil, err
+	}
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &products[0], nil
+	}
+	return nil, fmt.Errorf("sku %q is used by more than one product", sku)
+}
+
+// importRow validates a row and, unless the job is a dry run, creates or
+// updates its product.
+func (im *productImporter) importRow(ctx context.Context, row map[string]string) models.ImportRow {
+	result := models.ImportRow{SKU: row["sku"]}
+	fail := func(errs ...string) models.ImportRow {
+		result.Action = ImportError
+		result.Errors = errs
+		return result
+	}
+
+	product, errs := im.catalog.validateImportRow(row, im.images)
+	if len(errs) > 0 {
+		return fail(errs...)
+	}
+	current, err := im.existing(ctx, product.sku)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if current != nil && im.job.Role == utils.Seller && !containsSeller(current.SellerRegistered, im.job.SellerID) {
+		return fail(fmt.Sprintf("sku %q belongs to another seller", product.sku))
+	}
+	result.Action = ImportCreate
+	if current != nil {
+		result.Action = ImportUpdate
+		result.Product_id = current.Product_ID.Hex()
+	}
+	if im.job.DryRun {
+		return result
+	}
+
+	productID := primitive.NewObjectID()
+	if current != nil {
+		productID = current.Product_ID
+	}
+	images := []models.Image{}
+	for _, image := range product.images {
+		if isImageURL(image) {
+			images = append(images, models.Image{Original: image})
+			continue
+		}
+		uploaded, err := im.uploadImage(ctx, productID, image)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("uploading image %q: %s", image, err))
+			continue
+		}
+		images = append(images, uploaded)
+	}
+	if len(errs) > 0 {
+		return fail(errs...)
+	}
+
+	now := time.Now()
+	if current != nil && im.job.Role == utils.Seller {
+		return im.submitRevision(ctx, result, current, product, images)
+	}
+	if current != nil {
+		set := repository.Fields{
+			"product_name": product.name,
+			"category":     product.category,
+			"price":        product.price,
+			"updated_at":   now,
+		}
+		// Columns left out or blank keep what the product has.
+		if len(product.tiers) > 0 {
+			set["pricerange"] = product.tiers
+		}
+		if len(product.attributes) > 0 {
+			set["attributes"] = product.attributes
+		}
+		if product.description != "" {
+			set["discription"] = product.description
+		}
+		if product.ageGroup != "" {
+			set["agegroup"] = product.ageGroup
+		}
+		if product.gender != "" {
+			set["gender"] = product.gender
+		}
+		if len(images) > 0 {
+			set["image"] = images
+		}
+		if err := im.app.repos.Products.Update(ctx, current.Product_ID, repository.Update{Set: set}); err != nil {
+			log.Println("import: updating product", current.Product_ID.Hex(), err)
+			return fail("could not update the product")
+		}
+		updated, err := im.app.repos.Products.FindByID(ctx, current.Product_ID)
+		if err != nil {
+			log.Println("import: loading updated product", current.Product_ID.Hex(), err)
+			return result
+		}
+		im.audit(ctx, current, updated)
+		return result
+	}
+
+	created := models.Product{
+		Product_ID:   productID,
+		Product_Name: product.name,
+		SKU:          product.sku,
+		Category:     product.category,
+		Price:        product.price,
+		PriceRange:   product.tiers,
+		Attributes:   product.attributes,
+		Image:        images,
+		Discription:  product.description,
+		AgeGroup:     product.ageGroup,
+		Gender:       product.gender,
+		AddedBy:      "Admin",
+		Created_at:   now,
+		Updated_at:   now,
+	}
+	if im.job.SellerID != "" {
+		created.SellerRegistered = []string{im.job.SellerID}
+	}
+	if im.job.Role == utils.Seller {
+		created.AddedBy = "seller"
+	}
+	if err := im.app.repos.Products.Insert(ctx, &created); err != nil {
+		log.Println("import: inserting product", product.sku, err)
+		return fail("could not create the product")
+	}
+	im.audit(ctx, nil, &created)
+	result.Product_id = created.Product_ID.Hex()
+	return result
+}
+
+// submitRevision proposes the row as a revision of current, sellers
+// cannot change live products without review.
+func (im *productImporter) submitRevision(ctx context.Context, result models.ImportRow, current *models.Product, product *importProduct, images []models.Image) models.ImportRow {
+	draft, pending, err := im.app.revisionDraft(ctx, current)
+	if err != nil {
+		log.Println("import: loading revisions of", current.Product_ID.Hex(), err)
+		result.Action, result.Errors = ImportError, []string{"could not update the product"}
+		return result
+	}
+	draft.Product_Name = product.name
+	draft.Category = product.category
+	draft.Price = product.price
+	if len(product.tiers) > 0 {
+		draft.PriceRange = product.tiers
+	}
+	if len(product.attributes) > 0 {
+		draft.Attributes = product.attributes
+	}
+	if product.description != "" {
+		draft.Discription = product.description
+	}
+	if product.ageGroup != "" {
+		draft.AgeGroup = product.ageGroup
+	}
+	if product.gender != "" {
+		draft.Gender = product.gender
+	}
+	if len(images) > 0 {
+		draft.Image = images
+	}
+	revision, err := im.app.submitRevision(ctx, im.job.Actor, im.job.Role, current, draft, pending)
+	if err == errNoChanges {
+		return result
+	}
+	if err != nil {
+		log.Println("import: submitting revision of", current.Product_ID.Hex(), err)
+		result.Action, result.Errors = ImportError, []string{"could not update the product"}
+		return result
+	}
+	result.Revision_id = revision.ID.Hex()
+	return result
+}
+
+func containsSeller(sellers []string, id string) bool {
+	for _, seller := range sellers {
+		if seller == id {
+			return true
+		}
+	}
+	return false
+}
+
+// run imports every row of sheet and stores the results on the job.
+func (im *productImporter) run(sheet *importSheet) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	set := repository.Fields{"status": ImportDone}
+	catalog, err := im.app.loadImportCatalog(ctx)
+	if err != nil {
+		log.Println("import: loading catalog", im.job.ID.Hex(), err)
+		set = repository.Fields{"status": ImportFailed, "error": "could not load categories and attributes"}
+	} else {
+		im.catalog = catalog
+		rows := make([]models.ImportRow, 0, len(sheet.rows))
+		seen := map[string]int{}
+		for i, row := range sheet.rows {
+			var result models.ImportRow
+			if first, ok := seen[row["sku"]]; ok && row["sku"] != "" {
+				result = models.ImportRow{SKU: row["sku"], Action: ImportError,
+					Errors: []string{fmt.Sprintf("sku %q is already used on row %d", row["sku"], first)}}
+			} else {
+				seen[row["sku"]] = sheet.lines[i]
+				result = im.importRow(ctx, row)
+			}
+			result.Row = sheet.lines[i]
+			switch result.Action {
+			case ImportCreate:
+				im.job.Created++
+			case ImportUpdate:
+				im.job.Updated++
+			default:
+				im.job.Failed++
+			}
+			rows = append(rows, result)
+		}
+		set["rows"] = rows
+		set["created"] = im.job.Created
+		set["updated"] = im.job.Updated
+		set["failed"] = im.job.Failed
+	}
+	set["finished_at"] = time.Now()
+	if err := im.app.repos.ProductImports.Update(ctx, im.job.ID, repository.Update{Set: set}); err != nil {
+		log.Println("import: saving results", im.job.ID.Hex(), err)
+	}
+}
+
+func readUpload(file *multipart.FileHeader, limit int64) ([]byte, error) {
+	if file.Size > limit {
+		return nil, fmt.Errorf("%s is larger than %d MB", file.Filename, limit>>20)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
+// ImportProducts starts a bulk import of the products in the "file" form
+// field, a CSV or XLSX sheet, with the images named in it taken from the
+// optional "images" ZIP archive. Products are created or updated by SKU;
+// with dry_run=true the rows are only validated. The job runs in the
+// background, poll GetProductImport for the per-row results.
+//
+// Sellers import into their own catalog, admins may name a seller with
+// the sellerId field.
+func (app *Application) ImportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		job := models.ProductImport{
+			ID:         primitive.NewObjectID(),
+			Status:     ImportRunning,
+			Actor:      c.GetString("uid"),
+			Role:       c.GetString("role"),
+			Rows:       []models.ImportRow{},
+			Created_at: time.Now(),
+		}
+		job.DryRun, _ = strconv.ParseBool(c.PostForm("dry_run"))
+		if job.Role == utils.Seller {
+			job.SellerID = job.Actor
+		} else if sellerID := c.PostForm("sellerId"); sellerID != "" {
+			id, err := primitive.ObjectIDFromHex(sellerID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid seller id"})
+				return
+			}
+			if _, err := app.repos.Sellers.FindByID(ctx, id); err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"Error": "seller not found"})
+				return
+			}
+			job.SellerID = sellerID
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "a .csv or .xlsx file is required"})
+			return
+		}
+		data, err := readUpload(file, maxImportSheet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		sheet, err := readImportSheet(file.Filename, data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		job.Filename = file.Filename
+		job.Total = len(sheet.rows)
+
+		images := map[string]*zip.File{}
+		if archive, err := c.FormFile("images"); err == nil {
+			data, err := readUpload(archive, maxImportArchive)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+				return
+			}
+			if images, err = readImportImages(data); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+				return
+			}
+		}
+
+		if err := app.repos.ProductImports.Insert(ctx, &job); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		// The importer updates its copy of the job while this one is sent.
+		running := job
+		importer := &productImporter{
+			app:        app,
+			job:        &running,
+			images:     images,
+			uploaded:   map[string]models.Image{},
+			actorEmail: c.GetString("email"),
+			requestID:  c.GetString("request_id"),
+		}
+		go importer.run(sheet)
+
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// GetProductImport returns an import job with its per-row results. Sellers
+// only see their own imports.
+func (app *Application) GetProductImport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid import id"})
+			return
+		}
+		job, err := app.repos.ProductImports.FindByID(ctx, id)
+		if err == repository.ErrNotFound || (err == nil && c.GetString("role") == utils.Seller && job.Actor != c.GetString("uid")) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "import not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestImportUpdateKeepsColumnsLeftOut(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	sellerID := primitive.NewObjectID().Hex()
+	colour := primitive.NewObjectID()
+	product := models.Product{
+		Product_ID:       primitive.NewObjectID(),
+		Product_Name:     "Mug",
+		SKU:              "MUG",
+		Category:         "Kitchen",
+		Price:            money.New(20000, "INR"),
+		PriceRange:       []models.ProductPriceRange{{MinQuantity: 10, MaxQuantity: 0, Price: money.New(18000, "INR")}},
+		Attributes:       []models.AttributeValue{{AttributeType: colour, Value: []string{"White"}}},
+		Approved:         true,
+		SellerRegistered: []string{sellerID},
+	}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+	catalog := &importCatalog{categories: map[string]string{"kitchen": "Kitchen"}, attributes: map[string]*models.AttributeType{}}
+
+	tests := []struct {
+		name  string
+		job   models.ProductImport
+		price int64
+	}{
+		{"admin", models.ProductImport{ID: primitive.NewObjectID(), Actor: primitive.NewObjectID().Hex(), Role: utils.Admin}, 250},
+		{"seller", models.ProductImport{ID: primitive.NewObjectID(), Actor: sellerID, Role: utils.Seller, SellerID: sellerID}, 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importer := &productImporter{app: app, job: &tt.job, catalog: catalog, uploaded: map[string]models.Image{}}
+			row := map[string]string{"name": "Large Mug", "sku": "MUG", "category": "kitchen", "price": strconv.FormatInt(tt.price, 10), "price_tiers": "", "attributes": ""}
+			result := importer.importRow(ctx, row)
+			if result.Action != ImportUpdate {
+				t.Fatalf("row %+v", result)
+			}
+			updated, err := app.repos.Products.FindByID(ctx, product.Product_ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.job.Role == utils.Seller {
+				id, _ := primitive.ObjectIDFromHex(result.Revision_id)
+				revision, err := app.repos.ProductRevisions.FindByID(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				updated = &revision.Proposed
+			}
+			if updated.Price != money.New(tt.price*100, "INR") {
+				t.Errorf("price %v, want the imported one", updated.Price)
+			}
+			if len(updated.PriceRange) != 1 || len(updated.Attributes) != 1 {
+				t.Errorf("tiers %v and attributes %v were wiped", updated.PriceRange, updated.Attributes)
+			}
+		})
+	}
+}
+
+func TestImportAuditsEveryRow(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	existing := models.Product{Product_ID: primitive.NewObjectID(), Product_Name: "Mug", SKU: "MUG", Category: "Kitchen"}
+	if err := app.repos.Products.Insert(ctx, &existing); err != nil {
+		t.Fatal(err)
+	}
+	job := models.ProductImport{ID: primitive.NewObjectID(), Actor: primitive.NewObjectID().Hex(), Role: utils.Admin, Rows: []models.ImportRow{}}
+	if err := app.repos.ProductImports.Insert(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+	importer := &productImporter{app: app, job: &job, uploaded: map[string]models.Image{}}
+	importer.catalog = &importCatalog{categories: map[string]string{"kitchen": "Kitchen"}, attributes: map[string]*models.AttributeType{}}
+	for _, row := range []map[string]string{
+		{"name": "Large Mug", "sku": "MUG", "category": "kitchen", "price": "250"},
+		{"name": "Plate", "sku": "PLATE", "category": "kitchen", "price": "100"},
+		{"name": "Bowl", "sku": "BOWL", "category": "garden", "price": "100"},
+	} {
+		importer.importRow(ctx, row)
+	}
+
+	entries, err := app.repos.Audit.Find(ctx, repository.AuditFilter{JobID: job.ID.Hex()}, repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%d audit entries, want one per imported row: %+v", len(entries), entries)
+	}
+	for _, entry := range entries {
+		if entry.Action != "product.import" || entry.Actor != job.Actor || len(entry.Changes) == 0 {
+			t.Errorf("entry %+v", entry)
+		}
+	}
+	if entries[0].Entity_id != existing.Product_ID.Hex() {
+		t.Errorf("first entry is for %s, want the updated product", entries[0].Entity_id)
+	}
+}
+
+func TestParsePriceTiers(t *testing.T) {
+	tiers := func(ranges ...[3]int64) []models.ProductPriceRange {
+		var result []models.ProductPriceRange
+		for _, r := range ranges {
+			result = append(result, models.ProductPriceRange{MinQuantity: int(r[0]), MaxQuantity: int(r[1]), Price: money.New(r[2], "INR")})
+		}
+		return result
+	}
+	tests := []struct {
+		in   string
+		want []models.ProductPriceRange
+		err  bool
+	}{
+		{"1-9:100; 10-49:90; 50+:80", tiers([3]int64{1, 9, 10000}, [3]int64{10, 49, 9000}, [3]int64{50, 0, 8000}), false},
+		{"50+:80;1-49:90", tiers([3]int64{1, 49, 9000}, [3]int64{50, 0, 8000}), false},
+		{" 5 : ₹1,200.50 ;", tiers([3]int64{5, 5, 120050}), false},
+		{"10 - 20:5", tiers([3]int64{10, 20, 500}), false},
+		{"", nil, false},
+		{"1-10:100;10-20:90", nil, true},
+		{"50+:80;60-70:75", nil, true},
+		{"1-9", nil, true},
+		{"9-1:100", nil, true},
+		{"0-9:100", nil, true},
+		{"a-9:100", nil, true},
+		{"+:100", nil, true},
+		{"1-9:free", nil, true},
+		{"1-9:-5", nil, true},
+		{"1-9:1.005", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parsePriceTiers(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadImportSheet(t *testing.T) {
+	xlsx := func(rows ...[]interface{}) []byte {
+		book := excelize.NewFile()
+		defer book.Close()
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := book.SetSheetRow("Sheet1", cell, &row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := book.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		rows     []map[string]string
+		lines    []int
+		err      string
+	}{
+		{"csv", "p.csv", []byte("Product Name,SKU,Category,Price,Notes\nMug, MUG ,Kitchen,200,ignored\n"),
+			[]map[string]string{{"name": "Mug", "sku": "MUG", "category": "Kitchen", "price": "200"}}, []int{2}, ""},
+		{"byte order mark", "p.csv", []byte("\xef\xbb\xbfname,sku,category,price\nMug,MUG,Kitchen,200\n"),
+			[]map[string]string{{"name": "Mug", "sku": "MUG", "category": "Kitchen", "price": "200"}}, []int{2}, ""},
+		{"blank rows and short records", "p.csv", []byte("name,sku,category,price_tiers\n,,,\nMug,MUG\n\nPlate,PLATE,Kitchen,1+:100\n"),
+			[]map[string]string{{"name": "Mug", "sku": "MUG"}, {"name": "Plate", "sku": "PLATE", "category": "Kitchen", "price_tiers": "1+:100"}}, []int{3, 5}, ""},
+		{"xlsx", "P.XLSX", xlsx([]interface{}{"Name", "SKU", "Category", "Price Range"}, []interface{}{"Mug", "MUG", "Kitchen", "1+:100"}),
+			[]map[string]string{{"name": "Mug", "sku": "MUG", "category": "Kitchen", "price_tiers": "1+:100"}}, []int{2}, ""},
+		{"duplicate header spellings", "p.csv", []byte("name,product_name,sku,category,price\n"), nil, nil, `column "name" appears more than once`},
+		{"missing column", "p.csv", []byte("name,category,price\nMug,Kitchen,1\n"), nil, nil, `missing column "sku"`},
+		{"no price", "p.csv", []byte("name,sku,category\nMug,MUG,Kitchen\n"), nil, nil, `missing column "price" or "price_tiers"`},
+		{"header only", "p.csv", []byte("name,sku,category,price\n"), nil, nil, "the file has no products"},
+		{"empty", "p.csv", nil, nil, nil, "the file is empty"},
+		{"other format", "p.xls", []byte("name"), nil, nil, "the file must be a .csv or .xlsx file"},
+		{"broken csv", "p.csv", []byte("name,sku,category,price\n\"Mug,MUG\n"), nil, nil, "reading csv"},
+		{"broken xlsx", "p.xlsx", []byte("not a zip"), nil, nil, "reading xlsx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := readImportSheet(tt.filename, tt.data)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sheet.rows, tt.rows) || !reflect.DeepEqual(sheet.lines, tt.lines) {
+				t.Errorf("got %v on lines %v, want %v on lines %v", sheet.rows, sheet.lines, tt.rows, tt.lines)
+			}
+		})
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	colour := &models.AttributeType{ID: primitive.NewObjectID(), Attribute_Name: "Colour", Attribute_Code: "col", Options: []string{"Red", "Blue"}}
+	material := &models.AttributeType{ID: primitive.NewObjectID(), Attribute_Name: "Material"}
+	catalog := &importCatalog{
+		categories: map[string]string{"kitchen": "Kitchen"},
+		attributes: map[string]*models.AttributeType{"colour": colour, "col": colour, "material": material},
+	}
+	images := map[string]*zip.File{"mug.jpg": {}}
+	valid := func(changes map[string]string) map[string]string {
+		row := map[string]string{"name": "Mug", "sku": "MUG", "category": "KITCHEN", "price": "200"}
+		for k, v := range changes {
+			row[k] = v
+		}
+		return row
+	}
+	tests := []struct {
+		name  string
+		row   map[string]string
+		check func(t *testing.T, p *importProduct)
+		errs  []string
+	}{
+		{"valid", valid(nil), func(t *testing.T, p *importProduct) {
+			if p.category != "Kitchen" || p.price != money.New(20000, "INR") {
+				t.Errorf("got %+v", p)
+			}
+		}, nil},
+		{"price from the first tier", valid(map[string]string{"price": "", "price_tiers": "10+:80; 1-9:90"}), func(t *testing.T, p *importProduct) {
+			if p.price != money.New(9000, "INR") || len(p.tiers) != 2 {
+				t.Errorf("got %+v", p)
+			}
+		}, nil},
+		{"attributes", valid(map[string]string{"attributes": "COL=red|Blue; Material=Stoneware"}), func(t *testing.T, p *importProduct) {
+			want := []models.AttributeValue{
+				{AttributeType: colour.ID, Value: []string{"Red", "Blue"}},
+				{AttributeType: material.ID, Value: []string{"Stoneware"}},
+			}
+			if !reflect.DeepEqual(p.attributes, want) {
+				t.Errorf("attributes %+v", p.attributes)
+			}
+		}, nil},
+		{"images", valid(map[string]string{"images": "photos/mug.jpg; https://cdn.example.com/a.jpg"}), func(t *testing.T, p *importProduct) {
+			if !reflect.DeepEqual(p.images, []string{"mug.jpg", "https://cdn.example.com/a.jpg"}) {
+				t.Errorf("images %v", p.images)
+			}
+		}, nil},
+		{"required", map[string]string{}, nil, []string{"name is required", "sku is required", "category is required", "price or price_tiers is required"}},
+		{"unknown category", valid(map[string]string{"category": "Garden"}), nil, []string{`unknown category "Garden"`}},
+		{"invalid price", valid(map[string]string{"price": "-5"}), nil, []string{`price "-5" is not a valid amount`}},
+		{"invalid tiers", valid(map[string]string{"price": "", "price_tiers": "1-10:5;5-20:4"}), nil, []string{"price tiers starting at 1 and 5 overlap"}},
+		{"attribute errors", valid(map[string]string{"attributes": "Size=M; Colour=Green; colour=Red; Material=; Colour"}), nil, []string{
+			`unknown attribute "Size"`,
+			`"Green" is not an option of attribute "Colour"`,
+			`attribute "Colour" has no value`,
+			`attribute "colour" is given more than once`,
+			`attribute "Material" has no value`,
+			`attribute "Colour" must look like Color=Red|Blue`,
+		}},
+		{"missing image", valid(map[string]string{"images": "plate.jpg"}), nil, []string{`image "plate.jpg" is not in the images archive`}},
+		{"age group", valid(map[string]string{"agegroup": "12-3"}), nil, []string{`agegroup "12-3" must look like 3-12`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, errs := catalog.validateImportRow(tt.row, images)
+			if !reflect.DeepEqual(errs, tt.errs) {
+				t.Fatalf("errors %q, want %q", errs, tt.errs)
+			}
+			if tt.check != nil {
+				tt.check(t, product)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetProduct(t *testing.T) {
+	app, store, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	product := models.Product{
+		Product_ID:   primitive.NewObjectID(),
+		Product_Name: "Mug",
+		Price:        money.New(19900, "INR"),
+		Approved:     true,
+	}
+	key := storage.NewKey(storage.ProductImagesPrefix(product.Product_ID.Hex()), ".png")
+	url, err := store.Put(ctx, key, bytes.NewReader([]byte("png")), storage.Metadata{ContentType: "image/png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	product.Image = []models.Image{{Original: url}}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET("/getproduct", app.GetProduct())
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"invalid id", "nope", http.StatusBadRequest},
+		{"unknown", primitive.NewObjectID().Hex(), http.StatusNotFound},
+		{"found", product.Product_ID.Hex(), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/getproduct?productId="+tt.id, "", nil)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var got models.Product
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Product_Name != "Mug" || got.Price != product.Price {
+				t.Errorf("got %+v", got)
+			}
+			if len(got.Image) != 1 || !strings.Contains(got.Image[0].Original, "signature=") {
+				t.Errorf("image not signed: %+v", got.Image)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errBelowMinimum = errors.New("quantity is below the minimum order quantity")
+
+// priceTier returns the price range tier for quantity. A MaxQuantity of
+// zero leaves a tier open ended, and quantities above every tier get the
+// tier with the highest minimum. Products without tiers sell at Price.
+func priceTier(product *models.Product, quantity int) (*models.ProductPriceRange, error) {
+	if len(product.PriceRange) == 0 {
+		return &models.ProductPriceRange{MinQuantity: 1, Price: product.Price}, nil
+	}
+	var match, top *models.ProductPriceRange
+	minimum := -1
+	for i := range product.PriceRange {
+		tier := &product.PriceRange[i]
+		if minimum < 0 || tier.MinQuantity < minimum {
+			minimum = tier.MinQuantity
+		}
+		if top == nil || tier.MinQuantity > top.MinQuantity {
+			top = tier
+		}
+		if quantity < tier.MinQuantity || (tier.MaxQuantity > 0 && quantity > tier.MaxQuantity) {
+			continue
+		}
+		// Overlapping tiers resolve to the one meant for larger orders.
+		if match == nil || tier.MinQuantity > match.MinQuantity {
+			match = tier
+		}
+	}
+	if match != nil {
+		return match, nil
+	}
+	if quantity < minimum {
+		return nil, fmt.Errorf("%w of %d", errBelowMinimum, minimum)
+	}
+	return top, nil
+}
+
+// Quote prices quantity units of a product, or of one of its variants
+// with variant_id: the unit price of the matching price tier, the line
+// total and the tax on it.
+func (app *Application) Quote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		productID, err := primitive.ObjectIDFromHex(c.Query("product_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid product id"})
+			return
+		}
+		quantity, err := strconv.Atoi(c.Query("quantity"))
+		if err != nil || quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "quantity must be a positive number"})
+			return
+		}
+		if maxQuantity := app.config.Pricing.MaxOrderQuantity; quantity > maxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("quantity can't be more than %d", maxQuantity)})
+			return
+		}
+
+		product, err := app.repos.Products.FindByID(ctx, productID)
+		if err == repository.ErrNotFound || (err == nil && (!product.Approved || product.IsArchived)) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "product not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		var variant *models.ProductVariant
+		if variantID := c.Query("variant_id"); variantID != "" {
+			if variant = findVariant(product, variantID); variant == nil {
+				c.JSON(http.StatusNotFound, gin.H{"Error": "variant not found"})
+				return
+			}
+		}
+
+		tier, err := priceTier(variantPricing(product, variant), quantity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		if tier.Price.Amount <= 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "this product has no price, please send an enquiry"})
+			return
+		}
+
+		taxRate := app.config.Pricing.CategoryTaxRate(product.Category)
+		lineTotal, err := tier.Price.Mul(int64(quantity))
+		var tax, total money.Money
+		if err == nil {
+			tax = lineTotal.BasisPoints(int64(taxRate))
+			total, err = lineTotal.Add(tax)
+		}
+		if errors.Is(err, money.ErrOverflow) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "the quantity is too large to quote"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		quote := gin.H{
+			"product_id": product.Product_ID,
+			"quantity":   quantity,
+			"tier":       gin.H{"minQuantity": tier.MinQuantity, "maxQuantity": tier.MaxQuantity},
+			"unit_price": tier.Price,
+			"line_total": lineTotal,
+			"tax":        gin.H{"rate_bps": taxRate, "amount": tax},
+			"total":      total,
+		}
+		if variant != nil {
+			quote["variant_id"] = variant.ID
+			quote["sku"] = variant.SKU
+			quote["in_stock"] = variant.Stock >= quantity
+		}
+		c.JSON(http.StatusOK, quote)
+	}
+}
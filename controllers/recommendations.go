@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/recommend"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recentViews is the number of a buyer's latest product views their
+// recommendations are based on, next to all their enquiries.
+const recentViews = 200
+
+// recommendable selects the products that can be recommended.
+func recommendable() repository.ProductFilter {
+	return repository.ProductFilter{
+		Approved: repository.Bool(true),
+		Archived: repository.Bool(false),
+		Rejected: repository.Bool(false),
+	}
+}
+
+func enquiryInteractions(enquiries []models.Enquire) []recommend.Interaction {
+	interactions := make([]recommend.Interaction, len(enquiries))
+	for i, enquiry := range enquiries {
+		interactions[i] = recommend.Interaction{User: enquiry.User_id, Product: enquiry.Product_id, Weight: recommend.EnquiryWeight}
+	}
+	return interactions
+}
+
+func viewInteractions(views []models.Event) []recommend.Interaction {
+	interactions := make([]recommend.Interaction, len(views))
+	for i, view := range views {
+		interactions[i] = recommend.Interaction{User: view.User_id, Product: view.Product_id, Weight: recommend.ViewWeight}
+	}
+	return interactions
+}
+
+// refreshRecommendations computes the recommendation table again from the
+// enquiries and product views of the configured window.
+func (app *Application) refreshRecommendations(ctx context.Context) error {
+	now := time.Now()
+	since := now.Add(-app.config.Recommendations.Window)
+	enquiries, err := app.repos.Enquiries.Find(ctx, repository.EnquiryFilter{Since: since}, repository.Page{})
+	if err != nil {
+		return err
+	}
+	views, err := app.repos.Events.Find(ctx, repository.EventFilter{Type: models.EventView, From: since}, repository.Page{})
+	if err != nil {
+		return err
+	}
+	products, err := app.repos.Products.Find(ctx, recommendable(), repository.Page{})
+	if err != nil {
+		return err
+	}
+	categoryOf := make(map[string]string, len(products))
+	for _, product := range products {
+		categoryOf[product.Product_ID.Hex()] = product.Category
+	}
+
+	interactions := append(enquiryInteractions(enquiries), viewInteractions(views)...)
+	n := app.config.Recommendations.Related
+	var rows []models.Recommendation
+	for id, related := range recommend.Related(interactions, categoryOf, n) {
+		rows = append(rows, models.Recommendation{ID: primitive.NewObjectID(), Key: recommend.ProductKey(id), Products: related, Computed_at: now})
+	}
+	for category, popular := range recommend.Popular(interactions, categoryOf, n) {
+		rows = append(rows, models.Recommendation{ID: primitive.NewObjectID(), Key: recommend.CategoryKey(category), Products: popular, Computed_at: now})
+	}
+	return app.repos.Recommendations.Replace(ctx, rows, now)
+}
+
+// RunRecommendations computes the recommendation table right away and then
+// every configured interval until ctx is done.
+func (app *Application) RunRecommendations(ctx context.Context) {
+	ticker := time.NewTicker(app.config.Recommendations.Interval)
+	defer ticker.Stop()
+	for {
+		if err := app.refreshRecommendations(ctx); err != nil {
+			log.Println("recommendations: computing table:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ageGroupFits reports whether age falls in an age group such as "18-25".
+// Products without a valid age group fit nobody.
+func ageGroupFits(group string, age int) bool {
+	bounds := strings.SplitN(group, "-", 2)
+	if len(bounds) != 2 {
+		return false
+	}
+	min, errMin := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	max, errMax := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	return errMin == nil && errMax == nil && min <= age && age <= max
+}
+
+// audience reads the age and gender to recommend for from the age and
+// gender parameters, the ones missing taken from the profile of the signed
+// in user. Either is empty when unknown.
+func (app *Application) audience(ctx context.Context, c *gin.Context) (age, gender string) {
+	age, gender = c.Query("age"), c.Query("gender")
+	uid := c.GetString("uid")
+	if (age != "" && gender != "") || uid == "" || app.userCollection == nil {
+		return age, gender
+	}
+	oid, err := primitive.ObjectIDFromHex(uid)
+	if err != nil {
+		return age, gender
+	}
+	var user models.USer
+	if err := app.userCollection.FindOne(ctx, bson.M{"_id": oid}).Decode(&user); err != nil {
+		log.Println(err)
+		return age, gender
+	}
+	if age == "" && !user.DOB.IsZero() {
+		now := time.Now()
+		years := now.Year() - user.DOB.Year()
+		// Adjust for the user's birthday not having occurred this year yet
+		if now.Month() < user.DOB.Month() || (now.Month() == user.DOB.Month() && now.Day() < user.DOB.Day()) {
+			years--
+		}
+		age = strconv.Itoa(years)
+	}
+	if gender == "" {
+		gender = user.Gender
+	}
+	return age, gender
+}
+
+// history returns how interested the signed in user is in the products they
+// enquired about and recently viewed, see recommend.Strengths.
+func (app *Application) history(ctx context.Context, uid string) (map[string]float64, error) {
+	enquiries, err := app.repos.Enquiries.Find(ctx, repository.EnquiryFilter{UserID: uid}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	views, err := app.repos.Events.Find(ctx, repository.EventFilter{Type: models.EventView, UserID: uid}, repository.Page{Sort: "-created_at", Limit: recentViews})
+	if err != nil {
+		return nil, err
+	}
+	return recommend.Strengths(append(enquiryInteractions(enquiries), viewInteractions(views)...))[uid], nil
+}
+
+// personalized returns the products recommended for a history, best first,
+// see recommend.Recommend.
+func (app *Application) personalized(ctx context.Context, history map[string]float64, filter repository.ProductFilter, n int) ([]models.Product, error) {
+	if len(history) == 0 {
+		return nil, nil
+	}
+	var ids []primitive.ObjectID
+	for id := range history {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	seen, err := app.repos.Products.Find(ctx, repository.ProductFilter{IDs: ids}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	categoryOf := map[string]string{}
+	categories := map[string]bool{}
+	keys := []string{}
+	for _, product := range seen {
+		id := product.Product_ID.Hex()
+		categoryOf[id] = product.Category
+		keys = append(keys, recommend.ProductKey(id))
+		if !categories[product.Category] {
+			categories[product.Category] = true
+			keys = append(keys, recommend.CategoryKey(product.Category))
+		}
+	}
+	rows, err := app.repos.Recommendations.FindByKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	scored := recommend.Recommend(history, categoryOf, recommend.NewTable(rows), n)
+	if len(scored) == 0 {
+		return nil, nil
+	}
+
+	rank := map[primitive.ObjectID]int{}
+	filter.IDs = nil
+	for i, s := range scored {
+		if oid, err := primitive.ObjectIDFromHex(s.Product_id); err == nil {
+			rank[oid] = i
+			filter.IDs = append(filter.IDs, oid)
+		}
+	}
+	products, err := app.repos.Products.Find(ctx, filter, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]*models.Product, len(scored))
+	for i := range products {
+		ordered[rank[products[i].Product_ID]] = &products[i]
+	}
+	recommended := make([]models.Product, 0, len(products))
+	for _, product := range ordered {
+		if product != nil {
+			recommended = append(recommended, *product)
+		}
+	}
+	return recommended, nil
+}
+
+// GetUserSpecificProduct recommends up to "limit" products, 20 by default
+// and 50 at most. Signed in users get the products related to what they
+// enquired about and viewed, see package recommend, topped up with featured
+// products. Everybody else gets the featured products. The age and gender
+// parameters, or those of the user's profile, narrow both to the products
+// for them.
+func (app *Application) GetUserSpecificProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 50 {
+			limit = 20
+		}
+		age, gender := app.audience(ctx, c)
+		filter := recommendable()
+		if gender != "" {
+			filter.Genders = []string{gender, "both"}
+		}
+		fits := func(p *models.Product) bool { return true }
+		if years, err := strconv.Atoi(age); err == nil {
+			fits = func(p *models.Product) bool { return ageGroupFits(p.AgeGroup, years) }
+		}
+
+		products := []models.Product{}
+		included := map[primitive.ObjectID]bool{}
+		add := func(candidates []models.Product) {
+			for i := range candidates {
+				if len(products) == limit || included[candidates[i].Product_ID] || !fits(&candidates[i]) {
+					continue
+				}
+				included[candidates[i].Product_ID] = true
+				products = append(products, candidates[i])
+			}
+		}
+
+		source := "featured"
+		if uid := c.GetString("uid"); uid != "" {
+			history, err := app.history(ctx, uid)
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+			// Ask for more than needed, some won't fit the age group.
+			recommended, err := app.personalized(ctx, history, filter, 4*limit)
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+			add(recommended)
+			if len(products) > 0 {
+				source = "personalized"
+			}
+		}
+		if len(products) < limit {
+			featured := filter
+			featured.Featured = repository.Bool(true)
+			candidates, err := app.repos.Products.Find(ctx, featured, repository.Page{Sort: "-updated_at"})
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+			add(candidates)
+		}
+
+		if err := app.presignProducts(products); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
+		}
+		c.JSON(http.StatusOK, gin.H{"products": products, "source": source})
+	}
+}
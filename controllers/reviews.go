@@ -9,11 +9,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func AddReviewHandler() gin.HandlerFunc {
+func (app *Application) AddReviewHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Parse request body to get review details
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -54,22 +55,20 @@ func AddReviewHandler() gin.HandlerFunc {
 		}
 
 		// Check if product exists
-		var product models.Product
 		productObjID, err := primitive.ObjectIDFromHex(review.ProductId.Hex())
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
 			return
 		}
-		filter := bson.M{"_id": productObjID}
-		err = ProductCollection.FindOne(ctx, filter).Decode(&product)
+		product, err := app.repos.Products.FindByID(ctx, productObjID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
 			return
 		}
 
 		// Check if user has already reviewed the product
-		filter = bson.M{"product_id": productObjID, "user_id": review.UserId}
-		count, err := ReviewsCollection.CountDocuments(ctx, filter)
+		filter := repository.ReviewFilter{ProductID: productObjID, UserID: review.UserId}
+		count, err := app.repos.Reviews.Count(ctx, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check if user has already reviewed the product"})
 			return
@@ -83,30 +82,21 @@ func AddReviewHandler() gin.HandlerFunc {
 		review.CreatedAt = time.Now()
 		review.UpdatedAt = time.Now()
 
-		_, errs := ReviewsCollection.InsertOne(ctx, review)
+		errs := app.repos.Reviews.Insert(ctx, &review)
 		if errs != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to add review"})
 			return
 		}
 
-		if product.Reviews != nil {
-
-			update := bson.M{"$push": bson.M{"reviews": review.Id}}
-			_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": review.ProductId}, update)
-			if err != nil {
-				fmt.Println(err)
-				c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
-				return
-			}
-		} else {
-
-			update := bson.M{"$set": bson.M{"reviews": []primitive.ObjectID{review.Id}}}
-			_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": review.ProductId}, update)
-			if err != nil {
-				fmt.Println(err)
-				c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
-				return
-			}
+		update := repository.Update{Push: repository.Fields{"reviews": []primitive.ObjectID{review.Id}}}
+		if product.Reviews == nil {
+			update = repository.Update{Set: repository.Fields{"reviews": []primitive.ObjectID{review.Id}}}
+		}
+		err = app.repos.Products.Update(ctx, product.Product_ID, update)
+		if err != nil {
+			fmt.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
+			return
 		}
 
 		// Return success response
@@ -114,7 +104,7 @@ func AddReviewHandler() gin.HandlerFunc {
 	}
 }
 
-func AddReviewByAdmin() gin.HandlerFunc {
+func (app *Application) AddReviewByAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -126,7 +116,7 @@ func AddReviewByAdmin() gin.HandlerFunc {
 			return
 		}
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized to add reviews"})
 			return
@@ -157,15 +147,9 @@ func AddReviewByAdmin() gin.HandlerFunc {
 		}
 
 		// Check if product exists
-		var product models.Product
 		pid := review.ProductId
 
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
-			return
-		}
-		productfilter := bson.M{"_id": pid}
-		err = ProductCollection.FindOne(ctx, productfilter).Decode(&product)
+		product, err := app.repos.Products.FindByID(ctx, pid)
 		if err != nil {
 			fmt.Println(err)
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
@@ -173,8 +157,8 @@ func AddReviewByAdmin() gin.HandlerFunc {
 		}
 
 		// Check if user has already reviewed the product
-		filter := bson.M{"product_id": product.Product_ID, "user_id": review.UserId}
-		count, err := ReviewsCollection.CountDocuments(ctx, filter)
+		filter := repository.ReviewFilter{ProductID: product.Product_ID, UserID: review.UserId}
+		count, err := app.repos.Reviews.Count(ctx, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check if user has already reviewed the product"})
 			return
@@ -188,43 +172,35 @@ func AddReviewByAdmin() gin.HandlerFunc {
 		review.CreatedAt = time.Now()
 		review.UpdatedAt = time.Now()
 
-		_, errs := ReviewsCollection.InsertOne(ctx, review)
+		errs := app.repos.Reviews.Insert(ctx, &review)
 		if errs != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to add review"})
 			return
 		}
 
-		if product.Reviews != nil {
-
-			update := bson.M{"$push": bson.M{"reviews": review.Id}}
-			_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": review.ProductId}, update)
-			if err != nil {
-				fmt.Println(err)
-				c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
-				return
-			}
-		} else {
-
-			update := bson.M{"$set": bson.M{"reviews": []primitive.ObjectID{review.Id}}}
-			_, err = ProductCollection.UpdateOne(ctx, productfilter, update)
-			if err != nil {
-				fmt.Println(err)
-				c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
-				return
-			}
+		update := repository.Update{Push: repository.Fields{"reviews": []primitive.ObjectID{review.Id}}}
+		if product.Reviews == nil {
+			update = repository.Update{Set: repository.Fields{"reviews": []primitive.ObjectID{review.Id}}}
+		}
+		err = app.repos.Products.Update(ctx, product.Product_ID, update)
+		if err != nil {
+			fmt.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update product with review ID"})
+			return
 		}
 
+		setAuditEntity(c, review.Id)
 		// Return success response
 		c.JSON(http.StatusOK, gin.H{"message": "Thanks for your review!"})
 	}
 }
 
-func ApproveReview() gin.HandlerFunc {
+func (app *Application) ApproveReview() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized to approve reviews"})
 			return
@@ -250,9 +226,8 @@ func ApproveReview() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"_id": oid}
-		update := bson.M{"$set": bson.M{"approved": statusBool}}
-		_, updteErr := ReviewsCollection.UpdateOne(ctx, filter, update)
+		update := repository.Update{Set: repository.Fields{"approved": statusBool}}
+		updteErr := app.repos.Reviews.Update(ctx, oid, update)
 		if updteErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to approve review"})
 			return
@@ -263,7 +238,7 @@ func ApproveReview() gin.HandlerFunc {
 	}
 }
 
-func GetProductApprovedReviews() gin.HandlerFunc {
+func (app *Application) GetProductApprovedReviews() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -274,78 +249,32 @@ func GetProductApprovedReviews() gin.HandlerFunc {
 			return
 		}
 
-		var product models.Product
 		productObjID, err := primitive.ObjectIDFromHex(productId)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
 			return
 		}
-		filter := bson.M{"_id": productObjID}
-		err = ProductCollection.FindOne(ctx, filter).Decode(&product)
-		if err != nil {
+		if _, err := app.repos.Products.FindByID(ctx, productObjID); err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
 			return
 		}
 
-		pipeline := []bson.M{
-			{
-				"$match": bson.M{"product_id": productObjID, "approved": true},
-			},
-			{
-				"$lookup": bson.M{
-					"from":         "User",
-					"localField":   "user_id",
-					"foreignField": "_id",
-					"as":           "user",
-				},
-			},
-			{
-				"$unwind": bson.M{
-					"path":                       "$user",
-					"preserveNullAndEmptyArrays": true,
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":             1,
-					"product_id":      1,
-					"reviews_details": 1,
-					"approved":        1,
-					"archived":        1,
-					"created_at":      1,
-
-					"user": bson.M{
-						"_id":      "$user._id",
-						"name":     "$user.user_name",
-						"mobileno": "$user.mobileno",
-					},
-				},
-			},
-		}
-
 		//get reviews with product_id
-		cursor, err := ReviewsCollection.Aggregate(ctx, pipeline)
+		reviews, err := app.repos.Reviews.Find(ctx, repository.ReviewFilter{ProductID: productObjID, Approved: repository.Bool(true)}, repository.Page{})
 		if err != nil {
 			fmt.Print(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find reviews"})
 			return
 		}
-		defer cursor.Close(ctx)
+		result := app.reviewsWithUser(ctx, reviews, false)
 
-		var result []bson.M
-		if err := cursor.All(ctx, &result); err != nil {
-			fmt.Print(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to get reviews"})
-			return
-		}
-
-		var totalRating int32 = 0
-		totalReviews := len(result)
-		ratingCounts := make(map[int32]int)
+		var totalRating int = 0
+		totalReviews := len(reviews)
+		ratingCounts := make(map[int]int)
 
 		// Iterate through each review in the result slice
-		for _, review := range result {
-			rating := review["reviews_details"].(bson.M)["review_rating"].(int32)
+		for _, review := range reviews {
+			rating := review.ReviewsDetails.ReviewRating
 
 			// Calculate total rating
 			totalRating += rating
@@ -358,7 +287,7 @@ func GetProductApprovedReviews() gin.HandlerFunc {
 		averageRating := float64(totalRating) / float64(totalReviews)
 
 		// Calculate percentage of reviews for each ReviewRating
-		percentageReviews := make(map[int32]float64)
+		percentageReviews := make(map[int]float64)
 		for rating, count := range ratingCounts {
 			percentage := float64(count) / float64(totalReviews) * 100
 			percentageReviews[rating] = percentage
@@ -374,7 +303,7 @@ func GetProductApprovedReviews() gin.HandlerFunc {
 	}
 }
 
-func GetProductReviews() gin.HandlerFunc {
+func (app *Application) GetProductReviews() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -385,149 +314,46 @@ func GetProductReviews() gin.HandlerFunc {
 			return
 		}
 
-		var product models.Product
 		productObjID, err := primitive.ObjectIDFromHex(productId)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
 			return
 		}
-		filter := bson.M{"_id": productObjID}
-		err = ProductCollection.FindOne(ctx, filter).Decode(&product)
-		if err != nil {
+		if _, err := app.repos.Products.FindByID(ctx, productObjID); err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
 			return
 		}
 
-		pipeline := []bson.M{
-			{
-				"$match": bson.M{"product_id": productObjID},
-			},
-			{
-				"$lookup": bson.M{
-					"from":         "User",
-					"localField":   "user_id",
-					"foreignField": "_id",
-					"as":           "user",
-				},
-			},
-			{
-				"$unwind": bson.M{
-					"path":                       "$user",
-					"preserveNullAndEmptyArrays": true,
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":             1,
-					"product_id":      1,
-					"reviews_details": 1,
-					"approved":        1,
-					"archived":        1,
-					"created_at":      1,
-
-					"user": bson.M{
-						"_id":      "$user._id",
-						"name":     "$user.username",
-						"mobileno": "$user.mobileno",
-					},
-				},
-			},
-		}
-
 		//get reviews with product_id
-		cursor, err := ReviewsCollection.Aggregate(ctx, pipeline)
+		reviews, err := app.repos.Reviews.Find(ctx, repository.ReviewFilter{ProductID: productObjID}, repository.Page{})
 		if err != nil {
 			fmt.Print(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find reviews"})
 			return
 		}
-		defer cursor.Close(ctx)
-
-		var result []bson.M
-		if err := cursor.All(ctx, &result); err != nil {
-
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to get reviews"})
-			return
-		}
+		result := app.reviewsWithUser(ctx, reviews, false)
 
 		c.JSON(http.StatusOK, result)
 	}
 }
 
-func GetReviews() gin.HandlerFunc {
+func (app *Application) GetReviews() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		var reviews []bson.M
-
-		pipeline := []bson.M{
-
-			{
-				"$lookup": bson.M{
-					"from":         "User",
-					"localField":   "user_id",
-					"foreignField": "_id",
-					"as":           "user",
-				},
-			},
-			{
-				"$lookup": bson.M{
-					"from":         "Products",
-					"localField":   "product_id",
-					"foreignField": "_id",
-					"as":           "product",
-				},
-			},
-			{
-				"$unwind": bson.M{
-					"path":                       "$user",
-					"preserveNullAndEmptyArrays": true,
-				},
-			}, {
-				"$unwind": bson.M{
-					"path":                       "$product",
-					"preserveNullAndEmptyArrays": true,
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":             1,
-					"product":         1,
-					"reviews_details": 1,
-					"approved":        1,
-					"archived":        1,
-					"created_at":      1,
-
-					"user": bson.M{
-						"_id":      "$user._id",
-						"name":     "$user.username",
-						"mobileno": "$user.mobileno",
-					},
-				},
-			},
-		}
-		cursor, err := ReviewsCollection.Aggregate(ctx, pipeline)
+		found, err := app.repos.Reviews.Find(ctx, repository.ReviewFilter{}, repository.Page{})
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, "Something went wrong while fetching the reviews")
 			return
 		}
-		defer cursor.Close(ctx)
-		for cursor.Next(ctx) {
-			var review bson.M
-			err := cursor.Decode(&review)
-			if err != nil {
-				c.IndentedJSON(http.StatusInternalServerError, "Something went wrong while fetching the reviews")
-				return
-			}
-			reviews = append(reviews, review)
-		}
+		reviews := app.reviewsWithUser(ctx, found, true)
 		c.IndentedJSON(http.StatusOK, reviews)
 
 	}
 }
 
-func GetReview() gin.HandlerFunc {
+func (app *Application) GetReview() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -537,9 +363,7 @@ func GetReview() gin.HandlerFunc {
 
 		objID, _ := primitive.ObjectIDFromHex(id)
 
-		var reviews models.Reviews
-
-		err := ReviewsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&reviews)
+		reviews, err := app.repos.Reviews.FindByID(ctx, objID)
 
 		if err != nil {
 			c.IndentedJSON(http.StatusInternalServerError, "Something went wrong while fetching the review")
@@ -549,13 +373,51 @@ func GetReview() gin.HandlerFunc {
 		//get productid and find product details
 		productid := reviews.ProductId
 
-		prodcut := getProductDetails(ctx, productid.Hex())
+		prodcut := app.getProductDetails(ctx, productid.Hex())
 
 		userid := reviews.UserId.Hex()
 
-		user := getUserDetails(ctx, userid)
+		user := app.getUserDetails(ctx, userid)
 
 		c.IndentedJSON(http.StatusOK, gin.H{"review": reviews, "product": prodcut, "user": user})
 
 	}
 }
+
+// reviewsWithUser shapes reviews for the listing endpoints, attaching the
+// reviewer's name and mobile number and, when withProduct is set, the
+// reviewed product.
+func (app *Application) reviewsWithUser(ctx context.Context, reviews []models.Reviews, withProduct bool) []gin.H {
+	result := make([]gin.H, 0, len(reviews))
+	for _, review := range reviews {
+		item := gin.H{
+			"_id":             review.Id,
+			"product_id":      review.ProductId,
+			"reviews_details": review.ReviewsDetails,
+			"approved":        review.Approved,
+			"archived":        review.Archived,
+			"created_at":      review.CreatedAt,
+		}
+
+		var user models.USer
+		if err := app.userCollection.FindOne(ctx, bson.M{"_id": review.UserId}).Decode(&user); err == nil {
+			item["user"] = gin.H{
+				"_id":      user.User_id,
+				"name":     user.UserName,
+				"mobileno": user.MobileNo,
+			}
+		} else {
+			item["user"] = gin.H{}
+		}
+
+		if withProduct {
+			delete(item, "product_id")
+			if product, err := app.repos.Products.FindByID(ctx, review.ProductId); err == nil {
+				item["product"] = product
+			}
+		}
+
+		result = append(result, item)
+	}
+	return result
+}
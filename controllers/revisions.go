@@ -0,0 +1,552 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Product revision states. A pending revision is superseded when its
+// author edits the product again before it was reviewed.
+const (
+	RevisionPending    = "pending"
+	RevisionApproved   = "approved"
+	RevisionRejected   = "rejected"
+	RevisionSuperseded = "superseded"
+)
+
+// revisionFields are the bson fields of a product that go through review.
+var revisionFields = []string{"product_name", "sku", "price", "image", "discription", "category", "agegroup", "gender", "pricerange", "attributes", "variant"}
+
+var errNoChanges = errors.New("the revision does not change the product")
+
+// revisionDoc returns the reviewed fields of product.
+func revisionDoc(product *models.Product) (bson.M, error) {
+	doc, err := toAuditDoc(product)
+	if err != nil {
+		return nil, err
+	}
+	picked := bson.M{}
+	for _, field := range revisionFields {
+		picked[field] = doc[field]
+	}
+	return picked, nil
+}
+
+// changedFields lists the reviewed fields that differ between two versions
+// of a product.
+func changedFields(from, to *models.Product) ([]string, error) {
+	old, err := revisionDoc(from)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := revisionDoc(to)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{}
+	for _, field := range revisionFields {
+		if !reflect.DeepEqual(old[field], updated[field]) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// overlay returns product with fields taken from source.
+func overlay(product, source *models.Product, fields []string) (*models.Product, error) {
+	doc, err := toAuditDoc(product)
+	if err != nil {
+		return nil, err
+	}
+	from, err := toAuditDoc(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		doc[field] = from[field]
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var result models.Product
+	err = bson.Unmarshal(data, &result)
+	return &result, err
+}
+
+// revisionDraft returns the version of product a new edit starts from: the
+// live product with the changes of the pending revision, if there is one.
+func (app *Application) revisionDraft(ctx context.Context, product *models.Product) (*models.Product, *models.ProductRevision, error) {
+	pending, err := app.repos.ProductRevisions.Find(ctx, repository.ProductRevisionFilter{
+		ProductID: product.Product_ID,
+		Status:    RevisionPending,
+	}, repository.Page{Sort: "-created_at", Limit: 1})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pending) == 0 {
+		draft := *product
+		return &draft, nil, nil
+	}
+	draft, err := overlay(product, &pending[0].Proposed, pending[0].Fields)
+	return draft, &pending[0], err
+}
+
+// submitRevision stores proposed as a pending revision of live by author,
+// replacing the pending revision the draft was built on.
+func (app *Application) submitRevision(ctx context.Context, author, role string, live, proposed *models.Product, pending *models.ProductRevision) (*models.ProductRevision, error) {
+	fields, err := changedFields(live, proposed)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errNoChanges
+	}
+	count, err := app.repos.ProductRevisions.Count(ctx, repository.ProductRevisionFilter{ProductID: live.Product_ID})
+	if err != nil {
+		return nil, err
+	}
+
+	revision := models.ProductRevision{
+		ID:          primitive.NewObjectID(),
+		Product_id:  live.Product_ID,
+		Number:      int(count) + 1,
+		Author:      author,
+		Author_role: role,
+		Status:      RevisionPending,
+		Fields:      fields,
+		Base:        *live,
+		Proposed:    *proposed,
+		Created_at:  time.Now(),
+	}
+	if err := app.repos.ProductRevisions.Insert(ctx, &revision); err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		err := app.repos.ProductRevisions.Resolve(ctx, pending.ID, RevisionPending, repository.Update{Set: repository.Fields{
+			"status":      RevisionSuperseded,
+			"review_note": "replaced by revision " + strconv.Itoa(revision.Number),
+		}})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Println("revisions: superseding", pending.ID.Hex(), err)
+		}
+	}
+	return &revision, nil
+}
+
+// applyRevision writes the fields of a revision to the live product and
+// returns the product before and after.
+func (app *Application) applyRevision(ctx context.Context, productID primitive.ObjectID, source *models.Product, fields []string) (*models.Product, *models.Product, error) {
+	live, err := app.repos.Products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := revisionDoc(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	set := repository.Fields{"updated_at": time.Now()}
+	for _, field := range fields {
+		set[field] = doc[field]
+	}
+	if err := app.repos.Products.Update(ctx, productID, repository.Update{Set: set}); err != nil {
+		return nil, nil, err
+	}
+	result, err := app.repos.Products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return live, result, nil
+}
+
+// revisionChanges is the field level diff a revision makes: against the
+// live product while it is pending, as it was applied otherwise.
+func (app *Application) revisionChanges(ctx context.Context, revision *models.ProductRevision) ([]models.AuditChange, error) {
+	if revision.Previous != nil && revision.Result != nil {
+		before, err := revisionDoc(revision.Previous)
+		if err != nil {
+			return nil, err
+		}
+		after, err := revisionDoc(revision.Result)
+		if err != nil {
+			return nil, err
+		}
+		return auditChanges(before, after)
+	}
+
+	live, err := app.repos.Products.FindByID(ctx, revision.Product_id)
+	if errors.Is(err, repository.ErrNotFound) {
+		live = &revision.Base
+	} else if err != nil {
+		return nil, err
+	}
+	proposed, err := overlay(live, &revision.Proposed, revision.Fields)
+	if err != nil {
+		return nil, err
+	}
+	before, err := revisionDoc(live)
+	if err != nil {
+		return nil, err
+	}
+	after, err := revisionDoc(proposed)
+	if err != nil {
+		return nil, err
+	}
+	return auditChanges(before, after)
+}
+
+// AuditRevisionProduct locates the product of the revision in the :id
+// parameter, to audit what approving or rolling back does to it.
+func (app *Application) AuditRevisionProduct() AuditLocator {
+	return func(c *gin.Context) string {
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			return ""
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		revision, err := app.repos.ProductRevisions.FindByID(ctx, id)
+		if err != nil {
+			return ""
+		}
+		return revision.Product_id.Hex()
+	}
+}
+
+func (app *Application) loadRevision(ctx context.Context, c *gin.Context) (*models.ProductRevision, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid revision id"})
+		return nil, false
+	}
+	revision, err := app.repos.ProductRevisions.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "revision not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return nil, false
+	}
+	return revision, true
+}
+
+// revisionSummary leaves the product snapshots out of revision lists.
+func revisionSummary(revision models.ProductRevision) gin.H {
+	return gin.H{
+		"id":          revision.ID,
+		"product_id":  revision.Product_id,
+		"number":      revision.Number,
+		"author":      revision.Author,
+		"author_role": revision.Author_role,
+		"status":      revision.Status,
+		"fields":      revision.Fields,
+		"rollback_of": revision.Rollback_of,
+		"review_note": revision.Review_note,
+		"reviewed_by": revision.Reviewed_by,
+		"reviewed_at": revision.Reviewed_at,
+		"created_at":  revision.Created_at,
+	}
+}
+
+func (app *Application) listRevisions(ctx context.Context, c *gin.Context, filter repository.ProductRevisionFilter) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	pageNo, err := strconv.Atoi(c.Query("page"))
+	if err != nil || pageNo <= 0 {
+		pageNo = 1
+	}
+	page := repository.Page{Sort: "-created_at", Skip: int64((pageNo - 1) * limit), Limit: int64(limit)}
+
+	revisions, err := app.repos.ProductRevisions.Find(ctx, filter, page)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return
+	}
+	count, err := app.repos.ProductRevisions.Count(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return
+	}
+	summaries := make([]gin.H, 0, len(revisions))
+	for _, revision := range revisions {
+		summaries = append(summaries, revisionSummary(revision))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": summaries,
+		"page":      pageNo,
+		"limit":     limit,
+		"total":     count,
+		"hasMore":   count > int64(pageNo*limit),
+	})
+}
+
+// GetProductRevisions lists revisions, newest first, filtered on status
+// and product_id. It is the review queue with status=pending.
+func (app *Application) GetProductRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		filter := repository.ProductRevisionFilter{Status: c.Query("status")}
+		if productID := c.Query("product_id"); productID != "" {
+			id, err := primitive.ObjectIDFromHex(productID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid product id"})
+				return
+			}
+			filter.ProductID = id
+		}
+		app.listRevisions(ctx, c, filter)
+	}
+}
+
+// GetSellerProductRevisions lists the revision history of one of the
+// calling seller's products.
+func (app *Application) GetSellerProductRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		product, ok := app.variantProduct(ctx, c)
+		if !ok {
+			return
+		}
+		app.listRevisions(ctx, c, repository.ProductRevisionFilter{ProductID: product.Product_ID, Status: c.Query("status")})
+	}
+}
+
+// GetProductRevision returns a revision with its field level diff.
+func (app *Application) GetProductRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		revision, ok := app.loadRevision(ctx, c)
+		if !ok {
+			return
+		}
+		changes, err := app.revisionChanges(ctx, revision)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"revision": revision, "changes": changes})
+	}
+}
+
+// ApproveProductRevision puts a pending revision live.
+func (app *Application) ApproveProductRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		revision, ok := app.loadRevision(ctx, c)
+		if !ok {
+			return
+		}
+		if revision.Status != RevisionPending {
+			c.JSON(http.StatusConflict, gin.H{"Error": "revision is " + revision.Status})
+			return
+		}
+
+		// Claim the revision first so it cannot be applied twice.
+		err := app.repos.ProductRevisions.Resolve(ctx, revision.ID, RevisionPending, repository.Update{Set: repository.Fields{
+			"status":      RevisionApproved,
+			"review_note": c.PostForm("note"),
+			"reviewed_by": c.GetString("uid"),
+			"reviewed_at": time.Now(),
+		}})
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusConflict, gin.H{"Error": "revision was reviewed already"})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		previous, result, err := app.applyRevision(ctx, revision.Product_id, &revision.Proposed, revision.Fields)
+		if err != nil {
+			log.Println("revisions: applying", revision.ID.Hex(), err)
+			// Hand the revision back to the queue.
+			if err := app.repos.ProductRevisions.Resolve(ctx, revision.ID, RevisionApproved, repository.Update{Set: repository.Fields{"status": RevisionPending}}); err != nil {
+				log.Println("revisions: reopening", revision.ID.Hex(), err)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "could not update the product"})
+			return
+		}
+		err = app.repos.ProductRevisions.Resolve(ctx, revision.ID, RevisionApproved, repository.Update{Set: repository.Fields{
+			"previous": previous,
+			"result":   result,
+		}})
+		if err != nil {
+			log.Println("revisions: recording", revision.ID.Hex(), err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "revision approved", "product": result})
+	}
+}
+
+// RejectProductRevision turns a pending revision down with the
+// rejection_note form value.
+func (app *Application) RejectProductRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		revision, ok := app.loadRevision(ctx, c)
+		if !ok {
+			return
+		}
+		err := app.repos.ProductRevisions.Resolve(ctx, revision.ID, RevisionPending, repository.Update{Set: repository.Fields{
+			"status":      RevisionRejected,
+			"review_note": c.PostForm("rejection_note"),
+			"reviewed_by": c.GetString("uid"),
+			"reviewed_at": time.Now(),
+		}})
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusConflict, gin.H{"Error": "revision is " + revision.Status})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "revision rejected"})
+	}
+}
+
+// rollbackConflicts lists the fields of revision that changed again after
+// it went live, by a later revision or by a direct edit. Rolling those
+// back would throw the later change away.
+func (app *Application) rollbackConflicts(ctx context.Context, revision *models.ProductRevision, live *models.Product) ([]string, error) {
+	touched := map[string]bool{}
+	approved, err := app.repos.ProductRevisions.Find(ctx, repository.ProductRevisionFilter{
+		ProductID: revision.Product_id,
+		Status:    RevisionApproved,
+	}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	for _, later := range approved {
+		if later.Number <= revision.Number {
+			continue
+		}
+		for _, field := range later.Fields {
+			touched[field] = true
+		}
+	}
+	if revision.Result != nil {
+		changed, err := changedFields(revision.Result, live)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range changed {
+			touched[field] = true
+		}
+	}
+	conflicts := []string{}
+	for _, field := range revision.Fields {
+		if touched[field] {
+			conflicts = append(conflicts, field)
+		}
+	}
+	return conflicts, nil
+}
+
+// RollbackProductRevision undoes an approved revision: the fields it
+// changed go back to what they were before it went live. It answers 409
+// when one of them changed again since. The rollback is recorded as a
+// revision of its own.
+func (app *Application) RollbackProductRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		revision, ok := app.loadRevision(ctx, c)
+		if !ok {
+			return
+		}
+		if revision.Status != RevisionApproved || revision.Previous == nil {
+			c.JSON(http.StatusConflict, gin.H{"Error": "only approved revisions can be rolled back"})
+			return
+		}
+		live, err := app.repos.Products.FindByID(ctx, revision.Product_id)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "product not found"})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		conflicts, err := app.rollbackConflicts(ctx, revision, live)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if len(conflicts) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"Error": "the product was changed since, roll back the later changes first", "conflicts": conflicts})
+			return
+		}
+		target, err := overlay(live, revision.Previous, revision.Fields)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		rollback, err := app.submitRevision(ctx, c.GetString("uid"), c.GetString("role"), live, target, nil)
+		if errors.Is(err, errNoChanges) {
+			c.JSON(http.StatusConflict, gin.H{"Error": "the product is already at that version"})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		previous, result, err := app.applyRevision(ctx, live.Product_ID, target, rollback.Fields)
+		if err != nil {
+			log.Println("revisions: rolling back", revision.ID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "could not update the product"})
+			return
+		}
+		err = app.repos.ProductRevisions.Resolve(ctx, rollback.ID, RevisionPending, repository.Update{Set: repository.Fields{
+			"status":      RevisionApproved,
+			"rollback_of": revision.ID,
+			"review_note": c.PostForm("note"),
+			"reviewed_by": c.GetString("uid"),
+			"reviewed_at": time.Now(),
+			"previous":    previous,
+			"result":      result,
+		}})
+		if err != nil {
+			log.Println("revisions: recording rollback", rollback.ID.Hex(), err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "revision rolled back", "product": result})
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRollbackRevertsOnlyItsFields(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	sellerID := primitive.NewObjectID().Hex()
+	product := models.Product{
+		Product_ID:       primitive.NewObjectID(),
+		Product_Name:     "Shirt",
+		SKU:              "SHIRT",
+		Category:         "apparel",
+		Discription:      "A shirt",
+		Approved:         true,
+		SellerRegistered: []string{sellerID},
+	}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("uid", primitive.NewObjectID().Hex())
+		c.Set("role", utils.Admin)
+	})
+	router.PUT("/revisions/:id/approve", app.ApproveProductRevision())
+	router.PUT("/revisions/:id/rollback", app.RollbackProductRevision())
+
+	// edit submits and approves a revision changing the product with edit.
+	edit := func(change func(p *models.Product)) *models.ProductRevision {
+		t.Helper()
+		live, err := app.repos.Products.FindByID(ctx, product.Product_ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proposed := *live
+		change(&proposed)
+		revision, err := app.submitRevision(ctx, sellerID, utils.Seller, live, &proposed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := serve(router, http.MethodPut, "/revisions/"+revision.ID.Hex()+"/approve", "", nil); w.Code != http.StatusOK {
+			t.Fatalf("approve: %d %s", w.Code, w.Body)
+		}
+		return revision
+	}
+	rename := edit(func(p *models.Product) { p.Product_Name = "Red Shirt" })
+	describe := edit(func(p *models.Product) { p.Discription = "A red shirt" })
+
+	if w := serve(router, http.MethodPut, "/revisions/"+rename.ID.Hex()+"/rollback", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("rollback: %d %s", w.Code, w.Body)
+	}
+	live, err := app.repos.Products.FindByID(ctx, product.Product_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Product_Name != "Shirt" {
+		t.Errorf("name %q, want it rolled back", live.Product_Name)
+	}
+	if live.Discription != "A red shirt" {
+		t.Errorf("description %q, the later revision was lost", live.Discription)
+	}
+
+	// The description changed again since describe went live.
+	edit(func(p *models.Product) { p.Discription = "A bright red shirt" })
+	if w := serve(router, http.MethodPut, "/revisions/"+describe.ID.Hex()+"/rollback", "", nil); w.Code != http.StatusConflict {
+		t.Fatalf("rollback over a later change: %d %s", w.Code, w.Body)
+	}
+	live, err = app.repos.Products.FindByID(ctx, product.Product_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Discription != "A bright red shirt" {
+		t.Errorf("description %q after a refused rollback", live.Discription)
+	}
+}
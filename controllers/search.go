@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/inventory"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bindPriceBounds reads the min_price and max_price query parameters into
+// the filter.
+func bindPriceBounds(c *gin.Context, filter *repository.ProductFilter) error {
+	for param, bound := range map[string]**int64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if value := c.Query(param); value != "" {
+			price, err := money.Parse(value, money.DefaultCurrency)
+			if err != nil {
+				return errors.New(param + " is not a valid amount")
+			}
+			*bound = &price.Amount
+		}
+	}
+	return nil
+}
+
+// searchHit is a product found by SearchProduct.
+type searchHit struct {
+	models.Product
+	Availability inventory.Summary `json:"availability"`
+	Score        float64           `json:"score"`
+	// Popularity is set when sorting by it, see popularity.Total.
+	Popularity float64 `json:"popularity,omitempty"`
+	// Highlights holds the matched fields with the matched words in <em>
+	// tags, see search.Query.Highlights.
+	Highlights map[string]string `json:"highlights"`
+}
+
+// searchSorts orders hits for the sort query parameter, relevance being the
+// default. Ties keep the order of relevance.
+var searchSorts = map[string]func(a, b *searchHit) bool{
+	"relevance": func(a, b *searchHit) bool { return a.Score > b.Score },
+	"price":     func(a, b *searchHit) bool { return a.Price.Amount < b.Price.Amount },
+	"-price":    func(a, b *searchHit) bool { return a.Price.Amount > b.Price.Amount },
+	"newest":    func(a, b *searchHit) bool { return a.Created_at.After(b.Created_at) },
+	"popular":   func(a, b *searchHit) bool { return a.Popularity > b.Popularity },
+}
+
+// attributeTypes returns the attribute types, none when the application
+// runs without a database.
+func (app *Application) attributeTypes(ctx context.Context) ([]models.AttributeType, error) {
+	if app.attributesCollection == nil {
+		return nil, nil
+	}
+	cursor, err := app.attributesCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var attributes []models.AttributeType
+	if err := cursor.All(ctx, &attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// attributeFacet is the facet of one attribute type. Param is the query
+// parameter that selects its values.
+type attributeFacet struct {
+	ID     string              `json:"id"`
+	Code   string              `json:"code"`
+	Name   string              `json:"name"`
+	Param  string              `json:"param"`
+	Values []search.FacetValue `json:"values"`
+}
+
+// facetSelection reads the multi-select facet parameters: category, price
+// (a bucket such as 500-1000), agegroup, gender and attr.<attribute>, the
+// attribute given by its code, name or ID. Parameters can be repeated.
+func facetSelection(c *gin.Context, attributes []models.AttributeType) (search.Selection, error) {
+	selection := search.Selection{}
+	for param, name := range map[string]string{
+		"category": search.CategoryFacet,
+		"price":    search.PriceFacet,
+		"agegroup": search.AgeGroupFacet,
+		"gender":   search.GenderFacet,
+	} {
+		if values := c.QueryArray(param); len(values) > 0 {
+			selection[name] = values
+		}
+	}
+	for param, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(param, "attr.") {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(param, "attr."))
+		id := ""
+		for _, attribute := range attributes {
+			if key == strings.ToLower(attribute.Attribute_Code) || key == strings.ToLower(attribute.Attribute_Name) || key == attribute.ID.Hex() {
+				id = attribute.ID.Hex()
+				break
+			}
+		}
+		if id == "" {
+			if _, err := primitive.ObjectIDFromHex(key); err != nil {
+				return nil, fmt.Errorf("unknown attribute %q", strings.TrimPrefix(param, "attr."))
+			}
+			id = key
+		}
+		selection[search.AttributeFacet+id] = append(selection[search.AttributeFacet+id], values...)
+	}
+	return selection, nil
+}
+
+// facetsView lays the facets out for the filter sidebar, attributes sorted
+// by name.
+func facetsView(facets search.Facets, attributes []models.AttributeType) gin.H {
+	byID := map[string]models.AttributeType{}
+	for _, attribute := range attributes {
+		byID[attribute.ID.Hex()] = attribute
+	}
+	attributeFacets := []attributeFacet{}
+	for name, values := range facets {
+		if !strings.HasPrefix(name, search.AttributeFacet) {
+			continue
+		}
+		id := strings.TrimPrefix(name, search.AttributeFacet)
+		facet := attributeFacet{ID: id, Name: id, Param: "attr." + id, Values: values}
+		if attribute, ok := byID[id]; ok {
+			facet.Code, facet.Name = attribute.Attribute_Code, attribute.Attribute_Name
+			if attribute.Attribute_Code != "" {
+				facet.Param = "attr." + attribute.Attribute_Code
+			}
+		}
+		attributeFacets = append(attributeFacets, facet)
+	}
+	sort.Slice(attributeFacets, func(i, j int) bool { return attributeFacets[i].Name < attributeFacets[j].Name })
+	return gin.H{
+		"categories": facets[search.CategoryFacet],
+		"prices":     facets[search.PriceFacet],
+		"agegroups":  facets[search.AgeGroupFacet],
+		"genders":    facets[search.GenderFacet],
+		"attributes": attributeFacets,
+	}
+}
+
+// SearchProduct ranks the approved products against the words of the
+// "query" parameter, see package search. Stopwords and synonyms can be
+// turned off for one query with stopwords=off and synonyms=off. Without a
+// query the newest products are listed. Results can be narrowed by
+// min_price, max_price and the facets of facetSelection, the response
+// counts the facets of the products found. Every product listed counts as
+// an impression, see package popularity.
+//
+// Only the first Search.MaxCandidates products the database finds, the
+// best text matches or the newest, are ranked. When more were found the
+// response is "capped": its total and facets count the ranked products
+// only, and the rest can't be paged to.
+func (app *Application) SearchProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		query := app.search.Parse(c.Query("query"), search.Options{
+			KeepStopwords: c.Query("stopwords") == "off",
+			NoSynonyms:    c.Query("synonyms") == "off",
+		})
+
+		filter := repository.ProductFilter{
+			Approved: repository.Bool(true),
+			Archived: repository.Bool(false),
+		}
+		if err := bindPriceBounds(c, &filter); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		attributes, err := app.attributeTypes(ctx)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		selection, err := facetSelection(c, attributes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+
+		sortBy := c.DefaultQuery("sort", "relevance")
+		less, ok := searchSorts[sortBy]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "sort must be one of relevance, price, -price, newest, popular"})
+			return
+		}
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		pageNo, err := strconv.Atoi(c.Query("page"))
+		if err != nil || pageNo <= 0 {
+			pageNo = 1
+		}
+
+		// One more than ranked is read to tell whether any were left out.
+		maxCandidates := app.config.Search.MaxCandidates
+		var candidates []models.Product
+		if query.Empty() {
+			candidates, err = app.repos.Products.Find(ctx, filter, repository.Page{Sort: "-updated_at", Limit: int64(maxCandidates + 1)})
+		} else {
+			candidates, err = app.repos.Products.TextSearch(ctx, query.Words(), filter, int64(maxCandidates+1))
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		capped := len(candidates) > maxCandidates
+		if capped {
+			candidates = candidates[:maxCandidates]
+		}
+		matched := make([]models.Product, 0, len(candidates))
+		var scores []float64
+		for i := range candidates {
+			score, ok := query.Score(search.Document(&candidates[i]))
+			if ok || query.Empty() {
+				matched = append(matched, candidates[i])
+				scores = append(scores, score)
+			}
+		}
+		kept, facets := app.search.Facet(matched, selection)
+		hits := make([]searchHit, len(kept))
+		for i, k := range kept {
+			hits[i] = searchHit{Product: matched[k], Score: scores[k]}
+			if !query.Empty() {
+				hits[i].Highlights = query.Highlights(search.Document(&matched[k]))
+			}
+		}
+		if sortBy == "popular" && len(hits) > 0 {
+			keys := make([]string, len(hits))
+			for i := range hits {
+				keys[i] = hits[i].Product_ID.Hex()
+			}
+			totals, err := app.popularityTotals(ctx, models.PopularityProduct, keys, app.popularityWindow())
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+				return
+			}
+			scores := make(map[string]float64, len(totals))
+			for _, total := range totals {
+				scores[total.Key] = total.Score
+			}
+			for i := range hits {
+				hits[i].Popularity = scores[hits[i].Product_ID.Hex()]
+			}
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return searchSorts["relevance"](&hits[i], &hits[j]) })
+		if sortBy != "relevance" {
+			sort.SliceStable(hits, func(i, j int) bool { return less(&hits[i], &hits[j]) })
+		}
+
+		total := len(hits)
+		from, to := (pageNo-1)*limit, pageNo*limit
+		if from > total {
+			from = total
+		}
+		if to > total {
+			to = total
+		}
+		hits = hits[from:to]
+
+		productIDs := make([]primitive.ObjectID, len(hits))
+		for i := range hits {
+			productIDs[i] = hits[i].Product_ID
+		}
+		availability, err := app.productAvailability(ctx, productIDs)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		var images signBatch
+		visitor := eventVisitor(c)
+		for i := range hits {
+			app.events.Record(models.Event{
+				Type:       models.EventImpression,
+				User_id:    c.GetString("uid"),
+				Visitor:    visitor,
+				Product_id: hits[i].Product_ID.Hex(),
+				Query:      eventQuery(c.Query("query")),
+				Position:   from + i + 1,
+			})
+			hits[i].Availability = inventory.Summarize(nil)
+			if summary, ok := availability[hits[i].Product_ID]; ok {
+				hits[i].Availability = summary
+			}
+			images.addImages(hits[i].Image)
+		}
+		if err := app.sign(&images); err != nil {
+			log.Println("Error generating pre-signed URL for image:", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"products": hits,
+			"query":    query,
+			"facets":   facetsView(facets, attributes),
+			"sort":     sortBy,
+			"page":     pageNo,
+			"limit":    limit,
+			"total":    total,
+			"hasMore":  total > to,
+			"capped":   capped,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSearchReportsACappedTotal(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	for _, name := range []string{"Red Mug", "Blue Mug", "Green Mug"} {
+		product := models.Product{Product_ID: primitive.NewObjectID(), Product_Name: name, Approved: true}
+		if err := app.repos.Products.Insert(ctx, &product); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := gin.New()
+	router.GET("/search", app.SearchProduct())
+
+	tests := []struct {
+		maxCandidates int
+		total         int
+		capped        bool
+	}{
+		{2, 2, true},
+		{3, 3, false},
+	}
+	for _, tt := range tests {
+		app.config.Search.MaxCandidates = tt.maxCandidates
+		for _, query := range []string{"mug", ""} {
+			w := serve(router, http.MethodGet, "/search?limit=1&query="+query, "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%d %s", w.Code, w.Body)
+			}
+			var body struct {
+				Total   int
+				HasMore bool
+				Capped  bool
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Total != tt.total || body.Capped != tt.capped || !body.HasMore {
+				t.Errorf("%d candidates, query %q: %+v", tt.maxCandidates, query, body)
+			}
+		}
+	}
+}
@@ -14,33 +14,30 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/otp"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	"github.com/kravi0/BizGrowth-backend/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var SellerCollection *mongo.Collection = database.ProductData(database.Client, "seller")
-var ProductReference *mongo.Collection = database.ProductData(database.Client, "ProductReference")
-
 //var  *mongo.Collection = database.ProductData(database.Client, "seller")
 
 // get all seller if no id is passesed all details if id id passed it will return sepcific seller
-func GetSeller() gin.HandlerFunc {
+func (app *Application) GetSeller() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		// Check if the user is a seller
-		if checkSeller(ctx, c) {
-			var sellerDetail models.Seller
+		if app.checkSeller(ctx, c) {
 			uid, _ := c.Get("uid")
 			uids := fmt.Sprintf("%v", uid)
 			sellerID, _ := primitive.ObjectIDFromHex(string(uids))
-			filter := primitive.M{"_id": sellerID}
-			if err := SellerCollection.FindOne(ctx, filter).Decode(&sellerDetail); err != nil {
+			sellerDetail, err := app.repos.Sellers.FindByID(ctx, sellerID)
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seller details"})
 				return
 			}
@@ -49,7 +46,7 @@ func GetSeller() gin.HandlerFunc {
 		}
 
 		// Check if the user is an admin
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			return
 		}
@@ -58,20 +55,20 @@ func GetSeller() gin.HandlerFunc {
 		sellerID := c.Query("sellerId")
 		if sellerID != "" {
 			// Fetch details of the specific seller
-			var sellerDetail models.Seller
 			sellerObjID, err := primitive.ObjectIDFromHex(sellerID)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
 				return
 			}
-			filter := primitive.M{"_id": sellerObjID, "user_type": utils.Seller}
-			if err := SellerCollection.FindOne(ctx, filter).Decode(&sellerDetail); err != nil {
+			filter := repository.SellerFilter{ID: sellerObjID, UserType: utils.Seller}
+			sellerDetail, err := app.repos.Sellers.FindOne(ctx, filter)
+			if err != nil {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Seller not found"})
 				return
 			}
 
 			if sellerDetail.CompanyDetail.PANImage != "" {
-				panPresignURL, err := getPresignURL(sellerDetail.CompanyDetail.PANImage)
+				panPresignURL, err := app.getPresignURL(sellerDetail.CompanyDetail.PANImage)
 				if err != nil {
 					log.Println(err)
 				}
@@ -79,7 +76,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.CompanyDetail.GSTINDoc != "" {
-				gstPresignURL, err := getPresignURL(sellerDetail.CompanyDetail.GSTINDoc)
+				gstPresignURL, err := app.getPresignURL(sellerDetail.CompanyDetail.GSTINDoc)
 				if err != nil {
 					log.Println(err)
 				}
@@ -87,7 +84,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.CompanyDetail.ProfilePicture != "" {
-				profilePresignURL, err := getPresignURL(sellerDetail.CompanyDetail.ProfilePicture)
+				profilePresignURL, err := app.getPresignURL(sellerDetail.CompanyDetail.ProfilePicture)
 				if err != nil {
 					log.Println(err)
 				}
@@ -95,7 +92,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.CompanyDetail.CINDoc != "" {
-				ciPresignURL, err := getPresignURL(sellerDetail.CompanyDetail.CINDoc)
+				ciPresignURL, err := app.getPresignURL(sellerDetail.CompanyDetail.CINDoc)
 				if err != nil {
 					log.Println(err)
 				}
@@ -103,7 +100,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.CompanyDetail.LLPINDoc != "" {
-				llpPresignURL, err := getPresignURL(sellerDetail.CompanyDetail.LLPINDoc)
+				llpPresignURL, err := app.getPresignURL(sellerDetail.CompanyDetail.LLPINDoc)
 				if err != nil {
 					log.Println(err)
 				}
@@ -111,7 +108,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.OwnerDetail.AadharDocument != "" {
-				aadharPresignURL, err := getPresignURL(sellerDetail.OwnerDetail.AadharDocument)
+				aadharPresignURL, err := app.getPresignURL(sellerDetail.OwnerDetail.AadharDocument)
 				if err != nil {
 					log.Println(err)
 				}
@@ -119,7 +116,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.OwnerDetail.PanDocument != "" {
-				panPresignURL, err := getPresignURL(sellerDetail.OwnerDetail.PanDocument)
+				panPresignURL, err := app.getPresignURL(sellerDetail.OwnerDetail.PanDocument)
 				if err != nil {
 					log.Println(err)
 				}
@@ -127,7 +124,7 @@ func GetSeller() gin.HandlerFunc {
 			}
 
 			if sellerDetail.OwnerDetail.PassportDocument != "" {
-				passportPresignURL, err := getPresignURL(sellerDetail.OwnerDetail.PassportDocument)
+				passportPresignURL, err := app.getPresignURL(sellerDetail.OwnerDetail.PassportDocument)
 				if err != nil {
 					log.Println(err)
 				}
@@ -139,18 +136,12 @@ func GetSeller() gin.HandlerFunc {
 		}
 
 		// Fetch details of all sellers
-		var sellerDetails []models.Seller
-		cur, err := SellerCollection.Find(ctx, bson.M{"user_type": utils.Seller})
+		sellerDetails, err := app.repos.Sellers.Find(ctx, repository.SellerFilter{UserType: utils.Seller}, repository.Page{})
 		if err != nil {
 			log.Println(err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to find sellers"})
 			return
 		}
-		if err := cur.All(ctx, &sellerDetails); err != nil {
-			log.Println(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seller details"})
-			return
-		}
 
 		c.JSON(http.StatusOK, sellerDetails)
 
@@ -166,11 +157,10 @@ func GetSeller() gin.HandlerFunc {
 // }
 
 // toggle consentToAdmin value
-func ToggleConsentToAdmin() gin.HandlerFunc {
+func (app *Application) ToggleConsentToAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		var seller models.Seller
 		sellerID, exists := c.Get("uid")
 		if !exists {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Seller ID not found in context"})
@@ -184,8 +174,7 @@ func ToggleConsentToAdmin() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.D{primitive.E{Key: "_id", Value: sellerObjID}}
-		err = SellerCollection.FindOne(ctx, filter).Decode(&seller)
+		seller, err := app.repos.Sellers.FindByID(ctx, sellerObjID)
 		if err != nil {
 			fmt.Print(err)
 			c.IndentedJSON(500, "Internal server error")
@@ -197,8 +186,8 @@ func ToggleConsentToAdmin() gin.HandlerFunc {
 			seller.ConsentToAdmin = true
 		}
 
-		update := bson.M{"$set": bson.M{"consentToAdmin": seller.ConsentToAdmin}}
-		_, err = SellerCollection.UpdateOne(ctx, filter, update)
+		update := repository.Update{Set: repository.Fields{"consentToAdmin": seller.ConsentToAdmin}}
+		err = app.repos.Sellers.Update(ctx, sellerObjID, update)
 		if err != nil {
 			fmt.Print(err)
 			c.IndentedJSON(500, "Internal server error")
@@ -211,7 +200,7 @@ func ToggleConsentToAdmin() gin.HandlerFunc {
 }
 
 // delete specific seller
-func DeleteSeller() gin.HandlerFunc {
+func (app *Application) DeleteSeller() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -226,9 +215,8 @@ func DeleteSeller() gin.HandlerFunc {
 		if err != nil {
 			c.IndentedJSON(500, "Internal server error")
 		}
-		filter := bson.D{primitive.E{Key: "_id", Value: sellerID}}
-		_, err = SellerCollection.DeleteOne(ctx, filter)
-		if err != nil {
+		err = app.repos.Sellers.Delete(ctx, sellerID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "fail to delete"})
 			c.Abort()
@@ -240,14 +228,14 @@ func DeleteSeller() gin.HandlerFunc {
 	}
 }
 
-func AddProductReferenceHandler() gin.HandlerFunc {
+func (app *Application) AddProductReferenceHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input struct {
-			SellerID    string `json:"seller_id" binding:"required"`
-			ProductID   string `json:"product_id" binding:"required"`
-			Price       string `json:"price" binding:"required"`
-			MinQuantity int    `json:"min_quantity" binding:"required"`
-			MaxQuantity int    `json:"max_quantity" binding:"required"`
+			SellerID    string      `json:"seller_id" binding:"required"`
+			ProductID   string      `json:"product_id" binding:"required"`
+			Price       money.Money `json:"price" binding:"required"`
+			MinQuantity int         `json:"min_quantity" binding:"required"`
+			MaxQuantity int         `json:"max_quantity" binding:"required"`
 		}
 		ctx := context.Background()
 
@@ -257,6 +245,11 @@ func AddProductReferenceHandler() gin.HandlerFunc {
 			return
 		}
 
+		if input.Price.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price must be positive"})
+			return
+		}
+
 		sellerID, err := primitive.ObjectIDFromHex(input.SellerID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid seller ID"})
@@ -283,24 +276,23 @@ func AddProductReferenceHandler() gin.HandlerFunc {
 		}
 
 		// Insert product reference into the ProductReferenceCollection
-		_, err = ProductReference.InsertOne(ctx, productReference)
+		err = app.repos.ProductReferences.Insert(ctx, &productReference)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		// Update seller model with the product reference ID
-		update := bson.M{"$push": bson.M{"product_references": productReference.ID}}
-		_, err = SellerCollection.UpdateOne(ctx, bson.M{"_id": sellerID}, update)
-		if err != nil {
+		update := repository.Update{Push: repository.Fields{"product_references": []primitive.ObjectID{productReference.ID}}}
+		err = app.repos.Sellers.Update(ctx, sellerID, update)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		// Update product model with the product reference ID
-		updateProduct := bson.M{"$push": bson.M{"product_references": productReference.ID}}
-		_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": productID}, updateProduct)
-		if err != nil {
+		err = app.repos.Products.Update(ctx, productID, update)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -309,7 +301,7 @@ func AddProductReferenceHandler() gin.HandlerFunc {
 	}
 }
 
-func SellerUpdateProfilePictureHandler() gin.HandlerFunc {
+func (app *Application) SellerUpdateProfilePictureHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -329,8 +321,7 @@ func SellerUpdateProfilePictureHandler() gin.HandlerFunc {
 		}
 
 		// Query the database to get seller information
-		var seller models.Seller // Assuming Seller struct is defined in models package
-		err = SellerCollection.FindOne(context.Background(), bson.M{"_id": sellerObjID}).Decode(&seller)
+		_, err = app.repos.Sellers.FindByID(ctx, sellerObjID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Seller not found"})
 			return
@@ -356,16 +347,15 @@ func SellerUpdateProfilePictureHandler() gin.HandlerFunc {
 			return
 		}
 
-		profilePictureUrl, err := saveFile(profilePicture, profile_picture[0])
+		profilePictureUrl, err := app.saveFile(profilePicture, profile_picture[0], storage.SellerProfilePrefix(sellerObjID.Hex()))
 		if err != nil {
 			c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving profile picture: %s", err.Error()))
 			return
 		}
 		fmt.Println(profilePictureUrl)
 
-		filter := bson.M{"_id": sellerObjID}
-		update := bson.M{"$set": bson.M{"companydetail.profilepicture": profilePictureUrl}}
-		_, err = SellerCollection.UpdateOne(ctx, filter, update)
+		update := repository.Update{Set: repository.Fields{"companydetail.profilepicture": profilePictureUrl}}
+		err = app.repos.Sellers.Update(ctx, sellerObjID, update)
 		if err != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
@@ -378,7 +368,7 @@ func SellerUpdateProfilePictureHandler() gin.HandlerFunc {
 }
 
 // find all products for specifc seller stored in sellerRegistered array
-func GetAllProductsForASellerHandler() gin.HandlerFunc {
+func (app *Application) GetAllProductsForASellerHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -390,45 +380,21 @@ func GetAllProductsForASellerHandler() gin.HandlerFunc {
 		}
 		var sellerId = uid.(string)
 
-		var products []models.Product
-
-		cursor, err := ProductCollection.Find(ctx, bson.M{"sellerregistered": sellerId})
+		products, err := app.repos.Products.Find(ctx, repository.ProductFilter{SellerID: sellerId}, repository.Page{})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if err := app.presignProducts(products); err != nil {
 
-		err = cursor.All(ctx, &products)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
+
 		}
 		for i := 0; i < len(products); i++ {
 
-			if products[i].Image != nil {
-
-				for j := 0; j < len(products[i].Image); j++ {
-
-					imageUrl, err := getPresignURL(products[i].Image[j])
-
-					if err !=
-
-						nil {
-
-						c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-
-						return
-
-					}
-
-					products[i].Image[j] = imageUrl
-
-				}
-
-			}
-
 			if products[i].AddedBy == "" {
 
 				products[i].AddedBy = "seller"
@@ -442,7 +408,7 @@ func GetAllProductsForASellerHandler() gin.HandlerFunc {
 }
 
 // update owner details
-func UpdateOwnerDetails() gin.HandlerFunc {
+func (app *Application) UpdateOwnerDetails() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 60*time.Second)
@@ -459,9 +425,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 			return
 		}
 
-		var seller models.Seller
-
-		findErr := SellerCollection.FindOne(ctx, bson.M{"_id": sellerId}).Decode(&seller)
+		_, findErr := app.repos.Sellers.FindByID(ctx, sellerId)
 		if findErr != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
@@ -469,7 +433,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{}
+		update := repository.Fields{}
 
 		OwnerName := c.PostForm("name")
 		OwnerEmail := c.PostForm("email")
@@ -503,7 +467,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 				return
 			}
 			defer aadharDocFile.Close()
-			url, saveError := saveFile(aadharDocFile, aadharDoc[0])
+			url, saveError := app.saveFile(aadharDocFile, aadharDoc[0], storage.SellerKYCPrefix(sellerId.Hex()))
 			if saveError != nil {
 				c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving aadharDoc document"})
 				return
@@ -519,7 +483,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 				return
 			}
 			defer panDocFile.Close()
-			url, saveError := saveFile(panDocFile, panDoc[0])
+			url, saveError := app.saveFile(panDocFile, panDoc[0], storage.SellerKYCPrefix(sellerId.Hex()))
 			if saveError != nil {
 				c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving panDoc document"})
 				return
@@ -535,7 +499,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 				return
 			}
 			defer passportDocFile.Close()
-			url, saveError := saveFile(passportDocFile, passportDoc[0])
+			url, saveError := app.saveFile(passportDocFile, passportDoc[0], storage.SellerKYCPrefix(sellerId.Hex()))
 			if saveError != nil {
 				c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving passportDoc document"})
 				return
@@ -599,11 +563,9 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"_id": sellerId}
-
-		updateError := SellerCollection.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}).Decode(&seller)
+		updateError := app.repos.Sellers.Update(ctx, sellerId, repository.Update{Set: update})
 		if updateError != nil {
-			if errors.Is(updateError, mongo.ErrNoDocuments) {
+			if errors.Is(updateError, repository.ErrNotFound) {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "No seller found"})
 				return
 			}
@@ -617,7 +579,7 @@ func UpdateOwnerDetails() gin.HandlerFunc {
 	}
 }
 
-func UpdateSellerBusinessDetails() gin.HandlerFunc {
+func (app *Application) UpdateSellerBusinessDetails() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 60*time.Second)
@@ -634,9 +596,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 			return
 		}
 
-		var seller models.Seller
-
-		findErr := SellerCollection.FindOne(ctx, bson.M{"_id": sellerId}).Decode(&seller)
+		seller, findErr := app.repos.Sellers.FindByID(ctx, sellerId)
 		if findErr != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
@@ -671,7 +631,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 
 		//create update
 
-		update := bson.M{}
+		update := repository.Fields{}
 
 		if Company_Name != "" {
 			update["company_name"] = Company_Name
@@ -723,7 +683,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening PAN file: %s", err.Error()))
 				return
 			}
-			panFileUrl, err := saveFile(panHeader, panFile[0])
+			panFileUrl, err := app.saveFile(panHeader, panFile[0], storage.SellerKYCPrefix(sellerId.Hex()))
 			if err != nil {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving PAN file: %s", err.Error()))
 				return
@@ -737,7 +697,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening Aadhar file: %s", err.Error()))
 				return
 			}
-			gstinFileUrl, err := saveFile(gstinHeader, gstinFile[0])
+			gstinFileUrl, err := app.saveFile(gstinHeader, gstinFile[0], storage.SellerKYCPrefix(sellerId.Hex()))
 			if err != nil {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving GSTIN file: %s", err.Error()))
 				return
@@ -752,7 +712,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening Aadhar file: %s", err.Error()))
 				return
 			}
-			profile_pictureFileUrl, err := saveFile(profile_pictureHeader, profile_picture[0])
+			profile_pictureFileUrl, err := app.saveFile(profile_pictureHeader, profile_picture[0], storage.SellerProfilePrefix(sellerId.Hex()))
 			if err != nil {
 				c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving Aadhar file: %s", err.Error()))
 				return
@@ -770,7 +730,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 					c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening LLPIN file: %s", err.Error()))
 					return
 				}
-				LLPINFileUrl, err := saveFile(LLPINHeader, LLPINFile[0])
+				LLPINFileUrl, err := app.saveFile(LLPINHeader, LLPINFile[0], storage.SellerKYCPrefix(sellerId.Hex()))
 				if err != nil {
 					c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving PAN file: %s", err.Error()))
 					return
@@ -792,7 +752,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 					c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening CIN file: %s", err.Error()))
 					return
 				}
-				CINFileUrl, err := saveFile(CINHeader, CINFile[0])
+				CINFileUrl, err := app.saveFile(CINHeader, CINFile[0], storage.SellerKYCPrefix(sellerId.Hex()))
 				if err != nil {
 					c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving CIN file: %s", err.Error()))
 					return
@@ -814,11 +774,9 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 		update["updated_at"] = updated_at
 		update["approved"] = false
 
-		filter := bson.M{"_id": sellerId}
-
-		updateError := SellerCollection.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}).Decode(&seller)
+		updateError := app.repos.Sellers.Update(ctx, sellerId, repository.Update{Set: update})
 		if updateError != nil {
-			if errors.Is(updateError, mongo.ErrNoDocuments) {
+			if errors.Is(updateError, repository.ErrNotFound) {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "No seller found"})
 				return
 			}
@@ -833,7 +791,7 @@ func UpdateSellerBusinessDetails() gin.HandlerFunc {
 	}
 }
 
-func SellerPasswordConfirmation() gin.HandlerFunc {
+func (app *Application) SellerPasswordConfirmation() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -849,14 +807,10 @@ func SellerPasswordConfirmation() gin.HandlerFunc {
 			return
 		}
 
-		var seller models.Seller
-
 		password := c.PostForm("password")
 
-		filter := bson.M{"_id": sellerId}
-
 		//match id and password
-		err = SellerCollection.FindOne(ctx, filter).Decode(&seller)
+		seller, err := app.repos.Sellers.FindByID(ctx, sellerId)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "You're not authorized to perform this action"})
 			return
@@ -875,7 +829,7 @@ func SellerPasswordConfirmation() gin.HandlerFunc {
 	}
 }
 
-func UpdatePassword() gin.HandlerFunc {
+func (app *Application) UpdatePassword() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -891,24 +845,27 @@ func UpdatePassword() gin.HandlerFunc {
 			return
 		}
 
-		var seller models.Seller
-
 		password := c.PostForm("password")
-		hashPassword := HashPassword(password)
 		newPassword := c.PostForm("new_password")
 		newPasswordHash := HashPassword(newPassword)
 
-		filter := bson.M{"_id": sellerId, "password": hashPassword}
-		update := bson.M{"$set": bson.M{"password": newPasswordHash}}
-
 		//match id and password
-		err = SellerCollection.FindOneAndUpdate(ctx, filter, update).Decode(&seller)
-		if err == mongo.ErrNoDocuments {
+		seller, err := app.repos.Sellers.FindByID(ctx, sellerId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "You're not authorized to perform this action"})
+			return
+		}
+
+		if validPassword, _ := Verifypassword(password, seller.Password); !validPassword {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Incorrect Password"})
+			return
 		}
 
+		update := repository.Update{Set: repository.Fields{"password": newPasswordHash}}
+		err = app.repos.Sellers.Update(ctx, sellerId, update)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "You're not authorized to perform this action"})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
@@ -916,7 +873,7 @@ func UpdatePassword() gin.HandlerFunc {
 	}
 }
 
-func SellerOtpVerfication() gin.HandlerFunc {
+func (app *Application) SellerOtpVerfication() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -927,42 +884,40 @@ func SellerOtpVerfication() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		otp := c.PostForm("otp")
-		if otp == "" {
+		code := c.PostForm("otp")
+		if code == "" {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "otp can't be empty"})
 			c.Abort()
 			return
 		}
-		filter := primitive.M{utils.Mobileno: contactNo}
-		var seller models.Seller
-		err := SellerCollection.FindOne(ctx, filter).Decode(&seller)
+		seller, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: contactNo})
 
-		if err != nil && err != mongo.ErrNoDocuments {
+		if err != nil && err != repository.ErrNotFound {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
 			c.Abort()
 			return
 		}
-		if err == mongo.ErrNoDocuments {
+		if err == repository.ErrNotFound {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Mobile number doesn't exist"})
 			c.Abort()
 			return
 		}
 
-		if seller.OTP == otp {
-			SellerCollection.FindOneAndUpdate(ctx, filter, primitive.M{"otp": ""})
-			c.Header("content-type", "application/json")
-			c.JSON(http.StatusOK, gin.H{"success": "verified"})
-		} else {
+		if err := app.otpStore.Verify(ctx, otp.PurposeSellerLogin, seller.MobileNo, code); err != nil {
+			status, msg := otpErrorResponse(err)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid OTP"})
+			c.JSON(status, gin.H{"Error": msg})
+			return
 		}
+		c.Header("content-type", "application/json")
+		c.JSON(http.StatusOK, gin.H{"success": "verified"})
 	}
 }
 
-func DownloadSellerDocs() gin.HandlerFunc {
+func (app *Application) DownloadSellerDocs() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -976,7 +931,7 @@ func DownloadSellerDocs() gin.HandlerFunc {
 		var err error
 
 		// Check if the request is made by the seller or an admin
-		if checkSeller(ctx, c) {
+		if app.checkSeller(ctx, c) {
 			uid, exist := c.Get("uid")
 			if !exist {
 				c.JSON(http.StatusUnauthorized, gin.H{"Error": "You're not authorized to perform this action"})
@@ -988,7 +943,7 @@ func DownloadSellerDocs() gin.HandlerFunc {
 				return
 			}
 		} else {
-			if !checkAdmin(ctx, c) {
+			if !app.checkAdmin(ctx, c) {
 				c.JSON(http.StatusUnauthorized, gin.H{"Error": "You're not authorized to perform this action"})
 				return
 			}
@@ -1008,17 +963,13 @@ func DownloadSellerDocs() gin.HandlerFunc {
 
 		}
 
-		SellerDocDownload(c, sellerId, docType)
+		app.SellerDocDownload(c, sellerId, docType)
 
 	}
 }
 
-func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType string) {
-	var seller models.Seller
-
-	filter := bson.M{"_id": sellerId}
-
-	err := SellerCollection.FindOne(context.TODO(), filter).Decode(&seller)
+func (app *Application) SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType string) {
+	seller, err := app.repos.Sellers.FindByID(context.TODO(), sellerId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
@@ -1028,7 +979,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 	switch docType {
 	case "aadhar":
-		aadharFile, err := DownloadPDFFromS3(seller.OwnerDetail.AadharDocument)
+		aadharFile, err := app.DownloadPDFFromS3(seller.OwnerDetail.AadharDocument)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1037,7 +988,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		c.Data(http.StatusOK, contentType, aadharFile)
 
 	case "owner_pan":
-		ownerPanFile, err := DownloadPDFFromS3(seller.OwnerDetail.PanDocument)
+		ownerPanFile, err := app.DownloadPDFFromS3(seller.OwnerDetail.PanDocument)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1046,7 +997,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		c.Data(http.StatusOK, contentType, ownerPanFile)
 
 	case "company_pan":
-		companyPanFile, err := DownloadPDFFromS3(seller.CompanyDetail.PANImage)
+		companyPanFile, err := app.DownloadPDFFromS3(seller.CompanyDetail.PANImage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1055,7 +1006,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		c.Data(http.StatusOK, contentType, companyPanFile)
 
 	case "gstin":
-		companyGstFile, err := DownloadPDFFromS3(seller.CompanyDetail.GSTINDoc)
+		companyGstFile, err := app.DownloadPDFFromS3(seller.CompanyDetail.GSTINDoc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1064,7 +1015,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		c.Data(http.StatusOK, contentType, companyGstFile)
 
 	case "cin":
-		companyCinFile, err := DownloadPDFFromS3(seller.CompanyDetail.CINDoc)
+		companyCinFile, err := app.DownloadPDFFromS3(seller.CompanyDetail.CINDoc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1073,7 +1024,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		c.Data(http.StatusOK, contentType, companyCinFile)
 
 	case "llpin":
-		companyLlpinFile, err := DownloadPDFFromS3(seller.CompanyDetail.LLPINDoc)
+		companyLlpinFile, err := app.DownloadPDFFromS3(seller.CompanyDetail.LLPINDoc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -1090,7 +1041,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 			extension := GetExtension(GetContentType(seller.OwnerDetail.AadharDocument))
 
-			filesToZip["aadhar"+extension], err = DownloadPDFFromS3(seller.OwnerDetail.AadharDocument)
+			filesToZip["aadhar"+extension], err = app.DownloadPDFFromS3(seller.OwnerDetail.AadharDocument)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1099,7 +1050,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 		if seller.OwnerDetail.PanDocument != "" {
 			extension := GetExtension(GetContentType(seller.OwnerDetail.PanDocument))
-			filesToZip["owner_pan"+extension], err = DownloadPDFFromS3(seller.OwnerDetail.PanDocument)
+			filesToZip["owner_pan"+extension], err = app.DownloadPDFFromS3(seller.OwnerDetail.PanDocument)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1108,7 +1059,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 		if seller.CompanyDetail.PANImage != "" {
 			extension := GetExtension(GetContentType(seller.CompanyDetail.PANImage))
-			filesToZip["company_pan"+extension], err = DownloadPDFFromS3(seller.CompanyDetail.PANImage)
+			filesToZip["company_pan"+extension], err = app.DownloadPDFFromS3(seller.CompanyDetail.PANImage)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1117,7 +1068,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 		if seller.CompanyDetail.GSTINDoc != "" {
 			extension := GetExtension(GetContentType(seller.CompanyDetail.GSTINDoc))
-			filesToZip["gstin"+extension], err = DownloadPDFFromS3(seller.CompanyDetail.GSTINDoc)
+			filesToZip["gstin"+extension], err = app.DownloadPDFFromS3(seller.CompanyDetail.GSTINDoc)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1126,7 +1077,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 
 		if seller.CompanyDetail.CINDoc != "" {
 			extension := GetExtension(GetContentType(seller.CompanyDetail.CINDoc))
-			filesToZip["cin"+extension], err = DownloadPDFFromS3(seller.CompanyDetail.CINDoc)
+			filesToZip["cin"+extension], err = app.DownloadPDFFromS3(seller.CompanyDetail.CINDoc)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1136,7 +1087,7 @@ func SellerDocDownload(c *gin.Context, sellerId primitive.ObjectID, docType stri
 		if seller.CompanyDetail.LLPINDoc != "" {
 			extension := GetExtension(GetContentType(seller.CompanyDetail.LLPINDoc))
 
-			filesToZip["llpin"+extension], err = DownloadPDFFromS3(seller.CompanyDetail.LLPINDoc)
+			filesToZip["llpin"+extension], err = app.DownloadPDFFromS3(seller.CompanyDetail.LLPINDoc)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -1218,7 +1169,7 @@ func ZipFiles(files map[string][]byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func DownloadAllFiles() gin.HandlerFunc {
+func (app *Application) DownloadAllFiles() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
@@ -1237,11 +1188,7 @@ func DownloadAllFiles() gin.HandlerFunc {
 
 		//find seller and all docs
 
-		var seller models.Seller
-
-		filter := bson.M{"_id": sellerId}
-
-		err = SellerCollection.FindOne(ctx, filter).Decode(&seller)
+		_, err = app.repos.Sellers.FindByID(ctx, sellerId)
 
 		if err != nil {
 
@@ -1253,13 +1200,13 @@ func DownloadAllFiles() gin.HandlerFunc {
 
 }
 
-func LoadSeller() gin.HandlerFunc {
+func (app *Application) LoadSeller() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if !checkSeller(ctx, c) {
+		if !app.checkSeller(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized"})
 			return
 		}
@@ -1277,15 +1224,14 @@ func LoadSeller() gin.HandlerFunc {
 		}
 
 		// Query the database to get seller information
-		var seller models.Seller // Assuming Seller struct is defined in models package
-		err = SellerCollection.FindOne(context.Background(), bson.M{"_id": sellerObjID}).Decode(&seller)
+		seller, err := app.repos.Sellers.FindByID(ctx, sellerObjID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Seller not found"})
 			return
 		}
 
 		if seller.CompanyDetail.ProfilePicture != "" {
-			profilePictureUrl, err := getPresignURL(seller.CompanyDetail.ProfilePicture)
+			profilePictureUrl, err := app.getPresignURL(seller.CompanyDetail.ProfilePicture)
 			if err != nil {
 				//
 			}
@@ -1293,8 +1239,8 @@ func LoadSeller() gin.HandlerFunc {
 			seller.CompanyDetail.ProfilePicture = profilePictureUrl
 		}
 
-		seller.CompanyDetail = getPresignUrlOfSellerBusinessDoc(seller.CompanyDetail)
-		seller.OwnerDetail = getPresignUrlOfOwnerDocs(seller.OwnerDetail)
+		seller.CompanyDetail = app.getPresignUrlOfSellerBusinessDoc(seller.CompanyDetail)
+		seller.OwnerDetail = app.getPresignUrlOfOwnerDocs(seller.OwnerDetail)
 
 		if !seller.Approved {
 			c.JSON(http.StatusOK, gin.H{"message": "Seller is not approved", "isApproved": false, "seller": seller})
@@ -1304,45 +1250,32 @@ func LoadSeller() gin.HandlerFunc {
 	}
 }
 
-func LoadAdmin() gin.HandlerFunc {
+func (app *Application) LoadAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 
 		defer cancel()
-		if !checkAdmin(ctx, c) {
+		if !app.checkAdmin(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "You are not authorized to access this "})
 			return
 		}
 
-		sellerID, exists := c.Get("uid")
-		if !exists {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Seller ID not found in context"})
+		admin, ok := app.currentAdmin(ctx, c)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin not found"})
 			return
 		}
 
-		sellerObjID, err := primitive.ObjectIDFromHex(sellerID.(string))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Seller ID"})
-			return
-		}
-
-		var seller models.Seller
-		err = SellerCollection.FindOne(ctx, bson.M{"_id": sellerObjID}).Decode(&seller)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Seller not found"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{"message": "Admin access granted"})
+		c.JSON(http.StatusOK, gin.H{"message": "Admin access granted", "admin": admin})
 	}
 }
 
-func getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models.CompanyDetail {
+func (app *Application) getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models.CompanyDetail {
 
 	if companyDetail.GSTINDoc != "" {
 
-		url, err := getPresignURL(companyDetail.GSTINDoc)
+		url, err := app.getPresignURL(companyDetail.GSTINDoc)
 		if err != nil {
 			url = ""
 		}
@@ -1352,7 +1285,7 @@ func getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models
 
 	if companyDetail.PANImage != "" {
 
-		url, err := getPresignURL(companyDetail.PANImage)
+		url, err := app.getPresignURL(companyDetail.PANImage)
 		if err != nil {
 			url = ""
 		}
@@ -1362,7 +1295,7 @@ func getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models
 
 	if companyDetail.CINDoc != "" {
 
-		url, err := getPresignURL(companyDetail.CINDoc)
+		url, err := app.getPresignURL(companyDetail.CINDoc)
 		if err != nil {
 			url = ""
 		}
@@ -1372,7 +1305,7 @@ func getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models
 
 	if companyDetail.LLPINDoc != "" {
 
-		url, err := getPresignURL(companyDetail.LLPINDoc)
+		url, err := app.getPresignURL(companyDetail.LLPINDoc)
 		if err != nil {
 			url = ""
 		}
@@ -1384,11 +1317,11 @@ func getPresignUrlOfSellerBusinessDoc(companyDetail models.CompanyDetail) models
 
 }
 
-func getPresignUrlOfOwnerDocs(ownerDetails models.OwnerDetails) models.OwnerDetails {
+func (app *Application) getPresignUrlOfOwnerDocs(ownerDetails models.OwnerDetails) models.OwnerDetails {
 
 	if ownerDetails.PassportDocument != "" {
 
-		url, err := getPresignURL(ownerDetails.PassportDocument)
+		url, err := app.getPresignURL(ownerDetails.PassportDocument)
 		if err != nil {
 			url = ""
 		}
@@ -1399,7 +1332,7 @@ func getPresignUrlOfOwnerDocs(ownerDetails models.OwnerDetails) models.OwnerDeta
 
 	if ownerDetails.AadharDocument != "" {
 
-		url, err := getPresignURL(ownerDetails.AadharDocument)
+		url, err := app.getPresignURL(ownerDetails.AadharDocument)
 		if err != nil {
 			url = ""
 		}
@@ -1409,7 +1342,7 @@ func getPresignUrlOfOwnerDocs(ownerDetails models.OwnerDetails) models.OwnerDeta
 
 	if ownerDetails.PanDocument != "" {
 
-		url, err := getPresignURL(ownerDetails.PanDocument)
+		url, err := app.getPresignURL(ownerDetails.PanDocument)
 		if err != nil {
 			url = ""
 		}
@@ -1421,7 +1354,7 @@ func getPresignUrlOfOwnerDocs(ownerDetails models.OwnerDetails) models.OwnerDeta
 }
 
 // delete image from a product based on index from query
-func DeleteImageFromProduct() gin.HandlerFunc {
+func (app *Application) DeleteImageFromProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -1443,15 +1376,13 @@ func DeleteImageFromProduct() gin.HandlerFunc {
 			return
 		}
 
-		var product models.Product
-
-		finderr := ProductCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+		product, finderr := app.repos.Products.FindByID(ctx, productID)
 		if finderr != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "product not found"})
 			return
 		}
 
-		if checkSeller(ctx, c) {
+		if app.checkSeller(ctx, c) {
 			uid, exist := c.Get("uid")
 			if !exist {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "You are not authorized to perform this action "})
@@ -1477,7 +1408,7 @@ func DeleteImageFromProduct() gin.HandlerFunc {
 
 		product.Image = append(product.Image[:index], product.Image[index+1:]...)
 
-		_, err = ProductCollection.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{"$set": bson.M{"image": product.Image}})
+		err = app.repos.Products.Update(ctx, productID, repository.Update{Set: repository.Fields{"image": product.Image}})
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "failed to delete image"})
@@ -1491,12 +1422,12 @@ func DeleteImageFromProduct() gin.HandlerFunc {
 }
 
 // get support ticket for specific seller
-func GetSellerSupportTicket() gin.HandlerFunc {
+func (app *Application) GetSellerSupportTicket() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		if !checkSeller(ctx, c) {
+		if !app.checkSeller(ctx, c) {
 			c.JSON(http.StatusForbidden, gin.H{"Error": "forbidden"})
 			return
 		}
@@ -1511,10 +1442,7 @@ func GetSellerSupportTicket() gin.HandlerFunc {
 
 		sellerID, _ := primitive.ObjectIDFromHex(uid.(string))
 
-		filter := bson.M{"_id": sellerID}
-
-		var seller models.Seller
-		err := SellerCollection.FindOne(ctx, filter).Decode(&seller)
+		seller, err := app.repos.Sellers.FindByID(ctx, sellerID)
 
 		if err != nil {
 			fmt.Println(err)
@@ -1525,14 +1453,14 @@ func GetSellerSupportTicket() gin.HandlerFunc {
 		mobileno := seller.MobileNo
 		email := seller.Email
 
-		support_filter := bson.M{
-			"mobileno": mobileno,
-			"email":    email,
+		support_filter := repository.TicketFilter{
+			MobileNo: mobileno,
+			Email:    email,
 		}
 
 		var support []models.CustomerSupportTicket
 
-		cursor, err := SupportTickerCollection.Find(ctx, support_filter)
+		tickets, err := app.repos.Tickets.Find(ctx, support_filter, repository.Page{})
 
 		if err != nil {
 			fmt.Println("Ticket error")
@@ -1541,26 +1469,17 @@ func GetSellerSupportTicket() gin.HandlerFunc {
 			return
 		}
 
-		defer cursor.Close(ctx)
-
-		for cursor.Next(ctx) {
-			var ticket models.CustomerSupportTicket
-			err := cursor.Decode(&ticket)
-			if err != nil {
-				fmt.Println("Ticket decode error")
-				fmt.Println(err)
-				c.JSON(http.StatusBadRequest, gin.H{"Error": "something went wrong"})
-				return
-			}
-
-			for j, attachment := range ticket.Attachments {
-				url, err := getPresignURL(attachment)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-					return
-				}
-				ticket.Attachments[j] = url
+		var attachments signBatch
+		for i := range tickets {
+			for j := range tickets[i].Attachments {
+				attachments.addURL(&tickets[i].Attachments[j])
 			}
+		}
+		if err := app.sign(&attachments); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		for _, ticket := range tickets {
 
 			support = append(support, ticket)
 
@@ -1572,13 +1491,13 @@ func GetSellerSupportTicket() gin.HandlerFunc {
 }
 
 // seller update product
-func SellerUpdateProduct() gin.HandlerFunc {
+func (app *Application) SellerUpdateProduct() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var sellerId string
-		if checkSeller(ctx, c) {
+		if app.checkSeller(ctx, c) {
 			sellerID, exists := c.Get("uid")
 			if !exists {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "Seller ID not found in context"})
@@ -1594,11 +1513,8 @@ func SellerUpdateProduct() gin.HandlerFunc {
 		}
 
 		productID, _ := primitive.ObjectIDFromHex(id)
-		filter := bson.M{"_id": productID}
-
-		var product models.Product
 
-		err := ProductCollection.FindOne(ctx, filter).Decode(&product)
+		product, err := app.repos.Products.FindByID(ctx, productID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
 			return
@@ -1642,10 +1558,16 @@ func SellerUpdateProduct() gin.HandlerFunc {
 		}
 
 		price := strings.TrimSpace(c.PostForm("price"))
+		var parsedPrice money.Money
+		if price != "" {
+			if parsedPrice, err = money.Parse(price, money.DefaultCurrency); err != nil || parsedPrice.Amount < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": "price is not a valid amount"})
+				return
+			}
+		}
 		description := c.PostForm("discription")
 		category := c.PostForm("category")
 		sku := c.PostForm("sku")
-		updated_at := time.Now()
 
 		form, err := c.MultipartForm()
 		if err != nil {
@@ -1656,7 +1578,7 @@ func SellerUpdateProduct() gin.HandlerFunc {
 		files := form.File["files"]
 
 		var errors []string
-		var uploadedURLs []string
+		var uploadedImages []models.Image
 		for _, file := range files {
 			f, err := file.Open()
 			if err != nil {
@@ -1664,11 +1586,11 @@ func SellerUpdateProduct() gin.HandlerFunc {
 				c.String(http.StatusInternalServerError, "get form err: %s", err.Error())
 				return
 			}
-			uploadedURL, err := saveFile(f, file)
+			uploadedImage, err := app.saveImage(f, file, storage.ProductImagesPrefix(product.Product_ID.Hex()))
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("Error saving file %s: %s", file.Filename, err.Error()))
 			} else {
-				uploadedURLs = append(uploadedURLs, uploadedURL)
+				uploadedImages = append(uploadedImages, uploadedImage)
 			}
 		}
 		if len(errors) > 0 {
@@ -1676,81 +1598,59 @@ func SellerUpdateProduct() gin.HandlerFunc {
 			return
 		}
 
-		update := bson.M{}
+		// Seller edits go live once an admin approves the revision
+		draft, pending, err := app.revisionDraft(ctx, product)
+		if err != nil {
+			fmt.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while updating product"})
+			return
+		}
 		if product_name != "" {
-			update["product_name"] = product_name
+			draft.Product_Name = product_name
 		}
 
 		if sku != "" {
-			update["sku"] = sku
+			draft.SKU = sku
 		}
 
 		if price != "" {
-			update["price"] = price
+			draft.Price = parsedPrice
 		}
 		if description != "" {
-			update["discription"] = description
+			draft.Discription = description
 		}
 		if category != "" {
-			update["category"] = category
-		}
-		update["updated_at"] = updated_at
-
-		pushUpdates := bson.M{}
-		if len(uploadedURLs) > 0 {
-			pushUpdates["image"] = bson.M{
-				"$each": uploadedURLs,
-			}
-		}
-		if len(productPriceRanges) > 0 {
-			pushUpdates["pricerange"] = bson.M{
-				"$each": productPriceRanges,
-			}
-		}
-		if len(attributes) > 0 {
-			pushUpdates["attributes"] = bson.M{
-				"$each": attributes,
-			}
+			draft.Category = category
 		}
+		draft.Image = append(draft.Image, uploadedImages...)
+		draft.PriceRange = append(draft.PriceRange, productPriceRanges...)
+		draft.Attributes = append(draft.Attributes, attributes...)
 
-		updateOperation := bson.M{"$set": update}
-		if len(pushUpdates) > 0 {
-			updateOperation["$push"] = pushUpdates
+		revision, err := app.submitRevision(ctx, c.GetString("uid"), c.GetString("role"), product, draft, pending)
+		if err == errNoChanges {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Nothing to update"})
+			return
 		}
-
-		result, err := ProductCollection.UpdateOne(ctx, filter, updateOperation)
 		if err != nil {
 			fmt.Println(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while updating product"})
 			return
 		}
 
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{"Message": "Product updated successfully"})
+		c.JSON(http.StatusAccepted, gin.H{"Message": "Product changes submitted for review", "revision": revisionSummary(*revision)})
 	}
 }
 
-func GetSellerCSV(c *gin.Context) {
+func (app *Application) GetSellerCSV(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Retrieve sellers from DB
-	result, err := SellerCollection.Find(ctx, bson.M{})
+	sellers, err := app.repos.Sellers.Find(ctx, repository.SellerFilter{}, repository.Page{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while getting sellers: " + err.Error()})
 		return
 	}
-	defer result.Close(ctx)
-
-	var sellers []models.Seller
-	if err := result.All(ctx, &sellers); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while decoding sellers: " + err.Error()})
-		return
-	}
 
 	// Define CSV headers
 	headers := []string{
@@ -1823,22 +1723,22 @@ func GetSellerCSV(c *gin.Context) {
 		row = append(row, strings.Join(exportlicenses, ","))
 
 		// Get pre-signed URLs for documents
-		cinres, _ := getPresignURL(seller.CompanyDetail.CINDoc)
+		cinres, _ := app.getPresignURL(seller.CompanyDetail.CINDoc)
 		row = append(row, cinres)
 
-		gstres, _ := getPresignURL(seller.CompanyDetail.GSTINDoc)
+		gstres, _ := app.getPresignURL(seller.CompanyDetail.GSTINDoc)
 		row = append(row, gstres)
 
-		panres, _ := getPresignURL(seller.CompanyDetail.PANImage)
+		panres, _ := app.getPresignURL(seller.CompanyDetail.PANImage)
 		row = append(row, panres)
 
-		ownerAadharres, _ := getPresignURL(seller.OwnerDetail.AadharDocument)
+		ownerAadharres, _ := app.getPresignURL(seller.OwnerDetail.AadharDocument)
 		row = append(row, ownerAadharres)
 
-		ownerPANres, _ := getPresignURL(seller.OwnerDetail.PanDocument)
+		ownerPANres, _ := app.getPresignURL(seller.OwnerDetail.PanDocument)
 		row = append(row, ownerPANres)
 
-		ownerPassportres, _ := getPresignURL(seller.OwnerDetail.PassportDocument)
+		ownerPassportres, _ := app.getPresignURL(seller.OwnerDetail.PassportDocument)
 		row = append(row, ownerPassportres)
 
 		rows = append(rows, row)
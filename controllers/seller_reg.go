@@ -4,18 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/otp"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
 	generate "github.com/kravi0/BizGrowth-backend/tokens"
 	"github.com/kravi0/BizGrowth-backend/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
@@ -32,11 +32,9 @@ func HashPassword(password string) string {
 
 var validate = validator.New()
 
-var SellerTmpCollection *mongo.Collection = database.ProductData(database.Client, "SellerTmp")
-
 /* seller registartion */
 
-func SellerRegistrationSendOTP() gin.HandlerFunc {
+func (app *Application) SellerRegistrationSendOTP() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -47,8 +45,7 @@ func SellerRegistrationSendOTP() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		filter := primitive.M{"mobileno": contactNo}
-		count, err := SellerCollection.CountDocuments(ctx, filter)
+		count, err := app.repos.Sellers.Count(ctx, repository.SellerFilter{MobileNo: contactNo})
 		defer cancel()
 		if err != nil {
 			log.Panic(err)
@@ -63,27 +60,22 @@ func SellerRegistrationSendOTP() gin.HandlerFunc {
 			ID:       primitive.NewObjectID(),
 			MobileNo: contactNo,
 		}
-		SellerTmpCollection.InsertOne(ctx, Seller)
-		otp, errG := generateOTP(contactNo)
+		app.sellerTmpCollection.InsertOne(ctx, Seller)
+		errG := app.sendOTP(ctx, otp.PurposeSellerRegistration, otp.Recipient{MobileNo: contactNo})
 		if errG != nil {
+			status, msg := otpErrorResponse(errG)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Something went wrong"})
+			c.JSON(status, gin.H{"Error": msg})
 			c.Abort()
 			return
 		}
-		update := primitive.M{
-			"$set": primitive.M{
-				"otp": otp,
-			},
-		}
-		SellerTmpCollection.UpdateOne(ctx, filter, update)
 
 		c.Header("content-type", "application/json")
 		c.JSON(http.StatusOK, gin.H{"success": "OTP sent successfully"})
 
 	}
 }
-func SellerRegistrationOtpVerification() gin.HandlerFunc {
+func (app *Application) SellerRegistrationOtpVerification() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -94,15 +86,15 @@ func SellerRegistrationOtpVerification() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		otp := c.PostForm("otp")
-		if otp == "" {
+		code := c.PostForm("otp")
+		if code == "" {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "otp can't be empty"})
 			c.Abort()
 			return
 		}
 		filter := primitive.M{utils.Mobileno: contactNo}
-		res := SellerTmpCollection.FindOne(ctx, filter)
+		res := app.sellerTmpCollection.FindOne(ctx, filter)
 		err := res.Err()
 		if err != nil && err != mongo.ErrNoDocuments {
 			c.Header("content-type", "application/json")
@@ -116,27 +108,18 @@ func SellerRegistrationOtpVerification() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		SellerDetails := models.SellerTmp{}
-		dbErr := SellerTmpCollection.FindOne(ctx, filter).Decode(&SellerDetails)
-		if dbErr != nil {
+		if err := app.otpStore.Verify(ctx, otp.PurposeSellerRegistration, contactNo, code); err != nil {
+			status, msg := otpErrorResponse(err)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong while decode"})
-			c.Abort()
+			c.JSON(status, gin.H{"Error": msg})
 			return
 		}
-
-		if SellerDetails.OTP == otp {
-			SellerTmpCollection.FindOneAndUpdate(ctx, filter, primitive.M{"otp": ""})
-			c.Header("content-type", "application/json")
-			c.JSON(http.StatusOK, gin.H{"success": "verified"})
-		} else {
-			c.Header("content-type", "application/json")
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "invalid OTP"})
-		}
+		c.Header("content-type", "application/json")
+		c.JSON(http.StatusOK, gin.H{"success": "verified"})
 	}
 }
 
-func SellerEmailUpdate() gin.HandlerFunc {
+func (app *Application) SellerEmailUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
@@ -153,14 +136,14 @@ func SellerEmailUpdate() gin.HandlerFunc {
 			return
 		}
 
-		err := SellerCollection.FindOne(ctx, bson.M{"mobileno": mobileno}).Decode(&seller)
+		_, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
 
 		if err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": " User already exists with this phone number"})
 			return
 		}
 
-		err = SellerCollection.FindOne(ctx, bson.M{"email": email}).Decode(&seller)
+		_, err = app.repos.Sellers.FindOne(ctx, repository.SellerFilter{Email: email})
 		if err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "User already exists with this email"})
 			return
@@ -174,11 +157,10 @@ func SellerEmailUpdate() gin.HandlerFunc {
 		seller.User_type = utils.Seller
 		seller.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		seller.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		token, refreshtoken, _ := generate.TokenGenerator(seller.Email, seller.MobileNo, seller.Company_Name, seller.Seller_ID)
+		token, _ := generate.TokenGenerator(seller.Email, seller.MobileNo, seller.Company_Name, seller.Seller_ID, seller.User_type)
 		seller.Token = token
-		seller.Refresh_token = refreshtoken
 
-		_, inserterr := SellerCollection.InsertOne(ctx, seller)
+		inserterr := app.repos.Sellers.Insert(ctx, &seller)
 		finalTime := time.Now()
 		fmt.Println("Current Time: ", currentTime)
 
@@ -195,11 +177,10 @@ func SellerEmailUpdate() gin.HandlerFunc {
 	}
 }
 
-func SellerCommpanyDetailsUpdate() gin.HandlerFunc {
+func (app *Application) SellerCommpanyDetailsUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
-		var seller models.Seller
 		mobileno := c.PostForm("mobileno")
 		if mobileno == "" {
 			c.Header("content-type", "application/json")
@@ -208,7 +189,7 @@ func SellerCommpanyDetailsUpdate() gin.HandlerFunc {
 			return
 		}
 
-		err := SellerCollection.FindOne(ctx, bson.M{"mobileno": mobileno}).Decode(&seller)
+		seller, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
 		if err != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
@@ -229,95 +210,50 @@ func SellerCommpanyDetailsUpdate() gin.HandlerFunc {
 		NoOfEmployee := c.PostForm("noofemployee")
 		LLPIN := c.PostForm("llpin")
 
-		form, err := c.MultipartForm()
-		if err != nil {
-			log.Println("error while multipart")
-			c.String(http.StatusBadRequest, "get form err: %s", err.Error())
-			return
-		}
-		panFile := form.File["panFile"]
-		gstinFile := form.File["gstinFile"]
-		profile_picture := form.File["profile_picture"]
-
-		if len(panFile) == 0 || len(gstinFile) == 0 || len(profile_picture) == 0 {
+		// Every document is a file, or the ID of an upload session confirmed
+		// for this seller.
+		owner := seller.ID.Hex()
+		if !hasFormUpload(c, "panFile") || !hasFormUpload(c, "gstinFile") || !hasFormUpload(c, "profile_picture") {
 			c.String(http.StatusBadRequest, "Please upload all required documents")
 			return
 		}
 
-		panHeader, err := panFile[0].Open()
-		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening PAN file: %s", err.Error()))
-			return
-		}
-		gstinHeader, err := gstinFile[0].Open()
-		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening Aadhar file: %s", err.Error()))
-			return
-		}
-
-		profile_Picture, err := profile_picture[0].Open()
+		profilePicture_Url, err := app.formDocument(ctx, c, "profile_picture", owner, storage.SellerProfilePrefix(owner))
 		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening profile picture: %s", err.Error()))
+			c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving profile picture: %s", err.Error()))
 			return
 		}
 
-		profilePicture_Url, err := saveFile(profile_Picture, profile_picture[0])
+		panFileUrl, err := app.formDocument(ctx, c, "panFile", owner, storage.SellerKYCPrefix(owner))
 		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving profile picture: %s", err.Error()))
+			c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving PAN file: %s", err.Error()))
 			return
 		}
 
-		panFileUrl, err := saveFile(panHeader, panFile[0])
+		gstinFileUrl, err := app.formDocument(ctx, c, "gstinFile", owner, storage.SellerKYCPrefix(owner))
 		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving PAN file: %s", err.Error()))
-			return
-		}
-
-		gstinFileUrl, err := saveFile(gstinHeader, gstinFile[0])
-		if err != nil {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving GSTIN file: %s", err.Error()))
+			c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving GSTIN file: %s", err.Error()))
 			return
 		}
 
 		if LLPIN != "" {
-			LLPINFile := form.File["llpinFile"]
-			LLPINHeader, err := LLPINFile[0].Open()
+			LLPINFileUrl, err := app.formDocument(ctx, c, "llpinFile", owner, storage.SellerKYCPrefix(owner))
 			if err != nil {
-				c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening LLPIN file: %s", err.Error()))
-				return
-			}
-			LLPINFileUrl, err := saveFile(LLPINHeader, LLPINFile[0])
-			if err != nil {
-				c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving PAN file: %s", err.Error()))
+				c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving LLPIN file: %s", err.Error()))
 				return
 			}
 			seller.CompanyDetail.LLPINDoc = LLPINFileUrl
-
-			defer LLPINHeader.Close()
-
 		}
 
 		if CIN != "" {
-			CINFile := form.File["cinFile"]
-			CINHeader, err := CINFile[0].Open()
-			if err != nil {
-				c.String(http.StatusInternalServerError, fmt.Sprintf("Error opening CIN file: %s", err.Error()))
-				return
-			}
-			CINFileUrl, err := saveFile(CINHeader, CINFile[0])
+			CINFileUrl, err := app.formDocument(ctx, c, "cinFile", owner, storage.SellerKYCPrefix(owner))
 			if err != nil {
-				c.String(http.StatusInternalServerError, fmt.Sprintf("Error saving CIN file: %s", err.Error()))
+				c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving CIN file: %s", err.Error()))
 				return
 			}
 			seller.CompanyDetail.CINDoc = CINFileUrl
-			defer CINHeader.Close()
 		}
 
-		defer panHeader.Close()
-		defer gstinHeader.Close()
-
-		defer profile_Picture.Close()
-
 		seller.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 		seller.Company_Name = Company_Name
@@ -337,16 +273,12 @@ func SellerCommpanyDetailsUpdate() gin.HandlerFunc {
 		seller.CompanyDetail.PANImage = panFileUrl
 		seller.CompanyDetail.GSTINDoc = gstinFileUrl
 
-		filter := primitive.M{
-			"mobileno": mobileno,
-		}
-		update := bson.M{"$set": bson.M{
+		update := repository.Update{Set: repository.Fields{
 			"company_name":  seller.Company_Name,
 			"companydetail": seller.CompanyDetail,
-		},
-		}
+		}}
 
-		_, updateError := SellerCollection.UpdateOne(ctx, filter, update)
+		updateError := app.repos.Sellers.Update(ctx, seller.ID, update)
 
 		if updateError != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": updateError.Error()})
@@ -359,7 +291,7 @@ func SellerCommpanyDetailsUpdate() gin.HandlerFunc {
 
 }
 
-func SellerOwnerDetailsUpdate() gin.HandlerFunc {
+func (app *Application) SellerOwnerDetailsUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
@@ -370,6 +302,11 @@ func SellerOwnerDetailsUpdate() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Mobile number is required"})
 			return
 		}
+		registered, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
+			return
+		}
 
 		OwnerName := c.PostForm("name")
 		OwnerMobileNo := c.PostForm("owner_mobileno")
@@ -413,14 +350,14 @@ func SellerOwnerDetailsUpdate() gin.HandlerFunc {
 		}
 		defer panDocFile.Close()
 
-		aadharDocUrl, saveError := saveFile(aadharDocFile, aadharDoc[0])
+		aadharDocUrl, saveError := app.saveFile(aadharDocFile, aadharDoc[0], storage.SellerKYCPrefix(registered.ID.Hex()))
 		if saveError != nil {
 
 			c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving aadharDoc document"})
 			return
 		}
 
-		panDocUrl, saveError := saveFile(panDocFile, panDoc[0])
+		panDocUrl, saveError := app.saveFile(panDocFile, panDoc[0], storage.SellerKYCPrefix(registered.ID.Hex()))
 		if saveError != nil {
 
 			c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving panDoc document"})
@@ -437,7 +374,7 @@ func SellerOwnerDetailsUpdate() gin.HandlerFunc {
 			}
 			defer passportDocFile.Close()
 			if passportDocFile != nil {
-				passportDocUrl, saveError := saveFile(passportDocFile, passportDoc[0])
+				passportDocUrl, saveError := app.saveFile(passportDocFile, passportDoc[0], storage.SellerKYCPrefix(registered.ID.Hex()))
 				if saveError != nil {
 
 					c.JSON(http.StatusServiceUnavailable, gin.H{"Error": "Something went wrong while saving passportDoc document"})
@@ -461,16 +398,11 @@ func SellerOwnerDetailsUpdate() gin.HandlerFunc {
 		seller.OwnerDetail.AadharDocument = aadharDocUrl
 		seller.OwnerDetail.PanDocument = panDocUrl
 
-		filter := primitive.M{
-			"mobileno": mobileno,
-		}
-
-		update := bson.M{"$set": bson.M{
+		update := repository.Update{Set: repository.Fields{
 			"ownerdetail": seller.OwnerDetail,
-		},
-		}
+		}}
 
-		_, updateError := SellerCollection.UpdateOne(ctx, filter, update)
+		updateError := app.updateSellerByMobile(ctx, mobileno, update)
 		if updateError != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to save owner details, try again "})
 			return
@@ -481,7 +413,7 @@ func SellerOwnerDetailsUpdate() gin.HandlerFunc {
 	}
 }
 
-func SellerLicenseUpdate() gin.HandlerFunc {
+func (app *Application) SellerLicenseUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
@@ -493,6 +425,11 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "Mobile number is required"})
 			return
 		}
+		registered, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
+			return
+		}
 
 		Consent := c.PostForm("consentToAdmin")
 
@@ -504,16 +441,9 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 		HaveBusinessLicensesStr := c.PostForm("havebusinesslicenses")
 		HaveExportPermissionStr := c.PostForm("haveexportpermission")
 
-		// Handle multipart form
-		form, err := c.MultipartForm()
-		if err != nil {
-			log.Println("error while parsing multipart form:", err)
-			c.String(http.StatusBadRequest, "error while parsing multipart form: %s", err.Error())
-			return
-		}
-
-		business_LicenseFile := form.File["business_LicenseFile"]
-		export_PermissionFile := form.File["export_PermissionFile"]
+		// Licenses are files, or IDs of upload sessions confirmed for this
+		// seller
+		owner := registered.ID.Hex()
 
 		HaveBusinessLicenses, err := strconv.ParseBool(HaveBusinessLicensesStr)
 		if err != nil {
@@ -533,7 +463,7 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 		LicenseIssuedDateArray := c.PostFormArray("businessLicense_Issueddate")
 
 		if HaveBusinessLicenses {
-			if len(business_LicenseFile) == 0 {
+			if !hasFormUpload(c, "business_LicenseFile") {
 				c.String(http.StatusBadRequest, "No business license files provided")
 				return
 			}
@@ -544,7 +474,11 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 			return
 		}
 
-		business_LicenseFileUrl := getFileUploadArray(business_LicenseFile)
+		business_LicenseFileUrl, err := app.formDocuments(ctx, c, "business_LicenseFile", owner, storage.SellerKYCPrefix(owner))
+		if err != nil {
+			c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving business license file: %s", err.Error()))
+			return
+		}
 
 		if len(business_LicenseFileUrl) == 0 {
 			c.String(http.StatusBadRequest, "No business license files provided")
@@ -588,10 +522,14 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 		ExportLicenseArray := c.PostFormArray("exportPermissionname")
 		ExportLicenseValueArray := c.PostFormArray("exportPermissionvalue")
 		ExportLicenseIssuedDateArray := c.PostFormArray("exportPermission_Issueddate")
-		export_PermissionFileUrl := getFileUploadArray(export_PermissionFile)
+		export_PermissionFileUrl, err := app.formDocuments(ctx, c, "export_PermissionFile", owner, storage.SellerKYCPrefix(owner))
+		if err != nil {
+			c.String(uploadErrorStatus(err), fmt.Sprintf("Error saving export permission file: %s", err.Error()))
+			return
+		}
 
 		if HaveExportPermission {
-			if len(export_PermissionFile) == 0 {
+			if len(export_PermissionFileUrl) == 0 {
 				c.JSON(http.StatusBadRequest, gin.H{"Error": "No export permission files provided"})
 				return
 			}
@@ -641,9 +579,7 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 		seller.CompanyDetail.BusinessLicenses = BusinessLicenseArray
 		seller.CompanyDetail.ExportPermission = export_PermissionFileArray
 
-		filter := bson.M{"mobileno": mobileno}
-
-		update := bson.M{"$set": bson.M{
+		update := repository.Update{Set: repository.Fields{
 			"companydetail.HaveBusinessLicenses": seller.CompanyDetail.HaveBusinessLicenses,
 			"companydetail.HaveExportPermission": seller.CompanyDetail.HaveExportPermission,
 			"companydetail.BusinessLicenses":     seller.CompanyDetail.BusinessLicenses,
@@ -652,7 +588,7 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 		}}
 
 		// Execute update operation
-		_, updateErr := SellerCollection.UpdateOne(ctx, filter, update)
+		updateErr := app.updateSellerByMobile(ctx, mobileno, update)
 		if updateErr != nil {
 			log.Println("error updating seller details:", updateErr)
 			c.String(http.StatusInternalServerError, fmt.Sprintf("Error updating seller details: %s", updateErr.Error()))
@@ -663,30 +599,8 @@ func SellerLicenseUpdate() gin.HandlerFunc {
 	}
 }
 
-func getFileUploadArray(fileHeaders []*multipart.FileHeader) []string {
-
-	var fileUploadArray []string
-	for _, file := range fileHeaders {
-		f, err := file.Open()
-		if err != nil {
-			log.Println("error opening file:", err)
-			return nil
-		}
-		defer f.Close()
-
-		// Assuming saveFile is a function to save the file and get its URL
-		fileUploadUrl, err := saveFile(f, file)
-		if err != nil {
-			log.Println("error saving file:", err)
-			return nil
-		}
-		fileUploadArray = append(fileUploadArray, fileUploadUrl)
-	}
-	return fileUploadArray
-}
-
 // seller id is mandatory field to call this api
-func ApproveSeller() gin.HandlerFunc {
+func (app *Application) ApproveSeller() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -708,12 +622,10 @@ func ApproveSeller() gin.HandlerFunc {
 			approved = true
 		}
 
-		filter := primitive.M{utils.BsonID: sellerID}
-		update := bson.M{
-			"$set": bson.M{
-				"approved": approved,
-			}}
-		_, err = SellerCollection.UpdateOne(ctx, filter, update)
+		update := repository.Update{Set: repository.Fields{
+			"approved": approved,
+		}}
+		err = app.repos.Sellers.Update(ctx, sellerID, update)
 		if err != nil {
 			c.Header("content-type", "application/json")
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
@@ -726,7 +638,7 @@ func ApproveSeller() gin.HandlerFunc {
 /* Login functions */
 
 // pass form data
-func SendLoginOTP() gin.HandlerFunc {
+func (app *Application) SendLoginOTP() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -737,28 +649,20 @@ func SendLoginOTP() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		var founduser models.Seller
-		err := SellerCollection.FindOne(ctx, bson.M{"mobileno": contactNo}).Decode(&founduser)
+		founduser, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: contactNo})
 		defer cancel()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "No user exists with this phone number"})
 			return
 		}
-		mobileNo := founduser.MobileNo
-		otp, errG := generateOTP(mobileNo)
+		errG := app.sendOTP(ctx, otp.PurposeSellerLogin, otp.Recipient{MobileNo: founduser.MobileNo, Email: founduser.Email})
 		if errG != nil {
+			status, msg := otpErrorResponse(errG)
 			c.Header("content-type", "application/json")
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Something went wrong"})
+			c.JSON(status, gin.H{"Error": msg})
 			c.Abort()
 			return
 		}
-		filter := primitive.M{utils.Mobileno: contactNo}
-		update := primitive.M{
-			"$set": primitive.M{
-				"otp": otp,
-			},
-		}
-		SellerCollection.UpdateOne(ctx, filter, update)
 		c.Header("content-type", "application/json")
 		c.JSON(http.StatusOK, gin.H{"success": "OTP sent successfully"})
 
@@ -767,7 +671,7 @@ func SendLoginOTP() gin.HandlerFunc {
 }
 
 // need to pass mobile no OTP and password
-func LoginValidatePasswordOTP() gin.HandlerFunc {
+func (app *Application) LoginValidatePasswordOTP() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -778,29 +682,57 @@ func LoginValidatePasswordOTP() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err})
 			return
 		}
-		var founduser models.Seller
-
-		err := SellerCollection.FindOne(ctx, bson.M{"mobileno": user.MobileNo}).Decode(&founduser)
+		founduser, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: user.MobileNo})
 		defer cancel()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": "No account exist with this mobile."})
 			return
 		}
 
+		// The OTP is checked first so that every password guess also uses
+		// up one of the code's attempts.
+		if err := app.otpStore.Verify(ctx, otp.PurposeSellerLogin, founduser.MobileNo, user.OTP); err != nil {
+			status, msg := otpErrorResponse(err)
+			if status == http.StatusBadRequest {
+				msg = "OTP or password is incorrect"
+			}
+			c.JSON(status, gin.H{"Error": msg})
+			return
+		}
 		passwordIsValid, _ := Verifypassword(user.Password, founduser.Password)
-		if founduser.OTP != user.OTP || !passwordIsValid {
+		if !passwordIsValid {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "OTP or password is incorrect"})
 
 			return
 		}
 
-		token, refreshToken, _ := generate.TokenGenerator(founduser.Email, founduser.MobileNo, founduser.Company_Name, founduser.Seller_ID)
-		generate.UpdateAllTokens(token, refreshToken, founduser.Seller_ID)
-		c.JSON(http.StatusAccepted, token)
+		token, _ := generate.TokenGenerator(founduser.Email, founduser.MobileNo, founduser.Company_Name, founduser.Seller_ID, founduser.User_type)
+		refreshToken, err := app.issueRefreshToken(ctx, founduser.Seller_ID, founduser.User_type, primitive.NilObjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		update := repository.Update{Set: repository.Fields{
+			"token":      token,
+			"updated_at": time.Now(),
+		}}
+		if err := app.repos.Sellers.Update(ctx, founduser.ID, update); err != nil {
+			log.Println(err)
+		}
+		c.JSON(http.StatusAccepted, gin.H{"token": token, "refresh_token": refreshToken})
 
 	}
 }
 
+// updateSellerByMobile applies upd to the seller registered with mobileno.
+func (app *Application) updateSellerByMobile(ctx context.Context, mobileno string, upd repository.Update) error {
+	seller, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
+	if err != nil {
+		return err
+	}
+	return app.repos.Sellers.Update(ctx, seller.ID, upd)
+}
+
 func Verifypassword(userPassword string, givenPassword string) (bool, string) {
 	err := bcrypt.CompareHashAndPassword([]byte(givenPassword), []byte(userPassword))
 	fmt.Println(err)
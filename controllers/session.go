@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	generate "github.com/kravi0/BizGrowth-backend/tokens"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errSessionEnded = errors.New("session has ended")
+
+// issueRefreshToken records a new refresh token for uid and returns it
+// signed. A zero family starts a new session.
+func (app *Application) issueRefreshToken(ctx context.Context, uid, role string, family primitive.ObjectID) (string, error) {
+	if family.IsZero() {
+		family = primitive.NewObjectID()
+	}
+	now := time.Now()
+	record := models.RefreshToken{
+		ID:         primitive.NewObjectID(),
+		Family:     family,
+		Uid:        uid,
+		Role:       role,
+		Expires_at: now.Add(generate.RefreshTokenTTL),
+		Created_at: now,
+	}
+	if err := app.repos.RefreshTokens.Insert(ctx, &record); err != nil {
+		return "", err
+	}
+	return generate.RefreshTokenGenerator(record.ID.Hex(), family.Hex(), uid, role, record.Expires_at)
+}
+
+// accessTokenFor signs a fresh access token for the account behind a
+// refresh token, failing when the account is gone or its role changed.
+func (app *Application) accessTokenFor(ctx context.Context, uid, role string) (string, error) {
+	id, err := primitive.ObjectIDFromHex(uid)
+	if err != nil {
+		return "", errSessionEnded
+	}
+	if role == utils.User {
+		var user models.USer
+		if err := app.userCollection.FindOne(ctx, primitive.M{"_id": id}).Decode(&user); err != nil {
+			return "", errSessionEnded
+		}
+		return generate.UserTokenGenerator(user.MobileNo, user.User_id.Hex())
+	}
+	if utils.IsAdminRole(role) {
+		admin, err := app.repos.Admins.FindByID(ctx, id)
+		if err != nil || admin.Role != role || admin.Disabled {
+			return "", errSessionEnded
+		}
+		return generate.TokenGenerator(admin.Email, admin.MobileNo, admin.Name, admin.ID.Hex(), admin.Role)
+	}
+	seller, err := app.repos.Sellers.FindByID(ctx, id)
+	if err != nil || seller.User_type != role || seller.IsArchived {
+		return "", errSessionEnded
+	}
+	return generate.TokenGenerator(seller.Email, seller.MobileNo, seller.Company_Name, seller.Seller_ID, seller.User_type)
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh
+// token. Every refresh token works once, presenting one again revokes all
+// tokens of its session.
+func (app *Application) RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var input struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "refresh_token is required"})
+			return
+		}
+		claims, msg := generate.ValidateRefreshToken(input.RefreshToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": msg})
+			return
+		}
+		id, err := primitive.ObjectIDFromHex(claims.Id)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "the token was invalid"})
+			return
+		}
+
+		stored, err := app.repos.RefreshTokens.Consume(ctx, id)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "the token was invalid"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		if stored.Revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "session has ended, please log in again"})
+			return
+		}
+		if stored.Used {
+			// A token that was already rotated is being replayed, so it may
+			// have leaked: end the whole session for both holders.
+			app.repos.RefreshTokens.RevokeFamily(ctx, stored.Family)
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "refresh token was already used, please log in again"})
+			return
+		}
+
+		token, err := app.accessTokenFor(ctx, stored.Uid, stored.Role)
+		if err == errSessionEnded {
+			app.repos.RefreshTokens.RevokeFamily(ctx, stored.Family)
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "session has ended, please log in again"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		refreshToken, err := app.issueRefreshToken(ctx, stored.Uid, stored.Role, stored.Family)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+	}
+}
+
+// Logout revokes every refresh token of the session the given one belongs
+// to. Access tokens already handed out stay valid until they expire.
+func (app *Application) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var input struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "refresh_token is required"})
+			return
+		}
+		claims, msg := generate.ValidateRefreshToken(input.RefreshToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": msg})
+			return
+		}
+		family, err := primitive.ObjectIDFromHex(claims.Family)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "the token was invalid"})
+			return
+		}
+		if err := app.repos.RefreshTokens.RevokeFamily(ctx, family); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+	}
+}
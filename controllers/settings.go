@@ -6,16 +6,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kravi0/BizGrowth-backend/database"
 	"github.com/kravi0/BizGrowth-backend/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var settingsCollection *mongo.Collection = database.ProductData(database.Client, "Settings")
-
-func CreateSetting() gin.HandlerFunc {
+func (app *Application) CreateSetting() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -30,7 +27,7 @@ func CreateSetting() gin.HandlerFunc {
 		setting.Created_at = time.Now()
 		setting.Updated_at = time.Now()
 
-		result, err := settingsCollection.InsertOne(ctx, setting)
+		result, err := app.settingsCollection.InsertOne(ctx, setting)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to create setting"})
 			return
@@ -41,7 +38,7 @@ func CreateSetting() gin.HandlerFunc {
 }
 
 // GetSetting retrieves a setting by its name
-func GetSetting() gin.HandlerFunc {
+func (app *Application) GetSetting() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -49,7 +46,7 @@ func GetSetting() gin.HandlerFunc {
 		name := c.Param("name")
 		var setting models.Settings
 
-		err := settingsCollection.FindOne(ctx, bson.M{"name": name}).Decode(&setting)
+		err := app.settingsCollection.FindOne(ctx, bson.M{"name": name}).Decode(&setting)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Setting not found"})
 			return
@@ -59,7 +56,7 @@ func GetSetting() gin.HandlerFunc {
 	}
 }
 
-func UpdateSetting() gin.HandlerFunc {
+func (app *Application) UpdateSetting() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -80,7 +77,7 @@ func UpdateSetting() gin.HandlerFunc {
 			},
 		}
 
-		result, err := settingsCollection.UpdateOne(ctx, bson.M{"name": name}, update)
+		result, err := app.settingsCollection.UpdateOne(ctx, bson.M{"name": name}, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to update setting"})
 			return
@@ -96,7 +93,7 @@ func UpdateSetting() gin.HandlerFunc {
 	}
 }
 
-func GetSettingByName() gin.HandlerFunc {
+func (app *Application) GetSettingByName() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -104,7 +101,7 @@ func GetSettingByName() gin.HandlerFunc {
 		name := c.Param("name")
 		var setting models.Settings
 
-		err := settingsCollection.FindOne(ctx, bson.M{"name": name}).Decode(&setting)
+		err := app.settingsCollection.FindOne(ctx, bson.M{"name": name}).Decode(&setting)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
 				c.JSON(http.StatusNotFound, gin.H{"Status": http.StatusNotFound, "Message": "error", "data": "Setting not found"})
@@ -118,14 +115,14 @@ func GetSettingByName() gin.HandlerFunc {
 	}
 }
 
-func DeleteSetting() gin.HandlerFunc {
+func (app *Application) DeleteSetting() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		name := c.Param("name")
 
-		result, err := settingsCollection.DeleteOne(ctx, bson.M{"name": name})
+		result, err := app.settingsCollection.DeleteOne(ctx, bson.M{"name": name})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Status": http.StatusInternalServerError, "Message": "error", "data": "Failed to delete setting"})
 			return
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/autocomplete"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// suggestProductRepo and suggestSellerRepo tell the autocomplete index
+// about every product and seller written.
+type suggestProductRepo struct {
+	repository.ProductRepo
+	changed func()
+}
+
+func (r suggestProductRepo) Insert(ctx context.Context, product *models.Product) error {
+	err := r.ProductRepo.Insert(ctx, product)
+	if err == nil {
+		r.changed()
+	}
+	return err
+}
+
+func (r suggestProductRepo) Update(ctx context.Context, id primitive.ObjectID, upd repository.Update) error {
+	err := r.ProductRepo.Update(ctx, id, upd)
+	if err == nil {
+		r.changed()
+	}
+	return err
+}
+
+type suggestSellerRepo struct {
+	repository.SellerRepo
+	changed func()
+}
+
+func (r suggestSellerRepo) Insert(ctx context.Context, seller *models.Seller) error {
+	err := r.SellerRepo.Insert(ctx, seller)
+	if err == nil {
+		r.changed()
+	}
+	return err
+}
+
+func (r suggestSellerRepo) Update(ctx context.Context, id primitive.ObjectID, upd repository.Update) error {
+	err := r.SellerRepo.Update(ctx, id, upd)
+	if err == nil {
+		r.changed()
+	}
+	return err
+}
+
+// suggestionEntries lists the approved, non-archived products, categories
+// and sellers for the autocomplete index. Products are ranked by their
+// reviews and sellers, featured ones first, categories and sellers by the
+// number of products they have.
+func (app *Application) suggestionEntries(ctx context.Context) ([]autocomplete.Entry, error) {
+	products, err := app.repos.Products.Find(ctx, repository.ProductFilter{
+		Approved: repository.Bool(true),
+		Archived: repository.Bool(false),
+		Rejected: repository.Bool(false),
+	}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	perCategory := map[string]int{}
+	perSeller := map[string]int{}
+	var entries []autocomplete.Entry
+	for _, product := range products {
+		popularity := float64(len(product.Reviews) + len(product.SellerRegistered))
+		if product.Featured {
+			popularity += 10
+		}
+		entries = append(entries, autocomplete.Entry{
+			Kind:       autocomplete.Product,
+			ID:         product.Product_ID.Hex(),
+			Text:       product.Product_Name,
+			Popularity: popularity,
+		})
+		perCategory[product.Category]++
+		for _, seller := range product.SellerRegistered {
+			perSeller[seller]++
+		}
+	}
+
+	if app.categoriesCollection != nil {
+		cursor, err := app.categoriesCollection.Find(ctx, bson.M{"isApproved": true, "isArchived": bson.M{"$ne": true}})
+		if err != nil {
+			return nil, err
+		}
+		var categories []models.Categories
+		if err := cursor.All(ctx, &categories); err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			popularity := float64(perCategory[category.Category])
+			if category.IsFeatured {
+				popularity += 10
+			}
+			entries = append(entries, autocomplete.Entry{
+				Kind:       autocomplete.Category,
+				ID:         category.Category_ID.Hex(),
+				Text:       category.Category,
+				Popularity: popularity,
+			})
+		}
+	}
+
+	sellers, err := app.repos.Sellers.Find(ctx, repository.SellerFilter{UserType: utils.Seller}, repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	for _, seller := range sellers {
+		if !seller.Approved || seller.IsArchived || seller.Company_Name == "" {
+			continue
+		}
+		entries = append(entries, autocomplete.Entry{
+			Kind:       autocomplete.Seller,
+			ID:         seller.ID.Hex(),
+			Text:       seller.Company_Name,
+			Popularity: float64(perSeller[seller.ID.Hex()]),
+		})
+	}
+	return entries, nil
+}
+
+// RunSuggestions keeps the autocomplete index warm until ctx is done.
+func (app *Application) RunSuggestions(ctx context.Context) {
+	app.suggestions.Run(ctx)
+}
+
+// SuggestionsHandler suggests products, categories and sellers with a word
+// starting with each word of "query". "types" narrows them to a comma
+// separated list of kinds, "limit" caps them at up to 20.
+func (app *Application) SuggestionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var kinds []string
+		if types := c.Query("types"); types != "" {
+			for _, kind := range strings.Split(types, ",") {
+				switch kind = strings.TrimSpace(kind); kind {
+				case autocomplete.Product, autocomplete.Category, autocomplete.Seller:
+					kinds = append(kinds, kind)
+				default:
+					c.JSON(http.StatusBadRequest, gin.H{"Error": "types must be a list of product, category, seller"})
+					return
+				}
+			}
+		}
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 || limit > 20 {
+			limit = 10
+		}
+
+		suggestions, err := app.suggestions.Suggest(ctx, c.Query("query"), kinds, limit)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+			return
+		}
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
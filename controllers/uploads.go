@@ -0,0 +1,502 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Clients can put files straight into storage instead of sending them
+// through the API: they open an upload session for a declared type and
+// size, PUT the file to the presigned URL it returns and confirm the
+// session. Handlers taking a file in a multipart field also take the ID of
+// a confirmed session in a plain field of the same name.
+
+// uploadTypes lists the content types each kind of upload accepts.
+var uploadTypes = map[string][]string{
+	models.UploadImage:    {"image/jpeg", "image/png", "image/gif", "image/webp"},
+	models.UploadDocument: {"application/pdf", "image/jpeg", "image/png"},
+}
+
+var (
+	errUploadNotFound = errors.New("upload not found, expired or not confirmed")
+	errUploadNotImage = errors.New("upload is not an image")
+)
+
+// uploadErrorStatus is the status to answer a failed attach with.
+func uploadErrorStatus(err error) int {
+	if err == errUploadNotFound || err == errUploadNotImage {
+		return http.StatusBadRequest
+	}
+	return imageErrorStatus(err)
+}
+
+// uploadOwner is who the upload sessions of a request belong to: the
+// signed in account, or during seller registration, before there is a
+// token, the seller with the given mobile number. Once a seller is approved
+// only a signed in request can upload for them.
+func (app *Application) uploadOwner(ctx context.Context, c *gin.Context, mobileno string) (string, bool) {
+	if uid := c.GetString("uid"); uid != "" {
+		return uid, true
+	}
+	if mobileno == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Mobile number is required"})
+		return "", false
+	}
+	seller, err := app.repos.Sellers.FindOne(ctx, repository.SellerFilter{MobileNo: mobileno})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Unable to find seller with this phone number"})
+		return "", false
+	}
+	if seller.Approved {
+		c.JSON(http.StatusForbidden, gin.H{"Error": "Seller registration is complete, please sign in to upload"})
+		return "", false
+	}
+	return seller.ID.Hex(), true
+}
+
+// CreateUploadSession opens an upload session and returns the presigned URL
+// to PUT the file to, with the headers to send.
+func (app *Application) CreateUploadSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var req struct {
+			Kind        string `json:"kind"`
+			Filename    string `json:"filename"`
+			ContentType string `json:"content_type"`
+			Size        int64  `json:"size"`
+			MobileNo    string `json:"mobileno"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		types, ok := uploadTypes[req.Kind]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "kind must be image or document"})
+			return
+		}
+		contentType := strings.ToLower(strings.TrimSpace(req.ContentType))
+		allowed := false
+		for _, t := range types {
+			allowed = allowed || t == contentType
+		}
+		if !allowed {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("content_type must be one of %s", strings.Join(types, ", "))})
+			return
+		}
+		maxSize := app.config.Uploads.MaxDocumentSize
+		if req.Kind == models.UploadImage {
+			maxSize = app.config.Uploads.MaxImageSize
+		}
+		if req.Size <= 0 || req.Size > int64(maxSize) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("size must be between 1 and %d bytes", maxSize)})
+			return
+		}
+		owner, ok := app.uploadOwner(ctx, c, req.MobileNo)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		session := models.UploadSession{
+			ID:           primitive.NewObjectID(),
+			Owner:        owner,
+			Kind:         req.Kind,
+			Filename:     path.Base(req.Filename),
+			Content_type: contentType,
+			Size:         req.Size,
+			Status:       models.UploadPending,
+			Created_at:   now,
+			Expires_at:   now.Add(app.config.Uploads.TTL),
+		}
+		if req.Filename == "" {
+			session.Filename = "upload" + mimetype.Lookup(contentType).Extension()
+		}
+		ext := storage.Extension(mimetype.Lookup(contentType), session.Filename)
+		session.Key = storage.NewKey(storage.UploadPrefix(session.ID.Hex()), ext)
+
+		url, err := app.storage.SignedPutURL(ctx, session.Key, contentType, session.Size, app.config.Uploads.URLExpiry)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		if err := app.repos.UploadSessions.Insert(ctx, &session); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"upload":         session,
+			"url":            url,
+			"method":         http.MethodPut,
+			"headers":        gin.H{"Content-Type": contentType},
+			"url_expires_at": now.Add(app.config.Uploads.URLExpiry),
+		})
+	}
+}
+
+// ConfirmUploadSession copies the file put into storage for a session out
+// of reach of its URL and checks the copy has the declared size and type.
+// A file that doesn't is removed, so it can be put again while the URL
+// lasts.
+func (app *Application) ConfirmUploadSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		var req struct {
+			MobileNo string `json:"mobileno"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+				return
+			}
+		}
+		id, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid upload ID"})
+			return
+		}
+		owner, ok := app.uploadOwner(ctx, c, req.MobileNo)
+		if !ok {
+			return
+		}
+		session, err := app.repos.UploadSessions.FindByID(ctx, id)
+		if err == nil && (session.Owner != owner || time.Now().After(session.Expires_at)) {
+			err = repository.ErrNotFound
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "upload not found or expired"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		if session.Status != models.UploadPending {
+			c.JSON(http.StatusOK, session)
+			return
+		}
+
+		// The presigned URL can still be used after this check, so it's a
+		// copy no URL can write to that is checked and later attached.
+		verified := storage.NewKey(storage.UploadPrefix(session.ID.Hex()), path.Ext(session.Key))
+		_, err = app.storage.Copy(ctx, session.Key, verified, storage.Metadata{
+			ContentType: session.Content_type,
+			Filename:    session.Filename,
+		})
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "nothing was uploaded yet"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		problem, err := app.checkUpload(ctx, session, verified)
+		if err != nil {
+			app.removeUploadFile(ctx, session, verified)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		if problem != "" {
+			app.removeUploadFile(ctx, session, verified)
+			app.removeUploadFile(ctx, session, session.Key)
+			c.JSON(http.StatusBadRequest, gin.H{"Error": problem})
+			return
+		}
+
+		err = app.repos.UploadSessions.Confirm(ctx, session.ID, verified)
+		if errors.Is(err, repository.ErrNotFound) {
+			// confirmed meanwhile by another request, with its own copy
+			app.removeUploadFile(ctx, session, verified)
+			session, err = app.repos.UploadSessions.FindByID(ctx, session.ID)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
+		session.Status = models.UploadConfirmed
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// checkUpload tells what's wrong with the file under key for session, ""
+// when it has the declared size and type.
+func (app *Application) checkUpload(ctx context.Context, session *models.UploadSession, key string) (string, error) {
+	info, err := app.storage.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if info.Size != session.Size {
+		return fmt.Sprintf("%d bytes were uploaded, %d were declared", info.Size, session.Size), nil
+	}
+	// Only the first few kilobytes are read to tell the type.
+	body, err := app.storage.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	mtype, err := mimetype.DetectReader(body)
+	if err != nil {
+		return "", err
+	}
+	if !mtype.Is(session.Content_type) {
+		return fmt.Sprintf("the upload is %s, %s was declared", mtype.String(), session.Content_type), nil
+	}
+	return "", nil
+}
+
+func (app *Application) removeUploadFile(ctx context.Context, session *models.UploadSession, key string) {
+	if err := app.storage.Delete(ctx, key); err != nil {
+		log.Println("uploads: removing file of", session.ID.Hex()+":", err)
+	}
+}
+
+// ReceiveStoredFile takes the PUTs of upload sessions when files are kept
+// by the local driver, see ServeStoredFile.
+func (app *Application) ReceiveStoredFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		local, ok := app.storage.(*storage.Local)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Not found"})
+			return
+		}
+		local.PutHandler()(c)
+	}
+}
+
+// claimUpload takes the confirmed upload session id of owner to attach it.
+func (app *Application) claimUpload(ctx context.Context, id, owner string) (*models.UploadSession, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errUploadNotFound
+	}
+	session, err := app.repos.UploadSessions.Claim(ctx, objID, owner)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.Expires_at) {
+		// being removed by the cleanup
+		return nil, errUploadNotFound
+	}
+	return session, nil
+}
+
+// releaseUpload hands back a claimed session that couldn't be attached, so
+// it can be tried again.
+func (app *Application) releaseUpload(ctx context.Context, session *models.UploadSession) {
+	err := app.repos.UploadSessions.Update(ctx, session.ID, repository.Update{Set: repository.Fields{"status": models.UploadConfirmed}})
+	if err != nil {
+		log.Println("uploads: releasing", session.ID.Hex()+":", err)
+	}
+}
+
+// finishUpload marks a session attached and removes its checked copy,
+// which has been moved under the prefix of its document. The session stays
+// until it expires, for the cleanup to remove what's put to its URL
+// meanwhile.
+func (app *Application) finishUpload(ctx context.Context, session *models.UploadSession) {
+	app.removeUploadFile(ctx, session, session.Verified_key)
+	err := app.repos.UploadSessions.Update(ctx, session.ID, repository.Update{Set: repository.Fields{"status": models.UploadAttached}})
+	if err != nil {
+		log.Println("uploads: marking", session.ID.Hex(), "attached:", err)
+	}
+}
+
+// attachDocument moves the file of the confirmed upload session id of owner
+// under prefix and returns its URL, like saveFile.
+func (app *Application) attachDocument(ctx context.Context, id, owner, prefix string) (string, error) {
+	session, err := app.claimUpload(ctx, id, owner)
+	if err != nil {
+		return "", err
+	}
+	url, err := app.storage.Copy(ctx, session.Verified_key, storage.NewKey(prefix, path.Ext(session.Verified_key)), storage.Metadata{
+		ContentType: session.Content_type,
+		Filename:    session.Filename,
+	})
+	if err != nil {
+		app.releaseUpload(ctx, session)
+		return "", err
+	}
+	app.finishUpload(ctx, session)
+	return url, nil
+}
+
+// attachImage stores the image of the confirmed upload session id of owner
+// under prefix with its renditions, like saveImage.
+func (app *Application) attachImage(ctx context.Context, id, owner, prefix string) (models.Image, error) {
+	session, err := app.claimUpload(ctx, id, owner)
+	if err != nil {
+		return models.Image{}, err
+	}
+	if session.Kind != models.UploadImage {
+		app.releaseUpload(ctx, session)
+		return models.Image{}, errUploadNotImage
+	}
+	data, err := app.storage.Get(ctx, session.Verified_key)
+	if err != nil {
+		app.releaseUpload(ctx, session)
+		return models.Image{}, err
+	}
+	image, err := app.storeImage(ctx, prefix, session.Filename, data)
+	if err != nil {
+		app.releaseUpload(ctx, session)
+		return models.Image{}, err
+	}
+	app.finishUpload(ctx, session)
+	return image, nil
+}
+
+// hasFormUpload tells whether a form carries a file or an upload session
+// in field.
+func hasFormUpload(c *gin.Context, field string) bool {
+	if _, err := c.FormFile(field); err == nil {
+		return true
+	}
+	return c.PostForm(field) != ""
+}
+
+// formDocument stores the document in field of a form, a file or the ID of
+// an upload session of owner, under prefix. It returns "" when there is
+// neither.
+func (app *Application) formDocument(ctx context.Context, c *gin.Context, field, owner, prefix string) (string, error) {
+	if file, err := c.FormFile(field); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return app.saveFile(f, file, prefix)
+	}
+	if id := c.PostForm(field); id != "" {
+		return app.attachDocument(ctx, id, owner, prefix)
+	}
+	return "", nil
+}
+
+// formDocuments stores every document in field of a form, files first and
+// then upload sessions of owner, under prefix.
+func (app *Application) formDocuments(ctx context.Context, c *gin.Context, field, owner, prefix string) ([]string, error) {
+	var urls []string
+	if form, err := c.MultipartForm(); err == nil {
+		for _, file := range form.File[field] {
+			f, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			url, err := app.saveFile(f, file, prefix)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, url)
+		}
+	}
+	for _, id := range c.PostFormArray(field) {
+		if id == "" {
+			continue
+		}
+		url, err := app.attachDocument(ctx, id, owner, prefix)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// formImage stores the image in field of a form, a file or the ID of an
+// upload session of owner, under prefix. It returns a zero Image when there
+// is neither.
+func (app *Application) formImage(ctx context.Context, c *gin.Context, field, owner, prefix string) (models.Image, error) {
+	if file, err := c.FormFile(field); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return models.Image{}, err
+		}
+		defer f.Close()
+		return app.saveImage(f, file, prefix)
+	}
+	if id := c.PostForm(field); id != "" {
+		return app.attachImage(ctx, id, owner, prefix)
+	}
+	return models.Image{}, nil
+}
+
+// RunUploadCleanup removes the expired upload sessions, and their files,
+// right away and then every configured interval
+// until ctx is done.
+func (app *Application) RunUploadCleanup(ctx context.Context) {
+	ticker := time.NewTicker(app.config.Uploads.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		if err := app.cleanupUploads(ctx); err != nil {
+			log.Println("uploads: cleaning up:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *Application) cleanupUploads(ctx context.Context) error {
+	const batch = 100
+	// Sessions whose files couldn't be removed are kept for the next run,
+	// and come back in every batch until then.
+	failed := map[primitive.ObjectID]bool{}
+	for {
+		sessions, err := app.repos.UploadSessions.FindExpired(ctx, time.Now(), batch)
+		if err != nil {
+			return err
+		}
+		removed := 0
+	sessions:
+		for _, session := range sessions {
+			if failed[session.ID] {
+				continue
+			}
+			for _, key := range []string{session.Key, session.Verified_key} {
+				if key == "" {
+					continue
+				}
+				if err := app.storage.Delete(ctx, key); err != nil {
+					log.Println("uploads: removing file of", session.ID.Hex()+":", err)
+					failed[session.ID] = true
+					continue sessions
+				}
+			}
+			if err := app.repos.UploadSessions.Delete(ctx, session.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			removed++
+		}
+		if len(sessions) < batch || removed == 0 {
+			break
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d expired upload sessions kept, their files couldn't be removed", len(failed))
+	}
+	return nil
+}
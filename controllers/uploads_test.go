@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newUploadTestApp(t *testing.T) (*Application, *storage.Local, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, err := storage.NewLocal(storage.LocalConfig{Dir: t.TempDir(), PublicURL: "http://test", SigningKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApplication(config.Default(), repository.NewMemory(), store, nil, nil, nil, nil)
+	router := gin.New()
+	router.PUT(storage.LocalRoutePrefix+"/*key", app.ReceiveStoredFile())
+	router.POST("/seller/registration/uploads", app.CreateUploadSession())
+	router.POST("/seller/registration/uploads/:id/confirm", app.ConfirmUploadSession())
+	return app, store, router
+}
+
+func serve(router *gin.Engine, method, target, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadAttachesTheConfirmedFile(t *testing.T) {
+	app, store, router := newUploadTestApp(t)
+	ctx := context.Background()
+	seller := models.Seller{ID: primitive.NewObjectID(), MobileNo: "9000000001"}
+	if err := app.repos.Sellers.Insert(ctx, &seller); err != nil {
+		t.Fatal(err)
+	}
+
+	pdf := []byte("%PDF-1.4\n%%EOF\n")
+	create, _ := json.Marshal(gin.H{
+		"kind":         models.UploadDocument,
+		"filename":     "licence.pdf",
+		"content_type": "application/pdf",
+		"size":         len(pdf),
+		"mobileno":     seller.MobileNo,
+	})
+	w := serve(router, http.MethodPost, "/seller/registration/uploads", "application/json", create)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		Upload models.UploadSession `json:"upload"`
+		URL    string               `json:"url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	putURL, err := url.Parse(created.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(body []byte) int {
+		req := httptest.NewRequest(http.MethodPut, putURL.RequestURI(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/pdf")
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	id := created.Upload.ID.Hex()
+	confirm, _ := json.Marshal(gin.H{"mobileno": seller.MobileNo})
+
+	if w := serve(router, http.MethodPost, "/seller/registration/uploads/"+id+"/confirm", "application/json", confirm); w.Code != http.StatusBadRequest {
+		t.Fatalf("confirm before put: %d %s", w.Code, w.Body)
+	}
+	if code := put(pdf); code != http.StatusOK {
+		t.Fatalf("put: %d", code)
+	}
+	if w := serve(router, http.MethodPost, "/seller/registration/uploads/"+id+"/confirm", "application/json", confirm); w.Code != http.StatusOK {
+		t.Fatalf("confirm: %d %s", w.Code, w.Body)
+	}
+
+	// The URL is still valid: other bytes of the declared length can be put
+	// after the check, and must not be what gets attached.
+	if code := put([]byte("GIF89a-not-a-pdf")[:len(pdf)]); code != http.StatusOK {
+		t.Fatalf("second put: %d", code)
+	}
+	attached, err := app.attachDocument(ctx, id, seller.ID.Hex(), storage.SellerKYCPrefix(seller.ID.Hex()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := store.Get(ctx, store.KeyFromURL(attached))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, pdf) {
+		t.Errorf("attached %q, want the confirmed %q", data, pdf)
+	}
+	if _, err := app.attachDocument(ctx, id, seller.ID.Hex(), storage.SellerKYCPrefix(seller.ID.Hex())); err != errUploadNotFound {
+		t.Errorf("second attach: %v, want %v", err, errUploadNotFound)
+	}
+}
+
+func TestUploadConfirmRejectsAnotherType(t *testing.T) {
+	app, _, router := newUploadTestApp(t)
+	ctx := context.Background()
+	seller := models.Seller{ID: primitive.NewObjectID(), MobileNo: "9000000002"}
+	if err := app.repos.Sellers.Insert(ctx, &seller); err != nil {
+		t.Fatal(err)
+	}
+	session := models.UploadSession{
+		ID:           primitive.NewObjectID(),
+		Owner:        seller.ID.Hex(),
+		Kind:         models.UploadDocument,
+		Filename:     "licence.pdf",
+		Content_type: "application/pdf",
+		Status:       models.UploadPending,
+		Expires_at:   time.Now().Add(app.config.Uploads.TTL),
+	}
+	body := []byte("GIF89a-not-a-pdf")
+	session.Size = int64(len(body))
+	session.Key = storage.NewKey(storage.UploadPrefix(session.ID.Hex()), ".pdf")
+	if _, err := app.storage.Put(ctx, session.Key, bytes.NewReader(body), storage.Metadata{ContentType: "application/pdf"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.repos.UploadSessions.Insert(ctx, &session); err != nil {
+		t.Fatal(err)
+	}
+
+	confirm, _ := json.Marshal(gin.H{"mobileno": seller.MobileNo})
+	w := serve(router, http.MethodPost, "/seller/registration/uploads/"+session.ID.Hex()+"/confirm", "application/json", confirm)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("confirm: %d %s", w.Code, w.Body)
+	}
+	if _, err := app.storage.Stat(ctx, session.Key); err != storage.ErrNotFound {
+		t.Errorf("rejected upload still stored: %v", err)
+	}
+	stored, err := app.repos.UploadSessions.FindByID(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.UploadPending || stored.Verified_key != "" {
+		t.Errorf("rejected upload is %s with copy %q", stored.Status, stored.Verified_key)
+	}
+}
+
+func TestUploadRegistrationRefusesApprovedSeller(t *testing.T) {
+	app, _, router := newUploadTestApp(t)
+	seller := models.Seller{ID: primitive.NewObjectID(), MobileNo: "9000000003", Approved: true}
+	if err := app.repos.Sellers.Insert(context.Background(), &seller); err != nil {
+		t.Fatal(err)
+	}
+	create, _ := json.Marshal(gin.H{
+		"kind":         models.UploadDocument,
+		"content_type": "application/pdf",
+		"size":         100,
+		"mobileno":     seller.MobileNo,
+	})
+	if w := serve(router, http.MethodPost, "/seller/registration/uploads", "application/json", create); w.Code != http.StatusForbidden {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+}
+
+// failingDeleteStore fails to delete the keys it lists.
+type failingDeleteStore struct {
+	storage.Storage
+	fail map[string]bool
+}
+
+func (s *failingDeleteStore) Delete(ctx context.Context, key string) error {
+	if s.fail[key] {
+		return errors.New("delete failed")
+	}
+	return s.Storage.Delete(ctx, key)
+}
+
+func TestUploadCleanupGoesOnAfterFailedDeletes(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	app, store, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	failing := &failingDeleteStore{Storage: store, fail: map[string]bool{}}
+	app.storage = failing
+	insert := func(key string) *models.UploadSession {
+		t.Helper()
+		session := &models.UploadSession{ID: primitive.NewObjectID(), Key: key, Expires_at: time.Now().Add(-time.Minute)}
+		if err := app.repos.UploadSessions.Insert(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+		return session
+	}
+
+	kept := insert("uploads/kept")
+	failing.fail[kept.Key] = true
+	removed := insert("uploads/removed")
+	if err := app.cleanupUploads(ctx); err == nil {
+		t.Error("the failed delete wasn't reported")
+	}
+	if _, err := app.repos.UploadSessions.FindByID(ctx, kept.ID); err != nil {
+		t.Errorf("session of the file left behind: %v", err)
+	}
+	if _, err := app.repos.UploadSessions.FindByID(ctx, removed.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("session after the failed one: %v", err)
+	}
+
+	// More failures than a batch holds still end the run.
+	for i := 0; i < 150; i++ {
+		failing.fail[insert("uploads/"+strconv.Itoa(i)).Key] = true
+	}
+	done := make(chan error, 1)
+	go func() { done <- app.cleanupUploads(ctx) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("the failed deletes weren't reported")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup didn't end")
+	}
+}
@@ -17,7 +17,7 @@ import (
 )
 
 // update user profile
-func UpdateUserProfile() gin.HandlerFunc {
+func (app *Application) UpdateUserProfile() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -75,7 +75,7 @@ func UpdateUserProfile() gin.HandlerFunc {
 
 		var foundUser models.USer
 
-		updateErr := UserCollection.FindOneAndUpdate(ctx, filter, update).Decode(&foundUser)
+		updateErr := app.userCollection.FindOneAndUpdate(ctx, filter, update).Decode(&foundUser)
 
 		if updateErr != nil {
 			if errors.Is(updateErr, mongo.ErrNoDocuments) {
@@ -96,7 +96,7 @@ func UpdateUserProfile() gin.HandlerFunc {
 
 /*Admin Route function*/
 /* fetch All user details name,email,mobileno, address */
-func GetAllUsers() gin.HandlerFunc {
+func (app *Application) GetAllUsers() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -114,18 +114,18 @@ func GetAllUsers() gin.HandlerFunc {
 		startIndex := (page - 1) * recordPerPage
 		startIndex, err = strconv.Atoi(c.Query("startIndex"))
 
-		matchStage := bson.D{{"$match", bson.D{{}}}}
+		matchStage := bson.D{{Key: "$match", Value: bson.D{}}}
 
-		countStage := bson.D{{"$count", "count"}}
+		countStage := bson.D{{Key: "$count", Value: "count"}}
 
 		projectStage := bson.D{
-			{"$project", bson.D{
-				{"_id", 0},
-				{"total", 1},
-				{"userItems", bson.D{{"$slice", []interface{}{"$data", startIndex, recordPerPage}}}},
+			{Key: "$project", Value: bson.D{
+				{Key: "_id", Value: 0},
+				{Key: "total", Value: 1},
+				{Key: "userItems", Value: bson.D{{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}}}},
 			}}}
 
-		result, err := UserCollection.Aggregate(ctx, mongo.Pipeline{
+		result, err := app.userCollection.Aggregate(ctx, mongo.Pipeline{
 			matchStage, countStage, projectStage,
 		})
 
@@ -144,7 +144,7 @@ func GetAllUsers() gin.HandlerFunc {
 	}
 }
 
-func GetUsersDetails_Admin() gin.HandlerFunc {
+func (app *Application) GetUsersDetails_Admin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -152,7 +152,7 @@ func GetUsersDetails_Admin() gin.HandlerFunc {
 		// Define the projection to include only the specified fields
 
 		// Query the collection with the projection
-		cursor, err := UserCollection.Find(ctx, bson.M{})
+		cursor, err := app.userCollection.Find(ctx, bson.M{})
 		if err != nil {
 			fmt.Println(err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Error occurred while fetching users"})
@@ -169,13 +169,13 @@ func GetUsersDetails_Admin() gin.HandlerFunc {
 	}
 }
 
-func GetUserCsv(c *gin.Context) {
+func (app *Application) GetUserCsv(c *gin.Context) {
 	var users []models.USer
 
 	var ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	cursor, err := UserCollection.Find(ctx, bson.M{})
+	cursor, err := app.userCollection.Find(ctx, bson.M{})
 	if err != nil {
 		c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Error fetching users: " + err.Error()})
 		return
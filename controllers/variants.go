@@ -0,0 +1,526 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// findVariant returns the variant of product with the hex id, nil when
+// there is none.
+func findVariant(product *models.Product, id string) *models.ProductVariant {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	for i := range product.Variant {
+		if product.Variant[i].ID == oid {
+			return &product.Variant[i]
+		}
+	}
+	return nil
+}
+
+// variantPricing returns product priced as variant: the variant's price and
+// tiers when it has any, the product's otherwise.
+func variantPricing(product *models.Product, variant *models.ProductVariant) *models.Product {
+	if variant == nil || (variant.Price.IsZero() && len(variant.PriceRange) == 0) {
+		return product
+	}
+	priced := *product
+	priced.Price = variant.Price
+	priced.PriceRange = variant.PriceRange
+	return &priced
+}
+
+// variantKey identifies the attribute values of a variant independent of
+// their order.
+func variantKey(variant *models.ProductVariant) string {
+	values := make(map[primitive.ObjectID]string, len(variant.Attribute))
+	for _, attribute := range variant.Attribute {
+		values[attribute.AttributeType] = strings.Join(attribute.Value, "|")
+	}
+	keys := make([]string, 0, len(values))
+	for id, value := range values {
+		keys = append(keys, id.Hex()+"="+strings.ToLower(value))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ";")
+}
+
+// validateVariant checks variant against the other variants of product.
+// Every attribute of a variant takes exactly one of the values the product
+// lists for that attribute, and no two variants share their values or SKU.
+func validateVariant(product *models.Product, variant *models.ProductVariant) error {
+	if variant.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if len(variant.Attribute) == 0 {
+		return errors.New("a variant needs at least one attribute")
+	}
+	if variant.Stock < 0 {
+		return errors.New("stock cannot be negative")
+	}
+	if variant.Price.Amount < 0 {
+		return errors.New("price is not a valid amount")
+	}
+
+	offered := map[primitive.ObjectID][]string{}
+	for _, attribute := range product.Attributes {
+		offered[attribute.AttributeType] = append(offered[attribute.AttributeType], attribute.Value...)
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for i, attribute := range variant.Attribute {
+		values, ok := offered[attribute.AttributeType]
+		if !ok {
+			return fmt.Errorf("attribute %s is not an attribute of the product", attribute.AttributeType.Hex())
+		}
+		if seen[attribute.AttributeType] {
+			return fmt.Errorf("attribute %s is given more than once", attribute.AttributeType.Hex())
+		}
+		seen[attribute.AttributeType] = true
+		if len(attribute.Value) != 1 {
+			return fmt.Errorf("attribute %s needs exactly one value", attribute.AttributeType.Hex())
+		}
+		known := ""
+		for _, value := range values {
+			if strings.EqualFold(value, attribute.Value[0]) {
+				known = value
+				break
+			}
+		}
+		if known == "" {
+			return fmt.Errorf("%q is not a value of attribute %s on this product", attribute.Value[0], attribute.AttributeType.Hex())
+		}
+		variant.Attribute[i].Value = []string{known}
+	}
+
+	key := variantKey(variant)
+	for i := range product.Variant {
+		other := &product.Variant[i]
+		if other.ID == variant.ID {
+			continue
+		}
+		if strings.EqualFold(other.SKU, variant.SKU) {
+			return fmt.Errorf("sku %q is already used by another variant", variant.SKU)
+		}
+		if variantKey(other) == key {
+			return errors.New("a variant with these attribute values already exists")
+		}
+	}
+	return nil
+}
+
+// skuTaken reports whether sku is used by a product other than productID or
+// by a variant of one.
+func (app *Application) skuTaken(ctx context.Context, productID primitive.ObjectID, sku string) (bool, error) {
+	for _, filter := range []repository.ProductFilter{{SKU: sku}, {VariantSKU: sku}} {
+		products, err := app.repos.Products.Find(ctx, filter, repository.Page{Limit: 2})
+		if err != nil {
+			return false, err
+		}
+		for _, product := range products {
+			if product.Product_ID != productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// variantProduct loads the product of the :id parameter. Sellers can only
+// change the variants of their own products.
+func (app *Application) variantProduct(ctx context.Context, c *gin.Context) (*models.Product, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid product ID"})
+		return nil, false
+	}
+	product, err := app.repos.Products.FindByID(ctx, id)
+	if err == nil && c.GetString("role") == utils.Seller && !containsSeller(product.SellerRegistered, c.GetString("uid")) {
+		err = repository.ErrNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Product not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return nil, false
+	}
+	return product, true
+}
+
+// variantDraft returns the version of product a variant change applies to:
+// for sellers the draft of a revision, as their changes go through review
+// like SellerUpdateProduct, for admins the live product.
+func (app *Application) variantDraft(ctx context.Context, c *gin.Context, product *models.Product) (*models.Product, *models.ProductRevision, bool) {
+	if c.GetString("role") != utils.Seller {
+		return product, nil, true
+	}
+	draft, pending, err := app.revisionDraft(ctx, product)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return nil, nil, false
+	}
+	// Variants are changed in place, they can't be shared with product.
+	draft.Variant = append([]models.ProductVariant(nil), draft.Variant...)
+	return draft, pending, true
+}
+
+// bindVariant applies the fields of a variant form to variant. Fields left
+// out of the form keep their value. The files of the form are only stored
+// once the variant is valid, by saveVariantImages.
+func bindVariant(c *gin.Context, variant *models.ProductVariant) error {
+	if sku, ok := c.GetPostForm("sku"); ok {
+		variant.SKU = strings.TrimSpace(sku)
+	}
+	if attributes := c.PostForm("attributes"); attributes != "" {
+		variant.Attribute = nil
+		if err := json.Unmarshal([]byte(attributes), &variant.Attribute); err != nil {
+			return errors.New("Error while parsing attributes")
+		}
+	}
+	if price, ok := c.GetPostForm("price"); ok {
+		variant.Price = money.Money{}
+		if strings.TrimSpace(price) != "" {
+			parsed, err := money.Parse(price, money.DefaultCurrency)
+			if err != nil {
+				return errors.New("price is not a valid amount")
+			}
+			variant.Price = parsed
+		}
+	}
+	if priceRanges, ok := c.GetPostForm("priceRange"); ok {
+		variant.PriceRange = nil
+		if priceRanges != "" {
+			if err := json.Unmarshal([]byte(priceRanges), &variant.PriceRange); err != nil {
+				return errors.New("Error while parsing price range")
+			}
+		}
+	}
+	if stock, ok := c.GetPostForm("stock"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(stock))
+		if err != nil {
+			return errors.New("stock must be a number")
+		}
+		variant.Stock = n
+	}
+	return nil
+}
+
+// saveVariantImages stores the files of a variant form with the images of
+// product and adds them to variant. It returns the images it stored, none
+// are left behind when one fails.
+func (app *Application) saveVariantImages(ctx context.Context, c *gin.Context, product *models.Product, variant *models.ProductVariant) ([]models.Image, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		// A plain urlencoded form carries no images.
+		return nil, nil
+	}
+	var saved []models.Image
+	for _, file := range form.File["files"] {
+		f, err := file.Open()
+		if err != nil {
+			app.removeImages(ctx, saved)
+			return nil, fmt.Errorf("Error opening file %s", file.Filename)
+		}
+		image, err := app.saveImage(f, file, storage.ProductImagesPrefix(product.Product_ID.Hex()))
+		f.Close()
+		if err != nil {
+			app.removeImages(ctx, saved)
+			return nil, fmt.Errorf("Error saving file %s: %s", file.Filename, err.Error())
+		}
+		saved = append(saved, image)
+	}
+	variant.Image = append(variant.Image, saved...)
+	return saved, nil
+}
+
+// saveVariants validates variant against the other variants of draft, then
+// stores the images of the form and the variants of draft, see
+// storeVariants. The images are removed again when that fails.
+func (app *Application) saveVariants(ctx context.Context, c *gin.Context, live, draft *models.Product, pending *models.ProductRevision, variant *models.ProductVariant) (*models.ProductRevision, bool) {
+	if err := validateVariant(draft, variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return nil, false
+	}
+	taken, err := app.skuTaken(ctx, draft.Product_ID, variant.SKU)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "something went wrong"})
+		return nil, false
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"Error": fmt.Sprintf("sku %q is already used by another product", variant.SKU)})
+		return nil, false
+	}
+	uploaded, err := app.saveVariantImages(ctx, c, draft, variant)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return nil, false
+	}
+	variant.Updated_at = time.Now()
+	revision, ok := app.storeVariants(ctx, c, live, draft, pending)
+	if !ok {
+		app.removeImages(ctx, uploaded)
+	}
+	return revision, ok
+}
+
+// storeVariants writes the variants of draft to the live product for
+// admins. For sellers it submits draft as a revision of live, which it
+// returns.
+func (app *Application) storeVariants(ctx context.Context, c *gin.Context, live, draft *models.Product, pending *models.ProductRevision) (*models.ProductRevision, bool) {
+	if c.GetString("role") == utils.Seller {
+		revision, err := app.submitRevision(ctx, c.GetString("uid"), c.GetString("role"), live, draft, pending)
+		if err == errNoChanges {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Nothing to update"})
+			return nil, false
+		}
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while updating product"})
+			return nil, false
+		}
+		return revision, true
+	}
+
+	err := app.repos.Products.Update(ctx, live.Product_ID, repository.Update{Set: repository.Fields{
+		"variant":    draft.Variant,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error while updating product"})
+		return nil, false
+	}
+	return nil, true
+}
+
+// variantSubmitted answers a variant change that waits for review, and
+// reports whether there was one.
+func variantSubmitted(c *gin.Context, revision *models.ProductRevision) bool {
+	if revision == nil {
+		return false
+	}
+	c.JSON(http.StatusAccepted, gin.H{"Message": "Variant changes submitted for review", "revision": revisionSummary(*revision)})
+	return true
+}
+
+// AddProductVariant adds a variant to the product of the :id parameter
+// from a multipart form with sku, attributes (a JSON list of attribute
+// values, one value each), price, priceRange, stock and image files. The
+// variant of a seller goes live once an admin approves the revision.
+func (app *Application) AddProductVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		product, ok := app.variantProduct(ctx, c)
+		if !ok {
+			return
+		}
+		draft, pending, ok := app.variantDraft(ctx, c, product)
+		if !ok {
+			return
+		}
+		variant := models.ProductVariant{ID: primitive.NewObjectID(), Image: []models.Image{}, Created_at: time.Now()}
+		if err := bindVariant(c, &variant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		draft.Variant = append(draft.Variant, variant)
+		added := &draft.Variant[len(draft.Variant)-1]
+		revision, ok := app.saveVariants(ctx, c, product, draft, pending, added)
+		if !ok || variantSubmitted(c, revision) {
+			return
+		}
+		c.JSON(http.StatusOK, added)
+	}
+}
+
+// UpdateProductVariant changes the fields of the variant :variantId given
+// in the form, see AddProductVariant.
+func (app *Application) UpdateProductVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		product, ok := app.variantProduct(ctx, c)
+		if !ok {
+			return
+		}
+		draft, pending, ok := app.variantDraft(ctx, c, product)
+		if !ok {
+			return
+		}
+		variant := findVariant(draft, c.Param("variantId"))
+		if variant == nil {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Variant not found"})
+			return
+		}
+		if err := bindVariant(c, variant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		revision, ok := app.saveVariants(ctx, c, product, draft, pending, variant)
+		if !ok || variantSubmitted(c, revision) {
+			return
+		}
+		c.JSON(http.StatusOK, variant)
+	}
+}
+
+// DeleteProductVariant removes the variant :variantId from its product.
+func (app *Application) DeleteProductVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		product, ok := app.variantProduct(ctx, c)
+		if !ok {
+			return
+		}
+		draft, pending, ok := app.variantDraft(ctx, c, product)
+		if !ok {
+			return
+		}
+		variant := findVariant(draft, c.Param("variantId"))
+		if variant == nil {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Variant not found"})
+			return
+		}
+		variants := make([]models.ProductVariant, 0, len(draft.Variant)-1)
+		for _, v := range draft.Variant {
+			if v.ID != variant.ID {
+				variants = append(variants, v)
+			}
+		}
+		draft.Variant = variants
+		revision, ok := app.storeVariants(ctx, c, product, draft, pending)
+		if !ok || variantSubmitted(c, revision) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"Message": "Variant deleted successfully"})
+	}
+}
+
+// variantDimension is one axis of a variant matrix: an attribute and the
+// values variants take in it.
+type variantDimension struct {
+	AttributeType primitive.ObjectID `json:"attribute_type"`
+	Name          string             `json:"name"`
+	Values        []string           `json:"values"`
+}
+
+// variantCell is one variant of a variant matrix. Options maps the hex id
+// of each attribute type to the value of the variant.
+type variantCell struct {
+	ID         primitive.ObjectID         `json:"_id"`
+	SKU        string                     `json:"sku"`
+	Options    map[string]string          `json:"options"`
+	Price      money.Money                `json:"price"`
+	PriceRange []models.ProductPriceRange `json:"pricerange"`
+	Image      []models.Image             `json:"image"`
+	Stock      int                        `json:"stock"`
+	InStock    bool                       `json:"in_stock"`
+}
+
+type variantMatrix struct {
+	Dimensions []variantDimension `json:"dimensions"`
+	Variants   []variantCell      `json:"variants"`
+}
+
+// buildVariantMatrix lays the variants of product out by attribute, in the
+// order the product lists its attributes and values. Prices are the
+// effective ones, variants without their own take the product's.
+func buildVariantMatrix(product *models.Product, attributes []models.AttributeType) variantMatrix {
+	names := map[primitive.ObjectID]string{}
+	for _, attribute := range attributes {
+		names[attribute.ID] = attribute.Attribute_Name
+	}
+
+	used := map[primitive.ObjectID]map[string]bool{}
+	matrix := variantMatrix{Dimensions: []variantDimension{}, Variants: []variantCell{}}
+	for i := range product.Variant {
+		variant := &product.Variant[i]
+		priced := variantPricing(product, variant)
+		cell := variantCell{
+			ID:         variant.ID,
+			SKU:        variant.SKU,
+			Options:    map[string]string{},
+			Price:      priced.Price,
+			PriceRange: priced.PriceRange,
+			Image:      variant.Image,
+			Stock:      variant.Stock,
+			InStock:    variant.Stock > 0,
+		}
+		if cell.Image == nil {
+			cell.Image = []models.Image{}
+		}
+		for _, attribute := range variant.Attribute {
+			if len(attribute.Value) == 0 {
+				continue
+			}
+			cell.Options[attribute.AttributeType.Hex()] = attribute.Value[0]
+			if used[attribute.AttributeType] == nil {
+				used[attribute.AttributeType] = map[string]bool{}
+			}
+			used[attribute.AttributeType][attribute.Value[0]] = true
+		}
+		matrix.Variants = append(matrix.Variants, cell)
+	}
+
+	added := map[primitive.ObjectID]bool{}
+	addDimension := func(id primitive.ObjectID, order []string) {
+		if added[id] || used[id] == nil {
+			return
+		}
+		added[id] = true
+		dimension := variantDimension{AttributeType: id, Name: names[id], Values: []string{}}
+		listed := map[string]bool{}
+		for _, value := range order {
+			if used[id][value] && !listed[value] {
+				listed[value] = true
+				dimension.Values = append(dimension.Values, value)
+			}
+		}
+		// Values the product no longer lists go last.
+		var rest []string
+		for value := range used[id] {
+			if !listed[value] {
+				rest = append(rest, value)
+			}
+		}
+		sort.Strings(rest)
+		dimension.Values = append(dimension.Values, rest...)
+		matrix.Dimensions = append(matrix.Dimensions, dimension)
+	}
+	for _, attribute := range product.Attributes {
+		addDimension(attribute.AttributeType, attribute.Value)
+	}
+	for i := range product.Variant {
+		for _, attribute := range product.Variant[i].Attribute {
+			addDimension(attribute.AttributeType, nil)
+		}
+	}
+	return matrix
+}
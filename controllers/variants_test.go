@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/repository"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"github.com/kravi0/BizGrowth-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSellerVariantChangesGoThroughReview(t *testing.T) {
+	app, _, _ := newUploadTestApp(t)
+	ctx := context.Background()
+	sellerID := primitive.NewObjectID().Hex()
+	colour := primitive.NewObjectID()
+	product := models.Product{
+		Product_ID:       primitive.NewObjectID(),
+		Product_Name:     "Shirt",
+		SKU:              "SHIRT",
+		Approved:         true,
+		SellerRegistered: []string{sellerID},
+		Attributes:       []models.AttributeValue{{AttributeType: colour, Value: []string{"Red", "Blue"}}},
+	}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+
+	as := func(uid, role string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("uid", uid)
+			c.Set("role", role)
+		}
+	}
+	router := gin.New()
+	router.POST("/seller/product/:id/variants", as(sellerID, utils.Seller), app.AddProductVariant())
+	router.POST("/admin/revisions/:id/approve", as(primitive.NewObjectID().Hex(), utils.Admin), app.ApproveProductRevision())
+
+	attributes, _ := json.Marshal([]models.AttributeValue{{AttributeType: colour, Value: []string{"red"}}})
+	form := url.Values{"sku": {"SHIRT-RED"}, "attributes": {string(attributes)}, "stock": {"3"}}
+	w := serve(router, http.MethodPost, "/seller/product/"+product.Product_ID.Hex()+"/variants", "application/x-www-form-urlencoded", []byte(form.Encode()))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("add: %d %s", w.Code, w.Body)
+	}
+	var submitted struct {
+		Revision struct {
+			ID     primitive.ObjectID `json:"id"`
+			Fields []string           `json:"fields"`
+		} `json:"revision"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &submitted); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(submitted.Revision.Fields, ",") != "variant" {
+		t.Errorf("revision changes %v, want [variant]", submitted.Revision.Fields)
+	}
+	live, err := app.repos.Products.FindByID(ctx, product.Product_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(live.Variant) != 0 {
+		t.Fatalf("variant went live before review: %+v", live.Variant)
+	}
+
+	w = serve(router, http.MethodPost, "/admin/revisions/"+submitted.Revision.ID.Hex()+"/approve", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", w.Code, w.Body)
+	}
+	live, err = app.repos.Products.FindByID(ctx, product.Product_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(live.Variant) != 1 || live.Variant[0].SKU != "SHIRT-RED" || live.Variant[0].Attribute[0].Value[0] != "Red" {
+		t.Errorf("approved variants %+v", live.Variant)
+	}
+}
+
+func TestInvalidVariantStoresNoImages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	store, err := storage.NewLocal(storage.LocalConfig{Dir: dir, PublicURL: "http://test", SigningKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApplication(config.Default(), repository.NewMemory(), store, nil, nil, nil, nil)
+	ctx := context.Background()
+	colour := primitive.NewObjectID()
+	product := models.Product{
+		Product_ID: primitive.NewObjectID(),
+		SKU:        "MUG",
+		Attributes: []models.AttributeValue{{AttributeType: colour, Value: []string{"White"}}},
+	}
+	if err := app.repos.Products.Insert(ctx, &product); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.POST("/product/:id/variants", func(c *gin.Context) { c.Set("role", utils.Admin) }, app.AddProductVariant())
+
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	add := func(value string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		attributes, _ := json.Marshal([]models.AttributeValue{{AttributeType: colour, Value: []string{value}}})
+		form.WriteField("sku", "MUG-"+value)
+		form.WriteField("attributes", string(attributes))
+		file, _ := form.CreateFormFile("files", "mug.png")
+		file.Write(picture.Bytes())
+		form.Close()
+		return serve(router, http.MethodPost, "/product/"+product.Product_ID.Hex()+"/variants", form.FormDataContentType(), body.Bytes())
+	}
+	stored := func() int {
+		n := 0
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				n++
+			}
+			return nil
+		})
+		return n
+	}
+
+	if w := add("Black"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid variant: %d %s", w.Code, w.Body)
+	}
+	if n := stored(); n != 0 {
+		t.Errorf("an invalid variant left %d files", n)
+	}
+	if w := add("White"); w.Code != http.StatusOK {
+		t.Fatalf("valid variant: %d %s", w.Code, w.Body)
+	}
+	if stored() == 0 {
+		t.Error("the images of a valid variant weren't stored")
+	}
+}
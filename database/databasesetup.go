@@ -4,26 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/url"
-	"os"
 	"time"
 
+	"github.com/kravi0/BizGrowth-backend/config"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func DBSet() *mongo.Client {
+func DBSet(cfg config.Mongo) *mongo.Client {
 
-	/*if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-	}*/
-
-	username := url.QueryEscape(os.Getenv("DB_USER"))
-	password := url.QueryEscape(os.Getenv("DB_PASSWORD"))
-
-	uri := "mongodb+srv://" + username + ":" + password + "@grwothbiz.srweepy.mongodb.net/?retryWrites=true&w=majority&appName=GrwothBiz"
-
-	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
+	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.ConnectionURI()))
 
 	if err != nil {
 		log.Fatal(err)
@@ -48,17 +38,7 @@ func DBSet() *mongo.Client {
 
 }
 
-var Client *mongo.Client = DBSet()
-
-func UserData(client *mongo.Client, collectionName string) *mongo.Collection {
-
-	var collection = client.Database("giftOrchids").Collection(collectionName)
-	return collection
-}
-
-func ProductData(client *mongo.Client, collectionName string) *mongo.Collection {
-	var productCollection = client.Database("giftOrchids").Collection(collectionName)
-	return productCollection
+// Database returns the configured application database.
+func Database(client *mongo.Client, cfg config.Mongo) *mongo.Database {
+	return client.Database(cfg.Database)
 }
-
-//func CategoriesData(client *mongo.Client, collectionNAme)
@@ -0,0 +1,119 @@
+// Package events records what buyers do with products without slowing
+// down the requests they do it in. Events are queued in memory and written
+// in batches in the background.
+package events
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sink writes a batch of events.
+type Sink func(ctx context.Context, events []models.Event) error
+
+// writeTimeout bounds the write of one batch.
+const writeTimeout = 10 * time.Second
+
+// Recorder queues events for its sink.
+type Recorder struct {
+	cfg   config.Events
+	sink  Sink
+	queue chan models.Event
+	// dropped counts the events lost to a full queue or a failed write.
+	dropped int64
+}
+
+func New(cfg config.Events, sink Sink) *Recorder {
+	return &Recorder{cfg: cfg, sink: sink, queue: make(chan models.Event, cfg.Buffer)}
+}
+
+// Record queues an event, setting its ID and time when missing. It never
+// blocks: when the queue is full the event is dropped.
+func (r *Recorder) Record(event models.Event) {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.Created_at.IsZero() {
+		event.Created_at = time.Now()
+	}
+	select {
+	case r.queue <- event:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// Dropped returns the number of events lost so far.
+func (r *Recorder) Dropped() int64 {
+	return atomic.LoadInt64(&r.dropped)
+}
+
+// Run writes the queued events until ctx is done, a batch whenever
+// BatchSize events are queued or the oldest waited FlushInterval. The
+// events still queued are written before it returns.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]models.Event, 0, r.cfg.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			r.flush(context.Background(), batch)
+			return
+		case event := <-r.queue:
+			if batch = append(batch, event); len(batch) == r.cfg.BatchSize {
+				r.write(context.Background(), batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.write(context.Background(), batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// Flush writes the events queued right now.
+func (r *Recorder) Flush(ctx context.Context) {
+	r.flush(ctx, nil)
+}
+
+// flush writes batch and the events queued, in batches of BatchSize.
+func (r *Recorder) flush(ctx context.Context, batch []models.Event) {
+	for {
+		drained := false
+		for !drained && len(batch) < r.cfg.BatchSize {
+			select {
+			case event := <-r.queue:
+				batch = append(batch, event)
+			default:
+				drained = true
+			}
+		}
+		if len(batch) > 0 {
+			r.write(ctx, batch)
+		}
+		if drained {
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// write hands a batch to the sink. Events that fail to be written are
+// dropped, so a database outage doesn't fill up the memory.
+func (r *Recorder) write(ctx context.Context, batch []models.Event) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := r.sink(ctx, batch); err != nil {
+		atomic.AddInt64(&r.dropped, int64(len(batch)))
+		log.Printf("events: writing %d events: %v", len(batch), err)
+	}
+}
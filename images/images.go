@@ -0,0 +1,200 @@
+// Package images prepares uploaded pictures for the storefront. The type of
+// an upload is detected from its content, its metadata is removed and it is
+// scaled down to a few renditions, so listings don't load full size photos.
+//
+// Renditions are JPEG: there is no WebP encoder in Go without cgo. WebP
+// uploads are accepted, and kept as they are apart from their metadata.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupported is returned for uploads that aren't JPEG, PNG, GIF or
+	// WebP images, whatever their name says.
+	ErrUnsupported = errors.New("images: only JPEG, PNG, GIF and WebP images are supported")
+	// ErrTooLarge is returned for images with more pixels than allowed.
+	ErrTooLarge = errors.New("images: image is too large")
+)
+
+// Size is a rendition, scaled down to Width pixels wide.
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Sizes are the renditions made of every image, smallest first.
+var Sizes = []Size{
+	{Name: "thumbnail", Width: 200},
+	{Name: "medium", Width: 600},
+	{Name: "large", Width: 1200},
+}
+
+// RenditionType is the content type of the renditions.
+const RenditionType = "image/jpeg"
+
+// File is an encoded image.
+type File struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	// Width and Height are the dimensions the image is shown at, after its
+	// orientation is applied.
+	Width  int
+	Height int
+}
+
+// Result is a processed upload.
+type Result struct {
+	// Original is the upload without its metadata. JPEGs keep their
+	// orientation, PNGs and WebPs lose it with their EXIF data, their
+	// renditions are upright either way.
+	Original File
+	// Renditions are the scaled down copies, smallest first. Images are
+	// never scaled up: a small image has a single rendition of its own
+	// width.
+	Renditions []File
+}
+
+// Process checks that data is an image and makes its renditions.
+func Process(cfg config.Images, data []byte) (*Result, error) {
+	contentType := mimetype.Detect(data).String()
+	var (
+		stripped    []byte
+		orientation = 1
+		err         error
+	)
+	switch contentType {
+	case "image/jpeg":
+		stripped, orientation, err = stripJPEG(data)
+	case "image/png":
+		stripped, orientation, err = stripPNG(data)
+	case "image/webp":
+		stripped, orientation, err = stripWebP(data)
+	case "image/gif":
+		// GIFs carry no EXIF
+		stripped = data
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		// not really of its type, or cut short
+		return nil, ErrUnsupported
+	}
+
+	header, _, err := image.DecodeConfig(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if header.Width > cfg.MaxPixels/header.Height {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	width, height := header.Width, header.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	result := &Result{Original: File{
+		Data:        stripped,
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+	}}
+	for _, size := range Sizes {
+		w := size.Width
+		if w > width {
+			w = width
+		}
+		h := (height*w + width/2) / width
+		if h < 1 {
+			h = 1
+		}
+		rendition, err := render(img, orientation, w, h, cfg.Quality)
+		if err != nil {
+			return nil, err
+		}
+		result.Renditions = append(result.Renditions, File{
+			Name:        size.Name,
+			Data:        rendition,
+			ContentType: RenditionType,
+			Width:       w,
+			Height:      h,
+		})
+		if w == width {
+			break
+		}
+	}
+	return result, nil
+}
+
+// render scales img to w by h pixels once turned upright, on a white
+// background for transparent images, and encodes it as a JPEG.
+func render(img image.Image, orientation, w, h, quality int) ([]byte, error) {
+	sw, sh := w, h
+	if orientation >= 5 {
+		sw, sh = h, w
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, orient(scaled, orientation), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orient turns img upright according to its EXIF orientation.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // flip horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotate 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // flip vertically
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate clockwise
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate counterclockwise
+				sx, sy = w-1-y, x
+			}
+			out.SetRGBA(x, y, img.RGBAAt(sx, sy))
+		}
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errCorrupt = errors.New("images: corrupt image")
+
+// exifOrientation reads the orientation tag, 1 to 8, from TIFF formatted
+// EXIF data. It returns 1, upright, when there is none.
+func exifOrientation(tiff []byte) int {
+	tiff = bytes.TrimPrefix(tiff, []byte("Exif\x00\x00"))
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		// The orientation is a single SHORT, stored in the entry itself.
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 1
+}
+
+// orientationExif is an APP1 segment with EXIF data holding nothing but
+// the orientation.
+func orientationExif(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian header, IFD0 at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // orientation, SHORT, 1 value
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// stripJPEG removes the EXIF, XMP, IPTC and comment segments of a JPEG and
+// returns its orientation. The JFIF header, ICC profile and Adobe color
+// segments are kept, the image data isn't touched. A rotated image keeps
+// an EXIF segment with just its orientation, so it still shows upright.
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errCorrupt
+	}
+	out := []byte{0xFF, 0xD8}
+	orientation := 1
+	exifAt := len(out)
+	for i := 2; ; {
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, 0, errCorrupt
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			// fill byte
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// The scan runs to the end of the file.
+			out = append(out, data[i:]...)
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, 0, errCorrupt
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, 0, errCorrupt
+		}
+		segment := data[i:end]
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+				orientation = exifOrientation(segment[4:])
+			}
+		case marker == 0xE0:
+			out = append(out, segment...)
+			exifAt = len(out)
+		case marker == 0xE2 || marker == 0xEE:
+			out = append(out, segment...)
+		case marker >= 0xE3 && marker <= 0xEF, marker == 0xFE:
+			// other application data and comments
+		default:
+			out = append(out, segment...)
+		}
+		i = end
+	}
+	if orientation != 1 {
+		exif := orientationExif(orientation)
+		out = append(out[:exifAt], append(exif, out[exifAt:]...)...)
+	}
+	return out, orientation, nil
+}
+
+// stripPNG removes the EXIF and text chunks of a PNG and returns the
+// orientation its EXIF chunk had.
+func stripPNG(data []byte) ([]byte, int, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, 0, errCorrupt
+	}
+	out := []byte(signature)
+	orientation := 1
+	for i := len(signature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, 0, errCorrupt
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i {
+			return nil, 0, errCorrupt
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf":
+			orientation = exifOrientation(data[i+8 : end-4])
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, orientation, nil
+}
+
+// stripWebP removes the EXIF and XMP chunks of a WebP and returns the
+// orientation its EXIF chunk had.
+func stripWebP(data []byte) ([]byte, int, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, 0, errCorrupt
+	}
+	out := append([]byte{}, data[:12]...)
+	orientation := 1
+	vp8x := -1
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, 0, errCorrupt
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end > len(data) {
+			// the padding of the last chunk is sometimes missing
+			end = i + 8 + size
+		}
+		if end > len(data) || end < i {
+			return nil, 0, errCorrupt
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF":
+			orientation = exifOrientation(data[i+8 : i+8+size])
+		case "XMP ":
+		case "VP8X":
+			vp8x = len(out)
+			out = append(out, data[i:end]...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if vp8x >= 0 && vp8x+8 < len(out) {
+		// clear the EXIF and XMP flags
+		out[vp8x+8] &^= 0x08 | 0x04
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, orientation, nil
+}
@@ -0,0 +1,198 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"os"
+	"testing"
+)
+
+// The fixtures carry EXIF data with a camera make of "SecretCam 1" and an
+// XMP packet with a location, plus IPTC, comment and text metadata where
+// the format has them. photo.webp is gopher-doc.1bpp.lossless.webp of
+// golang.org/x/image, wrapped in a VP8X container with EXIF and XMP chunks.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var leaks = []string{"SecretCam", "xmpmeta", "GPSLatitude"}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		strip       func([]byte) ([]byte, int, error)
+		orientation int
+		width       int
+		height      int
+	}{
+		{"photo.jpg", stripJPEG, 6, 16, 8},
+		{"photo.png", stripPNG, 8, 16, 8},
+		{"photo.webp", stripWebP, 3, 75, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := readFixture(t, tt.name)
+			for _, leak := range leaks {
+				if !bytes.Contains(data, []byte(leak)) {
+					t.Fatalf("the fixture lacks %q", leak)
+				}
+			}
+			out, orientation, err := tt.strip(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if orientation != tt.orientation {
+				t.Errorf("orientation %d, want %d", orientation, tt.orientation)
+			}
+			for _, leak := range leaks {
+				if bytes.Contains(out, []byte(leak)) {
+					t.Errorf("%q was kept", leak)
+				}
+			}
+			header, _, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("the stripped image doesn't decode: %v", err)
+			}
+			if header.Width != tt.width || header.Height != tt.height {
+				t.Errorf("%dx%d, want %dx%d", header.Width, header.Height, tt.width, tt.height)
+			}
+			again, _, err := tt.strip(out)
+			if err != nil || !bytes.Equal(again, out) {
+				t.Errorf("stripping twice changed the image: %v", err)
+			}
+		})
+	}
+}
+
+func TestStripJPEGKeepsItsOrientation(t *testing.T) {
+	out, _, err := stripJPEG(readFixture(t, "photo.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out[2:], []byte{0xFF, 0xE0}) {
+		t.Error("the JFIF header isn't first")
+	}
+	exif := orientationExif(6)
+	at := bytes.Index(out, exif)
+	if at < 0 {
+		t.Fatal("no orientation was written")
+	}
+	if o := exifOrientation(exif[4:]); o != 6 {
+		t.Errorf("written orientation reads as %d", o)
+	}
+	if _, orientation, _ := stripJPEG(out); orientation != 6 {
+		t.Errorf("orientation %d after stripping twice", orientation)
+	}
+}
+
+func TestStripWebPClearsItsFlags(t *testing.T) {
+	out, _, err := stripWebP(readFixture(t, "photo.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags := out[20]; flags&(0x08|0x04) != 0 {
+		t.Errorf("VP8X flags %#x still announce metadata", flags)
+	}
+	if size := binary.LittleEndian.Uint32(out[4:]); int(size) != len(out)-8 {
+		t.Errorf("RIFF size %d, want %d", size, len(out)-8)
+	}
+}
+
+func TestStripCorrupt(t *testing.T) {
+	jpg := readFixture(t, "photo.jpg")
+	png := readFixture(t, "photo.png")
+	webp := readFixture(t, "photo.webp")
+
+	// set returns a copy of data with b written at i.
+	set := func(data []byte, i int, b ...byte) []byte {
+		data = append([]byte{}, data...)
+		copy(data[i:], b)
+		return data
+	}
+	tests := []struct {
+		name  string
+		strip func([]byte) ([]byte, int, error)
+		data  []byte
+	}{
+		{"jpeg without its start", stripJPEG, jpg[2:]},
+		{"jpeg cut in a segment", stripJPEG, jpg[:30]},
+		{"jpeg cut in a segment length", stripJPEG, jpg[:23]},
+		{"jpeg cut before the scan", stripJPEG, jpg[:20]},
+		{"jpeg segment shorter than its length", stripJPEG, set(jpg, 4, 0, 1)},
+		{"jpeg segment running past the end", stripJPEG, set(jpg, 4, 0xFF, 0xFF)},
+		{"jpeg segment not starting with a marker", stripJPEG, set(jpg, 20, 0)},
+		{"png without its signature", stripPNG, png[1:]},
+		{"png cut in a chunk", stripPNG, png[:len(png)-5]},
+		{"png cut in a chunk header", stripPNG, png[:8+6]},
+		{"png chunk running past the end", stripPNG, set(png, 8, 0x7F, 0xFF, 0xFF, 0xFF)},
+		{"webp without its header", stripWebP, webp[:10]},
+		{"webp of another RIFF type", stripWebP, set(webp, 8, 'A', 'V', 'I', ' ')},
+		{"webp cut in a chunk header", stripWebP, webp[:12+4]},
+		{"webp cut in a chunk", stripWebP, webp[:40]},
+		{"webp chunk running past the end", stripWebP, set(webp, 16, 0xFF, 0xFF, 0xFF, 0x7F)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.strip(tt.data); err != errCorrupt {
+				t.Errorf("got %v, want errCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	// exif is EXIF data with entries, each a tag, a type and a value, in
+	// byte order order.
+	exif := func(order string, entries ...[3]uint16) []byte {
+		var bo binary.ByteOrder = binary.BigEndian
+		if order == "II" {
+			bo = binary.LittleEndian
+		}
+		b := append([]byte("Exif\x00\x00"+order), 0, 0, 0, 0, 0, 0)
+		bo.PutUint16(b[8:], 42)
+		bo.PutUint32(b[10:], 8)
+		b = append(b, 0, 0)
+		bo.PutUint16(b[14:], uint16(len(entries)))
+		for _, e := range entries {
+			entry := make([]byte, 12)
+			bo.PutUint16(entry, e[0])
+			bo.PutUint16(entry[2:], e[1])
+			bo.PutUint32(entry[4:], 1)
+			bo.PutUint16(entry[8:], e[2])
+			b = append(b, entry...)
+		}
+		return append(b, 0, 0, 0, 0)
+	}
+	rotated := exif("MM", [3]uint16{0x0112, 3, 6})
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"big endian", rotated, 6},
+		{"little endian", exif("II", [3]uint16{0x010F, 2, 0}, [3]uint16{0x0112, 3, 8}), 8},
+		{"without the prefix", rotated[6:], 6},
+		{"none", exif("MM", [3]uint16{0x010F, 2, 0}), 1},
+		{"out of range", exif("MM", [3]uint16{0x0112, 3, 9}), 1},
+		{"of another type", exif("MM", [3]uint16{0x0112, 4, 6}), 1},
+		{"unknown byte order", append([]byte("XX"), rotated[8:]...), 1},
+		{"cut in the header", rotated[:10], 1},
+		{"cut in the entries", rotated[:len(rotated)-10], 1},
+		{"IFD past the end", append(append([]byte{}, rotated[:10]...), 0, 0, 1, 0), 1},
+		{"IFD in the header", append(append([]byte("MM"), 0, 42, 0, 0, 0, 4), rotated[14:]...), 1},
+		{"empty", nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
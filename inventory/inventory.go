@@ -0,0 +1,80 @@
+// Package inventory derives the availability buyers see from the stock
+// levels sellers keep on their offers.
+package inventory
+
+import "github.com/kravi0/BizGrowth-backend/models"
+
+// Availability statuses, from best to worst.
+const (
+	InStock     = "in_stock"
+	LowStock    = "low_stock"
+	MadeToOrder = "made_to_order"
+	OutOfStock  = "out_of_stock"
+	// Unknown is reported when no offer tracks its stock.
+	Unknown = "unknown"
+)
+
+var rank = map[string]int{InStock: 0, LowStock: 1, MadeToOrder: 2, OutOfStock: 3, Unknown: 4}
+
+// Available is the stock that can still be promised to buyers.
+func Available(inv models.Inventory) int {
+	if available := inv.OnHand - inv.Reserved; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// IsLow reports whether a tracked offer is at or below its threshold.
+func IsLow(inv models.Inventory) bool {
+	return inv.Tracked && Available(inv) <= inv.LowStockThreshold
+}
+
+// Status classifies an offer. Sold out offers with a lead time can still be
+// produced to order.
+func Status(inv models.Inventory) string {
+	switch available := Available(inv); {
+	case !inv.Tracked:
+		return Unknown
+	case available > inv.LowStockThreshold:
+		return InStock
+	case available > 0:
+		return LowStock
+	case inv.LeadTimeDays > 0:
+		return MadeToOrder
+	}
+	return OutOfStock
+}
+
+// Summary is the availability of a product over all of its offers.
+type Summary struct {
+	Status    string `json:"status"`
+	Available int    `json:"available"`
+	// LeadTimeDays is the shortest lead time of the tracked offers, zero
+	// when none gave one.
+	LeadTimeDays int `json:"lead_time_days"`
+	Offers       int `json:"offers"`
+}
+
+// Summarize combines the offers of one product. Archived offers are left
+// out.
+func Summarize(offers []models.ProductReference) Summary {
+	summary := Summary{Status: Unknown}
+	for _, offer := range offers {
+		if offer.Archived {
+			continue
+		}
+		summary.Offers++
+		inv := offer.Inventory
+		if !inv.Tracked {
+			continue
+		}
+		summary.Available += Available(inv)
+		if inv.LeadTimeDays > 0 && (summary.LeadTimeDays == 0 || inv.LeadTimeDays < summary.LeadTimeDays) {
+			summary.LeadTimeDays = inv.LeadTimeDays
+		}
+		if status := Status(inv); rank[status] < rank[summary.Status] {
+			summary.Status = status
+		}
+	}
+	return summary
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+func TestLogLeavesTheBodyOut(t *testing.T) {
+	var out bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&out)
+
+	m := New(config.SMTP{})
+	if Configured(m) {
+		t.Fatal("a mailer without SMTP host counts as configured")
+	}
+	if err := m.Send(context.Background(), "a@example.com", "Invitation", "https://admin.example.com/accept?token=secret"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("the body was logged: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "a@example.com") {
+		t.Errorf("the mail wasn't noted: %s", out.String())
+	}
+	if !Configured(New(config.SMTP{Host: "smtp.example.com"})) {
+		t.Error("an SMTP mailer doesn't count as configured")
+	}
+}
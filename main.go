@@ -1,30 +1,81 @@
 package main
 
 import (
+	"context"
 	"log"
-	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
 	"github.com/kravi0/BizGrowth-backend/controllers"
+	"github.com/kravi0/BizGrowth-backend/database"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"github.com/kravi0/BizGrowth-backend/otp"
+	"github.com/kravi0/BizGrowth-backend/ratelimit"
+	"github.com/kravi0/BizGrowth-backend/repository"
 	"github.com/kravi0/BizGrowth-backend/routes"
+	"github.com/kravi0/BizGrowth-backend/storage"
+	"github.com/kravi0/BizGrowth-backend/tokens"
 	cors "github.com/rs/cors/wrapper/gin"
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router := gin.Default()
 	router.Use(cors.Default())
+	if err := tokens.Configure(cfg.JWT); err != nil {
+		log.Fatal(err)
+	}
+	money.DefaultCurrency = cfg.Pricing.Currency
+
+	client := database.DBSet(cfg.Mongo)
+	if client == nil {
+		log.Fatal("unable to connect to mongodb")
+	}
+	db := database.Database(client, cfg.Mongo)
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sender, err := otp.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	otpStore := otp.NewMongoStore(db.Collection("OTP"), cfg.OTP)
+	if err := otpStore.EnsureIndexes(context.Background()); err != nil {
+		log.Println("creating otp indexes:", err)
 	}
 
+	if err := repository.EnsureIndexes(context.Background(), db); err != nil {
+		log.Println("creating repository indexes:", err)
+	}
+
+	limitStore, err := ratelimit.NewStore(context.Background(), cfg.RateLimit, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limiter := ratelimit.New(cfg.RateLimit, limitStore)
+
+	app := controllers.NewApplication(cfg, repository.NewMongo(db), store, sender, otpStore, limiter, db)
+
+	go app.RunSuggestions(context.Background())
+	go app.RunRecommendations(context.Background())
+	go app.RunEvents(context.Background())
+	go app.RunPopularity(context.Background())
+	go app.RunUploadCleanup(context.Background())
+
 	router = gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
 	router.Use(gin.Logger())
-	routes.UserRoutes(router)
-
-	log.Fatal(router.Run(":" + port))
-	router.GET("/", controllers.GetCategory())
-	router.GET("/getproduct", controllers.ValidateOtpHandler())
+	routes.UserRoutes(router, app)
 
+	log.Fatal(router.Run(":" + cfg.Server.Port))
 }
@@ -28,6 +28,7 @@ func Authentication() gin.HandlerFunc {
 
 		c.Set("email", calims.Email)
 		c.Set("uid", calims.Uid)
+		c.Set("role", calims.Role)
 		c.Next()
 
 	}
@@ -55,6 +56,7 @@ func UserAuthentication() gin.HandlerFunc {
 	
 		c.Set("mobile", calims.MobileNo)
 		c.Set("uid", calims.Uid)
+		c.Set("role", calims.Role)
 		c.Next()
 
 	}
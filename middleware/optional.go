@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/tokens"
+)
+
+// OptionalUserAuthentication identifies storefront users on public
+// endpoints. A valid user token sets the same keys as UserAuthentication,
+// requests without one, or with one that does not validate, go on
+// anonymously.
+func OptionalUserAuthentication() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Request.Header.Get("token"); token != "" {
+			if claims, msg := tokens.ValidateUSERToken(token); msg == "" {
+				c.Set("mobile", claims.MobileNo)
+				c.Set("uid", claims.Uid)
+				c.Set("role", claims.Role)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/utils"
+)
+
+// Permission names a group of endpoints that is granted to roles as a whole.
+type Permission string
+
+const (
+	UserAccount     Permission = "user:account"
+	SellerAccount   Permission = "seller:account"
+	AdminAccount    Permission = "admin:account"
+	ManageSellers   Permission = "admin:sellers"
+	ManageCatalog   Permission = "admin:catalog"
+	ManageEnquiries Permission = "admin:enquiries"
+	ManageReviews   Permission = "admin:reviews"
+	ManageSupport   Permission = "admin:support"
+	ManageContent   Permission = "admin:content"
+	ViewReports     Permission = "admin:reports"
+	ManageAdmins    Permission = "admin:admins"
+	ViewAudit       Permission = "admin:audit"
+)
+
+// Permissions is the role matrix: every permission lists the roles that may
+// call the endpoints guarded by it. A permission missing from the matrix is
+// granted to nobody.
+var Permissions = map[Permission][]string{
+	UserAccount:     {utils.User},
+	SellerAccount:   {utils.Seller},
+	AdminAccount:    utils.AdminRoles,
+	ManageSellers:   {utils.SuperAdmin},
+	ManageCatalog:   {utils.SuperAdmin, utils.CatalogManager},
+	ManageEnquiries: {utils.SuperAdmin, utils.SupportAgent},
+	ManageReviews:   {utils.SuperAdmin, utils.CatalogManager},
+	ManageSupport:   {utils.SuperAdmin, utils.SupportAgent},
+	ManageContent:   {utils.SuperAdmin, utils.CatalogManager},
+	ViewReports:     {utils.SuperAdmin},
+	ManageAdmins:    {utils.SuperAdmin},
+	ViewAudit:       {utils.SuperAdmin},
+}
+
+// RequireRole aborts with 403 unless the role claim set by Authentication
+// or UserAuthentication is one of roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role != "" && role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"Error": "You're not authorized to access this resource"})
+		c.Abort()
+	}
+}
+
+// RequirePermission is RequireRole for the roles granted perm in Permissions.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return RequireRole(Permissions[perm]...)
+}
@@ -15,7 +15,7 @@ type Blog struct {
 	ContentUrl string             `json:"contentUrl" bson:"contentUrl" validate:"required"`
 	Keywords   []string           `json:"keywords" bson:"keywords"`
 	Published  bool               `json:"published" bson:"published"`
-	CoverImage string             `json:"coverImage" bson:"coverImage"`
+	CoverImage Image              `json:"coverImage" bson:"coverImage"`
 	Created_at time.Time          `json:"created_at" bson:"created_at" `
 	Updated_at time.Time          `json:"updated_at" bson:"updated_at" `
 	IsArchived bool               `json:"isArchived" bson:"isArchived" `
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Image is an uploaded picture: the upload itself, with its metadata
+// removed, and smaller renditions of it. Images uploaded before renditions
+// were made are stored as plain URL strings, they decode to an Image with
+// only Original set.
+type Image struct {
+	Original   string      `bson:"original" json:"original"`
+	Width      int         `bson:"width,omitempty" json:"width,omitempty"`
+	Height     int         `bson:"height,omitempty" json:"height,omitempty"`
+	Renditions []Rendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	// Srcset lists the signed URLs of the renditions and the original with
+	// their widths, ready for an <img srcset>. It is filled in for responses
+	// only.
+	Srcset string `bson:"-" json:"srcset,omitempty"`
+}
+
+// Rendition is a scaled down copy of an image.
+type Rendition struct {
+	Name        string `bson:"name" json:"name"`
+	URL         string `bson:"url" json:"url"`
+	ContentType string `bson:"content_type" json:"content_type"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+}
+
+// imageFields is Image without its methods, to decode documents.
+type imageFields Image
+
+func (img *Image) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.String:
+		var url string
+		if err := bson.UnmarshalValue(t, data, &url); err != nil {
+			return err
+		}
+		*img = Image{Original: url}
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		*img = Image{}
+		return nil
+	case bsontype.EmbeddedDocument:
+		var fields imageFields
+		if err := bson.UnmarshalValue(t, data, &fields); err != nil {
+			return err
+		}
+		*img = Image(fields)
+		return nil
+	}
+	return fmt.Errorf("models: cannot decode %v into an Image", t)
+}
+
+func (img *Image) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*img = Image{Original: url}
+		return nil
+	}
+	var fields imageFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*img = Image(fields)
+	return nil
+}
+
+// IsZero reports whether no image is set, so empty images are left out of
+// documents with omitempty.
+func (img Image) IsZero() bool {
+	return img.Original == "" && len(img.Renditions) == 0
+}
+
+// URL returns the URL of the named rendition, or of the original when there
+// is no such rendition.
+func (img Image) URL(rendition string) string {
+	for _, r := range img.Renditions {
+		if r.Name == rendition {
+			return r.URL
+		}
+	}
+	return img.Original
+}
+
+// ImageURLs returns the URLs of the originals of images.
+func ImageURLs(images []Image) []string {
+	urls := make([]string, len(images))
+	for i, img := range images {
+		urls[i] = img.Original
+	}
+	return urls
+}
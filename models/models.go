@@ -3,14 +3,16 @@ package models
 import (
 	"time"
 
+	"github.com/kravi0/BizGrowth-backend/money"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Product struct {
 	Product_ID       primitive.ObjectID   `bson:"_id"`
 	Product_Name     string               `json:"product_name" validate:"required"`
-	Price            string               `json:"price" validate:"required"`
-	Image            []string             `json:"image" validate:"required"`
+	Price            money.Money          `json:"price" validate:"required"`
+	Image            []Image              `json:"image" validate:"required"`
 	Discription      string               `json:"discription" validate:"required"`
 	Category         string               `json:"category" validate:"required"`
 	AgeGroup         string               `json:"agegroup" validate:"required"`
@@ -42,14 +44,25 @@ type AttributeValue struct {
 	Value         []string           `bson:"attribute_value" json:"attribute_value"`
 }
 
+// ProductVariant is a sellable unit of a product with one value for each
+// attribute it varies in, e.g. red in size M. A variant without a price
+// and price tiers sells at the price of its product.
 type ProductVariant struct {
-	Attribute []AttributeValue `bson:"attribute" json:"attribute"`
+	ID         primitive.ObjectID  `bson:"_id" json:"_id"`
+	SKU        string              `bson:"sku" json:"sku"`
+	Attribute  []AttributeValue    `bson:"attribute" json:"attribute"`
+	Price      money.Money         `bson:"price" json:"price"`
+	PriceRange []ProductPriceRange `bson:"pricerange" json:"pricerange"`
+	Image      []Image             `bson:"image" json:"image"`
+	Stock      int                 `bson:"stock" json:"stock"`
+	Created_at time.Time           `bson:"created_at" json:"created_at"`
+	Updated_at time.Time           `bson:"updated_at" json:"updated_at"`
 }
 
 type ProductPriceRange struct {
-	MinQuantity int    `bson:"minQuantity" json:"minQuantity"`
-	MaxQuantity int    `bson:"maxQuantity" json:"maxQuantity"`
-	Price       string `bson:"price" json:"price" `
+	MinQuantity int         `bson:"minQuantity" json:"minQuantity"`
+	MaxQuantity int         `bson:"maxQuantity" json:"maxQuantity"`
+	Price       money.Money `bson:"price" json:"price" `
 }
 
 type Reviews struct {
@@ -74,7 +87,7 @@ type ProductReference struct {
 	ID          primitive.ObjectID `bson:"_id" json:"_id"`
 	ProductID   primitive.ObjectID `bson:"product_id" json:"product_id"`
 	SellerID    primitive.ObjectID `bson:"seller_id" json:"seller_id"`
-	Price       string             `bson:"price"`
+	Price       money.Money        `bson:"price"`
 	MinQuantity int                `bson:"minQuantity" `
 
 	MaxQuantity int       `bson:"maxQuantity" json:"maxQuantity"`
@@ -83,6 +96,20 @@ type ProductReference struct {
 
 	Approved bool `bson:"approved" json:"approved"`
 	Archived bool `bson:"archived" json:"archived"`
+
+	Inventory Inventory `bson:"inventory" json:"inventory"`
+}
+
+// Inventory is the stock of a seller offer. Reserved units are promised to
+// buyers but still on hand; LeadTimeDays is how long the seller needs to
+// produce a bulk order. Offers nobody set a stock level for are untracked.
+type Inventory struct {
+	Tracked           bool      `bson:"tracked" json:"tracked"`
+	OnHand            int       `bson:"on_hand" json:"on_hand"`
+	Reserved          int       `bson:"reserved" json:"reserved"`
+	LowStockThreshold int       `bson:"low_stock_threshold" json:"low_stock_threshold"`
+	LeadTimeDays      int       `bson:"lead_time_days" json:"lead_time_days"`
+	Updated_at        time.Time `bson:"updated_at" json:"updated_at"`
 }
 
 type Units struct {
@@ -95,7 +122,7 @@ type Units struct {
 type Categories struct {
 	Category_ID          primitive.ObjectID `bson:"_id"`
 	Category             string             `json:"category" bson:"category"`
-	Category_image       string             `json:"category_image" bson:"category_image"`
+	Category_image       Image              `json:"category_image" bson:"category_image"`
 	Category_Description string             `json:"category_description" bson:"category_description"`
 	Parent_Category      primitive.ObjectID `json:"parent_category" bson:"parent_category"`
 	Approved             bool               `json:"isApproved" bson:"isApproved"`
@@ -113,7 +140,7 @@ type Seller struct {
 	CompanyDetail   CompanyDetail      `json:"companydetail" validate:"required"`
 	MobileNo        string             `json:"mobileno"`
 	Email           string             `json:"email" validate:"required"`
-	OTP             string             `json:"otp"`
+	OTP             string             `json:"otp" bson:"-"` // login input only, codes live in the otp store
 	Address_Details Address            `json:"address" bson:"address"`
 	Approved        bool               `json:"approved"`
 	Password        string             `json:"password" validate:"required,min=6"`
@@ -173,7 +200,6 @@ type BusinessLicense struct {
 type SellerTmp struct {
 	ID       primitive.ObjectID `bson:"_id"`
 	MobileNo string             `json:"mobileno"`
-	OTP      string             `json:"otp"`
 }
 
 type Address struct {
@@ -191,7 +217,6 @@ type USer struct {
 	Email         string             `json:"email" bson:"email"`
 	DOB           time.Time          `json:"dob" bson:"dob"`
 	Gender        string             `json:"gender" bson:"gender"`
-	OTP           string             `json:"otp" bson:"otp" `
 	Token         string             `json:"token" bson:"token" `
 	Refresh_token string             `json:"refresh_token" bson:"refresh_token"`
 	Created_at    time.Time          `json:"created_at" bson:"created_at" `
@@ -205,6 +230,7 @@ type Enquire struct {
 	EnquireId    string             `json:"enquire_id" bson:"enquire_id"`
 	User_id      string             `json:"user_id" bson:"user_id"`
 	Product_id   string             `json:"product_id" bson:"product_id"`
+	Variant_id   string             `json:"variant_id" bson:"variant_id"`
 	Quantity     int                `json:"quantity" bson:"quantity" `
 	Resolved     bool               ` json:"resolved" bson:"resolved" `
 	Status       string             `json:"status" bson:"status" `
@@ -273,3 +299,217 @@ type Settings struct {
 	Created_at time.Time          `bson:"created_at" json:"created_at"`
 	Updated_at time.Time          `bson:"updated_at" json:"updated_at"`
 }
+
+// RefreshToken records an issued refresh token. Every token rotated out of
+// the same login shares its Family, so a replayed token can end the whole
+// session.
+type RefreshToken struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Family     primitive.ObjectID `bson:"family" json:"family"`
+	Uid        string             `bson:"uid" json:"uid"`
+	Role       string             `bson:"role" json:"role"`
+	Used       bool               `bson:"used" json:"used"`
+	Revoked    bool               `bson:"revoked" json:"revoked"`
+	Expires_at time.Time          `bson:"expires_at" json:"expires_at"`
+	Created_at time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// AdminUser is an account of the admin panel. Role is one of the admin
+// roles in utils, which decide the permissions of the account.
+type AdminUser struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	Email      string             `bson:"email" json:"email"`
+	MobileNo   string             `bson:"mobileno" json:"mobileno"`
+	Password   string             `bson:"password" json:"-"`
+	Role       string             `bson:"role" json:"role"`
+	Disabled   bool               `bson:"disabled" json:"disabled"`
+	InvitedBy  string             `bson:"invited_by" json:"invited_by"`
+	Created_at time.Time          `bson:"created_at" json:"created_at"`
+	Updated_at time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// AdminInvite is a pending invitation. Only the SHA-256 of the token sent
+// by e-mail is stored.
+type AdminInvite struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Email       string             `bson:"email" json:"email"`
+	Role        string             `bson:"role" json:"role"`
+	TokenHash   string             `bson:"token_hash" json:"-"`
+	InvitedBy   string             `bson:"invited_by" json:"invited_by"`
+	Accepted    bool               `bson:"accepted" json:"accepted"`
+	Expires_at  time.Time          `bson:"expires_at" json:"expires_at"`
+	Created_at  time.Time          `bson:"created_at" json:"created_at"`
+	Accepted_at time.Time          `bson:"accepted_at" json:"accepted_at"`
+}
+
+// AuditEntry records one successful mutation made through the API.
+type AuditEntry struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Actor       string             `bson:"actor" json:"actor"`
+	Actor_email string             `bson:"actor_email" json:"actor_email"`
+	Role        string             `bson:"role" json:"role"`
+	Action      string             `bson:"action" json:"action"`
+	Entity_type string             `bson:"entity_type" json:"entity_type"`
+	Entity_id   string             `bson:"entity_id" json:"entity_id"`
+	Changes     []AuditChange      `bson:"changes" json:"changes"`
+	Request_id  string             `bson:"request_id" json:"request_id"`
+	// Job_id names the background job, e.g. a product import, that made
+	// the change.
+	Job_id     string    `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	Created_at time.Time `bson:"created_at" json:"created_at"`
+}
+
+// AuditChange is the value of one top level field before and after a
+// mutation, nil when the field did not exist.
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// ProductImport is a bulk import of products from a CSV or XLSX sheet. Rows
+// holds the outcome of every row once the job has finished.
+type ProductImport struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Filename    string             `bson:"filename" json:"filename"`
+	DryRun      bool               `bson:"dry_run" json:"dry_run"`
+	Status      string             `bson:"status" json:"status"`
+	Error       string             `bson:"error" json:"error,omitempty"`
+	Actor       string             `bson:"actor" json:"actor"`
+	Role        string             `bson:"role" json:"role"`
+	SellerID    string             `bson:"seller_id" json:"seller_id,omitempty"`
+	Total       int                `bson:"total" json:"total"`
+	Created     int                `bson:"created" json:"created"`
+	Updated     int                `bson:"updated" json:"updated"`
+	Failed      int                `bson:"failed" json:"failed"`
+	Rows        []ImportRow        `bson:"rows" json:"rows"`
+	Created_at  time.Time          `bson:"created_at" json:"created_at"`
+	Finished_at time.Time          `bson:"finished_at" json:"finished_at"`
+}
+
+// ImportRow is the result of one sheet row. Row counts from 1 for the
+// header, so it matches the row number shown by spreadsheet programs.
+type ImportRow struct {
+	Row        int    `bson:"row" json:"row"`
+	SKU        string `bson:"sku" json:"sku"`
+	Action     string `bson:"action" json:"action"`
+	Product_id string `bson:"product_id" json:"product_id,omitempty"`
+	// Revision_id is set when the update waits for review.
+	Revision_id string   `bson:"revision_id" json:"revision_id,omitempty"`
+	Errors      []string `bson:"errors" json:"errors,omitempty"`
+}
+
+// ProductRevision is a change to a product waiting for, or through, review.
+// Base is the live product the change was made against and Proposed the
+// product as the author wants it. Once approved, Previous and Result hold
+// the live product before and after, so it can be rolled back.
+type ProductRevision struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Product_id  primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Number      int                `bson:"number" json:"number"`
+	Author      string             `bson:"author" json:"author"`
+	Author_role string             `bson:"author_role" json:"author_role"`
+	Status      string             `bson:"status" json:"status"`
+	Fields      []string           `bson:"fields" json:"fields"`
+	Base        Product            `bson:"base" json:"base"`
+	Proposed    Product            `bson:"proposed" json:"proposed"`
+	Previous    *Product           `bson:"previous,omitempty" json:"previous,omitempty"`
+	Result      *Product           `bson:"result,omitempty" json:"result,omitempty"`
+	Rollback_of primitive.ObjectID `bson:"rollback_of,omitempty" json:"rollback_of,omitempty"`
+	Review_note string             `bson:"review_note" json:"review_note"`
+	Reviewed_by string             `bson:"reviewed_by" json:"reviewed_by"`
+	Reviewed_at time.Time          `bson:"reviewed_at" json:"reviewed_at"`
+	Created_at  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Event types recorded by the event pipeline.
+const (
+	EventView       = "view"
+	EventImpression = "impression"
+	EventClick      = "click"
+)
+
+// Event is a buyer opening the page of a product (a view), seeing it in the
+// search results (an impression) or clicking it there. Query and Position
+// are set for the latter two, User_id for signed in buyers. Visitor tells
+// apart who clicked: the user ID or a hash of the client IP.
+type Event struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Type       string             `bson:"type" json:"type"`
+	User_id    string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Product_id string             `bson:"product_id" json:"product_id"`
+	Query      string             `bson:"query,omitempty" json:"query,omitempty"`
+	Position   int                `bson:"position,omitempty" json:"position,omitempty"`
+	Visitor    string             `bson:"visitor,omitempty" json:"-"`
+	Created_at time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Popularity kinds.
+const (
+	PopularityProduct  = "product"
+	PopularityCategory = "category"
+)
+
+// Popularity is the roll-up of one day, starting at midnight UTC, of the
+// events and enquiries of a product or a category. Key is the ID of the
+// product or the name of the category.
+type Popularity struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Day         time.Time          `bson:"day" json:"day"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Key         string             `bson:"key" json:"key"`
+	Category    string             `bson:"category,omitempty" json:"category,omitempty"`
+	Views       int                `bson:"views" json:"views"`
+	Impressions int                `bson:"impressions" json:"impressions"`
+	Clicks      int                `bson:"clicks" json:"clicks"`
+	Enquiries   int                `bson:"enquiries" json:"enquiries"`
+	Score       float64            `bson:"score" json:"score"`
+}
+
+// Recommendation is a row of the precomputed recommendation table. Key is
+// "product:<id>" for the products buyers of that product also enquired
+// about, or "category:<name>" for the most popular products of a category.
+type Recommendation struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Key         string             `bson:"key" json:"key"`
+	Products    []ScoredProduct    `bson:"products" json:"products"`
+	Computed_at time.Time          `bson:"computed_at" json:"computed_at"`
+}
+
+type ScoredProduct struct {
+	Product_id string  `bson:"product_id" json:"product_id"`
+	Category   string  `bson:"category" json:"category"`
+	Score      float64 `bson:"score" json:"score"`
+}
+
+// Upload session kinds and states.
+const (
+	UploadImage    = "image"
+	UploadDocument = "document"
+
+	UploadPending   = "pending"
+	UploadConfirmed = "confirmed"
+	UploadAttaching = "attaching"
+	UploadAttached  = "attached"
+)
+
+// UploadSession is a file a client puts straight into storage with a
+// presigned URL, to Key. Once the client confirms it, the object is copied
+// to Verified_key, which no URL can write to, and the copy is checked
+// against what was declared; it's the copy that is attached to a document.
+// Sessions are removed with their files after Expires_at, attached or not,
+// so whatever is put to Key while the URL lasts doesn't stay behind.
+type UploadSession struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	Owner        string             `bson:"owner" json:"-"`
+	Kind         string             `bson:"kind" json:"kind"`
+	Filename     string             `bson:"filename" json:"filename"`
+	Content_type string             `bson:"content_type" json:"content_type"`
+	Size         int64              `bson:"size" json:"size"`
+	Key          string             `bson:"key" json:"-"`
+	Verified_key string             `bson:"verified_key,omitempty" json:"-"`
+	Status       string             `bson:"status" json:"status"`
+	Created_at   time.Time          `bson:"created_at" json:"created_at"`
+	Expires_at   time.Time          `bson:"expires_at" json:"expires_at"`
+}
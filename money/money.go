@@ -0,0 +1,237 @@
+// Package money represents prices as an integer amount of minor units
+// (paise, cents) and an ISO 4217 currency code, so they can be sorted,
+// filtered and multiplied without rounding errors.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// DefaultCurrency is assumed for prices given without a currency, such as
+// form values and the free-form strings stored before prices had one.
+var DefaultCurrency = "INR"
+
+var (
+	ErrInvalid          = errors.New("money: not a valid amount")
+	ErrPrecision        = errors.New("money: more decimals than the currency has")
+	ErrCurrencyMismatch = errors.New("money: currencies differ")
+	ErrOverflow         = errors.New("money: amount out of range")
+)
+
+type Money struct {
+	// Amount is in minor units of Currency, e.g. paise for INR.
+	Amount   int64  `bson:"amount" json:"amount"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// exponents lists the currencies whose minor unit is not a hundredth.
+var exponents = map[string]int{
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0,
+}
+
+// Exponent returns the number of decimals of currency.
+func Exponent(currency string) int {
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// symbols are stripped from parsed amounts.
+var symbols = []string{"₹", "rs.", "rs", "inr", "$", "usd", "€", "eur", "£", "gbp", "/-"}
+
+// Parse reads a decimal amount such as "1,299.50", "₹ 499" or "Rs. 20/-"
+// in currency. Amounts with more decimals than the currency has are
+// rejected rather than rounded.
+func Parse(s, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	clean := strings.ToLower(strings.TrimSpace(s))
+	for _, symbol := range symbols {
+		clean = strings.ReplaceAll(clean, symbol, "")
+	}
+	clean = strings.ReplaceAll(clean, ",", "")
+	clean = strings.Join(strings.Fields(clean), "")
+	if clean == "" {
+		return Money{}, ErrInvalid
+	}
+
+	negative := strings.HasPrefix(clean, "-")
+	clean = strings.TrimPrefix(clean, "-")
+	whole, frac, _ := strings.Cut(clean, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	exp := Exponent(currency)
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > exp {
+		return Money{}, ErrPrecision
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, ErrInvalid
+		}
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalid
+	}
+	if negative {
+		amount = -amount
+	}
+	return New(amount, currency), nil
+}
+
+// Decimal formats the amount without the currency, e.g. "1299.50".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Mul returns the price of quantity items of m, ErrOverflow when it
+// doesn't fit an int64 amount.
+func (m Money) Mul(quantity int64) (Money, error) {
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(quantity))
+	if !n.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: n.Int64(), Currency: m.Currency}, nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// BasisPoints returns bps hundredths of a percent of m, rounded half away
+// from zero to the minor unit.
+func (m Money) BasisPoints(bps int64) Money {
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(bps))
+	q, r := new(big.Int).QuoRem(n, big.NewInt(10000), new(big.Int))
+	if r.CmpAbs(big.NewInt(5000)) >= 0 {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return Money{Amount: q.Int64(), Currency: m.Currency}
+}
+
+// UnmarshalJSON accepts the {"amount", "currency"} object as well as a
+// decimal string or number in DefaultCurrency, which older clients send.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case trimmed == "null":
+		return nil
+	case strings.HasPrefix(trimmed, "{"):
+		type plain Money
+		var p plain
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = New(p.Amount, p.Currency)
+		if m.Currency == "" {
+			m.Currency = DefaultCurrency
+		}
+		return nil
+	case strings.HasPrefix(trimmed, `"`):
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*m = Money{}
+			return nil
+		}
+		parsed, err := Parse(s, DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, s)
+		}
+		*m = parsed
+		return nil
+	}
+	parsed, err := Parse(trimmed, DefaultCurrency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, trimmed)
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalBSONValue reads the money document and, until the price
+// migration has run, the free-form strings and numbers stored before.
+// Legacy strings that do not parse decode as zero.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		type plain Money
+		var p plain
+		if err := raw.Unmarshal(&p); err != nil {
+			return err
+		}
+		*m = Money(p)
+	case bsontype.String:
+		parsed, err := Parse(raw.StringValue(), DefaultCurrency)
+		if err != nil {
+			*m = Money{}
+			return nil
+		}
+		*m = parsed
+	case bsontype.Int32, bsontype.Int64, bsontype.Double:
+		var f float64
+		if err := raw.Unmarshal(&f); err != nil {
+			return err
+		}
+		parsed, err := Parse(strconv.FormatFloat(f, 'f', -1, 64), DefaultCurrency)
+		if err != nil {
+			*m = Money{}
+			return nil
+		}
+		*m = parsed
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("money: cannot decode bson %s", t)
+	}
+	return nil
+}
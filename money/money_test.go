@@ -0,0 +1,163 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+		err      error
+	}{
+		{"1,299.50", "INR", New(129950, "INR"), nil},
+		{"₹ 499", "INR", New(49900, "INR"), nil},
+		{"Rs. 20/-", "INR", New(2000, "INR"), nil},
+		{"$12.5", "usd", New(1250, "USD"), nil},
+		{".99", "INR", New(99, "INR"), nil},
+		{"-5", "INR", New(-500, "INR"), nil},
+		{"10.500", "INR", New(1050, "INR"), nil},
+		{"42", "", New(4200, DefaultCurrency), nil},
+		{"1500", "JPY", New(1500, "JPY"), nil},
+		{"1.5", "JPY", Money{}, ErrPrecision},
+		{"1.234", "KWD", New(1234, "KWD"), nil},
+		{"1.2345", "KWD", Money{}, ErrPrecision},
+		{"1.999", "INR", Money{}, ErrPrecision},
+		{"", "INR", Money{}, ErrInvalid},
+		{"₹", "INR", Money{}, ErrInvalid},
+		{"12abc", "INR", Money{}, ErrInvalid},
+		{"1..5", "INR", Money{}, ErrInvalid},
+		{"99999999999999999999", "INR", Money{}, ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in+" "+tt.currency, func(t *testing.T) {
+			got, err := Parse(tt.in, tt.currency)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Money
+		err  error
+	}{
+		{"object", `{"amount":49900,"currency":"usd"}`, New(49900, "USD"), nil},
+		{"object without currency", `{"amount":100}`, New(100, DefaultCurrency), nil},
+		{"legacy string", `"1,299.50"`, New(129950, DefaultCurrency), nil},
+		{"legacy number", `499.5`, New(49950, DefaultCurrency), nil},
+		{"empty string", `""`, Money{}, nil},
+		{"null", `null`, Money{}, nil},
+		{"unparsable string", `"free"`, Money{}, ErrInvalid},
+		{"too precise", `"1.999"`, Money{}, ErrPrecision},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBSONValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		price interface{}
+		want  Money
+	}{
+		{"document", bson.M{"amount": int64(49900), "currency": "USD"}, New(49900, "USD")},
+		{"legacy string", "₹ 1,299", New(129900, DefaultCurrency)},
+		{"legacy double", 499.5, New(49950, DefaultCurrency)},
+		{"legacy int32", int32(20), New(2000, DefaultCurrency)},
+		{"legacy int64", int64(20), New(2000, DefaultCurrency)},
+		{"unparsable string", "call for price", Money{}},
+		{"too precise double", 0.125, Money{}},
+		{"null", nil, Money{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"price": tt.price})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc struct {
+				Price Money `bson:"price"`
+			}
+			if err := bson.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Price != tt.want {
+				t.Errorf("got %v, want %v", doc.Price, tt.want)
+			}
+		})
+	}
+	data, _ := bson.Marshal(bson.M{"price": true})
+	var doc struct {
+		Price Money `bson:"price"`
+	}
+	if err := bson.Unmarshal(data, &doc); err == nil {
+		t.Error("decoded a boolean price")
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		quantity int64
+		want     int64
+		err      error
+	}{
+		{"units", 49950, 3, 149850, nil},
+		{"zero", 0, math.MaxInt64, 0, nil},
+		{"largest", math.MaxInt64, 1, math.MaxInt64, nil},
+		{"overflow", 1 << 40, 1 << 30, 0, ErrOverflow},
+		{"negative overflow", math.MinInt64, -1, 0, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "INR").Mul(tt.quantity)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && got != New(tt.want, "INR") {
+				t.Errorf("got %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	sum, err := New(100, "INR").Add(New(250, "INR"))
+	if err != nil || sum != New(350, "INR") {
+		t.Errorf("got %v, %v", sum, err)
+	}
+	if _, err := New(100, "INR").Add(New(100, "USD")); err != ErrCurrencyMismatch {
+		t.Errorf("mixed currencies: %v", err)
+	}
+	if _, err := New(math.MaxInt64, "INR").Add(New(1, "INR")); err != ErrOverflow {
+		t.Errorf("overflow: %v", err)
+	}
+	if _, err := New(math.MinInt64, "INR").Add(New(-1, "INR")); err != ErrOverflow {
+		t.Errorf("negative overflow: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// ErrNoAddress is returned when the recipient has no address for the
+// channel of the configured provider, e.g. an e-mail OTP for a user who only
+// registered a mobile number.
+var ErrNoAddress = errors.New("otp: recipient has no address for this channel")
+
+// Recipient is whoever should receive a code. Each provider picks the
+// address it delivers to.
+type Recipient struct {
+	MobileNo string
+	Email    string
+}
+
+// Sender delivers one-time passwords to users and sellers.
+type Sender interface {
+	Send(ctx context.Context, to Recipient, code string) error
+}
+
+// New builds the provider selected by cfg.OTP.Provider.
+func New(cfg *config.Config) (Sender, error) {
+	msg, err := NewMessage(cfg.OTP)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(cfg.OTP.Provider) {
+	case "", "sms":
+		return NewSMS(cfg.SMS, msg), nil
+	case "email":
+		return NewSMTP(cfg.SMTP, msg), nil
+	case "log":
+		return NewLog(msg), nil
+	default:
+		return nil, errors.New("otp: unknown provider " + cfg.OTP.Provider)
+	}
+}
+
+// Generate returns a random six digit code.
+func Generate() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// Message renders the configured body and subject templates.
+type Message struct {
+	body    *template.Template
+	subject *template.Template
+}
+
+// NewMessage parses the templates of cfg.
+func NewMessage(cfg config.OTP) (*Message, error) {
+	body, err := template.New("body").Option("missingkey=error").Parse(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("otp: parsing template: %w", err)
+	}
+	subject, err := template.New("subject").Option("missingkey=error").Parse(cfg.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("otp: parsing subject: %w", err)
+	}
+	return &Message{body: body, subject: subject}, nil
+}
+
+type messageData struct {
+	Code string
+}
+
+func (m *Message) render(t *template.Template, code string) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, messageData{Code: code}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (m *Message) Body(code string) (string, error) {
+	return m.render(m.body, code)
+}
+
+func (m *Message) Subject(code string) (string, error) {
+	return m.render(m.subject, code)
+}
@@ -0,0 +1,58 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// SMS delivers codes through the rapidsms HTTP gateway.
+type SMS struct {
+	cfg    config.SMS
+	msg    *Message
+	client *http.Client
+}
+
+func NewSMS(cfg config.SMS, msg *Message) *SMS {
+	return &SMS{cfg: cfg, msg: msg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *SMS) Send(ctx context.Context, to Recipient, code string) error {
+	if to.MobileNo == "" {
+		return ErrNoAddress
+	}
+	text, err := s.msg.Body(code)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("apikey", s.cfg.APIKey)
+	query.Set("route", s.cfg.Route)
+	query.Set("sender", s.cfg.Sender)
+	query.Set("mobileno", to.MobileNo)
+	query.Set("text", text)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.BaseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The gateway URL carries the API key, keep it out of the error.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("otp: sms gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("otp: sms gateway returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/mail"
+)
+
+// SMTP delivers codes by e-mail.
+type SMTP struct {
+	mailer mail.Mailer
+	msg    *Message
+}
+
+func NewSMTP(cfg config.SMTP, msg *Message) *SMTP {
+	return &SMTP{mailer: mail.NewSMTP(cfg), msg: msg}
+}
+
+func (s *SMTP) Send(ctx context.Context, to Recipient, code string) error {
+	if to.Email == "" {
+		return ErrNoAddress
+	}
+	subject, err := s.msg.Subject(code)
+	if err != nil {
+		return err
+	}
+	body, err := s.msg.Body(code)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, to.Email, subject, body)
+}
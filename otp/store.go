@@ -0,0 +1,78 @@
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+var (
+	ErrNoCode          = errors.New("otp: no code was requested")
+	ErrInvalidCode     = errors.New("otp: invalid code")
+	ErrExpired         = errors.New("otp: code has expired")
+	ErrTooManyAttempts = errors.New("otp: too many attempts")
+	ErrCooldown        = errors.New("otp: a code was sent recently")
+)
+
+// Purposes keep the codes of different flows apart, so a code requested to
+// log in can't be used to finish a registration.
+const (
+	PurposeUserLogin          = "user-login"
+	PurposeSellerRegistration = "seller-registration"
+	PurposeSellerLogin        = "seller-login"
+)
+
+// Store keeps issued codes. Only an HMAC of each code is persisted, codes
+// expire after the configured TTL, are locked after too many wrong guesses
+// and are consumed by the first successful Verify.
+type Store interface {
+	// Issue creates a new code for subject, replacing any earlier one. It
+	// fails with ErrCooldown while the previous code is still too recent.
+	Issue(ctx context.Context, purpose, subject string) (string, error)
+	// Verify checks code and consumes it on success.
+	Verify(ctx context.Context, purpose, subject, code string) error
+	// Discard removes the pending code, e.g. when it couldn't be delivered.
+	Discard(ctx context.Context, purpose, subject string) error
+}
+
+// record is the stored form of an issued code.
+type record struct {
+	Key       string    `bson:"_id"`
+	Hash      string    `bson:"hash"`
+	Attempts  int       `bson:"attempts"`
+	SentAt    time.Time `bson:"sent_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type hasher struct {
+	cfg config.OTP
+}
+
+func (h hasher) key(purpose, subject string) string {
+	return purpose + ":" + subject
+}
+
+func (h hasher) hash(key, code string) string {
+	mac := hmac.New(sha256.New, []byte(h.cfg.Secret))
+	mac.Write([]byte(key + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h hasher) matches(rec *record, code string) bool {
+	return hmac.Equal([]byte(rec.Hash), []byte(h.hash(rec.Key, code)))
+}
+
+func (h hasher) newRecord(purpose, subject, code string, now time.Time) record {
+	key := h.key(purpose, subject)
+	return record{
+		Key:       key,
+		Hash:      h.hash(key, code),
+		SentAt:    now,
+		ExpiresAt: now.Add(h.cfg.TTL),
+	}
+}
@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// MemoryStore keeps codes in process, for tests and single instance
+// development setups.
+type MemoryStore struct {
+	hasher
+	mu      sync.Mutex
+	records map[string]*record
+}
+
+func NewMemoryStore(cfg config.OTP) *MemoryStore {
+	return &MemoryStore{hasher: hasher{cfg: cfg}, records: map[string]*record{}}
+}
+
+func (s *MemoryStore) Issue(ctx context.Context, purpose, subject string) (string, error) {
+	code, err := Generate()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	rec := s.newRecord(purpose, subject, code, now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[rec.Key]; ok && now.Sub(existing.SentAt) < s.cfg.Cooldown {
+		return "", ErrCooldown
+	}
+	s.records[rec.Key] = &rec
+	return code, nil
+}
+
+func (s *MemoryStore) Verify(ctx context.Context, purpose, subject, code string) error {
+	key := s.key(purpose, subject)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return ErrNoCode
+	}
+	if rec.Attempts >= s.cfg.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+	rec.Attempts++
+	if time.Now().After(rec.ExpiresAt) {
+		delete(s.records, key)
+		return ErrExpired
+	}
+	if !s.matches(rec, code) {
+		return ErrInvalidCode
+	}
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryStore) Discard(ctx context.Context, purpose, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, s.key(purpose, subject))
+	return nil
+}
@@ -0,0 +1,96 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoStore struct {
+	hasher
+	coll *mongo.Collection
+}
+
+func NewMongoStore(coll *mongo.Collection, cfg config.OTP) *MongoStore {
+	return &MongoStore{hasher: hasher{cfg: cfg}, coll: coll}
+}
+
+// EnsureIndexes lets Mongo drop codes that were never verified once they
+// have expired.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (s *MongoStore) Issue(ctx context.Context, purpose, subject string) (string, error) {
+	code, err := Generate()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	rec := s.newRecord(purpose, subject, code, now)
+	// Only replace a code that is older than the cooldown. When a recent one
+	// exists the filter misses and the upsert collides on _id.
+	filter := bson.M{"_id": rec.Key, "sent_at": bson.M{"$lte": now.Add(-s.cfg.Cooldown)}}
+	_, err = s.coll.ReplaceOne(ctx, filter, rec, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrCooldown
+	}
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *MongoStore) Verify(ctx context.Context, purpose, subject, code string) error {
+	key := s.key(purpose, subject)
+	var rec record
+	err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": key, "attempts": bson.M{"$lt": s.cfg.MaxAttempts}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, countErr := s.coll.CountDocuments(ctx, bson.M{"_id": key})
+		if countErr != nil {
+			return countErr
+		}
+		if count > 0 {
+			return ErrTooManyAttempts
+		}
+		return ErrNoCode
+	}
+	if err != nil {
+		return err
+	}
+	if time.Now().After(rec.ExpiresAt) {
+		s.coll.DeleteOne(ctx, bson.M{"_id": key})
+		return ErrExpired
+	}
+	if !s.matches(&rec, code) {
+		return ErrInvalidCode
+	}
+	// Deleting by hash makes the code single use even when two requests
+	// verify it at the same time.
+	result, err := s.coll.DeleteOne(ctx, bson.M{"_id": key, "hash": rec.Hash})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func (s *MongoStore) Discard(ctx context.Context, purpose, subject string) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{"_id": s.key(purpose, subject)})
+	return err
+}
@@ -0,0 +1,104 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+func testConfig() config.OTP {
+	return config.OTP{TTL: time.Minute, MaxAttempts: 3, Cooldown: time.Minute, Secret: "secret"}
+}
+
+func TestMemoryStoreCooldown(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(testConfig())
+	if _, err := store.Issue(ctx, PurposeUserLogin, "9000000001"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name             string
+		purpose, subject string
+		want             error
+	}{
+		{"same subject", PurposeUserLogin, "9000000001", ErrCooldown},
+		{"other subject", PurposeUserLogin, "9000000002", nil},
+		{"other purpose", PurposeSellerLogin, "9000000001", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Issue(ctx, tt.purpose, tt.subject); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// The cooldown only applies while a code is pending.
+	if err := store.Discard(ctx, PurposeUserLogin, "9000000001"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Issue(ctx, PurposeUserLogin, "9000000001"); err != nil {
+		t.Errorf("issue after discard: %v", err)
+	}
+}
+
+func TestMemoryStoreVerify(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(testConfig())
+	code, err := store.Issue(ctx, PurposeUserLogin, "9000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(ctx, PurposeSellerLogin, "9000000001", code); !errors.Is(err, ErrNoCode) {
+		t.Errorf("code of another purpose: %v", err)
+	}
+	if err := store.Verify(ctx, PurposeUserLogin, "9000000001", code); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(ctx, PurposeUserLogin, "9000000001", code); !errors.Is(err, ErrNoCode) {
+		t.Errorf("code used twice: %v", err)
+	}
+}
+
+func TestMemoryStoreAttempts(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := NewMemoryStore(cfg)
+	code, err := store.Issue(ctx, PurposeUserLogin, "9000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+	for i := 0; i < cfg.MaxAttempts; i++ {
+		if err := store.Verify(ctx, PurposeUserLogin, "9000000001", wrong); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("guess %d: %v", i+1, err)
+		}
+	}
+	// Locked: even the right code is refused now.
+	if err := store.Verify(ctx, PurposeUserLogin, "9000000001", code); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("after %d wrong guesses: %v", cfg.MaxAttempts, err)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	cfg.TTL = -time.Second
+	store := NewMemoryStore(cfg)
+	code, err := store.Issue(ctx, PurposeUserLogin, "9000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(ctx, PurposeUserLogin, "9000000001", code); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired code: %v", err)
+	}
+	if err := store.Verify(ctx, PurposeUserLogin, "9000000001", code); !errors.Is(err, ErrNoCode) {
+		t.Errorf("expired code is kept: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package popularity scores products and categories by the interest buyers
+// show in them. Events and enquiries are rolled up per day, recent days
+// counting more than older ones.
+package popularity
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+)
+
+// Weights of the counts of a day in its score. Impressions only say how
+// often a product was shown, they make up the click-through rate.
+const (
+	ViewWeight    = 1.0
+	ClickWeight   = 2.0
+	EnquiryWeight = 5.0
+)
+
+// Day returns the start of the UTC day of t, the day of its roll-up.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Score scores the counts of a day.
+func Score(p models.Popularity) float64 {
+	return ViewWeight*float64(p.Views) + ClickWeight*float64(p.Clicks) + EnquiryWeight*float64(p.Enquiries)
+}
+
+// Categories adds the product rows of a day up into category rows. Products
+// without a category are left out.
+func Categories(products []models.Popularity) []models.Popularity {
+	index := map[string]int{}
+	var categories []models.Popularity
+	for _, p := range products {
+		if p.Category == "" {
+			continue
+		}
+		i, ok := index[p.Category]
+		if !ok {
+			i = len(categories)
+			index[p.Category] = i
+			categories = append(categories, models.Popularity{Day: p.Day, Kind: models.PopularityCategory, Key: p.Category})
+		}
+		c := &categories[i]
+		c.Views += p.Views
+		c.Impressions += p.Impressions
+		c.Clicks += p.Clicks
+		c.Enquiries += p.Enquiries
+	}
+	for i := range categories {
+		categories[i].Score = Score(categories[i])
+	}
+	return categories
+}
+
+// Total is the popularity of a product or a category over several days.
+type Total struct {
+	Key         string `json:"key"`
+	Category    string `json:"category,omitempty"`
+	Views       int    `json:"views"`
+	Impressions int    `json:"impressions"`
+	Clicks      int    `json:"clicks"`
+	Enquiries   int    `json:"enquiries"`
+	// ClickThrough is the share of the impressions that were clicked.
+	ClickThrough float64 `json:"click_through"`
+	// Score adds up the scores of the days, halved for every halfLife of
+	// their age.
+	Score float64 `json:"score"`
+}
+
+// Totals adds the daily rows up per key, the most popular first.
+func Totals(rows []models.Popularity, now time.Time, halfLife time.Duration) []Total {
+	today := Day(now)
+	index := map[string]int{}
+	var totals []Total
+	for _, row := range rows {
+		i, ok := index[row.Key]
+		if !ok {
+			i = len(totals)
+			index[row.Key] = i
+			totals = append(totals, Total{Key: row.Key})
+		}
+		t := &totals[i]
+		if row.Category != "" {
+			t.Category = row.Category
+		}
+		t.Views += row.Views
+		t.Impressions += row.Impressions
+		t.Clicks += row.Clicks
+		t.Enquiries += row.Enquiries
+		age := today.Sub(row.Day)
+		if age < 0 {
+			age = 0
+		}
+		t.Score += row.Score * math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+	for i := range totals {
+		if totals[i].Impressions > 0 {
+			totals[i].ClickThrough = float64(totals[i].Clicks) / float64(totals[i].Impressions)
+		}
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Score > totals[j].Score })
+	return totals
+}
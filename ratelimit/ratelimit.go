@@ -0,0 +1,210 @@
+// Package ratelimit throttles the OTP, login, registration upload and event
+// endpoints per client IP and per mobile number or e-mail, and blocks
+// numbers that keep hitting the limits.
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Scope selects the limits applied to a group of endpoints.
+type Scope string
+
+const (
+	// ScopeOTP covers endpoints that send a code.
+	ScopeOTP Scope = "otp"
+	// ScopeLogin covers endpoints that check a code or password.
+	ScopeLogin Scope = "login"
+	// ScopeUpload covers the upload sessions of sellers registering.
+	ScopeUpload Scope = "upload"
+	// ScopeEvent covers the events the storefront sends.
+	ScopeEvent Scope = "event"
+)
+
+// NewStore returns the store named by cfg.Store, creating the indexes of
+// the mongo store in db.
+func NewStore(ctx context.Context, cfg config.RateLimit, db *mongo.Database) (Store, error) {
+	switch strings.ToLower(cfg.Store) {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "mongo":
+		store := NewMongoStore(db)
+		if err := store.EnsureIndexes(ctx); err != nil {
+			return nil, fmt.Errorf("ratelimit: creating indexes: %w", err)
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("ratelimit: unknown store %q", cfg.Store)
+}
+
+type Limiter struct {
+	cfg   config.RateLimit
+	store Store
+	now   func() time.Time
+}
+
+func New(cfg config.RateLimit, store Store) *Limiter {
+	return &Limiter{cfg: cfg, store: store, now: time.Now}
+}
+
+func (l *Limiter) rule(scope Scope) config.RateRule {
+	switch scope {
+	case ScopeOTP:
+		return l.cfg.OTP
+	case ScopeUpload:
+		return l.cfg.Upload
+	case ScopeEvent:
+		return l.cfg.Event
+	}
+	return l.cfg.Login
+}
+
+// Subject extracts the mobile number or e-mail a request is about.
+type Subject func(c *gin.Context) string
+
+// Field reads name from a form or a JSON body, leaving the body readable
+// for the handler.
+func Field(name string) Subject {
+	return func(c *gin.Context) string {
+		if c.ContentType() != gin.MIMEJSON {
+			return c.PostForm(name)
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+		var fields map[string]interface{}
+		if json.Unmarshal(body, &fields) != nil {
+			return ""
+		}
+		value, _ := fields[name].(string)
+		return value
+	}
+}
+
+func normalise(subject string) string {
+	return strings.ToLower(strings.Join(strings.Fields(subject), ""))
+}
+
+// Limit refuses requests over the limits of scope with 429 and a
+// Retry-After header. A store failure lets the request through, so an
+// outage of the store does not lock everybody out.
+func (l *Limiter) Limit(scope Scope, subject Subject) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		rule := l.rule(scope)
+		now := l.now()
+		ip := c.ClientIP()
+		sub := ""
+		if subject != nil {
+			sub = normalise(subject(c))
+		}
+		event := Event{
+			Scope:     string(scope),
+			IP:        ip,
+			Subject:   sub,
+			Path:      c.FullPath(),
+			RequestID: c.GetString("request_id"),
+			At:        now,
+		}
+
+		if sub != "" {
+			until, err := l.store.BlockedUntil(ctx, "block:"+sub, now)
+			if err != nil {
+				log.Println("ratelimit: reading blocklist:", err)
+			} else if !until.IsZero() {
+				l.abuse(ctx, event, ReasonBlocked)
+				l.reject(c, until.Sub(now))
+				return
+			}
+		}
+
+		if rule.IP.Requests > 0 {
+			ok, retry, err := l.store.Allow(ctx, string(scope)+":ip:"+ip, rule.IP.Requests, rule.IP.Window, now)
+			if err != nil {
+				log.Println("ratelimit:", err)
+			} else if !ok {
+				l.abuse(ctx, event, ReasonIPLimit)
+				l.reject(c, retry)
+				return
+			}
+		}
+
+		if sub != "" && rule.Subject.Requests > 0 {
+			ok, retry, err := l.store.Allow(ctx, string(scope)+":subject:"+sub, rule.Subject.Requests, rule.Subject.Window, now)
+			if err != nil {
+				log.Println("ratelimit:", err)
+			} else if !ok {
+				l.abuse(ctx, event, ReasonSubjectLimit)
+				if until := l.strike(ctx, sub, now); !until.IsZero() {
+					l.abuse(ctx, event, ReasonBlocklisted)
+					retry = until.Sub(now)
+				}
+				l.reject(c, retry)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// strike counts a limit hit against sub and blocks it once it reached
+// BlockAfter hits within BlockWindow. It returns the end of the new block.
+func (l *Limiter) strike(ctx context.Context, sub string, now time.Time) time.Time {
+	if l.cfg.BlockAfter <= 0 {
+		return time.Time{}
+	}
+	// The strike log allows BlockAfter-1 entries, so being refused means
+	// this is the BlockAfter-th strike.
+	ok, _, err := l.store.Allow(ctx, "strikes:"+sub, l.cfg.BlockAfter-1, l.cfg.BlockWindow, now)
+	if err != nil {
+		log.Println("ratelimit:", err)
+		return time.Time{}
+	}
+	if ok {
+		return time.Time{}
+	}
+	until := now.Add(l.cfg.BlockFor)
+	if err := l.store.Block(ctx, "block:"+sub, until); err != nil {
+		log.Println("ratelimit: blocking", sub, err)
+		return time.Time{}
+	}
+	return until
+}
+
+func (l *Limiter) abuse(ctx context.Context, event Event, reason string) {
+	event.Reason = reason
+	log.Printf("ratelimit: abuse %s on %s scope=%s ip=%s subject=%q request=%s",
+		reason, event.Path, event.Scope, event.IP, event.Subject, event.RequestID)
+	if err := l.store.RecordAbuse(ctx, event); err != nil {
+		log.Println("ratelimit: recording abuse event:", err)
+	}
+}
+
+func (l *Limiter) reject(c *gin.Context, retry time.Duration) {
+	seconds := int(math.Ceil(retry.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"Error": "too many requests, please try again later", "retry_after": seconds})
+	c.Abort()
+}
@@ -0,0 +1,200 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+func TestMemoryStoreAllow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		at    time.Duration
+		ok    bool
+		retry time.Duration
+	}{
+		{0, true, 0},
+		{10 * time.Second, true, 0},
+		{20 * time.Second, false, 40 * time.Second},
+		// The first hit left the window.
+		{time.Minute + time.Second, true, 0},
+		{time.Minute + 2*time.Second, false, 8 * time.Second},
+	}
+	for i, step := range steps {
+		ok, retry, err := store.Allow(ctx, "k", 2, time.Minute, start.Add(step.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != step.ok || retry != step.retry {
+			t.Errorf("step %d: got %v %v, want %v %v", i, ok, retry, step.ok, step.retry)
+		}
+	}
+	if ok, _, _ := store.Allow(ctx, "other", 2, time.Minute, start); !ok {
+		t.Error("keys share their counters")
+	}
+}
+
+func TestMemoryStoreBlock(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	if err := store.Block(ctx, "k", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if until, _ := store.BlockedUntil(ctx, "k", now); !until.Equal(now.Add(time.Minute)) {
+		t.Errorf("blocked until %v", until)
+	}
+	if until, _ := store.BlockedUntil(ctx, "k", now.Add(time.Minute)); !until.IsZero() {
+		t.Errorf("block didn't end: %v", until)
+	}
+}
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(cfg config.RateLimit) (*testClock, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	clock := &testClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := New(cfg, NewMemoryStore())
+	limiter.now = func() time.Time { return clock.now }
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/otp", limiter.Limit(ScopeOTP, Field("mobileno")), ok)
+	router.POST("/events", limiter.Limit(ScopeEvent, nil), ok)
+	return clock, router
+}
+
+func post(router *gin.Engine, path, ip, mobileNo string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"mobileno":"`+mobileNo+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimiter(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	window := time.Minute
+	clock, router := newTestLimiter(config.RateLimit{
+		OTP: config.RateRule{
+			IP:      config.Limit{Requests: 2, Window: window},
+			Subject: config.Limit{Requests: 2, Window: window},
+		},
+		Event: config.RateRule{IP: config.Limit{Requests: 1, Window: window}},
+	})
+
+	steps := []struct {
+		name     string
+		path     string
+		ip       string
+		mobileNo string
+		want     int
+	}{
+		{"first", "/otp", "10.0.0.1", "9000000001", http.StatusOK},
+		{"same number, spaced", "/otp", "10.0.0.2", " 90000 00001", http.StatusOK},
+		{"number over its limit", "/otp", "10.0.0.3", "9000000001", http.StatusTooManyRequests},
+		{"other number", "/otp", "10.0.0.1", "9000000002", http.StatusOK},
+		{"ip over its limit", "/otp", "10.0.0.1", "9000000003", http.StatusTooManyRequests},
+		{"no subject", "/events", "10.0.0.1", "", http.StatusOK},
+		{"no subject, ip over its limit", "/events", "10.0.0.1", "", http.StatusTooManyRequests},
+	}
+	for _, step := range steps {
+		w := post(router, step.path, step.ip, step.mobileNo)
+		if w.Code != step.want {
+			t.Fatalf("%s: %d, want %d", step.name, w.Code, step.want)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("%s: Retry-After %q", step.name, w.Header().Get("Retry-After"))
+		}
+	}
+
+	clock.advance(window)
+	if w := post(router, "/otp", "10.0.0.1", "9000000001"); w.Code != http.StatusOK {
+		t.Errorf("after the window: %d", w.Code)
+	}
+}
+
+func TestLimiterBlocksRepeatOffenders(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	clock, router := newTestLimiter(config.RateLimit{
+		OTP:         config.RateRule{Subject: config.Limit{Requests: 1, Window: time.Minute}},
+		BlockAfter:  2,
+		BlockWindow: time.Hour,
+		BlockFor:    24 * time.Hour,
+	})
+	post(router, "/otp", "10.0.0.1", "9000000001")
+	if w := post(router, "/otp", "10.0.0.1", "9000000001"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("first strike: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	clock.advance(time.Minute)
+	post(router, "/otp", "10.0.0.1", "9000000001")
+	w := post(router, "/otp", "10.0.0.1", "9000000001")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "86400" {
+		t.Fatalf("second strike: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Blocked everywhere, even once its limit has reset.
+	clock.advance(time.Hour)
+	if w := post(router, "/otp", "10.0.0.2", "9000000001"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("blocked number: %d", w.Code)
+	}
+	if w := post(router, "/otp", "10.0.0.2", "9000000002"); w.Code != http.StatusOK {
+		t.Errorf("other number: %d", w.Code)
+	}
+	clock.advance(24 * time.Hour)
+	if w := post(router, "/otp", "10.0.0.2", "9000000001"); w.Code != http.StatusOK {
+		t.Errorf("after the block: %d", w.Code)
+	}
+}
+
+func TestField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"json", "application/json", `{"mobileno":"9000000001"}`, "9000000001"},
+		{"json without the field", "application/json", `{"email":"a@example.com"}`, ""},
+		{"json of another type", "application/json", `{"mobileno":9000000001}`, ""},
+		{"invalid json", "application/json", `{`, ""},
+		{"form", "application/x-www-form-urlencoded", "mobileno=9000000001", "9000000001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, rest string
+			router := gin.New()
+			router.POST("/", func(c *gin.Context) {
+				got = Field("mobileno")(c)
+				body, _ := io.ReadAll(c.Request.Body)
+				rest = string(body)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.contentType == "application/json" && rest != tt.body {
+				t.Errorf("handler read %q, want %q", rest, tt.body)
+			}
+		})
+	}
+}
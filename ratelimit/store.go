@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps sliding-window request logs and the blocklist.
+type Store interface {
+	// Allow records a request for key at now unless limit requests were
+	// already recorded within the window ending at now. When the request is
+	// refused it returns how long until the oldest of them leaves the window.
+	Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error)
+	// Block refuses key until the given time.
+	Block(ctx context.Context, key string, until time.Time) error
+	// BlockedUntil returns when the block on key ends, or the zero time when
+	// key is not blocked at now.
+	BlockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error)
+	// RecordAbuse keeps an abuse event for later inspection.
+	RecordAbuse(ctx context.Context, event Event) error
+}
+
+// Event describes a refused request.
+type Event struct {
+	Scope     string    `bson:"scope" json:"scope"`
+	Reason    string    `bson:"reason" json:"reason"`
+	IP        string    `bson:"ip" json:"ip"`
+	Subject   string    `bson:"subject" json:"subject"`
+	Path      string    `bson:"path" json:"path"`
+	RequestID string    `bson:"request_id" json:"request_id"`
+	At        time.Time `bson:"at" json:"at"`
+}
+
+// Reasons of an Event.
+const (
+	ReasonIPLimit      = "ip_limit"
+	ReasonSubjectLimit = "subject_limit"
+	ReasonBlocked      = "blocked"
+	ReasonBlocklisted  = "added_to_blocklist"
+)
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the counters in process, for single instance
+// deployments. Abuse events only end up in the server log.
+type MemoryStore struct {
+	mu        sync.Mutex
+	hits      map[string][]time.Time
+	blocked   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{hits: map[string][]time.Time{}, blocked: map[string]time.Time{}}
+}
+
+// sweepEvery bounds how often idle keys are dropped.
+const sweepEvery = time.Minute
+
+// maxWindow is how long the request log of an idle key is kept.
+const maxWindow = 24 * time.Hour
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	hits := prune(s.hits[key], now.Add(-window))
+	if len(hits) >= limit {
+		s.hits[key] = hits
+		if len(hits) == 0 {
+			return false, window, nil
+		}
+		return false, hits[0].Add(window).Sub(now), nil
+	}
+	s.hits[key] = append(hits, now)
+	return true, 0, nil
+}
+
+func (s *MemoryStore) Block(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocked[key] = until
+	return nil
+}
+
+func (s *MemoryStore) BlockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.blocked[key]
+	if !ok || !now.Before(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+func (s *MemoryStore) RecordAbuse(ctx context.Context, event Event) error {
+	return nil
+}
+
+// prune drops the hits before since, hits being in ascending order.
+func prune(hits []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(since) {
+		i++
+	}
+	return hits[i:]
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepEvery {
+		return
+	}
+	s.lastSweep = now
+	for key, hits := range s.hits {
+		if len(hits) == 0 || now.Sub(hits[len(hits)-1]) > maxWindow {
+			delete(s.hits, key)
+		}
+	}
+	for key, until := range s.blocked {
+		if !now.Before(until) {
+			delete(s.blocked, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore shares the counters between instances. Every request is one
+// document, recorded before its window is counted and removed again when
+// it's refused, so concurrent requests can't overshoot a limit. They can be
+// refused while others are being counted, a limit is never exceeded but
+// may be undershot under contention.
+type MongoStore struct {
+	hits    *mongo.Collection
+	blocked *mongo.Collection
+	abuse   *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{
+		hits:    db.Collection("RateLimit"),
+		blocked: db.Collection("Blocklist"),
+		abuse:   db.Collection("AbuseEvent"),
+	}
+}
+
+// EnsureIndexes lets Mongo drop request logs and blocks once they no longer
+// matter and indexes the window lookups.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	expiring := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := s.hits.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		expiring,
+		{Keys: bson.D{{Key: "key", Value: 1}, {Key: "at", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	_, err := s.blocked.Indexes().CreateOne(ctx, expiring)
+	return err
+}
+
+type hit struct {
+	Key       string    `bson:"key"`
+	At        time.Time `bson:"at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func (s *MongoStore) Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	res, err := s.hits.InsertOne(ctx, hit{Key: key, At: now, ExpiresAt: now.Add(window)})
+	if err != nil {
+		return false, 0, err
+	}
+	inWindow := bson.M{"key": key, "at": bson.M{"$gt": now.Add(-window)}}
+	count, err := s.hits.CountDocuments(ctx, inWindow)
+	if err != nil {
+		return false, 0, err
+	}
+	if count <= int64(limit) {
+		return true, 0, nil
+	}
+
+	if _, err := s.hits.DeleteOne(ctx, bson.M{"_id": res.InsertedID}); err != nil {
+		log.Println("ratelimit: removing refused hit:", err)
+	}
+	var oldest hit
+	err = s.hits.FindOne(ctx, inWindow, options.FindOne().SetSort(bson.D{{Key: "at", Value: 1}})).Decode(&oldest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, window, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return false, oldest.At.Add(window).Sub(now), nil
+}
+
+func (s *MongoStore) Block(ctx context.Context, key string, until time.Time) error {
+	_, err := s.blocked.ReplaceOne(ctx, bson.M{"_id": key},
+		bson.M{"_id": key, "expires_at": until}, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoStore) BlockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	var block struct {
+		ExpiresAt time.Time `bson:"expires_at"`
+	}
+	err := s.blocked.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&block)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return block.ExpiresAt, nil
+}
+
+func (s *MongoStore) RecordAbuse(ctx context.Context, event Event) error {
+	_, err := s.abuse.InsertOne(ctx, event)
+	return err
+}
@@ -0,0 +1,249 @@
+// Package recommend suggests products from what buyers looked at and
+// enquired about. Products are related when the same buyers were
+// interested in both ("buyers who enquired X also enquired Y"), and a
+// buyer's history leans towards the popular products of the categories
+// they are interested in.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+)
+
+// Interaction weights. An enquiry says much more about a buyer's interest
+// than opening a product page.
+const (
+	ViewWeight    = 1.0
+	EnquiryWeight = 3.0
+)
+
+// AffinityWeight is how much the categories of a history count next to the
+// products related to it.
+const AffinityWeight = 0.5
+
+// maxPerUser caps the products of one buyer counted, so a crawler or a
+// purchasing agent enquiring about everything doesn't relate all products.
+const maxPerUser = 200
+
+// Prefixes of the keys of the rows of the table, see models.Recommendation.
+const (
+	productPrefix  = "product:"
+	categoryPrefix = "category:"
+)
+
+// ProductKey and CategoryKey return the keys of the rows of the table.
+func ProductKey(productID string) string { return productPrefix + productID }
+
+func CategoryKey(category string) string { return categoryPrefix + category }
+
+// Interaction is a buyer viewing or enquiring about a product.
+type Interaction struct {
+	User    string
+	Product string
+	Weight  float64
+}
+
+// Strengths returns how interested each buyer is in each product: the
+// weight of their strongest interaction with it, so repeated views don't
+// outweigh an enquiry.
+func Strengths(interactions []Interaction) map[string]map[string]float64 {
+	strengths := map[string]map[string]float64{}
+	for _, in := range interactions {
+		if in.User == "" || in.Product == "" {
+			continue
+		}
+		products := strengths[in.User]
+		if products == nil {
+			products = map[string]float64{}
+			strengths[in.User] = products
+		}
+		if in.Weight > products[in.Product] {
+			products[in.Product] = in.Weight
+		}
+	}
+	for user, products := range strengths {
+		strengths[user] = strongest(products, maxPerUser)
+	}
+	return strengths
+}
+
+// strongest keeps the n products a buyer is most interested in.
+func strongest(products map[string]float64, n int) map[string]float64 {
+	if len(products) <= n {
+		return products
+	}
+	ids := make([]string, 0, len(products))
+	for id := range products {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if products[ids[i]] != products[ids[j]] {
+			return products[ids[i]] > products[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	kept := make(map[string]float64, n)
+	for _, id := range ids[:n] {
+		kept[id] = products[id]
+	}
+	return kept
+}
+
+// Related returns, for every product with an interaction, up to n other
+// products of categoryOf the same buyers were interested in, most related
+// first. Products are compared by the cosine of the interest every buyer
+// has in them, so a product everybody looks at isn't related to everything.
+// Products missing from categoryOf are never recommended.
+func Related(interactions []Interaction, categoryOf map[string]string, n int) map[string][]models.ScoredProduct {
+	dots := map[string]map[string]float64{}
+	norms := map[string]float64{}
+	for _, products := range Strengths(interactions) {
+		for a, wa := range products {
+			norms[a] += wa * wa
+			for b, wb := range products {
+				if a == b {
+					continue
+				}
+				if _, ok := categoryOf[b]; !ok {
+					continue
+				}
+				if dots[a] == nil {
+					dots[a] = map[string]float64{}
+				}
+				dots[a][b] += wa * wb
+			}
+		}
+	}
+	related := map[string][]models.ScoredProduct{}
+	for a, products := range dots {
+		var scored []models.ScoredProduct
+		for b, dot := range products {
+			scored = append(scored, models.ScoredProduct{
+				Product_id: b,
+				Category:   categoryOf[b],
+				Score:      dot / math.Sqrt(norms[a]*norms[b]),
+			})
+		}
+		related[a] = top(scored, n)
+	}
+	return related
+}
+
+// Popular returns up to n of the products of categoryOf per category, the
+// ones buyers were most interested in first. Their scores are relative to
+// the most popular product of the category.
+func Popular(interactions []Interaction, categoryOf map[string]string, n int) map[string][]models.ScoredProduct {
+	interest := map[string]float64{}
+	for _, products := range Strengths(interactions) {
+		for id, weight := range products {
+			if _, ok := categoryOf[id]; ok {
+				interest[id] += weight
+			}
+		}
+	}
+	perCategory := map[string][]models.ScoredProduct{}
+	for id, score := range interest {
+		category := categoryOf[id]
+		perCategory[category] = append(perCategory[category], models.ScoredProduct{Product_id: id, Category: category, Score: score})
+	}
+	for category, scored := range perCategory {
+		scored = top(scored, n)
+		best := scored[0].Score
+		for i := range scored {
+			scored[i].Score /= best
+		}
+		perCategory[category] = scored
+	}
+	return perCategory
+}
+
+// top sorts scored best first and keeps n of them.
+func top(scored []models.ScoredProduct, n int) []models.ScoredProduct {
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Product_id < scored[j].Product_id
+	})
+	if len(scored) > n {
+		scored = scored[:n]
+	}
+	return scored
+}
+
+// Table holds the rows of the precomputed table a recommendation needs: the
+// Related products of the history and the Popular products of its
+// categories.
+type Table struct {
+	Related map[string][]models.ScoredProduct
+	Popular map[string][]models.ScoredProduct
+}
+
+// NewTable sorts rows into a table by their keys.
+func NewTable(rows []models.Recommendation) Table {
+	table := Table{Related: map[string][]models.ScoredProduct{}, Popular: map[string][]models.ScoredProduct{}}
+	for _, row := range rows {
+		switch {
+		case strings.HasPrefix(row.Key, productPrefix):
+			table.Related[strings.TrimPrefix(row.Key, productPrefix)] = row.Products
+		case strings.HasPrefix(row.Key, categoryPrefix):
+			table.Popular[strings.TrimPrefix(row.Key, categoryPrefix)] = row.Products
+		}
+	}
+	return table
+}
+
+// Recommend returns up to n products for a buyer with the given history,
+// the products in it excluded. categoryOf gives the categories of the
+// history. A product scores its similarity to the history, weighted by the
+// interest in each product of it, plus AffinityWeight times the share of
+// the history in its category, more so the more popular it is there.
+func Recommend(history map[string]float64, categoryOf map[string]string, table Table, n int) []models.ScoredProduct {
+	var total float64
+	affinity := map[string]float64{}
+	for id, weight := range history {
+		total += weight
+		if category, ok := categoryOf[id]; ok {
+			affinity[category] += weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	candidates := map[string]*models.ScoredProduct{}
+	candidate := func(p models.ScoredProduct) *models.ScoredProduct {
+		c, ok := candidates[p.Product_id]
+		if !ok {
+			c = &models.ScoredProduct{Product_id: p.Product_id, Category: p.Category}
+			candidates[p.Product_id] = c
+		}
+		return c
+	}
+	for id, weight := range history {
+		for _, related := range table.Related[id] {
+			if _, seen := history[related.Product_id]; !seen {
+				candidate(related).Score += weight / total * related.Score
+			}
+		}
+	}
+	popularity := map[string]float64{}
+	for category := range affinity {
+		for _, popular := range table.Popular[category] {
+			if _, seen := history[popular.Product_id]; !seen {
+				candidate(popular)
+				popularity[popular.Product_id] = popular.Score
+			}
+		}
+	}
+
+	scored := make([]models.ScoredProduct, 0, len(candidates))
+	for id, c := range candidates {
+		c.Score += AffinityWeight * affinity[c.Category] / total * (1 + popularity[id]) / 2
+		scored = append(scored, *c)
+	}
+	return top(scored, n)
+}
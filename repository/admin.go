@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AdminFilter selects admin accounts. Zero values are ignored.
+type AdminFilter struct {
+	Email    string
+	Role     string
+	Disabled *bool
+}
+
+type AdminRepo interface {
+	Insert(ctx context.Context, admin *models.AdminUser) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminUser, error)
+	FindOne(ctx context.Context, filter AdminFilter) (*models.AdminUser, error)
+	Find(ctx context.Context, filter AdminFilter, page Page) ([]models.AdminUser, error)
+	Count(ctx context.Context, filter AdminFilter) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+}
+
+type AdminInviteRepo interface {
+	Insert(ctx context.Context, invite *models.AdminInvite) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminInvite, error)
+	FindByTokenHash(ctx context.Context, hash string) (*models.AdminInvite, error)
+	// Accept marks a pending invite as accepted. It returns ErrNotFound when
+	// the invite does not exist or was accepted already.
+	Accept(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// Reopen undoes Accept, for an invite whose account could not be
+	// created.
+	Reopen(ctx context.Context, id primitive.ObjectID) error
+}
+
+func (f AdminFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.Email != "" {
+		filter["email"] = f.Email
+	}
+	if f.Role != "" {
+		filter["role"] = f.Role
+	}
+	if f.Disabled != nil {
+		filter["disabled"] = *f.Disabled
+	}
+	return filter
+}
+
+func (f AdminFilter) match() func(*models.AdminUser) bool {
+	return func(a *models.AdminUser) bool {
+		return (f.Email == "" || a.Email == f.Email) &&
+			(f.Role == "" || a.Role == f.Role) &&
+			(f.Disabled == nil || a.Disabled == *f.Disabled)
+	}
+}
+
+type mongoAdminRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAdminRepo) Insert(ctx context.Context, admin *models.AdminUser) error {
+	_, err := r.coll.InsertOne(ctx, admin)
+	return err
+}
+
+func (r *mongoAdminRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminUser, error) {
+	return mongoFindOne[models.AdminUser](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoAdminRepo) FindOne(ctx context.Context, filter AdminFilter) (*models.AdminUser, error) {
+	return mongoFindOne[models.AdminUser](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoAdminRepo) Find(ctx context.Context, filter AdminFilter, page Page) ([]models.AdminUser, error) {
+	return mongoFind[models.AdminUser](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoAdminRepo) Count(ctx context.Context, filter AdminFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoAdminRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+type mongoAdminInviteRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAdminInviteRepo) Insert(ctx context.Context, invite *models.AdminInvite) error {
+	_, err := r.coll.InsertOne(ctx, invite)
+	return err
+}
+
+func (r *mongoAdminInviteRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminInvite, error) {
+	return mongoFindOne[models.AdminInvite](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoAdminInviteRepo) FindByTokenHash(ctx context.Context, hash string) (*models.AdminInvite, error) {
+	return mongoFindOne[models.AdminInvite](ctx, r.coll, bson.M{"token_hash": hash})
+}
+
+func (r *mongoAdminInviteRepo) Accept(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return mongoUpdateOne(ctx, r.coll, bson.M{"_id": id, "accepted": false},
+		Update{Set: Fields{"accepted": true, "accepted_at": at}})
+}
+
+func (r *mongoAdminInviteRepo) Reopen(ctx context.Context, id primitive.ObjectID) error {
+	return mongoUpdateOne(ctx, r.coll, bson.M{"_id": id, "accepted": true},
+		Update{Set: Fields{"accepted": false, "accepted_at": time.Time{}}})
+}
+
+type memoryAdminRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryAdminRepo) Insert(ctx context.Context, admin *models.AdminUser) error {
+	return r.coll.insert(admin)
+}
+
+func (r *memoryAdminRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminUser, error) {
+	return memFindOne(r.coll, func(a *models.AdminUser) bool { return a.ID == id })
+}
+
+func (r *memoryAdminRepo) FindOne(ctx context.Context, filter AdminFilter) (*models.AdminUser, error) {
+	return memFindOne(r.coll, filter.match())
+}
+
+func (r *memoryAdminRepo) Find(ctx context.Context, filter AdminFilter, page Page) ([]models.AdminUser, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryAdminRepo) Count(ctx context.Context, filter AdminFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memoryAdminRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+type memoryAdminInviteRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryAdminInviteRepo) Insert(ctx context.Context, invite *models.AdminInvite) error {
+	return r.coll.insert(invite)
+}
+
+func (r *memoryAdminInviteRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AdminInvite, error) {
+	return memFindOne(r.coll, func(i *models.AdminInvite) bool { return i.ID == id })
+}
+
+func (r *memoryAdminInviteRepo) FindByTokenHash(ctx context.Context, hash string) (*models.AdminInvite, error) {
+	return memFindOne(r.coll, func(i *models.AdminInvite) bool { return i.TokenHash == hash })
+}
+
+func (r *memoryAdminInviteRepo) Accept(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	n, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["accepted"] == false
+	}, Update{Set: Fields{"accepted": true, "accepted_at": at}}, false)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryAdminInviteRepo) Reopen(ctx context.Context, id primitive.ObjectID) error {
+	n, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["accepted"] == true
+	}, Update{Set: Fields{"accepted": false, "accepted_at": time.Time{}}}, false)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
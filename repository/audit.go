@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuditFilter selects audit entries. Zero values are ignored, the time
+// range is [From, To).
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	Action     string
+	JobID      string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepo is append-only: entries cannot be changed or removed through it.
+type AuditRepo interface {
+	Insert(ctx context.Context, entry *models.AuditEntry) error
+	Find(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEntry, error)
+	Count(ctx context.Context, filter AuditFilter) (int64, error)
+}
+
+func (f AuditFilter) bson() bson.M {
+	filter := createdFilter("created_at", f.From, f.To)
+	if f.EntityType != "" {
+		filter["entity_type"] = f.EntityType
+	}
+	if f.EntityID != "" {
+		filter["entity_id"] = f.EntityID
+	}
+	if f.Actor != "" {
+		filter["actor"] = f.Actor
+	}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.JobID != "" {
+		filter["job_id"] = f.JobID
+	}
+	return filter
+}
+
+func (f AuditFilter) match() func(*models.AuditEntry) bool {
+	return func(e *models.AuditEntry) bool {
+		return (f.EntityType == "" || e.Entity_type == f.EntityType) &&
+			(f.EntityID == "" || e.Entity_id == f.EntityID) &&
+			(f.Actor == "" || e.Actor == f.Actor) &&
+			(f.Action == "" || e.Action == f.Action) &&
+			(f.JobID == "" || e.Job_id == f.JobID) &&
+			createdMatch(e.Created_at, f.From, f.To)
+	}
+}
+
+type mongoAuditRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAuditRepo) Insert(ctx context.Context, entry *models.AuditEntry) error {
+	_, err := r.coll.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoAuditRepo) Find(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEntry, error) {
+	return mongoFind[models.AuditEntry](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoAuditRepo) Count(ctx context.Context, filter AuditFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+type memoryAuditRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryAuditRepo) Insert(ctx context.Context, entry *models.AuditEntry) error {
+	return r.coll.insert(entry)
+}
+
+func (r *memoryAuditRepo) Find(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEntry, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryAuditRepo) Count(ctx context.Context, filter AuditFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BlogFilter selects blog posts. Zero values are ignored.
+type BlogFilter struct {
+	Slug      string
+	Published *bool
+}
+
+type BlogRepo interface {
+	Insert(ctx context.Context, blog *models.Blog) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Blog, error)
+	FindOne(ctx context.Context, filter BlogFilter) (*models.Blog, error)
+	Find(ctx context.Context, filter BlogFilter, page Page) ([]models.Blog, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+func (f BlogFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.Slug != "" {
+		filter["slug"] = f.Slug
+	}
+	if f.Published != nil {
+		filter["published"] = *f.Published
+	}
+	return filter
+}
+
+func (f BlogFilter) match() func(*models.Blog) bool {
+	return func(b *models.Blog) bool {
+		return (f.Slug == "" || b.Slug == f.Slug) &&
+			(f.Published == nil || b.Published == *f.Published)
+	}
+}
+
+type mongoBlogRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoBlogRepo) Insert(ctx context.Context, blog *models.Blog) error {
+	_, err := r.coll.InsertOne(ctx, blog)
+	return err
+}
+
+func (r *mongoBlogRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Blog, error) {
+	return mongoFindOne[models.Blog](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoBlogRepo) FindOne(ctx context.Context, filter BlogFilter) (*models.Blog, error) {
+	return mongoFindOne[models.Blog](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoBlogRepo) Find(ctx context.Context, filter BlogFilter, page Page) ([]models.Blog, error) {
+	return mongoFind[models.Blog](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoBlogRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+func (r *mongoBlogRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return mongoDeleteByID(ctx, r.coll, id)
+}
+
+type memoryBlogRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryBlogRepo) Insert(ctx context.Context, blog *models.Blog) error {
+	return r.coll.insert(blog)
+}
+
+func (r *memoryBlogRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Blog, error) {
+	return memFindOne(r.coll, func(b *models.Blog) bool { return b.BlogID == id })
+}
+
+func (r *memoryBlogRepo) FindOne(ctx context.Context, filter BlogFilter) (*models.Blog, error) {
+	return memFindOne(r.coll, filter.match())
+}
+
+func (r *memoryBlogRepo) Find(ctx context.Context, filter BlogFilter, page Page) ([]models.Blog, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryBlogRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+func (r *memoryBlogRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if !r.coll.delete(id) {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ContentRepo stores the CMS content items rendered by the storefront.
+type ContentRepo interface {
+	Insert(ctx context.Context, item *models.ContentItem) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ContentItem, error)
+	FindByKey(ctx context.Context, key string) (*models.ContentItem, error)
+	Find(ctx context.Context, page Page) ([]models.ContentItem, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoContentRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoContentRepo) Insert(ctx context.Context, item *models.ContentItem) error {
+	_, err := r.coll.InsertOne(ctx, item)
+	return err
+}
+
+func (r *mongoContentRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ContentItem, error) {
+	return mongoFindOne[models.ContentItem](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoContentRepo) FindByKey(ctx context.Context, key string) (*models.ContentItem, error) {
+	return mongoFindOne[models.ContentItem](ctx, r.coll, bson.M{"contentKey": key})
+}
+
+func (r *mongoContentRepo) Find(ctx context.Context, page Page) ([]models.ContentItem, error) {
+	return mongoFind[models.ContentItem](ctx, r.coll, bson.M{}, page)
+}
+
+func (r *mongoContentRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+func (r *mongoContentRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return mongoDeleteByID(ctx, r.coll, id)
+}
+
+type memoryContentRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryContentRepo) Insert(ctx context.Context, item *models.ContentItem) error {
+	return r.coll.insert(item)
+}
+
+func (r *memoryContentRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ContentItem, error) {
+	return memFindOne(r.coll, func(item *models.ContentItem) bool { return item.ID == id })
+}
+
+func (r *memoryContentRepo) FindByKey(ctx context.Context, key string) (*models.ContentItem, error) {
+	return memFindOne(r.coll, func(item *models.ContentItem) bool { return item.ContentKey == key })
+}
+
+func (r *memoryContentRepo) Find(ctx context.Context, page Page) ([]models.ContentItem, error) {
+	return memFind[models.ContentItem](r.coll, nil, page)
+}
+
+func (r *memoryContentRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+func (r *memoryContentRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if !r.coll.delete(id) {
+		return ErrNotFound
+	}
+	return nil
+}
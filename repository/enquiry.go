@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EnquiryFilter selects enquiries. Zero values are ignored.
+type EnquiryFilter struct {
+	UserID    string
+	ProductID string
+	Status    string
+	// Since keeps the enquiries raised at or after it.
+	Since time.Time
+}
+
+type EnquiryRepo interface {
+	Insert(ctx context.Context, enquiry *models.Enquire) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Enquire, error)
+	Find(ctx context.Context, filter EnquiryFilter, page Page) ([]models.Enquire, error)
+	// CountCreated counts enquiries raised in [from, to), a zero bound is open.
+	CountCreated(ctx context.Context, from, to time.Time) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+}
+
+func (f EnquiryFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.ProductID != "" {
+		filter["product_id"] = f.ProductID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if !f.Since.IsZero() {
+		filter["enquire_date"] = bson.M{"$gte": f.Since}
+	}
+	return filter
+}
+
+func (f EnquiryFilter) match() func(*models.Enquire) bool {
+	return func(e *models.Enquire) bool {
+		return (f.UserID == "" || e.User_id == f.UserID) &&
+			(f.ProductID == "" || e.Product_id == f.ProductID) &&
+			(f.Status == "" || e.Status == f.Status) &&
+			(f.Since.IsZero() || !e.Enquire_date.Before(f.Since))
+	}
+}
+
+type mongoEnquiryRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoEnquiryRepo) Insert(ctx context.Context, enquiry *models.Enquire) error {
+	_, err := r.coll.InsertOne(ctx, enquiry)
+	return err
+}
+
+func (r *mongoEnquiryRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Enquire, error) {
+	return mongoFindOne[models.Enquire](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoEnquiryRepo) Find(ctx context.Context, filter EnquiryFilter, page Page) ([]models.Enquire, error) {
+	return mongoFind[models.Enquire](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoEnquiryRepo) CountCreated(ctx context.Context, from, to time.Time) (int64, error) {
+	return r.coll.CountDocuments(ctx, createdFilter("enquire_date", from, to))
+}
+
+func (r *mongoEnquiryRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+type memoryEnquiryRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryEnquiryRepo) Insert(ctx context.Context, enquiry *models.Enquire) error {
+	return r.coll.insert(enquiry)
+}
+
+func (r *memoryEnquiryRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Enquire, error) {
+	return memFindOne(r.coll, func(e *models.Enquire) bool { return e.Enquire_id == id })
+}
+
+func (r *memoryEnquiryRepo) Find(ctx context.Context, filter EnquiryFilter, page Page) ([]models.Enquire, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryEnquiryRepo) CountCreated(ctx context.Context, from, to time.Time) (int64, error) {
+	return memCount(r.coll, func(e *models.Enquire) bool { return createdMatch(e.Enquire_date, from, to) })
+}
+
+func (r *memoryEnquiryRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
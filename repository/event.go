@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EventFilter selects events. Zero values are ignored, the time range is
+// [From, To).
+type EventFilter struct {
+	Type   string
+	UserID string
+	From   time.Time
+	To     time.Time
+}
+
+// EventCount is the number of events of a type for a product.
+type EventCount struct {
+	ProductID string
+	Type      string
+	Count     int
+}
+
+// EventRepo is append-only, events are written in batches by the event
+// pipeline.
+type EventRepo interface {
+	InsertMany(ctx context.Context, events []models.Event) error
+	Find(ctx context.Context, filter EventFilter, page Page) ([]models.Event, error)
+	// CountByProduct counts the events of the filter per product and type.
+	CountByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error)
+	// CountVisitorsByProduct counts the distinct visitors of the events of
+	// the filter per product and type. Events without a visitor count once
+	// each.
+	CountVisitorsByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error)
+}
+
+func (f EventFilter) bson() bson.M {
+	filter := createdFilter("created_at", f.From, f.To)
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	return filter
+}
+
+func (f EventFilter) match() func(*models.Event) bool {
+	return func(e *models.Event) bool {
+		return (f.Type == "" || e.Type == f.Type) &&
+			(f.UserID == "" || e.User_id == f.UserID) &&
+			createdMatch(e.Created_at, f.From, f.To)
+	}
+}
+
+type mongoEventRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoEventRepo) InsertMany(ctx context.Context, events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(events))
+	for i := range events {
+		docs[i] = events[i]
+	}
+	_, err := r.coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoEventRepo) Find(ctx context.Context, filter EventFilter, page Page) ([]models.Event, error) {
+	return mongoFind[models.Event](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoEventRepo) CountByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error) {
+	return r.count(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter.bson()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"product_id": "$product_id", "type": "$type"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+}
+
+func (r *mongoEventRepo) CountVisitorsByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error) {
+	return r.count(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter.bson()}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"product_id": "$product_id",
+				"type":       "$type",
+				"visitor":    bson.M{"$ifNull": bson.A{"$visitor", "$_id"}},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"product_id": "$_id.product_id", "type": "$_id.type"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+}
+
+// count runs a pipeline grouping on product_id and type with a count.
+func (r *mongoEventRepo) count(ctx context.Context, pipeline mongo.Pipeline) ([]EventCount, error) {
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var groups []struct {
+		ID struct {
+			ProductID string `bson:"product_id"`
+			Type      string `bson:"type"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make([]EventCount, len(groups))
+	for i, group := range groups {
+		counts[i] = EventCount{ProductID: group.ID.ProductID, Type: group.ID.Type, Count: group.Count}
+	}
+	return counts, nil
+}
+
+type memoryEventRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryEventRepo) InsertMany(ctx context.Context, events []models.Event) error {
+	for i := range events {
+		if err := r.coll.insert(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryEventRepo) Find(ctx context.Context, filter EventFilter, page Page) ([]models.Event, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryEventRepo) CountByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error) {
+	return r.count(filter, false)
+}
+
+func (r *memoryEventRepo) CountVisitorsByProduct(ctx context.Context, filter EventFilter) ([]EventCount, error) {
+	return r.count(filter, true)
+}
+
+func (r *memoryEventRepo) count(filter EventFilter, visitors bool) ([]EventCount, error) {
+	events, err := memFind(r.coll, filter.match(), Page{})
+	if err != nil {
+		return nil, err
+	}
+	index := map[[2]string]int{}
+	seen := map[[3]string]bool{}
+	var counts []EventCount
+	for _, event := range events {
+		if visitors && event.Visitor != "" {
+			visit := [3]string{event.Product_id, event.Type, event.Visitor}
+			if seen[visit] {
+				continue
+			}
+			seen[visit] = true
+		}
+		key := [2]string{event.Product_id, event.Type}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, EventCount{ProductID: event.Product_id, Type: event.Type})
+		}
+		counts[i].Count++
+	}
+	return counts, nil
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memCollection is a tiny document store used by the in-memory
+// repositories. Documents are kept in their bson form so partial updates
+// with dotted field paths behave the same way they do against Mongo.
+type memCollection struct {
+	mu   sync.RWMutex
+	ids  []primitive.ObjectID
+	docs map[primitive.ObjectID]bson.M
+}
+
+func newMemCollection() *memCollection {
+	return &memCollection{docs: map[primitive.ObjectID]bson.M{}}
+}
+
+func toDoc(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+func fromDoc(doc bson.M, out interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+func (m *memCollection) insert(v interface{}) error {
+	doc, err := toDoc(v)
+	if err != nil {
+		return err
+	}
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if !ok || id.IsZero() {
+		return errors.New("repository: document has no _id")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.docs[id]; exists {
+		return errors.New("repository: duplicate _id")
+	}
+	m.ids = append(m.ids, id)
+	m.docs[id] = doc
+	return nil
+}
+
+func (m *memCollection) delete(id primitive.ObjectID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.docs[id]; !ok {
+		return false
+	}
+	delete(m.docs, id)
+	for i, existing := range m.ids {
+		if existing == id {
+			m.ids = append(m.ids[:i], m.ids[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// snapshot returns the stored documents in insertion order.
+func (m *memCollection) snapshot() []bson.M {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docs := make([]bson.M, 0, len(m.ids))
+	for _, id := range m.ids {
+		docs = append(docs, m.docs[id])
+	}
+	return docs
+}
+
+// update applies upd to the first document (or every document when many is
+// set) for which match returns true, and reports how many were changed.
+func (m *memCollection) update(match func(bson.M) bool, upd Update, many bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := 0
+	for _, id := range m.ids {
+		doc := m.docs[id]
+		if !match(doc) {
+			continue
+		}
+		for path, value := range upd.Set {
+			setPath(doc, path, value)
+		}
+		for path, values := range upd.Push {
+			existing, _ := getPath(doc, path)
+			arr, _ := existing.(bson.A)
+			rv := reflect.ValueOf(values)
+			if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+				for i := 0; i < rv.Len(); i++ {
+					arr = append(arr, rv.Index(i).Interface())
+				}
+			} else {
+				arr = append(arr, values)
+			}
+			setPath(doc, path, arr)
+		}
+		normalised, err := toDoc(doc)
+		if err != nil {
+			return changed, err
+		}
+		m.docs[id] = normalised
+		changed++
+		if !many {
+			break
+		}
+	}
+	return changed, nil
+}
+
+func (m *memCollection) updateByID(id primitive.ObjectID, upd Update) error {
+	n, err := m.update(func(doc bson.M) bool { return doc["_id"] == id }, upd, false)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func getPath(doc bson.M, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var current interface{} = doc
+	for _, part := range parts {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setPath(doc bson.M, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(bson.M)
+		if !ok {
+			next = bson.M{}
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// memFind decodes every document into T and keeps the ones accepted by
+// match, then applies the ordering and window described by page.
+func memFind[T any](m *memCollection, match func(*T) bool, page Page) ([]T, error) {
+	docs := m.snapshot()
+	if page.Sort != "" {
+		field := strings.TrimPrefix(page.Sort, "-")
+		desc := page.direction() < 0
+		sort.SliceStable(docs, func(i, j int) bool {
+			a, _ := getPath(docs[i], field)
+			b, _ := getPath(docs[j], field)
+			if desc {
+				return lessValue(b, a)
+			}
+			return lessValue(a, b)
+		})
+	}
+	var results []T
+	skipped := int64(0)
+	for _, doc := range docs {
+		var item T
+		if err := fromDoc(doc, &item); err != nil {
+			return nil, err
+		}
+		if match != nil && !match(&item) {
+			continue
+		}
+		if skipped < page.Skip {
+			skipped++
+			continue
+		}
+		results = append(results, item)
+		if page.Limit > 0 && int64(len(results)) >= page.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func memFindOne[T any](m *memCollection, match func(*T) bool) (*T, error) {
+	results, err := memFind(m, match, Page{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	return &results[0], nil
+}
+
+func memCount[T any](m *memCollection, match func(*T) bool) (int64, error) {
+	results, err := memFind(m, match, Page{})
+	return int64(len(results)), err
+}
+
+func lessValue(a, b interface{}) bool {
+	switch av := a.(type) {
+	case primitive.DateTime:
+		bv, _ := b.(primitive.DateTime)
+		return av < bv
+	case string:
+		bv, _ := b.(string)
+		return av < bv
+	case int32:
+		bv, _ := b.(int32)
+		return av < bv
+	case int64:
+		bv, _ := b.(int64)
+		return av < bv
+	case float64:
+		bv, _ := b.(float64)
+		return av < bv
+	case bool:
+		bv, _ := b.(bool)
+		return !av && bv
+	}
+	return a == nil && b != nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
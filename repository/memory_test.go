@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func int64Ptr(n int64) *int64 { return &n }
+
+func TestMemCollection(t *testing.T) {
+	type doc struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Name  string             `bson:"name"`
+		Inner struct {
+			Count int `bson:"count"`
+		} `bson:"inner"`
+		Tags []string `bson:"tags"`
+	}
+	coll := newMemCollection()
+	d := doc{ID: primitive.NewObjectID(), Name: "a", Tags: []string{"x"}}
+	if err := coll.insert(&d); err != nil {
+		t.Fatal(err)
+	}
+	if err := coll.insert(&d); err == nil {
+		t.Error("inserted a duplicate _id")
+	}
+	if err := coll.insert(&doc{}); err == nil {
+		t.Error("inserted a document without _id")
+	}
+
+	err := coll.updateByID(d.ID, Update{Set: Fields{"name": "b", "inner.count": 2}, Push: Fields{"tags": []string{"y", "z"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := memFindOne(coll, func(x *doc) bool { return x.ID == d.ID })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "b" || got.Inner.Count != 2 || len(got.Tags) != 3 || got.Tags[2] != "z" {
+		t.Errorf("updated to %+v", got)
+	}
+	if err := coll.updateByID(primitive.NewObjectID(), Update{Set: Fields{"name": "c"}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update of a missing document: %v", err)
+	}
+
+	if !coll.delete(d.ID) || coll.delete(d.ID) {
+		t.Error("delete should succeed once")
+	}
+	if _, err := memFindOne(coll, func(x *doc) bool { return true }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("find after delete: %v", err)
+	}
+}
+
+func TestMemoryProductFind(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory().Products
+	product := func(name, category, seller string, price int64, approved bool) models.Product {
+		return models.Product{
+			Product_ID:       primitive.NewObjectID(),
+			Product_Name:     name,
+			Category:         category,
+			SKU:              name,
+			Price:            money.New(price, "INR"),
+			Approved:         approved,
+			SellerRegistered: []string{seller},
+		}
+	}
+	products := []models.Product{
+		product("Red Shirt", "apparel", "s1", 49900, true),
+		product("Blue Shirt", "apparel", "s2", 59900, true),
+		product("Mug", "kitchen", "s1", 19900, false),
+	}
+	products[2].Variant = []models.ProductVariant{{ID: primitive.NewObjectID(), SKU: "MUG-WHITE"}}
+	for i := range products {
+		if err := repo.Insert(ctx, &products[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		page   Page
+		want   []string
+	}{
+		{"all in insertion order", ProductFilter{}, Page{}, []string{"Red Shirt", "Blue Shirt", "Mug"}},
+		{"seller", ProductFilter{SellerID: "s1"}, Page{}, []string{"Red Shirt", "Mug"}},
+		{"category", ProductFilter{Category: "apparel"}, Page{}, []string{"Red Shirt", "Blue Shirt"}},
+		{"approved", ProductFilter{Approved: boolPtr(false)}, Page{}, []string{"Mug"}},
+		{"name like", ProductFilter{NameLike: "shirt"}, Page{}, []string{"Red Shirt", "Blue Shirt"}},
+		{"variant sku", ProductFilter{VariantSKU: "MUG-WHITE"}, Page{}, []string{"Mug"}},
+		{"ids", ProductFilter{IDs: []primitive.ObjectID{products[1].Product_ID}}, Page{}, []string{"Blue Shirt"}},
+		{"price range", ProductFilter{MinPrice: int64Ptr(20000), MaxPrice: int64Ptr(50000)}, Page{}, []string{"Red Shirt"}},
+		{"sorted by price", ProductFilter{}, Page{Sort: "price.amount"}, []string{"Mug", "Red Shirt", "Blue Shirt"}},
+		{"sorted descending", ProductFilter{}, Page{Sort: "-price.amount"}, []string{"Blue Shirt", "Red Shirt", "Mug"}},
+		{"skip and limit", ProductFilter{}, Page{Sort: "price.amount", Skip: 1, Limit: 1}, []string{"Red Shirt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.Find(ctx, tt.filter, tt.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, p := range found {
+				names = append(names, p.Product_Name)
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("got %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", names, tt.want)
+				}
+			}
+			count, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.page == (Page{}) && count != int64(len(tt.want)) {
+				t.Errorf("count %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemorySellerFindOne(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory().Sellers
+	seller := models.Seller{ID: primitive.NewObjectID(), MobileNo: "9000000001", Email: "a@example.com"}
+	if err := repo.Insert(ctx, &seller); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		filter SellerFilter
+		found  bool
+	}{
+		{"mobile", SellerFilter{MobileNo: "9000000001"}, true},
+		{"email", SellerFilter{Email: "a@example.com"}, true},
+		{"id", SellerFilter{ID: seller.ID}, true},
+		{"other mobile", SellerFilter{MobileNo: "9000000002"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindOne(ctx, tt.filter)
+			if !tt.found {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("err = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil || got.ID != seller.ID {
+				t.Errorf("got %v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestMemoryAdminInviteAcceptAndReopen(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory().AdminInvites
+	invite := models.AdminInvite{ID: primitive.NewObjectID(), Email: "a@example.com", TokenHash: "hash"}
+	if err := repo.Insert(ctx, &invite); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name string
+		do   func() error
+		want error
+	}{
+		{"reopen pending", func() error { return repo.Reopen(ctx, invite.ID) }, ErrNotFound},
+		{"accept", func() error { return repo.Accept(ctx, invite.ID, time.Now()) }, nil},
+		{"accept twice", func() error { return repo.Accept(ctx, invite.ID, time.Now()) }, ErrNotFound},
+		{"reopen", func() error { return repo.Reopen(ctx, invite.ID) }, nil},
+		{"accept reopened", func() error { return repo.Accept(ctx, invite.ID, time.Now()) }, nil},
+	}
+	for _, step := range steps {
+		if err := step.do(); !errors.Is(err, step.want) {
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.want)
+		}
+	}
+	found, err := repo.FindByTokenHash(ctx, "hash")
+	if err != nil || !found.Accepted {
+		t.Errorf("got %+v, %v", found, err)
+	}
+}
+
+func TestMemoryUploadSessionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory().UploadSessions
+	now := time.Now()
+	session := models.UploadSession{ID: primitive.NewObjectID(), Owner: "o", Status: models.UploadPending, Expires_at: now.Add(time.Hour)}
+	old := models.UploadSession{ID: primitive.NewObjectID(), Owner: "o", Status: models.UploadPending, Expires_at: now.Add(-time.Hour)}
+	for _, s := range []*models.UploadSession{&session, &old} {
+		if err := repo.Insert(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := repo.Claim(ctx, session.ID, "o"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("claimed a pending session: %v", err)
+	}
+	if err := repo.Confirm(ctx, session.ID, "verified"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Confirm(ctx, session.ID, "other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("confirmed twice: %v", err)
+	}
+	if _, err := repo.Claim(ctx, session.ID, "someone else"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("claimed by another owner: %v", err)
+	}
+	claimed, err := repo.Claim(ctx, session.ID, "o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.Status != models.UploadAttaching || claimed.Verified_key != "verified" {
+		t.Errorf("claimed %+v", claimed)
+	}
+	if _, err := repo.Claim(ctx, session.ID, "o"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("claimed twice: %v", err)
+	}
+
+	expired, err := repo.FindExpired(ctx, now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != old.ID {
+		t.Errorf("expired %+v", expired)
+	}
+}
+
+func TestMemoryEventCounts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory().Events
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	event := func(typ, product, visitor string, at time.Time) models.Event {
+		return models.Event{ID: primitive.NewObjectID(), Type: typ, Product_id: product, Visitor: visitor, Created_at: at}
+	}
+	err := repo.InsertMany(ctx, []models.Event{
+		event(models.EventClick, "p1", "a", day.Add(time.Hour)),
+		event(models.EventClick, "p1", "a", day.Add(2*time.Hour)),
+		event(models.EventClick, "p1", "b", day.Add(3*time.Hour)),
+		event(models.EventClick, "p1", "", day.Add(4*time.Hour)),
+		event(models.EventClick, "p1", "", day.Add(5*time.Hour)),
+		event(models.EventView, "p2", "", day.Add(time.Hour)),
+		event(models.EventClick, "p1", "c", day.AddDate(0, 0, 1)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := EventFilter{From: day, To: day.AddDate(0, 0, 1)}
+	tests := []struct {
+		name  string
+		count func() ([]EventCount, error)
+		want  map[[2]string]int
+	}{
+		{"events", func() ([]EventCount, error) { return repo.CountByProduct(ctx, filter) },
+			map[[2]string]int{{"p1", models.EventClick}: 5, {"p2", models.EventView}: 1}},
+		{"visitors", func() ([]EventCount, error) { return repo.CountVisitorsByProduct(ctx, filter) },
+			map[[2]string]int{{"p1", models.EventClick}: 4, {"p2", models.EventView}: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, err := tt.count()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(counts) != len(tt.want) {
+				t.Fatalf("counts %+v", counts)
+			}
+			for _, count := range counts {
+				if want := tt.want[[2]string{count.ProductID, count.Type}]; count.Count != want {
+					t.Errorf("%s %s: %d, want %d", count.ProductID, count.Type, count.Count, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kravi0/BizGrowth-backend/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func mongoFind[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, page Page) ([]T, error) {
+	cursor, err := coll.Find(ctx, filter, page.findOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var results []T
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func mongoFindOne[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	var result T
+	err := coll.FindOne(ctx, filter, opts...).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func mongoUpdateByID(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateOne(ctx, coll, bson.M{"_id": id}, upd)
+}
+
+func mongoUpdateOne(ctx context.Context, coll *mongo.Collection, filter bson.M, upd Update) error {
+	result, err := coll.UpdateOne(ctx, filter, upd.bson())
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func mongoDeleteByID(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID) error {
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureIndexes creates the indexes the repositories query on: the weighted
+// product_search text index over search.Fields and the lookups of events,
+// popularity, recommendations and expired upload sessions.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	keys := bson.D{}
+	weights := bson.M{}
+	for _, field := range search.Fields {
+		for _, path := range field.Paths {
+			keys = append(keys, bson.E{Key: path, Value: "text"})
+			weights[path] = field.Weight
+		}
+	}
+	_, err := db.Collection("Products").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetName("product_search").SetWeights(weights).SetDefaultLanguage("english"),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("Event").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "type", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("Popularity").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "day", Value: 1}, {Key: "kind", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "key", Value: 1}, {Key: "day", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("Recommendation").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "key", Value: 1}, {Key: "computed_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("UploadSession").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+	})
+	return err
+}
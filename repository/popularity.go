@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PopularityFilter selects daily popularity rows. Zero values are ignored,
+// the days are in [From, To).
+type PopularityFilter struct {
+	Kind string
+	Keys []string
+	From time.Time
+	To   time.Time
+}
+
+type PopularityRepo interface {
+	// Upsert writes rows, replacing the ones of the same day, kind and key.
+	Upsert(ctx context.Context, rows []models.Popularity) error
+	Find(ctx context.Context, filter PopularityFilter, page Page) ([]models.Popularity, error)
+}
+
+func (f PopularityFilter) bson() bson.M {
+	filter := createdFilter("day", f.From, f.To)
+	if f.Kind != "" {
+		filter["kind"] = f.Kind
+	}
+	if len(f.Keys) > 0 {
+		filter["key"] = bson.M{"$in": f.Keys}
+	}
+	return filter
+}
+
+func (f PopularityFilter) match() func(*models.Popularity) bool {
+	return func(p *models.Popularity) bool {
+		return (f.Kind == "" || p.Kind == f.Kind) &&
+			(len(f.Keys) == 0 || containsString(f.Keys, p.Key)) &&
+			createdMatch(p.Day, f.From, f.To)
+	}
+}
+
+type mongoPopularityRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoPopularityRepo) Upsert(ctx context.Context, rows []models.Popularity) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, len(rows))
+	for i, row := range rows {
+		row.ID = primitive.NilObjectID
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"day": row.Day, "kind": row.Kind, "key": row.Key}).
+			SetReplacement(row).
+			SetUpsert(true)
+	}
+	_, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *mongoPopularityRepo) Find(ctx context.Context, filter PopularityFilter, page Page) ([]models.Popularity, error) {
+	return mongoFind[models.Popularity](ctx, r.coll, filter.bson(), page)
+}
+
+type memoryPopularityRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryPopularityRepo) Upsert(ctx context.Context, rows []models.Popularity) error {
+	for _, row := range rows {
+		existing, err := memFindOne(r.coll, func(p *models.Popularity) bool {
+			return p.Day.Equal(row.Day) && p.Kind == row.Kind && p.Key == row.Key
+		})
+		switch {
+		case err == nil:
+			row.ID = existing.ID
+			r.coll.delete(existing.ID)
+		case err == ErrNotFound:
+			row.ID = primitive.NewObjectID()
+		default:
+			return err
+		}
+		if err := r.coll.insert(&row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryPopularityRepo) Find(ctx context.Context, filter PopularityFilter, page Page) ([]models.Popularity, error) {
+	return memFind(r.coll, filter.match(), page)
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProductFilter selects products. Zero values are ignored.
+type ProductFilter struct {
+	IDs         []primitive.ObjectID
+	SellerID    string
+	Category    string
+	ProductName string
+	SKU         string
+	// VariantSKU matches products with a variant of that SKU.
+	VariantSKU string
+	// NameLike is a case-insensitive regular expression on the product name.
+	NameLike string
+	Genders  []string
+	Approved *bool
+	Featured *bool
+	Rejected *bool
+	Archived *bool
+	// MinPrice and MaxPrice bound the price in minor units, inclusive.
+	MinPrice *int64
+	MaxPrice *int64
+}
+
+type ProductRepo interface {
+	Insert(ctx context.Context, product *models.Product) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
+	Find(ctx context.Context, filter ProductFilter, page Page) ([]models.Product, error)
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	// TextSearch returns up to limit products of the filter that contain
+	// any of the words, see search.Tokenize, in one of the search.Fields.
+	// The best text matches come first.
+	TextSearch(ctx context.Context, words []string, filter ProductFilter, limit int64) ([]models.Product, error)
+}
+
+type mongoProductRepo struct {
+	coll *mongo.Collection
+}
+
+func (f ProductFilter) bson() bson.M {
+	filter := bson.M{}
+	var and []bson.M
+	if len(f.IDs) > 0 {
+		filter["_id"] = bson.M{"$in": f.IDs}
+	}
+	if f.SellerID != "" {
+		filter["sellerregistered"] = f.SellerID
+	}
+	if f.Category != "" {
+		filter["category"] = f.Category
+	}
+	if f.ProductName != "" {
+		filter["product_name"] = f.ProductName
+	}
+	if f.SKU != "" {
+		filter["sku"] = f.SKU
+	}
+	if f.VariantSKU != "" {
+		filter["variant.sku"] = f.VariantSKU
+	}
+	if f.NameLike != "" {
+		and = append(and, bson.M{"product_name": bson.M{"$regex": f.NameLike, "$options": "i"}})
+	}
+	if len(f.Genders) > 0 {
+		filter["gender"] = bson.M{"$in": f.Genders}
+	}
+	if f.Approved != nil {
+		filter["approved"] = *f.Approved
+	}
+	if f.Featured != nil {
+		filter["featured"] = *f.Featured
+	}
+	if f.Rejected != nil {
+		filter["isRejected"] = *f.Rejected
+	}
+	if f.Archived != nil {
+		filter["isArchived"] = *f.Archived
+	}
+	if f.MinPrice != nil || f.MaxPrice != nil {
+		price := bson.M{}
+		if f.MinPrice != nil {
+			price["$gte"] = *f.MinPrice
+		}
+		if f.MaxPrice != nil {
+			price["$lte"] = *f.MaxPrice
+		}
+		filter["price.amount"] = price
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	return filter
+}
+
+func (r *mongoProductRepo) Insert(ctx context.Context, product *models.Product) error {
+	_, err := r.coll.InsertOne(ctx, product)
+	return err
+}
+
+func (r *mongoProductRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	return mongoFindOne[models.Product](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoProductRepo) Find(ctx context.Context, filter ProductFilter, page Page) ([]models.Product, error) {
+	return mongoFind[models.Product](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoProductRepo) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoProductRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+// TextSearch runs on the product_search text index, see EnsureIndexes.
+func (r *mongoProductRepo) TextSearch(ctx context.Context, words []string, filter ProductFilter, limit int64) ([]models.Product, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	query := filter.bson()
+	query["$text"] = bson.M{"$search": strings.Join(words, " ")}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().SetProjection(bson.M{"score": score}).SetSort(bson.D{{Key: "score", Value: score}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var results []models.Product
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+type memoryProductRepo struct {
+	coll *memCollection
+}
+
+func (f ProductFilter) match() func(*models.Product) bool {
+	nameLike := compileInsensitive(f.NameLike)
+	return func(p *models.Product) bool {
+		if len(f.IDs) > 0 && !containsID(f.IDs, p.Product_ID) {
+			return false
+		}
+		if f.SellerID != "" && !containsString(p.SellerRegistered, f.SellerID) {
+			return false
+		}
+		if f.Category != "" && p.Category != f.Category {
+			return false
+		}
+		if f.ProductName != "" && p.Product_Name != f.ProductName {
+			return false
+		}
+		if f.SKU != "" && p.SKU != f.SKU {
+			return false
+		}
+		if f.VariantSKU != "" && !hasVariantSKU(p.Variant, f.VariantSKU) {
+			return false
+		}
+		if nameLike != nil && !nameLike.MatchString(p.Product_Name) {
+			return false
+		}
+		if len(f.Genders) > 0 && !containsString(f.Genders, p.Gender) {
+			return false
+		}
+		if f.Approved != nil && p.Approved != *f.Approved {
+			return false
+		}
+		if f.Featured != nil && p.Featured != *f.Featured {
+			return false
+		}
+		if f.Rejected != nil && p.IsRejected != *f.Rejected {
+			return false
+		}
+		if f.Archived != nil && p.IsArchived != *f.Archived {
+			return false
+		}
+		if f.MinPrice != nil && p.Price.Amount < *f.MinPrice {
+			return false
+		}
+		if f.MaxPrice != nil && p.Price.Amount > *f.MaxPrice {
+			return false
+		}
+		return true
+	}
+}
+
+func hasVariantSKU(variants []models.ProductVariant, sku string) bool {
+	for _, variant := range variants {
+		if variant.SKU == sku {
+			return true
+		}
+	}
+	return false
+}
+
+// compileInsensitive mirrors Mongo's $regex with the "i" option. Patterns
+// that do not compile match nothing.
+func compileInsensitive(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return regexp.MustCompile(`[^\s\S]`)
+	}
+	return re
+}
+
+func (r *memoryProductRepo) Insert(ctx context.Context, product *models.Product) error {
+	return r.coll.insert(product)
+}
+
+func (r *memoryProductRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	return memFindOne(r.coll, func(p *models.Product) bool { return p.Product_ID == id })
+}
+
+func (r *memoryProductRepo) Find(ctx context.Context, filter ProductFilter, page Page) ([]models.Product, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryProductRepo) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memoryProductRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+// TextSearch scores every word on its own with search.Query.Score, so a
+// product needs only one of them like on the text index.
+func (r *memoryProductRepo) TextSearch(ctx context.Context, words []string, filter ProductFilter, limit int64) ([]models.Product, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	products, err := memFind(r.coll, filter.match(), Page{})
+	if err != nil {
+		return nil, err
+	}
+	scores := map[primitive.ObjectID]float64{}
+	var results []models.Product
+	for i := range products {
+		doc := search.Document(&products[i])
+		for _, word := range words {
+			if score, ok := (search.Query{Terms: []search.Term{{Word: word}}}).Score(doc); ok {
+				scores[products[i].Product_ID] += score
+			}
+		}
+		if scores[products[i].Product_ID] > 0 {
+			results = append(results, products[i])
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return scores[results[i].Product_ID] > scores[results[j].Product_ID]
+	})
+	if limit > 0 && int64(len(results)) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Bool is a helper for the optional boolean fields of filters.
+func Bool(v bool) *bool {
+	return &v
+}
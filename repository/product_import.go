@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ProductImportRepo interface {
+	Insert(ctx context.Context, job *models.ProductImport) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductImport, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+}
+
+type mongoProductImportRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoProductImportRepo) Insert(ctx context.Context, job *models.ProductImport) error {
+	_, err := r.coll.InsertOne(ctx, job)
+	return err
+}
+
+func (r *mongoProductImportRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductImport, error) {
+	return mongoFindOne[models.ProductImport](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoProductImportRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+type memoryProductImportRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryProductImportRepo) Insert(ctx context.Context, job *models.ProductImport) error {
+	return r.coll.insert(job)
+}
+
+func (r *memoryProductImportRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductImport, error) {
+	return memFindOne(r.coll, func(j *models.ProductImport) bool { return j.ID == id })
+}
+
+func (r *memoryProductImportRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProductReferenceFilter selects seller offers. Zero values are ignored.
+type ProductReferenceFilter struct {
+	ProductIDs []primitive.ObjectID
+	SellerID   primitive.ObjectID
+	Archived   *bool
+	// LowStock selects tracked offers whose available stock is at or below
+	// their low stock threshold.
+	LowStock bool
+}
+
+type ProductReferenceRepo interface {
+	Insert(ctx context.Context, reference *models.ProductReference) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductReference, error)
+	Find(ctx context.Context, filter ProductReferenceFilter, page Page) ([]models.ProductReference, error)
+	Count(ctx context.Context, filter ProductReferenceFilter) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+}
+
+func (f ProductReferenceFilter) bson() bson.M {
+	filter := bson.M{}
+	if len(f.ProductIDs) > 0 {
+		filter["product_id"] = bson.M{"$in": f.ProductIDs}
+	}
+	if !f.SellerID.IsZero() {
+		filter["seller_id"] = f.SellerID
+	}
+	if f.Archived != nil {
+		filter["archived"] = *f.Archived
+	}
+	if f.LowStock {
+		filter["inventory.tracked"] = true
+		filter["$expr"] = bson.M{"$lte": bson.A{
+			bson.M{"$subtract": bson.A{"$inventory.on_hand", "$inventory.reserved"}},
+			"$inventory.low_stock_threshold",
+		}}
+	}
+	return filter
+}
+
+func (f ProductReferenceFilter) match() func(*models.ProductReference) bool {
+	return func(r *models.ProductReference) bool {
+		if len(f.ProductIDs) > 0 && !containsID(f.ProductIDs, r.ProductID) {
+			return false
+		}
+		inv := r.Inventory
+		return (f.SellerID.IsZero() || r.SellerID == f.SellerID) &&
+			(f.Archived == nil || r.Archived == *f.Archived) &&
+			(!f.LowStock || (inv.Tracked && inv.OnHand-inv.Reserved <= inv.LowStockThreshold))
+	}
+}
+
+type mongoProductReferenceRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoProductReferenceRepo) Insert(ctx context.Context, reference *models.ProductReference) error {
+	_, err := r.coll.InsertOne(ctx, reference)
+	return err
+}
+
+func (r *mongoProductReferenceRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductReference, error) {
+	return mongoFindOne[models.ProductReference](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoProductReferenceRepo) Find(ctx context.Context, filter ProductReferenceFilter, page Page) ([]models.ProductReference, error) {
+	return mongoFind[models.ProductReference](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoProductReferenceRepo) Count(ctx context.Context, filter ProductReferenceFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoProductReferenceRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+type memoryProductReferenceRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryProductReferenceRepo) Insert(ctx context.Context, reference *models.ProductReference) error {
+	return r.coll.insert(reference)
+}
+
+func (r *memoryProductReferenceRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductReference, error) {
+	return memFindOne(r.coll, func(ref *models.ProductReference) bool { return ref.ID == id })
+}
+
+func (r *memoryProductReferenceRepo) Find(ctx context.Context, filter ProductReferenceFilter, page Page) ([]models.ProductReference, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryProductReferenceRepo) Count(ctx context.Context, filter ProductReferenceFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memoryProductReferenceRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProductRevisionFilter selects product revisions. Zero values are ignored.
+type ProductRevisionFilter struct {
+	ProductID primitive.ObjectID
+	Author    string
+	Status    string
+}
+
+type ProductRevisionRepo interface {
+	Insert(ctx context.Context, revision *models.ProductRevision) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductRevision, error)
+	Find(ctx context.Context, filter ProductRevisionFilter, page Page) ([]models.ProductRevision, error)
+	Count(ctx context.Context, filter ProductRevisionFilter) (int64, error)
+	// Resolve updates a revision that is still in status from. It returns
+	// ErrNotFound when the revision does not exist or was resolved already.
+	Resolve(ctx context.Context, id primitive.ObjectID, from string, upd Update) error
+}
+
+func (f ProductRevisionFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ProductID.IsZero() {
+		filter["product_id"] = f.ProductID
+	}
+	if f.Author != "" {
+		filter["author"] = f.Author
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	return filter
+}
+
+func (f ProductRevisionFilter) match() func(*models.ProductRevision) bool {
+	return func(r *models.ProductRevision) bool {
+		return (f.ProductID.IsZero() || r.Product_id == f.ProductID) &&
+			(f.Author == "" || r.Author == f.Author) &&
+			(f.Status == "" || r.Status == f.Status)
+	}
+}
+
+type mongoProductRevisionRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoProductRevisionRepo) Insert(ctx context.Context, revision *models.ProductRevision) error {
+	_, err := r.coll.InsertOne(ctx, revision)
+	return err
+}
+
+func (r *mongoProductRevisionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductRevision, error) {
+	return mongoFindOne[models.ProductRevision](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoProductRevisionRepo) Find(ctx context.Context, filter ProductRevisionFilter, page Page) ([]models.ProductRevision, error) {
+	return mongoFind[models.ProductRevision](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoProductRevisionRepo) Count(ctx context.Context, filter ProductRevisionFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoProductRevisionRepo) Resolve(ctx context.Context, id primitive.ObjectID, from string, upd Update) error {
+	return mongoUpdateOne(ctx, r.coll, bson.M{"_id": id, "status": from}, upd)
+}
+
+type memoryProductRevisionRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryProductRevisionRepo) Insert(ctx context.Context, revision *models.ProductRevision) error {
+	return r.coll.insert(revision)
+}
+
+func (r *memoryProductRevisionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ProductRevision, error) {
+	return memFindOne(r.coll, func(rev *models.ProductRevision) bool { return rev.ID == id })
+}
+
+func (r *memoryProductRevisionRepo) Find(ctx context.Context, filter ProductRevisionFilter, page Page) ([]models.ProductRevision, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryProductRevisionRepo) Count(ctx context.Context, filter ProductRevisionFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memoryProductRevisionRepo) Resolve(ctx context.Context, id primitive.ObjectID, from string, upd Update) error {
+	n, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["status"] == from
+	}, upd, false)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RecommendationRepo stores the precomputed recommendation table.
+type RecommendationRepo interface {
+	// FindByKeys returns the current row of each of the keys that has one.
+	FindByKeys(ctx context.Context, keys []string) ([]models.Recommendation, error)
+	// Replace swaps the whole table for rows computed at computedAt. The
+	// new rows are written before the old ones go, so readers never find
+	// the table empty.
+	Replace(ctx context.Context, rows []models.Recommendation, computedAt time.Time) error
+}
+
+// latestRows keeps the newest row of every key, they can briefly overlap
+// while the table is replaced.
+func latestRows(rows []models.Recommendation) []models.Recommendation {
+	latest := map[string]int{}
+	var kept []models.Recommendation
+	for _, row := range rows {
+		i, ok := latest[row.Key]
+		if !ok {
+			latest[row.Key] = len(kept)
+			kept = append(kept, row)
+		} else if row.Computed_at.After(kept[i].Computed_at) {
+			kept[i] = row
+		}
+	}
+	return kept
+}
+
+type mongoRecommendationRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoRecommendationRepo) FindByKeys(ctx context.Context, keys []string) ([]models.Recommendation, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	rows, err := mongoFind[models.Recommendation](ctx, r.coll, bson.M{"key": bson.M{"$in": keys}}, Page{})
+	if err != nil {
+		return nil, err
+	}
+	return latestRows(rows), nil
+}
+
+func (r *mongoRecommendationRepo) Replace(ctx context.Context, rows []models.Recommendation, computedAt time.Time) error {
+	for start := 0; start < len(rows); start += 500 {
+		end := start + 500
+		if end > len(rows) {
+			end = len(rows)
+		}
+		docs := make([]interface{}, 0, end-start)
+		for i := range rows[start:end] {
+			docs = append(docs, rows[start+i])
+		}
+		if _, err := r.coll.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+	}
+	_, err := r.coll.DeleteMany(ctx, bson.M{"computed_at": bson.M{"$lt": computedAt}})
+	return err
+}
+
+type memoryRecommendationRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryRecommendationRepo) FindByKeys(ctx context.Context, keys []string) ([]models.Recommendation, error) {
+	rows, err := memFind(r.coll, func(row *models.Recommendation) bool { return containsString(keys, row.Key) }, Page{})
+	if err != nil {
+		return nil, err
+	}
+	return latestRows(rows), nil
+}
+
+func (r *memoryRecommendationRepo) Replace(ctx context.Context, rows []models.Recommendation, computedAt time.Time) error {
+	current := map[primitive.ObjectID]bool{}
+	for i := range rows {
+		if err := r.coll.insert(&rows[i]); err != nil {
+			return err
+		}
+		current[rows[i].ID] = true
+	}
+	old, err := memFind(r.coll, func(row *models.Recommendation) bool { return !current[row.ID] }, Page{})
+	if err != nil {
+		return err
+	}
+	for _, row := range old {
+		r.coll.delete(row.ID)
+	}
+	return nil
+}
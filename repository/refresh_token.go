@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RefreshTokenRepo interface {
+	Insert(ctx context.Context, token *models.RefreshToken) error
+	// Consume marks the token as used and returns it as it was before, so a
+	// first use can be told apart from a replay.
+	Consume(ctx context.Context, id primitive.ObjectID) (*models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, family primitive.ObjectID) error
+}
+
+type mongoRefreshTokenRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoRefreshTokenRepo) Insert(ctx context.Context, token *models.RefreshToken) error {
+	_, err := r.coll.InsertOne(ctx, token)
+	return err
+}
+
+func (r *mongoRefreshTokenRepo) Consume(ctx context.Context, id primitive.ObjectID) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"used": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *mongoRefreshTokenRepo) RevokeFamily(ctx context.Context, family primitive.ObjectID) error {
+	_, err := r.coll.UpdateMany(ctx, bson.M{"family": family}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+type memoryRefreshTokenRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryRefreshTokenRepo) Insert(ctx context.Context, token *models.RefreshToken) error {
+	return r.coll.insert(token)
+}
+
+func (r *memoryRefreshTokenRepo) Consume(ctx context.Context, id primitive.ObjectID) (*models.RefreshToken, error) {
+	first, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["used"] == false
+	}, Update{Set: Fields{"used": true}}, false)
+	if err != nil {
+		return nil, err
+	}
+	token, err := memFindOne(r.coll, func(t *models.RefreshToken) bool { return t.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if first == 1 {
+		token.Used = false
+	}
+	return token, nil
+}
+
+func (r *memoryRefreshTokenRepo) RevokeFamily(ctx context.Context, family primitive.ObjectID) error {
+	_, err := r.coll.update(func(doc bson.M) bool {
+		return doc["family"] == family
+	}, Update{Set: Fields{"revoked": true}}, true)
+	return err
+}
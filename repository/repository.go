@@ -0,0 +1,151 @@
+// Package repository hides MongoDB behind typed, per-entity interfaces so
+// handlers can run against either a live cluster or an in-memory store.
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrNotFound = errors.New("repository: document not found")
+
+// Fields maps bson field names, dotted paths allowed, to values.
+type Fields map[string]interface{}
+
+// Update describes a partial update of a single document. Set overwrites
+// fields, Push appends every element of the given slice to an array field.
+type Update struct {
+	Set  Fields
+	Push Fields
+}
+
+func (u Update) bson() bson.M {
+	update := bson.M{}
+	if len(u.Set) > 0 {
+		update["$set"] = bson.M(u.Set)
+	}
+	if len(u.Push) > 0 {
+		push := bson.M{}
+		for field, values := range u.Push {
+			push[field] = bson.M{"$each": values}
+		}
+		update["$push"] = push
+	}
+	return update
+}
+
+// Page controls ordering and windowing of list queries. Sort is a bson field
+// name, prefixed with "-" for descending order.
+type Page struct {
+	Sort  string
+	Skip  int64
+	Limit int64
+}
+
+func (p Page) findOptions() *options.FindOptions {
+	opts := options.Find()
+	if p.Sort != "" {
+		opts.SetSort(bson.D{{Key: strings.TrimPrefix(p.Sort, "-"), Value: p.direction()}})
+	}
+	if p.Skip > 0 {
+		opts.SetSkip(p.Skip)
+	}
+	if p.Limit > 0 {
+		opts.SetLimit(p.Limit)
+	}
+	return opts
+}
+
+func (p Page) direction() int {
+	if strings.HasPrefix(p.Sort, "-") {
+		return -1
+	}
+	return 1
+}
+
+// Repositories bundles every repository the controllers depend on.
+type Repositories struct {
+	Products  ProductRepo
+	Sellers   SellerRepo
+	Enquiries EnquiryRepo
+	Reviews   ReviewRepo
+	Tickets   TicketRepo
+	Blogs     BlogRepo
+	Contents  ContentRepo
+
+	ProductReferences ProductReferenceRepo
+	ProductRevisions  ProductRevisionRepo
+
+	RefreshTokens RefreshTokenRepo
+	Admins        AdminRepo
+	AdminInvites  AdminInviteRepo
+	Audit         AuditRepo
+
+	ProductImports ProductImportRepo
+	UploadSessions UploadSessionRepo
+
+	Events          EventRepo
+	Popularity      PopularityRepo
+	Recommendations RecommendationRepo
+}
+
+// NewMongo returns repositories backed by collections of db.
+func NewMongo(db *mongo.Database) Repositories {
+	return Repositories{
+		Products:  &mongoProductRepo{db.Collection("Products")},
+		Sellers:   &mongoSellerRepo{db.Collection("seller")},
+		Enquiries: &mongoEnquiryRepo{db.Collection("enquire")},
+		Reviews:   &mongoReviewRepo{db.Collection("Reviews")},
+		Tickets:   &mongoTicketRepo{tickets: db.Collection("CustomerSupportTicket"), chats: db.Collection("SupportChatMessage")},
+		Blogs:     &mongoBlogRepo{db.Collection("Blog")},
+		Contents:  &mongoContentRepo{db.Collection("ContentItem")},
+
+		ProductReferences: &mongoProductReferenceRepo{db.Collection("ProductReference")},
+		ProductRevisions:  &mongoProductRevisionRepo{db.Collection("ProductRevision")},
+
+		RefreshTokens: &mongoRefreshTokenRepo{db.Collection("RefreshToken")},
+		Admins:        &mongoAdminRepo{db.Collection("AdminUser")},
+		AdminInvites:  &mongoAdminInviteRepo{db.Collection("AdminInvite")},
+		Audit:         &mongoAuditRepo{db.Collection("AuditLog")},
+
+		ProductImports: &mongoProductImportRepo{db.Collection("ProductImport")},
+		UploadSessions: &mongoUploadSessionRepo{db.Collection("UploadSession")},
+
+		Events:          &mongoEventRepo{db.Collection("Event")},
+		Popularity:      &mongoPopularityRepo{db.Collection("Popularity")},
+		Recommendations: &mongoRecommendationRepo{db.Collection("Recommendation")},
+	}
+}
+
+// NewMemory returns empty repositories that keep everything in process
+// memory, intended for tests and local development.
+func NewMemory() Repositories {
+	return Repositories{
+		Products:  &memoryProductRepo{newMemCollection()},
+		Sellers:   &memorySellerRepo{newMemCollection()},
+		Enquiries: &memoryEnquiryRepo{newMemCollection()},
+		Reviews:   &memoryReviewRepo{newMemCollection()},
+		Tickets:   &memoryTicketRepo{tickets: newMemCollection(), chats: newMemCollection()},
+		Blogs:     &memoryBlogRepo{newMemCollection()},
+		Contents:  &memoryContentRepo{newMemCollection()},
+
+		ProductReferences: &memoryProductReferenceRepo{newMemCollection()},
+		ProductRevisions:  &memoryProductRevisionRepo{newMemCollection()},
+
+		RefreshTokens: &memoryRefreshTokenRepo{newMemCollection()},
+		Admins:        &memoryAdminRepo{newMemCollection()},
+		AdminInvites:  &memoryAdminInviteRepo{newMemCollection()},
+		Audit:         &memoryAuditRepo{newMemCollection()},
+
+		ProductImports: &memoryProductImportRepo{newMemCollection()},
+		UploadSessions: &memoryUploadSessionRepo{newMemCollection()},
+
+		Events:          &memoryEventRepo{newMemCollection()},
+		Popularity:      &memoryPopularityRepo{newMemCollection()},
+		Recommendations: &memoryRecommendationRepo{newMemCollection()},
+	}
+}
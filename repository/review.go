@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReviewFilter selects reviews. Zero values are ignored.
+type ReviewFilter struct {
+	ProductID primitive.ObjectID
+	UserID    primitive.ObjectID
+	Approved  *bool
+}
+
+type ReviewRepo interface {
+	Insert(ctx context.Context, review *models.Reviews) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Reviews, error)
+	Find(ctx context.Context, filter ReviewFilter, page Page) ([]models.Reviews, error)
+	Count(ctx context.Context, filter ReviewFilter) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+}
+
+func (f ReviewFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ProductID.IsZero() {
+		filter["product_id"] = f.ProductID
+	}
+	if !f.UserID.IsZero() {
+		filter["user_id"] = f.UserID
+	}
+	if f.Approved != nil {
+		filter["approved"] = *f.Approved
+	}
+	return filter
+}
+
+func (f ReviewFilter) match() func(*models.Reviews) bool {
+	return func(r *models.Reviews) bool {
+		return (f.ProductID.IsZero() || r.ProductId == f.ProductID) &&
+			(f.UserID.IsZero() || r.UserId == f.UserID) &&
+			(f.Approved == nil || r.Approved == *f.Approved)
+	}
+}
+
+type mongoReviewRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoReviewRepo) Insert(ctx context.Context, review *models.Reviews) error {
+	_, err := r.coll.InsertOne(ctx, review)
+	return err
+}
+
+func (r *mongoReviewRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Reviews, error) {
+	return mongoFindOne[models.Reviews](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoReviewRepo) Find(ctx context.Context, filter ReviewFilter, page Page) ([]models.Reviews, error) {
+	return mongoFind[models.Reviews](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoReviewRepo) Count(ctx context.Context, filter ReviewFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoReviewRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+type memoryReviewRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryReviewRepo) Insert(ctx context.Context, review *models.Reviews) error {
+	return r.coll.insert(review)
+}
+
+func (r *memoryReviewRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Reviews, error) {
+	return memFindOne(r.coll, func(review *models.Reviews) bool { return review.Id == id })
+}
+
+func (r *memoryReviewRepo) Find(ctx context.Context, filter ReviewFilter, page Page) ([]models.Reviews, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memoryReviewRepo) Count(ctx context.Context, filter ReviewFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memoryReviewRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
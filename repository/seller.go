@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SellerFilter selects sellers and admins, both live in the seller
+// collection and are told apart by UserType. Zero values are ignored.
+type SellerFilter struct {
+	ID       primitive.ObjectID
+	UserType string
+	MobileNo string
+	Email    string
+}
+
+type SellerRepo interface {
+	Insert(ctx context.Context, seller *models.Seller) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Seller, error)
+	FindOne(ctx context.Context, filter SellerFilter) (*models.Seller, error)
+	Find(ctx context.Context, filter SellerFilter, page Page) ([]models.Seller, error)
+	Count(ctx context.Context, filter SellerFilter) (int64, error)
+	// CountCreated counts accounts created in [from, to), a zero bound is open.
+	CountCreated(ctx context.Context, from, to time.Time) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+func (f SellerFilter) bson() bson.M {
+	filter := bson.M{}
+	if !f.ID.IsZero() {
+		filter["_id"] = f.ID
+	}
+	if f.UserType != "" {
+		filter["user_type"] = f.UserType
+	}
+	if f.MobileNo != "" {
+		filter["mobileno"] = f.MobileNo
+	}
+	if f.Email != "" {
+		filter["email"] = f.Email
+	}
+	return filter
+}
+
+func (f SellerFilter) match() func(*models.Seller) bool {
+	return func(s *models.Seller) bool {
+		return (f.ID.IsZero() || s.ID == f.ID) &&
+			(f.UserType == "" || s.User_type == f.UserType) &&
+			(f.MobileNo == "" || s.MobileNo == f.MobileNo) &&
+			(f.Email == "" || s.Email == f.Email)
+	}
+}
+
+// createdFilter builds a range filter on field, a zero bound is open.
+func createdFilter(field string, from, to time.Time) bson.M {
+	cond := bson.M{}
+	if !from.IsZero() {
+		cond["$gte"] = from
+	}
+	if !to.IsZero() {
+		cond["$lt"] = to
+	}
+	if len(cond) == 0 {
+		return bson.M{}
+	}
+	return bson.M{field: cond}
+}
+
+func createdMatch(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+type mongoSellerRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoSellerRepo) Insert(ctx context.Context, seller *models.Seller) error {
+	_, err := r.coll.InsertOne(ctx, seller)
+	return err
+}
+
+func (r *mongoSellerRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Seller, error) {
+	return mongoFindOne[models.Seller](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoSellerRepo) FindOne(ctx context.Context, filter SellerFilter) (*models.Seller, error) {
+	return mongoFindOne[models.Seller](ctx, r.coll, filter.bson())
+}
+
+func (r *mongoSellerRepo) Find(ctx context.Context, filter SellerFilter, page Page) ([]models.Seller, error) {
+	return mongoFind[models.Seller](ctx, r.coll, filter.bson(), page)
+}
+
+func (r *mongoSellerRepo) Count(ctx context.Context, filter SellerFilter) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoSellerRepo) CountCreated(ctx context.Context, from, to time.Time) (int64, error) {
+	return r.coll.CountDocuments(ctx, createdFilter("created_at", from, to))
+}
+
+func (r *mongoSellerRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+func (r *mongoSellerRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return mongoDeleteByID(ctx, r.coll, id)
+}
+
+type memorySellerRepo struct {
+	coll *memCollection
+}
+
+func (r *memorySellerRepo) Insert(ctx context.Context, seller *models.Seller) error {
+	return r.coll.insert(seller)
+}
+
+func (r *memorySellerRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Seller, error) {
+	return memFindOne(r.coll, SellerFilter{ID: id}.match())
+}
+
+func (r *memorySellerRepo) FindOne(ctx context.Context, filter SellerFilter) (*models.Seller, error) {
+	return memFindOne(r.coll, filter.match())
+}
+
+func (r *memorySellerRepo) Find(ctx context.Context, filter SellerFilter, page Page) ([]models.Seller, error) {
+	return memFind(r.coll, filter.match(), page)
+}
+
+func (r *memorySellerRepo) Count(ctx context.Context, filter SellerFilter) (int64, error) {
+	return memCount(r.coll, filter.match())
+}
+
+func (r *memorySellerRepo) CountCreated(ctx context.Context, from, to time.Time) (int64, error) {
+	return memCount(r.coll, func(s *models.Seller) bool { return createdMatch(s.Created_at, from, to) })
+}
+
+func (r *memorySellerRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+func (r *memorySellerRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if !r.coll.delete(id) {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TicketFilter selects support tickets. Zero values are ignored.
+type TicketFilter struct {
+	TicketID string
+	Status   string
+	MobileNo string
+	Email    string
+}
+
+// TicketRepo stores support tickets together with their chat threads.
+type TicketRepo interface {
+	Insert(ctx context.Context, ticket *models.CustomerSupportTicket) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerSupportTicket, error)
+	FindByTicketID(ctx context.Context, ticketID string) (*models.CustomerSupportTicket, error)
+	Find(ctx context.Context, filter TicketFilter, page Page) ([]models.CustomerSupportTicket, error)
+	Count(ctx context.Context, filter TicketFilter) (int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	// AppendMessage adds message to the chat thread of ticketID, creating the
+	// thread on first use. created reports whether a new thread was made.
+	AppendMessage(ctx context.Context, ticketID string, message models.ChatMessage) (chatID primitive.ObjectID, created bool, err error)
+	Messages(ctx context.Context, ticketID string) ([]models.ChatMessage, error)
+}
+
+func (f TicketFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.TicketID != "" {
+		filter["ticketid"] = f.TicketID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.MobileNo != "" {
+		filter["mobileno"] = f.MobileNo
+	}
+	if f.Email != "" {
+		filter["email"] = f.Email
+	}
+	return filter
+}
+
+func (f TicketFilter) match() func(*models.CustomerSupportTicket) bool {
+	return func(t *models.CustomerSupportTicket) bool {
+		return (f.TicketID == "" || t.TicketID == f.TicketID) &&
+			(f.Status == "" || t.Status == f.Status) &&
+			(f.MobileNo == "" || t.MobileNo == f.MobileNo) &&
+			(f.Email == "" || t.Email == f.Email)
+	}
+}
+
+type mongoTicketRepo struct {
+	tickets *mongo.Collection
+	chats   *mongo.Collection
+}
+
+func (r *mongoTicketRepo) Insert(ctx context.Context, ticket *models.CustomerSupportTicket) error {
+	_, err := r.tickets.InsertOne(ctx, ticket)
+	return err
+}
+
+func (r *mongoTicketRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerSupportTicket, error) {
+	return mongoFindOne[models.CustomerSupportTicket](ctx, r.tickets, bson.M{"_id": id})
+}
+
+func (r *mongoTicketRepo) FindByTicketID(ctx context.Context, ticketID string) (*models.CustomerSupportTicket, error) {
+	return mongoFindOne[models.CustomerSupportTicket](ctx, r.tickets, bson.M{"ticketid": ticketID})
+}
+
+func (r *mongoTicketRepo) Find(ctx context.Context, filter TicketFilter, page Page) ([]models.CustomerSupportTicket, error) {
+	return mongoFind[models.CustomerSupportTicket](ctx, r.tickets, filter.bson(), page)
+}
+
+func (r *mongoTicketRepo) Count(ctx context.Context, filter TicketFilter) (int64, error) {
+	return r.tickets.CountDocuments(ctx, filter.bson())
+}
+
+func (r *mongoTicketRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.tickets, id, upd)
+}
+
+func (r *mongoTicketRepo) AppendMessage(ctx context.Context, ticketID string, message models.ChatMessage) (primitive.ObjectID, bool, error) {
+	chat, err := mongoFindOne[models.SupportChatMessage](ctx, r.chats, bson.M{"ticketId": ticketID})
+	if err == ErrNotFound {
+		chat := models.SupportChatMessage{
+			SupportChatId:   primitive.NewObjectID(),
+			SupportTicketId: ticketID,
+			Messages:        []models.ChatMessage{message},
+		}
+		if _, err := r.chats.InsertOne(ctx, chat); err != nil {
+			return primitive.NilObjectID, false, err
+		}
+		return chat.SupportChatId, true, nil
+	}
+	if err != nil {
+		return primitive.NilObjectID, false, err
+	}
+	err = mongoUpdateByID(ctx, r.chats, chat.SupportChatId, Update{Push: Fields{"messages": []models.ChatMessage{message}}})
+	return chat.SupportChatId, false, err
+}
+
+func (r *mongoTicketRepo) Messages(ctx context.Context, ticketID string) ([]models.ChatMessage, error) {
+	chat, err := mongoFindOne[models.SupportChatMessage](ctx, r.chats, bson.M{"ticketId": ticketID})
+	if err != nil {
+		return nil, err
+	}
+	return chat.Messages, nil
+}
+
+type memoryTicketRepo struct {
+	tickets *memCollection
+	chats   *memCollection
+}
+
+func (r *memoryTicketRepo) Insert(ctx context.Context, ticket *models.CustomerSupportTicket) error {
+	return r.tickets.insert(ticket)
+}
+
+func (r *memoryTicketRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerSupportTicket, error) {
+	return memFindOne(r.tickets, func(t *models.CustomerSupportTicket) bool { return t.Ticket_id == id })
+}
+
+func (r *memoryTicketRepo) FindByTicketID(ctx context.Context, ticketID string) (*models.CustomerSupportTicket, error) {
+	return memFindOne(r.tickets, TicketFilter{TicketID: ticketID}.match())
+}
+
+func (r *memoryTicketRepo) Find(ctx context.Context, filter TicketFilter, page Page) ([]models.CustomerSupportTicket, error) {
+	return memFind(r.tickets, filter.match(), page)
+}
+
+func (r *memoryTicketRepo) Count(ctx context.Context, filter TicketFilter) (int64, error) {
+	return memCount(r.tickets, filter.match())
+}
+
+func (r *memoryTicketRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.tickets.updateByID(id, upd)
+}
+
+func (r *memoryTicketRepo) findChat(ticketID string) (*models.SupportChatMessage, error) {
+	return memFindOne(r.chats, func(chat *models.SupportChatMessage) bool { return chat.SupportTicketId == ticketID })
+}
+
+func (r *memoryTicketRepo) AppendMessage(ctx context.Context, ticketID string, message models.ChatMessage) (primitive.ObjectID, bool, error) {
+	chat, err := r.findChat(ticketID)
+	if err == ErrNotFound {
+		chat := models.SupportChatMessage{
+			SupportChatId:   primitive.NewObjectID(),
+			SupportTicketId: ticketID,
+			Messages:        []models.ChatMessage{message},
+		}
+		return chat.SupportChatId, true, r.chats.insert(chat)
+	}
+	if err != nil {
+		return primitive.NilObjectID, false, err
+	}
+	err = r.chats.updateByID(chat.SupportChatId, Update{Push: Fields{"messages": []models.ChatMessage{message}}})
+	return chat.SupportChatId, false, err
+}
+
+func (r *memoryTicketRepo) Messages(ctx context.Context, ticketID string) ([]models.ChatMessage, error) {
+	chat, err := r.findChat(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return chat.Messages, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UploadSessionRepo interface {
+	Insert(ctx context.Context, session *models.UploadSession) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.UploadSession, error)
+	Update(ctx context.Context, id primitive.ObjectID, upd Update) error
+	// Confirm moves a pending session to confirmed with the key of its
+	// checked copy. ErrNotFound when it's no longer pending, e.g. confirmed
+	// by a concurrent request.
+	Confirm(ctx context.Context, id primitive.ObjectID, verifiedKey string) error
+	// Claim moves a confirmed session of owner to attaching and returns it,
+	// so it's attached once however many requests name it. ErrNotFound
+	// when there is no such session waiting.
+	Claim(ctx context.Context, id primitive.ObjectID, owner string) (*models.UploadSession, error)
+	// FindExpired lists up to limit sessions that expired before t.
+	FindExpired(ctx context.Context, t time.Time, limit int64) ([]models.UploadSession, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoUploadSessionRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoUploadSessionRepo) Insert(ctx context.Context, session *models.UploadSession) error {
+	_, err := r.coll.InsertOne(ctx, session)
+	return err
+}
+
+func (r *mongoUploadSessionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.UploadSession, error) {
+	return mongoFindOne[models.UploadSession](ctx, r.coll, bson.M{"_id": id})
+}
+
+func (r *mongoUploadSessionRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return mongoUpdateByID(ctx, r.coll, id, upd)
+}
+
+func (r *mongoUploadSessionRepo) Confirm(ctx context.Context, id primitive.ObjectID, verifiedKey string) error {
+	return mongoUpdateOne(ctx, r.coll, bson.M{"_id": id, "status": models.UploadPending}, Update{Set: Fields{
+		"status":       models.UploadConfirmed,
+		"verified_key": verifiedKey,
+	}})
+}
+
+func (r *mongoUploadSessionRepo) Claim(ctx context.Context, id primitive.ObjectID, owner string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "owner": owner, "status": models.UploadConfirmed},
+		bson.M{"$set": bson.M{"status": models.UploadAttaching}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *mongoUploadSessionRepo) FindExpired(ctx context.Context, t time.Time, limit int64) ([]models.UploadSession, error) {
+	return mongoFind[models.UploadSession](ctx, r.coll, bson.M{"expires_at": bson.M{"$lt": t}}, Page{Sort: "expires_at", Limit: limit})
+}
+
+func (r *mongoUploadSessionRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return mongoDeleteByID(ctx, r.coll, id)
+}
+
+type memoryUploadSessionRepo struct {
+	coll *memCollection
+}
+
+func (r *memoryUploadSessionRepo) Insert(ctx context.Context, session *models.UploadSession) error {
+	return r.coll.insert(session)
+}
+
+func (r *memoryUploadSessionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.UploadSession, error) {
+	return memFindOne(r.coll, func(s *models.UploadSession) bool { return s.ID == id })
+}
+
+func (r *memoryUploadSessionRepo) Update(ctx context.Context, id primitive.ObjectID, upd Update) error {
+	return r.coll.updateByID(id, upd)
+}
+
+func (r *memoryUploadSessionRepo) Confirm(ctx context.Context, id primitive.ObjectID, verifiedKey string) error {
+	confirmed, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["status"] == models.UploadPending
+	}, Update{Set: Fields{"status": models.UploadConfirmed, "verified_key": verifiedKey}}, false)
+	if err != nil {
+		return err
+	}
+	if confirmed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryUploadSessionRepo) Claim(ctx context.Context, id primitive.ObjectID, owner string) (*models.UploadSession, error) {
+	claimed, err := r.coll.update(func(doc bson.M) bool {
+		return doc["_id"] == id && doc["owner"] == owner && doc["status"] == models.UploadConfirmed
+	}, Update{Set: Fields{"status": models.UploadAttaching}}, false)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == 0 {
+		return nil, ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *memoryUploadSessionRepo) FindExpired(ctx context.Context, t time.Time, limit int64) ([]models.UploadSession, error) {
+	return memFind(r.coll, func(s *models.UploadSession) bool { return s.Expires_at.Before(t) }, Page{Sort: "expires_at", Limit: limit})
+}
+
+func (r *memoryUploadSessionRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if !r.coll.delete(id) {
+		return ErrNotFound
+	}
+	return nil
+}
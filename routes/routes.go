@@ -6,9 +6,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kravi0/BizGrowth-backend/controllers"
 	"github.com/kravi0/BizGrowth-backend/middleware"
+	"github.com/kravi0/BizGrowth-backend/ratelimit"
+	"github.com/kravi0/BizGrowth-backend/storage"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) {
+func UserRoutes(incomingRoutes *gin.Engine, app *controllers.Application) {
 	// Use the provided gin.Engine instead of creating a new router
 	//incomingRoutes.Use(cors.Default())
 	router := gin.Default()
@@ -19,131 +21,193 @@ func UserRoutes(incomingRoutes *gin.Engine) {
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Token", "token"} // Add "Token" header
 	incomingRoutes.Use(cors.New(config))
 	incomingRoutes.Use(gzip.Gzip(gzip.DefaultCompression))
-	incomingRoutes.GET("/search-suggestions", controllers.SuggestionsHandler())
-	incomingRoutes.GET("/getrecommendations", controllers.GetUserSpecificProduct())
-	incomingRoutes.GET("/search-product", controllers.SearchProduct())
-	incomingRoutes.GET("/getcategory", controllers.GetCategory())
-	incomingRoutes.GET("/categories", controllers.GetCategoryTree())
-	incomingRoutes.GET("/featured-category", controllers.GetFeaturedCategory())
-	incomingRoutes.GET("/category", controllers.GetSingleCategory())
-	incomingRoutes.PUT("/updatecategory", controllers.EditCategory())
-	incomingRoutes.GET("/getproduct", controllers.GetProduct())
-	incomingRoutes.GET("/product", controllers.SearchProductByQuery())
-	incomingRoutes.POST("/update-user", controllers.UpdateUserDetails())
-	incomingRoutes.POST("/post-requirement", controllers.CreateRequirementMessage())
-	incomingRoutes.GET("/get-productReference", controllers.FetchProductsAndReferencesHandler())
-	incomingRoutes.GET("/product-reference", controllers.GetProductReferenceHandler())
-	incomingRoutes.GET("/featured-products", controllers.GetFeaturedProducts())
-	incomingRoutes.GET("/blogs", controllers.GetPublishedBlogs())
-	incomingRoutes.GET("/blog/get/:slug", controllers.GetBlogBySlug())
-	incomingRoutes.GET("/all-attributesType", controllers.GetAllAttributes())
-	incomingRoutes.GET("/get-attributeType/:id", controllers.GetAttributeByID())
-	incomingRoutes.GET("/approved-product-reviews", controllers.GetProductApprovedReviews())
-	incomingRoutes.POST("/create-ticket", controllers.CreateTicket())
-	incomingRoutes.GET("/get-feeds", controllers.GetAllFeedsHandler())
-	incomingRoutes.GET("/content/get-by-key/:contentKey", controllers.GetContentItemsByKey())
-
-	incomingRoutes.POST("/add-admin", controllers.RegisterAdmin())
-
-	incomingRoutes.POST("/seller/reset-password", controllers.ResetPassword())
-
-	incomingRoutes.POST("/validatesellerotp", controllers.LoginValidatePasswordOTP())
-
-	incomingRoutes.POST("/sendOTP", controllers.SetOtpHandler())
-	incomingRoutes.POST("/validate", controllers.ValidateOtpHandler())
-	incomingRoutes.POST("/sellerOTPRegistration", controllers.SellerRegistrationSendOTP())
-	incomingRoutes.POST("/validatesellerotpin", controllers.SellerRegistrationOtpVerification())
-	incomingRoutes.POST("/seller/detailsUpdate", controllers.SellerCommpanyDetailsUpdate())
-	incomingRoutes.POST("/seller/update/owner-details", controllers.SellerOwnerDetailsUpdate())
-	incomingRoutes.POST("/seller/registration", controllers.SellerEmailUpdate())
-	incomingRoutes.POST("/seller/licenseDetailsUpdate", controllers.SellerLicenseUpdate())
-	incomingRoutes.POST("/seller-login", controllers.SendLoginOTP())
-	incomingRoutes.POST("/seller/verify-otp", controllers.SellerOtpVerfication())
-
-	incomingRoutes.Use(middleware.UserAuthentication())
-	incomingRoutes.POST("/product-enquiry", controllers.EnquiryHandler())
-	incomingRoutes.GET("/get-enquiry", controllers.GetUserEnquiries())
-	incomingRoutes.POST("/post-review", controllers.AddReviewHandler())
-	incomingRoutes.GET("/load-user", controllers.LoadUser())
-	incomingRoutes.PUT("/user/update-profile", controllers.UpdateUserProfile())
-
-	incomingRoutes.Use(middleware.Authentication())
-	incomingRoutes.GET("/seller/products", controllers.GetAllProductsForASellerHandler())
-	incomingRoutes.POST("/seller/update/business-details", controllers.UpdateSellerBusinessDetails())
-	incomingRoutes.POST("/seller/profile/update/owner-details", controllers.UpdateOwnerDetails())
-	incomingRoutes.POST("/seller/toggle-admin-consent", controllers.ToggleConsentToAdmin())
-	incomingRoutes.POST("/seller/update-product/:id", controllers.SellerUpdateProduct())
-	incomingRoutes.POST("/seller/update-profilepicture", controllers.SellerUpdateProfilePictureHandler())
-	incomingRoutes.GET("/seller/support-tickets", controllers.GetSellerSupportTicket())
-	incomingRoutes.GET("/seller/info", controllers.LoadSeller())
-	incomingRoutes.POST("/seller/ticket/chat/message/:id", controllers.AddSellerMessage())
-	incomingRoutes.POST("/seller/confirm-password", controllers.SellerPasswordConfirmation())
-	incomingRoutes.POST("/seller/update-password", controllers.UpdatePassword())
-	incomingRoutes.POST("/admin/approveSeller", controllers.ApproveSeller())
-	incomingRoutes.POST("/admin/addcategory", controllers.AddCategory())
-	incomingRoutes.GET("/admin/categories", controllers.AdminGetCategoryHandler())
-	incomingRoutes.POST("/admin/category/approve/:id", controllers.ApproveCategory())
-	incomingRoutes.PUT("/admin/category/make-featured/:id", controllers.HandleCategoryFeatured())
-	incomingRoutes.PUT("/admin/update-product/:id", controllers.UpdateProduct())
-	incomingRoutes.POST("/admin/add-product", controllers.ProductViewerAdmin())
-	incomingRoutes.POST("/admin/add-product/seller", controllers.AddProductByAdmin())
-	incomingRoutes.GET("/admin/get-enquiry", controllers.GETEnquiryHandler())
-	incomingRoutes.GET("/admin/enquiry/:id", controllers.GetAdminSingleEnquiry())
-	incomingRoutes.POST("/admin/enquiry/update/status/:id", controllers.UpdateEnquireStatus())
-	incomingRoutes.GET("/admin/getseller", controllers.GetSeller())
-	incomingRoutes.GET("/admin/seller/products/:id", controllers.GetSellerProductForAdmin())
-	incomingRoutes.GET("/admin/approve-product", controllers.ApproveProduct())
-	incomingRoutes.PUT("/admin/reject-product/:id", controllers.RejectProduct())
-	incomingRoutes.DELETE("/admin/delete-product", controllers.DeleteProduct())
-	incomingRoutes.POST("/admin/approve-review/:id", controllers.ApproveReview())
-	incomingRoutes.GET("/admin/all-reviews", controllers.GetReviews())
-	incomingRoutes.GET("/admin/review/:id", controllers.GetReview())
-	incomingRoutes.GET("/admin/product-reviews", controllers.GetProductReviews())
-	incomingRoutes.POST("/admin/add-attributeType", controllers.AddAttributeType())
-	incomingRoutes.PUT("/admin/update-attribute/:id", controllers.UpdateAttributeType())
-	incomingRoutes.GET("/admin/getTickets", controllers.GetTickets())
-	incomingRoutes.GET("/admin/tickets/count", controllers.GetTicketCounts())
-	incomingRoutes.GET("/admin/ticket/:id", controllers.GetTicketById())
-	incomingRoutes.POST("/admin/ticket/chat/message/:id", controllers.AddMessage())
-	incomingRoutes.POST("/admin/ticket/update/status/:id", controllers.UpdateTicketStatus())
-	incomingRoutes.GET("/admin/ticket/chat/messages/:id", controllers.GetChatMessagesHandler())
-
-	incomingRoutes.POST("/admin/add-feed", controllers.PostFeedHandler())
-	incomingRoutes.DELETE("/admin/delete-feed", controllers.DeleteFeed())
-	incomingRoutes.POST("/admin/update-feed", controllers.UpdateFeed())
-	incomingRoutes.GET("/admin/products", controllers.GetAllProducts())
-	incomingRoutes.POST("/admin/updat/product/featured/:id", controllers.MakeProductFeatured())
-
-	incomingRoutes.GET("/admin/dashboard/analytics", controllers.GetAnalytics())
-
-	incomingRoutes.GET("/admin/all-users", controllers.GetUsersDetails_Admin())
-
-	incomingRoutes.PUT("/admin/product/remove-image/:id", controllers.DeleteImageFromProduct())
-
-	incomingRoutes.GET("/admin/requirement-messages", controllers.GetAllRequirementMessages())
-
-	incomingRoutes.GET("/admin/requirement-message/:id", controllers.GetRequirementMessage())
-
-	incomingRoutes.POST("/admin/add-reviews", controllers.AddReviewByAdmin())
-
-	incomingRoutes.GET("/admin/load", controllers.LoadAdmin())
-
-	incomingRoutes.GET("/admin/seller/doc/download", controllers.DownloadSellerDocs())
-
-	incomingRoutes.GET("/admin/get-csv", controllers.GenerateCSVByCollection())
-
-	incomingRoutes.POST("/admin/post-blog", controllers.CreateBlog())
-	incomingRoutes.GET("/admin/blogs", controllers.GetBlogs())
-	incomingRoutes.GET("/admin/blog/:id", controllers.GetBlogByID())
-	incomingRoutes.POST("/admin/blog/publish/:id", controllers.TogglePublishBlog())
-	incomingRoutes.POST("/admin/blog/archive/:id", controllers.ArchiveBlog())
-	incomingRoutes.DELETE("/admin/blog/delete/:id", controllers.DeleteBlog())
-	incomingRoutes.POST("/admin/blog/update/:id", controllers.UpdateBlog())
-
-	incomingRoutes.POST("/admin/content/create", controllers.CreateContentItem())
-	incomingRoutes.POST("/admin/content/toggle-status/:id", controllers.ToggleContentItemStatus())
-	incomingRoutes.POST("/admin/content/update-file-content/:contentKey", controllers.UpdateFileContentItemContent())
-	incomingRoutes.POST("/admin/content/delete-file-content/:contentItemId/:index", controllers.DeleteImageFromContentItem())
-	incomingRoutes.DELETE("/admin/content/delete/:id", controllers.DeleteContentItem())
-	incomingRoutes.GET("/admin/contents", controllers.GetAllContentItems())
+	incomingRoutes.Use(middleware.RequestID())
+	incomingRoutes.GET(storage.LocalRoutePrefix+"/*key", app.ServeStoredFile())
+	incomingRoutes.PUT(storage.LocalRoutePrefix+"/*key", app.ReceiveStoredFile())
+	incomingRoutes.GET("/search-suggestions", app.SuggestionsHandler())
+	incomingRoutes.GET("/getrecommendations", middleware.OptionalUserAuthentication(), app.GetUserSpecificProduct())
+	incomingRoutes.GET("/search-product", middleware.OptionalUserAuthentication(), app.SearchProduct())
+	incomingRoutes.POST("/events/click", app.RateLimit(ratelimit.ScopeEvent, nil), middleware.OptionalUserAuthentication(), app.TrackClick())
+	incomingRoutes.GET("/getcategory", app.GetCategory())
+	incomingRoutes.GET("/categories", app.GetCategoryTree())
+	incomingRoutes.GET("/featured-category", app.GetFeaturedCategory())
+	incomingRoutes.GET("/category", app.GetSingleCategory())
+	incomingRoutes.PUT("/updatecategory", middleware.Authentication(), middleware.RequirePermission(middleware.ManageCatalog), app.ActiveAdmin(), app.Audit("category.update", controllers.AuditCategory, controllers.AuditQuery("cat_id")), app.EditCategory())
+	incomingRoutes.GET("/getproduct", middleware.OptionalUserAuthentication(), app.GetProduct())
+	incomingRoutes.GET("/quote", app.Quote())
+	incomingRoutes.GET("/product", app.SearchProductByQuery())
+	incomingRoutes.POST("/update-user", app.UpdateUserDetails())
+	incomingRoutes.POST("/post-requirement", app.CreateRequirementMessage())
+	incomingRoutes.GET("/get-productReference", app.FetchProductsAndReferencesHandler())
+	incomingRoutes.GET("/product-reference", app.GetProductReferenceHandler())
+	incomingRoutes.GET("/featured-products", app.GetFeaturedProducts())
+	incomingRoutes.GET("/blogs", app.GetPublishedBlogs())
+	incomingRoutes.GET("/blog/get/:slug", app.GetBlogBySlug())
+	incomingRoutes.GET("/all-attributesType", app.GetAllAttributes())
+	incomingRoutes.GET("/get-attributeType/:id", app.GetAttributeByID())
+	incomingRoutes.GET("/approved-product-reviews", app.GetProductApprovedReviews())
+	incomingRoutes.POST("/create-ticket", app.CreateTicket())
+	incomingRoutes.GET("/get-feeds", app.GetAllFeedsHandler())
+	incomingRoutes.GET("/content/get-by-key/:contentKey", app.GetContentItemsByKey())
+
+	incomingRoutes.POST("/admin/login", app.RateLimit(ratelimit.ScopeLogin, ratelimit.Field("email")), app.AdminLogin())
+	incomingRoutes.POST("/admin/invitations/accept", app.RateLimit(ratelimit.ScopeLogin, nil), app.AcceptAdminInvite())
+
+	mobileNo := ratelimit.Field("mobileno")
+
+	auth := incomingRoutes.Group("/auth")
+	auth.POST("/refresh", app.RefreshToken())
+	auth.POST("/logout", app.Logout())
+
+	incomingRoutes.POST("/seller/reset-password", app.RateLimit(ratelimit.ScopeLogin, mobileNo), app.ResetPassword())
+
+	incomingRoutes.POST("/validatesellerotp", app.RateLimit(ratelimit.ScopeLogin, mobileNo), app.LoginValidatePasswordOTP())
+
+	incomingRoutes.POST("/sendOTP", app.RateLimit(ratelimit.ScopeOTP, mobileNo), app.SetOtpHandler())
+	incomingRoutes.POST("/validate", app.RateLimit(ratelimit.ScopeLogin, mobileNo), app.ValidateOtpHandler())
+	incomingRoutes.POST("/sellerOTPRegistration", app.RateLimit(ratelimit.ScopeOTP, mobileNo), app.SellerRegistrationSendOTP())
+	incomingRoutes.POST("/validatesellerotpin", app.RateLimit(ratelimit.ScopeLogin, mobileNo), app.SellerRegistrationOtpVerification())
+	incomingRoutes.POST("/seller/detailsUpdate", app.SellerCommpanyDetailsUpdate())
+	incomingRoutes.POST("/seller/update/owner-details", app.SellerOwnerDetailsUpdate())
+	incomingRoutes.POST("/seller/registration", app.SellerEmailUpdate())
+	incomingRoutes.POST("/seller/licenseDetailsUpdate", app.SellerLicenseUpdate())
+	// Sellers upload their documents before they have a token, the sessions
+	// belong to the seller with the mobile number of the request.
+	incomingRoutes.POST("/seller/registration/uploads", app.RateLimit(ratelimit.ScopeUpload, mobileNo), app.CreateUploadSession())
+	incomingRoutes.POST("/seller/registration/uploads/:id/confirm", app.RateLimit(ratelimit.ScopeUpload, mobileNo), app.ConfirmUploadSession())
+	incomingRoutes.POST("/seller-login", app.RateLimit(ratelimit.ScopeOTP, mobileNo), app.SendLoginOTP())
+	incomingRoutes.POST("/seller/verify-otp", app.RateLimit(ratelimit.ScopeLogin, mobileNo), app.SellerOtpVerfication())
+
+	userHandlers := func(group *gin.RouterGroup) {
+		group.POST("/product-enquiry", app.EnquiryHandler())
+		group.GET("/get-enquiry", app.GetUserEnquiries())
+		group.POST("/post-review", app.AddReviewHandler())
+		group.GET("/load-user", app.LoadUser())
+		group.PUT("/update-profile", app.UpdateUserProfile())
+	}
+	user := incomingRoutes.Group("/user", middleware.UserAuthentication(), middleware.RequirePermission(middleware.UserAccount))
+	userHandlers(user)
+	// The storefront still calls the user endpoints without the /user prefix.
+	legacyUser := incomingRoutes.Group("", middleware.UserAuthentication(), middleware.RequirePermission(middleware.UserAccount))
+	userHandlers(legacyUser)
+
+	uploads := incomingRoutes.Group("/uploads", middleware.Authentication())
+	uploads.POST("", app.CreateUploadSession())
+	uploads.POST("/:id/confirm", app.ConfirmUploadSession())
+
+	seller := incomingRoutes.Group("/seller", middleware.Authentication(), middleware.RequirePermission(middleware.SellerAccount))
+	seller.GET("/products", app.GetAllProductsForASellerHandler())
+	seller.POST("/update/business-details", app.Audit("seller.update_business_details", controllers.AuditSeller, controllers.AuditSelf()), app.UpdateSellerBusinessDetails())
+	seller.POST("/profile/update/owner-details", app.Audit("seller.update_owner_details", controllers.AuditSeller, controllers.AuditSelf()), app.UpdateOwnerDetails())
+	seller.POST("/toggle-admin-consent", app.Audit("seller.toggle_admin_consent", controllers.AuditSeller, controllers.AuditSelf()), app.ToggleConsentToAdmin())
+	seller.POST("/products/import", app.ImportProducts())
+	seller.GET("/products/import/:id", app.GetProductImport())
+	seller.POST("/product/:id/variants", app.Audit("product.variant_add", controllers.AuditProduct, controllers.AuditParam("id")), app.AddProductVariant())
+	seller.PUT("/product/:id/variants/:variantId", app.Audit("product.variant_update", controllers.AuditProduct, controllers.AuditParam("id")), app.UpdateProductVariant())
+	seller.DELETE("/product/:id/variants/:variantId", app.Audit("product.variant_delete", controllers.AuditProduct, controllers.AuditParam("id")), app.DeleteProductVariant())
+	seller.GET("/offers", app.GetSellerOffers())
+	seller.GET("/dashboard/popularity", app.GetSellerPopularity())
+	seller.PUT("/offers/:id/inventory", app.Audit("offer.update_inventory", controllers.AuditOffer, controllers.AuditParam("id")), app.UpdateOfferInventory())
+	// Seller edits are recorded as product revisions until an admin approves them.
+	seller.POST("/update-product/:id", app.SellerUpdateProduct())
+	seller.GET("/product/:id/revisions", app.GetSellerProductRevisions())
+	seller.POST("/update-profilepicture", app.Audit("seller.update_profile_picture", controllers.AuditSeller, controllers.AuditSelf()), app.SellerUpdateProfilePictureHandler())
+	seller.GET("/support-tickets", app.GetSellerSupportTicket())
+	seller.GET("/info", app.LoadSeller())
+	seller.POST("/ticket/chat/message/:id", app.AddSellerMessage())
+	seller.POST("/confirm-password", app.SellerPasswordConfirmation())
+	seller.POST("/update-password", app.UpdatePassword())
+
+	admin := incomingRoutes.Group("/admin", middleware.Authentication(), middleware.RequirePermission(middleware.AdminAccount), app.ActiveAdmin())
+	admin.GET("/load", app.LoadAdmin())
+
+	admins := admin.Group("/admins", middleware.RequirePermission(middleware.ManageAdmins))
+	admins.GET("", app.ListAdmins())
+	admins.POST("/invite", app.Audit("admin.invite", controllers.AuditAdminInvite, controllers.AuditCreated()), app.InviteAdmin())
+	admins.POST("/:id/disable", app.Audit("admin.disable", controllers.AuditAdmin, controllers.AuditParam("id")), app.DisableAdmin())
+	admins.POST("/:id/enable", app.Audit("admin.enable", controllers.AuditAdmin, controllers.AuditParam("id")), app.EnableAdmin())
+	admins.PUT("/:id/role", app.Audit("admin.change_role", controllers.AuditAdmin, controllers.AuditParam("id")), app.ChangeAdminRole())
+
+	admin.GET("/audit", middleware.RequirePermission(middleware.ViewAudit), app.GetAuditLog())
+
+	sellers := admin.Group("", middleware.RequirePermission(middleware.ManageSellers))
+	sellers.POST("/approveSeller", app.Audit("seller.approve", controllers.AuditSeller, controllers.AuditForm("sellerid")), app.ApproveSeller())
+	sellers.GET("/getseller", app.GetSeller())
+	sellers.GET("/seller/products/:id", app.GetSellerProductForAdmin())
+	sellers.GET("/seller/doc/download", app.DownloadSellerDocs())
+
+	catalog := admin.Group("", middleware.RequirePermission(middleware.ManageCatalog))
+	catalog.POST("/addcategory", app.Audit("category.create", controllers.AuditCategory, controllers.AuditCreated()), app.AddCategory())
+	catalog.GET("/categories", app.AdminGetCategoryHandler())
+	catalog.PUT("/updatecategory", app.Audit("category.update", controllers.AuditCategory, controllers.AuditQuery("cat_id")), app.EditCategory())
+	catalog.POST("/category/approve/:id", app.Audit("category.approve", controllers.AuditCategory, controllers.AuditParam("id")), app.ApproveCategory())
+	catalog.PUT("/category/make-featured/:id", app.Audit("category.feature", controllers.AuditCategory, controllers.AuditParam("id")), app.HandleCategoryFeatured())
+	catalog.PUT("/update-product/:id", app.Audit("product.update", controllers.AuditProduct, controllers.AuditParam("id")), app.UpdateProduct())
+	catalog.POST("/add-product", app.Audit("product.create", controllers.AuditProduct, controllers.AuditCreated()), app.ProductViewerAdmin())
+	catalog.POST("/add-product/seller", app.Audit("product.create", controllers.AuditProduct, controllers.AuditCreated()), app.AddProductByAdmin())
+	catalog.POST("/products/import", app.ImportProducts())
+	catalog.GET("/products/import/:id", app.GetProductImport())
+	catalog.POST("/product/:id/variants", app.Audit("product.variant_add", controllers.AuditProduct, controllers.AuditParam("id")), app.AddProductVariant())
+	catalog.PUT("/product/:id/variants/:variantId", app.Audit("product.variant_update", controllers.AuditProduct, controllers.AuditParam("id")), app.UpdateProductVariant())
+	catalog.DELETE("/product/:id/variants/:variantId", app.Audit("product.variant_delete", controllers.AuditProduct, controllers.AuditParam("id")), app.DeleteProductVariant())
+	catalog.GET("/approve-product", app.Audit("product.approve", controllers.AuditProduct, controllers.AuditQuery("id")), app.ApproveProduct())
+	catalog.PUT("/reject-product/:id", app.Audit("product.reject", controllers.AuditProduct, controllers.AuditParam("id")), app.RejectProduct())
+	catalog.DELETE("/delete-product", app.Audit("product.delete", controllers.AuditProduct, controllers.AuditQuery("id")), app.DeleteProduct())
+	catalog.GET("/products", app.GetAllProducts())
+	catalog.POST("/updat/product/featured/:id", app.Audit("product.feature", controllers.AuditProduct, controllers.AuditParam("id")), app.MakeProductFeatured())
+	catalog.PUT("/product/remove-image/:id", app.Audit("product.remove_image", controllers.AuditProduct, controllers.AuditParam("id")), app.DeleteImageFromProduct())
+	catalog.GET("/inventory/low-stock", app.GetLowStockReport())
+	catalog.GET("/product-revisions", app.GetProductRevisions())
+	catalog.GET("/product-revisions/:id", app.GetProductRevision())
+	catalog.POST("/product-revisions/:id/approve", app.Audit("product.revision_approve", controllers.AuditProduct, app.AuditRevisionProduct()), app.ApproveProductRevision())
+	catalog.PUT("/product-revisions/:id/reject", app.Audit("product.revision_reject", controllers.AuditProduct, app.AuditRevisionProduct()), app.RejectProductRevision())
+	catalog.POST("/product-revisions/:id/rollback", app.Audit("product.revision_rollback", controllers.AuditProduct, app.AuditRevisionProduct()), app.RollbackProductRevision())
+	catalog.POST("/add-attributeType", app.Audit("attribute.create", controllers.AuditAttribute, controllers.AuditCreated()), app.AddAttributeType())
+	catalog.PUT("/update-attribute/:id", app.Audit("attribute.update", controllers.AuditAttribute, controllers.AuditParam("id")), app.UpdateAttributeType())
+
+	enquiries := admin.Group("", middleware.RequirePermission(middleware.ManageEnquiries))
+	enquiries.GET("/get-enquiry", app.GETEnquiryHandler())
+	enquiries.GET("/enquiry/:id", app.GetAdminSingleEnquiry())
+	enquiries.POST("/enquiry/update/status/:id", app.Audit("enquiry.update_status", controllers.AuditEnquiry, controllers.AuditParam("id")), app.UpdateEnquireStatus())
+	enquiries.GET("/requirement-messages", app.GetAllRequirementMessages())
+	enquiries.GET("/requirement-message/:id", app.GetRequirementMessage())
+
+	reviews := admin.Group("", middleware.RequirePermission(middleware.ManageReviews))
+	reviews.POST("/approve-review/:id", app.Audit("review.approve", controllers.AuditReview, controllers.AuditParam("id")), app.ApproveReview())
+	reviews.GET("/all-reviews", app.GetReviews())
+	reviews.GET("/review/:id", app.GetReview())
+	reviews.GET("/product-reviews", app.GetProductReviews())
+	reviews.POST("/add-reviews", app.Audit("review.create", controllers.AuditReview, controllers.AuditCreated()), app.AddReviewByAdmin())
+
+	support := admin.Group("", middleware.RequirePermission(middleware.ManageSupport))
+	support.GET("/getTickets", app.GetTickets())
+	support.GET("/tickets/count", app.GetTicketCounts())
+	support.GET("/ticket/:id", app.GetTicketById())
+	support.POST("/ticket/chat/message/:id", app.AddMessage())
+	support.POST("/ticket/update/status/:id", app.Audit("ticket.update_status", controllers.AuditTicket, controllers.AuditParam("id")), app.UpdateTicketStatus())
+	support.GET("/ticket/chat/messages/:id", app.GetChatMessagesHandler())
+
+	content := admin.Group("", middleware.RequirePermission(middleware.ManageContent))
+	content.POST("/add-feed", app.Audit("feed.create", controllers.AuditFeed, controllers.AuditCreated()), app.PostFeedHandler())
+	content.DELETE("/delete-feed", app.Audit("feed.delete", controllers.AuditFeed, controllers.AuditQuery("id")), app.DeleteFeed())
+	content.POST("/update-feed", app.Audit("feed.update", controllers.AuditFeed, controllers.AuditQuery("id")), app.UpdateFeed())
+	content.POST("/post-blog", app.Audit("blog.create", controllers.AuditBlog, controllers.AuditCreated()), app.CreateBlog())
+	content.GET("/blogs", app.GetBlogs())
+	content.GET("/blog/:id", app.GetBlogByID())
+	content.POST("/blog/publish/:id", app.Audit("blog.toggle_publish", controllers.AuditBlog, controllers.AuditParam("id")), app.TogglePublishBlog())
+	content.POST("/blog/archive/:id", app.Audit("blog.archive", controllers.AuditBlog, controllers.AuditParam("id")), app.ArchiveBlog())
+	content.DELETE("/blog/delete/:id", app.Audit("blog.delete", controllers.AuditBlog, controllers.AuditParam("id")), app.DeleteBlog())
+	content.POST("/blog/update/:id", app.Audit("blog.update", controllers.AuditBlog, controllers.AuditParam("id")), app.UpdateBlog())
+	content.POST("/content/create", app.Audit("content.create", controllers.AuditContent, controllers.AuditCreated()), app.CreateContentItem())
+	content.POST("/content/toggle-status/:id", app.Audit("content.toggle_status", controllers.AuditContent, controllers.AuditParam("id")), app.ToggleContentItemStatus())
+	content.POST("/content/update-file-content/:contentKey", app.Audit("content.add_files", controllers.AuditContent, app.AuditContentKey()), app.UpdateFileContentItemContent())
+	content.POST("/content/delete-file-content/:contentItemId/:index", app.Audit("content.remove_file", controllers.AuditContent, controllers.AuditParam("contentItemId")), app.DeleteImageFromContentItem())
+	content.DELETE("/content/delete/:id", app.Audit("content.delete", controllers.AuditContent, controllers.AuditParam("id")), app.DeleteContentItem())
+	content.GET("/contents", app.GetAllContentItems())
+
+	reports := admin.Group("", middleware.RequirePermission(middleware.ViewReports))
+	reports.GET("/dashboard/analytics", app.GetAnalytics())
+	reports.GET("/dashboard/popularity", app.GetPopularity())
+	reports.GET("/all-users", app.GetUsersDetails_Admin())
+	reports.GET("/get-csv", app.GenerateCSVByCollection())
 }
@@ -0,0 +1,181 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/kravi0/BizGrowth-backend/models"
+	"github.com/kravi0/BizGrowth-backend/money"
+)
+
+// Facet names. Attribute facets are named AttributeFacet plus the hex ID of
+// their attribute type.
+const (
+	CategoryFacet  = "category"
+	PriceFacet     = "price"
+	AgeGroupFacet  = "agegroup"
+	GenderFacet    = "gender"
+	AttributeFacet = "attr:"
+)
+
+// priceBucket is a price range of the price facet, from inclusive and to
+// exclusive in minor units. The last bucket has no upper bound.
+type priceBucket struct {
+	key      string
+	from, to int64
+}
+
+func newPriceBuckets(edges []string, currency string) []priceBucket {
+	buckets := []priceBucket{}
+	from, fromKey := int64(0), "0"
+	for _, edge := range edges {
+		price, err := money.Parse(edge, currency)
+		if err != nil || price.Amount <= from {
+			continue
+		}
+		buckets = append(buckets, priceBucket{key: fromKey + "-" + edge, from: from, to: price.Amount})
+		from, fromKey = price.Amount, edge
+	}
+	return append(buckets, priceBucket{key: fromKey + "+", from: from})
+}
+
+func (c Config) priceKey(amount int64) string {
+	for _, bucket := range c.prices {
+		if amount >= bucket.from && (bucket.to == 0 || amount < bucket.to) {
+			return bucket.key
+		}
+	}
+	return ""
+}
+
+// Selection maps facet names to the values a search is narrowed to. The
+// values of one facet are alternatives, every facet has to match.
+type Selection map[string][]string
+
+// FacetValue is a value of a facet and the number of products that have it.
+type FacetValue struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+}
+
+// Facets counts the values of every facet. The counts of a facet take the
+// selections of all other facets into account but not its own, so choosing
+// more values of a facet widens the result by the counts shown.
+type Facets map[string][]FacetValue
+
+// facetValues returns the values of every facet of a product.
+func (c Config) facetValues(p *models.Product) map[string][]string {
+	values := map[string][]string{
+		CategoryFacet: {p.Category},
+		PriceFacet:    {c.priceKey(p.Price.Amount)},
+		AgeGroupFacet: {p.AgeGroup},
+		GenderFacet:   {p.Gender},
+	}
+	add := func(attributes []models.AttributeValue) {
+		for _, attribute := range attributes {
+			name := AttributeFacet + attribute.AttributeType.Hex()
+			for _, value := range attribute.Value {
+				if !containsWord(values[name], value) {
+					values[name] = append(values[name], value)
+				}
+			}
+		}
+	}
+	add(p.Attributes)
+	for _, variant := range p.Variant {
+		add(variant.Attribute)
+	}
+	return values
+}
+
+// Facet narrows products to the selection and counts the facets of the
+// products. It returns the indexes of the products kept, in order.
+func (c Config) Facet(products []models.Product, selection Selection) ([]int, Facets) {
+	all := make([]map[string][]string, len(products))
+	names := map[string]bool{}
+	for name := range selection {
+		names[name] = true
+	}
+	for i := range products {
+		all[i] = c.facetValues(&products[i])
+		for name := range all[i] {
+			names[name] = true
+		}
+	}
+	matches := func(values map[string][]string, name string) bool {
+		selected := selection[name]
+		if len(selected) == 0 {
+			return true
+		}
+		for _, value := range values[name] {
+			if containsWord(selected, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var kept []int
+	counts := map[string]map[string]int{}
+	for i, values := range all {
+		// failed is the only facet the product misses, "" when it misses
+		// none and it is kept
+		failed, misses := "", 0
+		for name := range names {
+			if !matches(values, name) {
+				failed = name
+				misses++
+			}
+		}
+		if misses == 0 {
+			kept = append(kept, i)
+		}
+		if misses > 1 {
+			continue
+		}
+		for name := range names {
+			if misses == 1 && name != failed {
+				continue
+			}
+			if counts[name] == nil {
+				counts[name] = map[string]int{}
+			}
+			for _, value := range values[name] {
+				if value != "" {
+					counts[name][value]++
+				}
+			}
+		}
+	}
+
+	facets := Facets{}
+	for name := range names {
+		var values []FacetValue
+		for value, count := range counts[name] {
+			values = append(values, FacetValue{Value: value, Count: count, Selected: containsWord(selection[name], value)})
+		}
+		for _, value := range selection[name] {
+			if counts[name][value] == 0 {
+				values = append(values, FacetValue{Value: value, Selected: true})
+			}
+		}
+		if name == PriceFacet {
+			order := map[string]int{}
+			for i, bucket := range c.prices {
+				order[bucket.key] = i
+			}
+			sort.Slice(values, func(i, j int) bool { return order[values[i].Value] < order[values[j].Value] })
+		} else {
+			sort.Slice(values, func(i, j int) bool {
+				if values[i].Count != values[j].Count {
+					return values[i].Count > values[j].Count
+				}
+				return values[i].Value < values[j].Value
+			})
+		}
+		if len(values) > 0 {
+			facets[name] = values
+		}
+	}
+	return kept, facets
+}
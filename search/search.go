@@ -0,0 +1,341 @@
+// Package search ranks products against free text queries. Queries and
+// product texts are cut into lower case words with their plural endings
+// removed, so "Mugs" finds "mug", and every word of a query has to be found
+// in one of the weighted product fields.
+package search
+
+import (
+	"html"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/models"
+)
+
+// Field is a weighted part of the text of a product. Paths are the bson
+// fields it is read from, they make up the database text index.
+type Field struct {
+	Name   string
+	Paths  []string
+	Weight int
+}
+
+// Fields lists the searched fields from the most to the least important.
+var Fields = []Field{
+	{Name: "product_name", Paths: []string{"product_name"}, Weight: 10},
+	{Name: "sku", Paths: []string{"sku", "variant.sku"}, Weight: 8},
+	{Name: "category", Paths: []string{"category"}, Weight: 5},
+	{Name: "attributes", Paths: []string{"attributes.attribute_value", "variant.attribute.attribute_value"}, Weight: 3},
+	{Name: "discription", Paths: []string{"discription"}, Weight: 1},
+}
+
+// Document returns the text of every field of a product.
+func Document(p *models.Product) map[string]string {
+	skus := []string{p.SKU}
+	var attributes []string
+	for _, attribute := range p.Attributes {
+		attributes = append(attributes, attribute.Value...)
+	}
+	for _, variant := range p.Variant {
+		skus = append(skus, variant.SKU)
+		for _, attribute := range variant.Attribute {
+			attributes = append(attributes, attribute.Value...)
+		}
+	}
+	return map[string]string{
+		"product_name": p.Product_Name,
+		"sku":          strings.Join(skus, " "),
+		"category":     p.Category,
+		"attributes":   strings.Join(attributes, " "),
+		"discription":  p.Discription,
+	}
+}
+
+// Tokenize cuts text into lower case words without plural endings.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), isSeparator)
+	for i, word := range words {
+		words[i] = stem(word)
+	}
+	return words
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// stem removes the common English plural endings.
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && (strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "xes") ||
+		strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// Config holds the stopwords and synonyms queries are parsed with and the
+// price buckets of the price facet.
+type Config struct {
+	stopwords map[string]bool
+	synonyms  map[string][]string
+	prices    []priceBucket
+}
+
+// NewConfig prepares the configured stopwords, synonyms and price buckets,
+// the latter in currency. Synonyms are made to work both ways.
+func NewConfig(c config.Search, currency string) Config {
+	cfg := Config{stopwords: map[string]bool{}, synonyms: map[string][]string{}, prices: newPriceBuckets(c.PriceBuckets, currency)}
+	for _, word := range c.Stopwords {
+		for _, token := range Tokenize(word) {
+			cfg.stopwords[token] = true
+		}
+	}
+	for word, synonyms := range c.Synonyms {
+		group := Tokenize(word + " " + strings.ReplaceAll(synonyms, "|", " "))
+		for _, a := range group {
+			for _, b := range group {
+				if a != b && !containsWord(cfg.synonyms[a], b) {
+					cfg.synonyms[a] = append(cfg.synonyms[a], b)
+				}
+			}
+		}
+	}
+	return cfg
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// Options adjust how a single query is parsed.
+type Options struct {
+	// KeepStopwords searches for the stopwords in the query too.
+	KeepStopwords bool
+	// NoSynonyms only finds the words of the query itself.
+	NoSynonyms bool
+}
+
+// Term is a word of a query and the synonyms that also match it.
+type Term struct {
+	Word     string   `json:"word"`
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// Query is a parsed search query.
+type Query struct {
+	Terms []Term `json:"terms"`
+}
+
+// Parse turns text into a query. Stopwords are dropped unless the query
+// consists of nothing else, repeated words are searched once.
+func (c Config) Parse(text string, opts Options) Query {
+	words := Tokenize(text)
+	if !opts.KeepStopwords {
+		var kept []string
+		for _, word := range words {
+			if !c.stopwords[word] {
+				kept = append(kept, word)
+			}
+		}
+		if len(kept) > 0 {
+			words = kept
+		}
+	}
+	var q Query
+	seen := map[string]bool{}
+	for _, word := range words {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		term := Term{Word: word}
+		if !opts.NoSynonyms {
+			term.Synonyms = c.synonyms[word]
+		}
+		q.Terms = append(q.Terms, term)
+	}
+	return q
+}
+
+// Empty reports whether the query has no words to search for.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0
+}
+
+// Words returns every word the query finds, synonyms included.
+func (q Query) Words() []string {
+	var words []string
+	for _, term := range q.Terms {
+		words = append(words, term.Word)
+		for _, synonym := range term.Synonyms {
+			if !containsWord(words, synonym) {
+				words = append(words, synonym)
+			}
+		}
+	}
+	return words
+}
+
+// Score rates how well a document, as returned by Document, matches the
+// query. It reports false when a word of the query is missing. Each word
+// scores the weight of the fields it is found in, growing slowly with the
+// number of times it occurs there, synonyms count half. Queries found as a
+// phrase in the product name score its weight once more.
+func (q Query) Score(doc map[string]string) (float64, bool) {
+	if q.Empty() {
+		return 0, false
+	}
+	counts := make([]map[string]int, len(Fields))
+	for i, field := range Fields {
+		counts[i] = map[string]int{}
+		for _, word := range Tokenize(doc[field.Name]) {
+			counts[i][word]++
+		}
+	}
+	frequency := func(n int) float64 {
+		if n == 0 {
+			return 0
+		}
+		return 1 + math.Log(float64(n))
+	}
+
+	var score float64
+	for _, term := range q.Terms {
+		var termScore float64
+		for i, field := range Fields {
+			termScore += float64(field.Weight) * frequency(counts[i][term.Word])
+			for _, synonym := range term.Synonyms {
+				termScore += float64(field.Weight) * frequency(counts[i][synonym]) / 2
+			}
+		}
+		if termScore == 0 {
+			return 0, false
+		}
+		score += termScore
+	}
+	if len(q.Terms) > 1 && strings.Contains(" "+strings.Join(Tokenize(doc[Fields[0].Name]), " ")+" ", " "+q.phrase()+" ") {
+		score += float64(Fields[0].Weight)
+	}
+	return score, true
+}
+
+func (q Query) phrase() string {
+	words := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		words[i] = term.Word
+	}
+	return strings.Join(words, " ")
+}
+
+// Highlights returns the fields of a document the query matches, HTML
+// escaped and with the matched words wrapped in <em> tags. Long
+// descriptions are cut to an excerpt around the first match.
+func (q Query) Highlights(doc map[string]string) map[string]string {
+	highlights := map[string]string{}
+	for _, field := range Fields {
+		max := 0
+		if field.Name == "discription" {
+			max = 160
+		}
+		if highlighted := q.Highlight(doc[field.Name], max); highlighted != "" {
+			highlights[field.Name] = highlighted
+		}
+	}
+	return highlights
+}
+
+// span is a run of text that is or is not a matched word.
+type span struct {
+	text  string
+	match bool
+}
+
+func (q Query) spans(text string) []span {
+	words := map[string]bool{}
+	for _, word := range q.Words() {
+		words[word] = true
+	}
+	var spans []span
+	for len(text) > 0 {
+		end := strings.IndexFunc(text, isSeparator)
+		if end == 0 {
+			end = strings.IndexFunc(text, func(r rune) bool { return !isSeparator(r) })
+			if end < 0 {
+				end = len(text)
+			}
+			spans = append(spans, span{text: text[:end]})
+			text = text[end:]
+			continue
+		}
+		if end < 0 {
+			end = len(text)
+		}
+		spans = append(spans, span{text: text[:end], match: words[stem(strings.ToLower(text[:end]))]})
+		text = text[end:]
+	}
+	return spans
+}
+
+// Highlight marks the words of text the query matches, see Highlights. A
+// positive max cuts text to an excerpt of about that many characters. It
+// returns "" when nothing matches.
+func (q Query) Highlight(text string, max int) string {
+	spans := q.spans(text)
+	first := -1
+	for i, s := range spans {
+		if s.match {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	from, to := 0, len(spans)
+	if max > 0 && utf8.RuneCountInString(text) > max {
+		// Start a little before the first match and stop at the word that
+		// goes over max.
+		from = first
+		for length := 0; from > 0 && length < max/4; {
+			from--
+			length += utf8.RuneCountInString(spans[from].text)
+		}
+		to = from
+		for length := 0; to < len(spans) && length+utf8.RuneCountInString(spans[to].text) <= max; to++ {
+			length += utf8.RuneCountInString(spans[to].text)
+		}
+		if to == from {
+			to = from + 1
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	for _, s := range spans[from:to] {
+		if s.match {
+			b.WriteString("<em>" + html.EscapeString(s.text) + "</em>")
+		} else {
+			b.WriteString(html.EscapeString(s.text))
+		}
+	}
+	if to < len(spans) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Red MUGS, glasses & Boxes; cherries!", []string{"red", "mug", "glass", "box", "cherry"}},
+		{"dishes, churches and kids", []string{"dish", "church", "and", "kid"}},
+		{"status class bus gas ties", []string{"status", "class", "bus", "gas", "tie"}},
+		{"5kg, 2-pack", []string{"5kg", "2", "pack"}},
+		{"Café-Crème", []string{"café", "crème"}},
+		{" -- ", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	doc := map[string]string{
+		"product_name": "Red Mug",
+		"category":     "kitchen",
+		"discription":  "A red mug, red as a cherry",
+	}
+	terms := func(words ...string) Query {
+		var q Query
+		for _, word := range words {
+			q.Terms = append(q.Terms, Term{Word: word})
+		}
+		return q
+	}
+	tests := []struct {
+		name  string
+		query Query
+		want  float64
+		ok    bool
+	}{
+		// 10 for the name, 1+ln 2 for the description mentioning it twice
+		{"one word", terms("red"), 11 + math.Log(2), true},
+		{"phrase in the name", terms("red", "mug"), 11 + math.Log(2) + 11 + 10, true},
+		{"words out of order", terms("mug", "red"), 11 + 11 + math.Log(2), true},
+		{"word in the category", terms("kitchen"), 5, true},
+		{"synonym", Query{Terms: []Term{{Word: "cup", Synonyms: []string{"mug"}}}}, 5.5, true},
+		{"word missing", terms("red", "plate"), 0, false},
+		{"empty", Query{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.query.Score(doc)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v %v, want %v %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	q := Query{Terms: []Term{{Word: "red"}, {Word: "mug", Synonyms: []string{"cup"}}}}
+	long := strings.Repeat("word ", 40) + "red mug " + strings.Repeat("tail ", 40)
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{"words", "Red mugs & more", 0, "<em>Red</em> <em>mugs</em> &amp; more"},
+		{"synonym", "Tea Cups", 0, "Tea <em>Cups</em>"},
+		{"escaped", "<b>red</b>", 0, "&lt;b&gt;<em>red</em>&lt;/b&gt;"},
+		{"part of a word", "redwood mugger", 0, ""},
+		{"no match", "blue plate", 0, ""},
+		{"short enough", "a red mug", 40, "a <em>red</em> <em>mug</em>"},
+		{"excerpt", long, 40, "…word word <em>red</em> <em>mug</em> tail tail tail tail …"},
+		{"excerpt from the start", "red " + strings.Repeat("tail ", 40), 20, "<em>red</em> tail tail tail …"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := q.Highlight(tt.text, tt.max); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	q := Query{Terms: []Term{{Word: "red"}}}
+	got := q.Highlights(map[string]string{
+		"product_name": "Red Mug",
+		"category":     "kitchen",
+		"discription":  strings.Repeat("plain ", 50) + "red",
+	})
+	if len(got) != 2 || got["product_name"] != "<em>Red</em> Mug" {
+		t.Errorf("got %q", got)
+	}
+	if d := got["discription"]; !strings.HasPrefix(d, "…") || !strings.HasSuffix(d, "<em>red</em>") {
+		t.Errorf("description %q, want an excerpt ending at the match", d)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Objects are stored under a prefix per owning document, so the uploads of
+// one seller, product or ticket are kept together and never collide with
+// another's.
+
+func SellerKYCPrefix(sellerID string) string {
+	return "sellers/" + sellerID + "/kyc/"
+}
+
+func SellerProfilePrefix(sellerID string) string {
+	return "sellers/" + sellerID + "/profile/"
+}
+
+func ProductImagesPrefix(productID string) string {
+	return "products/" + productID + "/images/"
+}
+
+func CategoryImagesPrefix(categoryID string) string {
+	return "categories/" + categoryID + "/images/"
+}
+
+func BlogImagesPrefix(blogID string) string {
+	return "blogs/" + blogID + "/images/"
+}
+
+func TicketPrefix(ticketID string) string {
+	return "tickets/" + ticketID + "/"
+}
+
+func ContentPrefix(contentID string) string {
+	return "content/" + contentID + "/"
+}
+
+func FeedPrefix(feedID string) string {
+	return "feeds/" + feedID + "/"
+}
+
+// UploadPrefix holds what a client puts into storage during an upload
+// session, until it's attached to a document and moved under its prefix.
+func UploadPrefix(sessionID string) string {
+	return "uploads/" + sessionID + "/"
+}
+
+// NewKey returns a key under prefix no other object has: a random UUID
+// with the extension ext, like ".pdf". The extension is kept so signed
+// URLs are served with the right content type.
+func NewKey(prefix, ext string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("storage: reading random bytes: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%s%x-%x-%x-%x-%x%s", prefix, b[0:4], b[4:6], b[6:8], b[8:10], b[10:], strings.ToLower(ext))
+}
+
+// Extension is the extension of the detected type of an upload, or of its
+// name when the type is unknown.
+func Extension(mtype *mimetype.MIME, filename string) string {
+	if mtype != nil && mtype.Extension() != "" {
+		return mtype.Extension()
+	}
+	ext := strings.ToLower(path.Ext(filename))
+	if len(ext) > 10 {
+		return ""
+	}
+	for _, r := range strings.TrimPrefix(ext, ".") {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// LocalRoutePrefix is where the Gin router serves objects of a Local store.
+const LocalRoutePrefix = "/files"
+
+// localMetaDir holds the Metadata of the objects of a Local store, as a JSON
+// file per key. It can't be written or served as an object itself.
+const localMetaDir = ".meta"
+
+type LocalConfig struct {
+	// Dir is the directory objects are written to, "uploads" when empty.
+	Dir string
+	// PublicURL is the externally visible address of this server,
+	// e.g. http://localhost:8080.
+	PublicURL string
+	// SigningKey is the HMAC key for signed URLs. A random key is used when
+	// empty, which invalidates outstanding URLs on restart.
+	SigningKey string
+}
+
+// Local keeps objects on the local filesystem and hands out HMAC signed URLs
+// that are served by Handler.
+type Local struct {
+	dir       string
+	publicURL string
+	key       []byte
+}
+
+func NewLocal(cfg LocalConfig) (*Local, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = "uploads"
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = "http://localhost:8080"
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	key := []byte(cfg.SigningKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Local{
+		dir:       cfg.Dir,
+		publicURL: strings.TrimSuffix(cfg.PublicURL, "/"),
+		key:       key,
+	}, nil
+}
+
+func (l *Local) path(key string) (string, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	if key == localMetaDir || strings.HasPrefix(key, localMetaDir+"/") {
+		return "", "", ErrInvalidKey
+	}
+	return key, filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) metaPath(key string) string {
+	return filepath.Join(l.dir, localMetaDir, filepath.FromSlash(key)+".json")
+}
+
+// metadata reads the Metadata stored with key, empty when there is none.
+func (l *Local) metadata(key string) Metadata {
+	var meta Metadata
+	if data, err := os.ReadFile(l.metaPath(key)); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, meta Metadata) (string, error) {
+	key, name, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.metaPath(key)), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(l.metaPath(key), data, 0o644); err != nil {
+		return "", err
+	}
+	return l.objectURL(key), nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	_, name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	_, name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	key, name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(l.metaPath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", l.sign(key, expires))
+	return l.objectURL(key) + "?" + q.Encode(), nil
+}
+
+func (l *Local) SignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", l.signPut(key, contentType, size, expires))
+	return l.objectURL(key) + "?" + q.Encode(), nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, name, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Size: info.Size(), ContentType: l.metadata(key).ContentType}, nil
+}
+
+func (l *Local) Copy(ctx context.Context, from, to string, meta Metadata) (string, error) {
+	_, name, err := l.path(from)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return l.Put(ctx, to, file, meta)
+}
+
+func (l *Local) KeyFromURL(rawURL string) string {
+	return keyFromPath(rawURL, LocalRoutePrefix)
+}
+
+func (l *Local) objectURL(key string) string {
+	return l.publicURL + LocalRoutePrefix + "/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signPut signs a PUT of size bytes of contentType to key. The method is
+// signed too, so a PUT URL can't be used to read and a read URL can't be
+// used to write.
+func (l *Local) signPut(key, contentType string, size int64, expires string) string {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write([]byte("PUT\n" + key + "\n" + contentType + "\n" + strconv.FormatInt(size, 10) + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves objects for requests carrying a valid, unexpired signature.
+// It is mounted on LocalRoutePrefix + "/*key".
+func (l *Local) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, name, err := l.path(c.Param("key"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid file key"})
+			return
+		}
+		expires := c.Query("expires")
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > unix {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "Link has expired"})
+			return
+		}
+		if !hmac.Equal([]byte(c.Query("signature")), []byte(l.sign(key, expires))) {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "Invalid signature"})
+			return
+		}
+		if _, err := os.Stat(name); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "File not found"})
+			return
+		}
+		meta := l.metadata(key)
+		contentType := meta.ContentType
+		if contentType == "" {
+			contentType = ContentTypeFromName(key)
+		}
+		if contentType == "application/octet-stream" {
+			if mtype, err := mimetype.DetectFile(name); err == nil {
+				contentType = mtype.String()
+			}
+		}
+		c.Header("Content-Type", contentType)
+		if disposition := meta.contentDisposition(); disposition != "" {
+			c.Header("Content-Disposition", disposition)
+		}
+		c.File(name)
+	}
+}
+
+// PutHandler stores the body of PUT requests made with a URL from
+// SignedPutURL, as S3 does for presigned PUTs: the Content-Type and
+// Content-Length must be the signed ones. It is mounted on LocalRoutePrefix
+// + "/*key".
+func (l *Local) PutHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, _, err := l.path(c.Param("key"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid file key"})
+			return
+		}
+		expires := c.Query("expires")
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > unix {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "Link has expired"})
+			return
+		}
+		contentType := c.GetHeader("Content-Type")
+		size := c.Request.ContentLength
+		if size < 0 || !hmac.Equal([]byte(c.Query("signature")), []byte(l.signPut(key, contentType, size, expires))) {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "Invalid signature"})
+			return
+		}
+		// The server stops reading the body at its Content-Length, and
+		// fails on a shorter one.
+		if _, err := l.Put(c.Request.Context(), key, c.Request.Body, Metadata{ContentType: contentType}); err != nil {
+			l.Delete(c.Request.Context(), key)
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type S3Config struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Bucket    string
+}
+
+// S3 stores objects in a single bucket. The AWS session is created once and
+// shared by every request.
+type S3 struct {
+	bucket     string
+	client     *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage: s3 bucket name is empty")
+	}
+	awsSession, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region:      aws.String(cfg.Region),
+			Credentials: credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3{
+		bucket:     cfg.Bucket,
+		client:     s3.New(awsSession),
+		uploader:   s3manager.NewUploader(awsSession),
+		downloader: s3manager.NewDownloader(awsSession),
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, meta Metadata) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	input := &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(meta.ContentType),
+	}
+	if meta.Filename != "" {
+		// S3 metadata is sent as headers, which only carry ASCII
+		input.Metadata = map[string]*string{"Original-Filename": aws.String(url.PathEscape(meta.Filename))}
+		input.ContentDisposition = aws.String(meta.contentDisposition())
+	}
+	_, err = s.uploader.UploadWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, key), nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	buffer := aws.NewWriteAtBuffer([]byte{})
+	_, err = s.downloader.DownloadWithContext(ctx, buffer, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	contentType := ContentTypeFromName(key)
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                  aws.String(s.bucket),
+		Key:                     aws.String(key),
+		ResponseContentType:     aws.String(contentType),
+		ResponseContentEncoding: aws.String("base64"),
+	})
+	req.SetContext(ctx)
+
+	q := req.HTTPRequest.URL.Query()
+	q.Add("x-amz-acl", "public-read")
+	q.Add("Content-Type", contentType)
+	req.HTTPRequest.URL.RawQuery = q.Encode()
+
+	return req.Presign(expiry)
+}
+
+func (s *S3) SignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+	req.SetContext(ctx)
+	return req.Presign(expiry)
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		// HEAD responses have no body, a missing key comes back as NotFound
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey) {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{
+		Size:        aws.Int64Value(out.ContentLength),
+		ContentType: aws.StringValue(out.ContentType),
+	}, nil
+}
+
+func (s *S3) Copy(ctx context.Context, from, to string, meta Metadata) (string, error) {
+	from, err := cleanKey(from)
+	if err != nil {
+		return "", err
+	}
+	to, err = cleanKey(to)
+	if err != nil {
+		return "", err
+	}
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(to),
+		CopySource:        aws.String((&url.URL{Path: s.bucket + "/" + from}).EscapedPath()),
+		MetadataDirective: aws.String(s3.MetadataDirectiveReplace),
+		ContentType:       aws.String(meta.ContentType),
+	}
+	if meta.Filename != "" {
+		input.Metadata = map[string]*string{"Original-Filename": aws.String(url.PathEscape(meta.Filename))}
+		input.ContentDisposition = aws.String(meta.contentDisposition())
+	}
+	if _, err := s.client.CopyObjectWithContext(ctx, input); err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, to), nil
+}
+
+func (s *S3) KeyFromURL(rawURL string) string {
+	return keyFromPath(rawURL, "")
+}
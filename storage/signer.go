@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// Signer hands out signed URLs to read stored objects. A URL is handed out
+// again until shortly before it expires, so listings requested over and
+// over don't sign the same images every time. Keys are never reused for
+// other content, a cached URL can't show a replaced object.
+type Signer struct {
+	store Storage
+	cfg   config.Signing
+
+	mu    sync.Mutex
+	cache map[string]signedURL
+}
+
+type signedURL struct {
+	url     string
+	expires time.Time
+}
+
+func NewSigner(store Storage, cfg config.Signing) *Signer {
+	return &Signer{
+		store: store,
+		cfg:   cfg,
+		cache: make(map[string]signedURL),
+	}
+}
+
+// Sign returns a signed URL for key, "" for an empty key.
+func (s *Signer) Sign(ctx context.Context, key string) (string, error) {
+	urls, err := s.SignAll(ctx, []string{key})
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+// SignAll signs keys in one pass: the cached URLs are all looked up at
+// once and only the others are signed. urls[i] is the URL of keys[i], ""
+// for an empty key.
+func (s *Signer) SignAll(ctx context.Context, keys []string) ([]string, error) {
+	urls := make([]string, len(keys))
+	now := time.Now()
+	var missing []int
+	s.mu.Lock()
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if cached, ok := s.cache[key]; ok && now.Before(cached.expires) {
+			urls[i] = cached.url
+			continue
+		}
+		missing = append(missing, i)
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return urls, nil
+	}
+
+	signed := make(map[string]string, len(missing))
+	for _, i := range missing {
+		key := keys[i]
+		if url, ok := signed[key]; ok {
+			urls[i] = url
+			continue
+		}
+		url, err := s.store.SignedURL(ctx, key, s.cfg.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		signed[key] = url
+		urls[i] = url
+	}
+
+	// Handed out until Renew is left, so a client gets at least that long
+	// to use it.
+	expires := now.Add(s.cfg.Expiry - s.cfg.Renew)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, url := range signed {
+		if len(s.cache) >= s.cfg.CacheSize {
+			s.evict(now)
+			if len(s.cache) >= s.cfg.CacheSize {
+				break
+			}
+		}
+		s.cache[key] = signedURL{url: url, expires: expires}
+	}
+	return urls, nil
+}
+
+// evict makes room in a full cache: the URLs due for renewal go first,
+// everything when none is.
+func (s *Signer) evict(now time.Time) {
+	for key, cached := range s.cache {
+		if !now.Before(cached.expires) {
+			delete(s.cache, key)
+		}
+	}
+	if len(s.cache) >= s.cfg.CacheSize {
+		s.cache = make(map[string]signedURL)
+	}
+}
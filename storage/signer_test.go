@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+// countingStore signs URLs carrying the number of times their key was
+// signed.
+type countingStore struct {
+	Storage
+	signed map[string]int
+	fail   bool
+}
+
+func (s *countingStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.fail {
+		return "", errors.New("signing failed")
+	}
+	s.signed[key]++
+	return fmt.Sprintf("%s?v=%d", key, s.signed[key]), nil
+}
+
+func newTestSigner(cfg config.Signing) (*Signer, *countingStore) {
+	store := &countingStore{signed: map[string]int{}}
+	return NewSigner(store, cfg), store
+}
+
+func TestSignerSignAll(t *testing.T) {
+	ctx := context.Background()
+	signer, store := newTestSigner(config.Signing{Expiry: time.Hour, Renew: time.Minute, CacheSize: 10})
+	urls, err := signer.SignAll(ctx, []string{"a", "", "b", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a?v=1", "", "b?v=1", "a?v=1"}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Fatalf("got %q, want %q", urls, want)
+		}
+	}
+	if store.signed["a"] != 1 {
+		t.Errorf("a key listed twice was signed %d times", store.signed["a"])
+	}
+
+	// Cached URLs are handed out again without signing.
+	store.fail = true
+	if url, err := signer.Sign(ctx, "b"); err != nil || url != "b?v=1" {
+		t.Errorf("cached: %q, %v", url, err)
+	}
+	if _, err := signer.Sign(ctx, "c"); err == nil {
+		t.Error("a signing failure was not reported")
+	}
+}
+
+func TestSignerCache(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		cfg  config.Signing
+		keys []string
+		want map[string]int
+	}{
+		{"cached", config.Signing{Expiry: time.Hour, Renew: time.Minute, CacheSize: 10},
+			[]string{"a", "b", "a", "b"}, map[string]int{"a": 1, "b": 1}},
+		// A URL left with only Renew to live is renewed.
+		{"renewed", config.Signing{Expiry: time.Minute, Renew: time.Minute, CacheSize: 10},
+			[]string{"a", "a", "a"}, map[string]int{"a": 3}},
+		{"no cache", config.Signing{Expiry: time.Hour, Renew: time.Minute},
+			[]string{"a", "a"}, map[string]int{"a": 2}},
+		// A full cache is emptied to make room for c, a is signed again.
+		{"evicted", config.Signing{Expiry: time.Hour, Renew: time.Minute, CacheSize: 2},
+			[]string{"a", "b", "c", "c", "a"}, map[string]int{"a": 2, "b": 1, "c": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, store := newTestSigner(tt.cfg)
+			for _, key := range tt.keys {
+				if _, err := signer.Sign(ctx, key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for key, n := range tt.want {
+				if store.signed[key] != n {
+					t.Errorf("%s signed %d times, want %d", key, store.signed[key], n)
+				}
+			}
+			if len(signer.cache) > tt.cfg.CacheSize {
+				t.Errorf("cache holds %d URLs, more than %d", len(signer.cache), tt.cfg.CacheSize)
+			}
+		})
+	}
+}
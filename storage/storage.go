@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kravi0/BizGrowth-backend/config"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+var ErrInvalidKey = errors.New("storage: invalid object key")
+
+// Storage is implemented by every object store the marketplace can upload
+// product images, seller documents and ticket attachments to.
+type Storage interface {
+	// Put stores body under key and returns the URL that should be persisted
+	// alongside the owning document.
+	Put(ctx context.Context, key string, body io.Reader, meta Metadata) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Open streams the object under key, ErrNotFound when there is none.
+	// The caller closes it, and can do so before reading it all.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a time limited URL granting read access to key.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// SignedPutURL returns a time limited URL a client can PUT an object to
+	// key with, sending contentType as its Content-Type and size as its
+	// Content-Length.
+	SignedPutURL(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (string, error)
+	// Stat describes the object under key, ErrNotFound when there is none.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Copy stores a copy of the object under from at to, described by meta,
+	// and returns its URL like Put.
+	Copy(ctx context.Context, from, to string, meta Metadata) (string, error)
+	// KeyFromURL maps a URL previously returned by Put back to its key.
+	KeyFromURL(rawURL string) string
+}
+
+// Metadata describes an object being stored.
+type Metadata struct {
+	ContentType string
+	// Filename is the name the object was uploaded with. Keys are generated,
+	// downloads are offered under this name instead.
+	Filename string
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// contentDisposition offers an object for display under its upload name.
+func (m Metadata) contentDisposition() string {
+	if m.Filename == "" {
+		return ""
+	}
+	return mime.FormatMediaType("inline", map[string]string{"filename": m.Filename})
+}
+
+// New builds the driver selected by cfg.Driver ("s3" or "local").
+// S3 is the default so existing deployments keep working unchanged.
+func New(cfg config.Storage) (Storage, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "s3":
+		return NewS3(S3Config{
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+		})
+	case "local":
+		return NewLocal(LocalConfig{
+			Dir:        cfg.Local.Dir,
+			PublicURL:  cfg.Local.PublicURL,
+			SigningKey: cfg.Local.SigningKey,
+		})
+	default:
+		return nil, errors.New("storage: unknown driver " + cfg.Driver)
+	}
+}
+
+// cleanKey normalises an object key and rejects keys escaping the store root.
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" || key == "." {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
+
+// keyFromPath returns the part of rawURL's path after prefix.
+func keyFromPath(rawURL, prefix string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	key := strings.TrimPrefix(u.Path, prefix)
+	key = strings.TrimPrefix(key, "/")
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	return key
+}
+
+// ContentTypeFromName guesses a content type from the file extension.
+func ContentTypeFromName(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".pdf":
+		return "application/pdf"
+	case ".docx":
+		return "application/docx"
+	case ".mp4":
+		return "video/mp4"
+	case ".jpg":
+		return "image/jpg"
+	case ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	case ".avif":
+		return "image/avif"
+	case ".svg":
+		return "image/svg"
+	}
+	return "application/octet-stream"
+}
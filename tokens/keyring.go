@@ -0,0 +1,102 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/utils"
+)
+
+// Token types, carried in the Type claim so a token minted for one audience
+// is refused by the validators of every other.
+const (
+	TypeUser    = "user"
+	TypeSeller  = "seller"
+	TypeAdmin   = "admin"
+	TypeRefresh = "refresh"
+)
+
+// TypeForRole returns the access token type of an account role.
+func TypeForRole(role string) string {
+	switch role {
+	case utils.User:
+		return TypeUser
+	case utils.Seller:
+		return TypeSeller
+	default:
+		return TypeAdmin
+	}
+}
+
+// Keyring holds the HMAC keys of one audience by key ID. Tokens are signed
+// with the active key and carry its ID in the kid header, every key in the
+// ring verifies, so a new key can be rolled out before the old one is
+// dropped.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+func NewKeyring(keys map[string]string, activeID string) (*Keyring, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("tokens: signing key %q is not in the keyring", activeID)
+	}
+	ring := &Keyring{activeID: activeID, keys: map[string][]byte{}}
+	for id, secret := range keys {
+		ring.keys[id] = []byte(secret)
+	}
+	return ring, nil
+}
+
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = k.activeID
+	return token.SignedString(k.keys[k.activeID])
+}
+
+// keyFunc resolves the verification key from the kid header. Tokens issued
+// before key IDs existed have none and are refused: they carry no type or
+// role either, so every session of that time has to log in again.
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("the token has no signing key ID")
+	}
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+var sellerKeys, userKeys *Keyring
+
+// Configure builds the keyrings. It must be called once at startup before
+// any token is generated or validated.
+func Configure(cfg config.JWT) error {
+	keys, activeID := cfg.SellerKeyring()
+	sellers, err := NewKeyring(keys, activeID)
+	if err != nil {
+		return err
+	}
+	keys, activeID = cfg.UserKeyring()
+	users, err := NewKeyring(keys, activeID)
+	if err != nil {
+		return err
+	}
+	sellerKeys, userKeys = sellers, users
+	return nil
+}
+
+// keyringForRole picks the keyring by audience: users have their own.
+func keyringForRole(role string) *Keyring {
+	if role == utils.User {
+		return userKeys
+	}
+	return sellerKeys
+}
@@ -0,0 +1,110 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/kravi0/BizGrowth-backend/config"
+	"github.com/kravi0/BizGrowth-backend/utils"
+)
+
+func configure(t *testing.T, cfg config.JWT) {
+	t.Helper()
+	if err := Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	configure(t, config.JWT{SellerSecret: "old", UserSecret: "user"})
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, &SignedDetails{
+		Uid:            "legacy",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	})
+	withoutKid, err := legacy.SignedString([]byte("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withDefault, err := TokenGenerator("", "", "", "default", utils.Seller)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Roll out a new key: the default one still verifies, new tokens use
+	// the new one.
+	configure(t, config.JWT{
+		SellerSecret: "old",
+		SellerKeys:   map[string]string{"2024": "new"},
+		SellerKeyID:  "2024",
+		UserSecret:   "user",
+	})
+	withNew, err := TokenGenerator("", "", "", "new", utils.Seller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &SignedDetails{Uid: "forged", Type: TypeSeller})
+	forged.Header["kid"] = "unknown"
+	withUnknown, err := forged.SignedString([]byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		uid   string
+	}{
+		{"no kid", withoutKid, ""},
+		{"old key", withDefault, "default"},
+		{"new key", withNew, "new"},
+		{"unknown kid", withUnknown, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, msg := ValidateToken(tt.token)
+			if tt.uid == "" {
+				if msg == "" {
+					t.Errorf("accepted %+v", claims)
+				}
+				return
+			}
+			if msg != "" || claims.Uid != tt.uid {
+				t.Errorf("got %+v, %q", claims, msg)
+			}
+		})
+	}
+
+	// Once the old key is dropped its tokens are refused.
+	configure(t, config.JWT{
+		SellerKeys:  map[string]string{"2024": "new"},
+		SellerKeyID: "2024",
+		UserSecret:  "user",
+	})
+	if _, msg := ValidateToken(withDefault); msg == "" {
+		t.Error("a token of a dropped key was accepted")
+	}
+	if _, msg := ValidateToken(withNew); msg != "" {
+		t.Errorf("token of the active key: %s", msg)
+	}
+}
+
+func TestKeyringsAreSeparate(t *testing.T) {
+	configure(t, config.JWT{SellerSecret: "seller", UserSecret: "user"})
+	user, err := UserTokenGenerator("9000000001", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, msg := ValidateToken(user); msg == "" {
+		t.Error("a user token was accepted as a seller token")
+	}
+	if _, msg := ValidateUSERToken(user); msg != "" {
+		t.Errorf("user token: %s", msg)
+	}
+}
+
+func TestNewKeyringNeedsTheActiveKey(t *testing.T) {
+	if _, err := NewKeyring(map[string]string{"a": "secret"}, "b"); err == nil {
+		t.Error("built a keyring without its signing key")
+	}
+}
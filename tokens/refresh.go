@@ -0,0 +1,57 @@
+package tokens
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// SignedRefreshDetails are the claims of a refresh token. Id (jti) names the
+// stored token record and Family the login it was rotated from.
+type SignedRefreshDetails struct {
+	Uid    string
+	Role   string
+	Type   string
+	Family string
+	jwt.StandardClaims
+}
+
+func RefreshTokenGenerator(id string, family string, uid string, role string, expiresAt time.Time) (signedToken string, err error) {
+	claims := &SignedRefreshDetails{
+		Uid:    uid,
+		Role:   role,
+		Type:   TypeRefresh,
+		Family: family,
+		StandardClaims: jwt.StandardClaims{
+			Id:        id,
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	return keyringForRole(role).Sign(claims)
+}
+
+func ValidateRefreshToken(signedToken string) (claims *SignedRefreshDetails, msg string) {
+	token, err := jwt.ParseWithClaims(signedToken, &SignedRefreshDetails{}, func(token *jwt.Token) (interface{}, error) {
+		return keyringForRole(token.Claims.(*SignedRefreshDetails).Role).keyFunc(token)
+	})
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+
+	claims, ok := token.Claims.(*SignedRefreshDetails)
+	if !ok || claims.Type != TypeRefresh || claims.Id == "" || claims.Family == "" {
+		msg = "the token was invalid"
+		return
+	}
+
+	if claims.StandardClaims.ExpiresAt < time.Now().Local().Unix() {
+		msg = "token is already expired"
+		return
+	}
+
+	return claims, msg
+}
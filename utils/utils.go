@@ -38,3 +38,25 @@ const (
 const (
 	Seller = "SELLER"
 )
+
+const (
+	User = "USER"
+)
+
+// admin roles
+const (
+	SuperAdmin     = "SUPER_ADMIN"
+	CatalogManager = "CATALOG_MANAGER"
+	SupportAgent   = "SUPPORT_AGENT"
+)
+
+var AdminRoles = []string{SuperAdmin, CatalogManager, SupportAgent}
+
+func IsAdminRole(role string) bool {
+	for _, r := range AdminRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}